@@ -2,9 +2,13 @@ package app
 
 import (
 	"backend/config"
+	"backend/events"
 	"backend/initialize"
+	"backend/jobs"
+	"backend/pubsub"
 	"backend/router"
 	"backend/store"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -24,12 +28,6 @@ func init() {
 }
 
 func RunApp() error {
-	s := store.NewStore()
-
-	if err := s.InitFillStore(); err != nil {
-		return fmt.Errorf("failed to fill store: %w", err)
-	}
-
 	configPath, err := config.ReadConfigPath()
 	if err != nil {
 		return fmt.Errorf("failed to read config path: %w", err)
@@ -40,7 +38,40 @@ func RunApp() error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	deliveries := initialize.InitDeliveries(s, conf)
+	s := store.NewStore()
+	s.SetRevisionRetention(store.RevisionRetention{
+		MaxRevisions: conf.History.MaxRevisions,
+		MaxAge:       time.Duration(conf.History.RetentionDays) * 24 * time.Hour,
+	})
+	if conf.Templates.Onboarding != nil {
+		s.SetOnboardingTemplates(initialize.InitOnboardingTemplates(conf))
+	}
+	// Заполняем хранилище после настройки: демо-пользователь получает
+	// заметки из шаблонов конфигурации.
+	if err = s.InitFillStore(); err != nil {
+		return fmt.Errorf("failed to fill store: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := pubsub.NewMemory()
+	blobs, err := initialize.InitBlobStore(conf)
+	if err != nil {
+		return fmt.Errorf("failed to init blob store: %w", err)
+	}
+	noteEvents := events.NewFeed(conf.Changes.LogSize)
+	jobs.Start(ctx, initialize.InitJobs(s, conf, bus, noteEvents, blobs))
+	images := jobs.NewPool(conf.Images.Workers, conf.Images.QueueSize)
+	images.Start(ctx)
+	imports := jobs.NewPool(conf.Imports.Workers, conf.Imports.QueueSize)
+	imports.Start(ctx)
+
+	fonts, err := initialize.InitPDFFonts(conf)
+	if err != nil {
+		return fmt.Errorf("failed to init pdf fonts: %w", err)
+	}
+
+	deliveries := initialize.InitDeliveries(s, conf, bus, noteEvents, blobs, images, imports, fonts)
 
 	r := router.NewRouter(s, deliveries)
 
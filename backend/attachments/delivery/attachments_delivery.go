@@ -0,0 +1,288 @@
+package attachmentsDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// multipartOverhead — запас на заголовки частей и границы multipart поверх
+	// максимального размера файла.
+	multipartOverhead = 1 << 20
+
+	// Содержимое вложения по его адресу не меняется, кроме изображений в
+	// обработке: их исходный файл ещё может быть повёрнут.
+	cacheImmutable  = "private, max-age=31536000, immutable"
+	cacheRevalidate = "private, no-cache"
+)
+
+type AttachmentsUsecase interface {
+	Upload(ctx context.Context, ownerID, actorID, noteID uint64, filename string, content io.Reader) (*models.Attachment, error)
+	ListAttachments(ownerID, actorID, noteID uint64) ([]models.Attachment, error)
+	Download(ctx context.Context, ownerID, actorID, noteID, attachmentID uint64, size string) (*models.Attachment, *models.ImageVariant, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, ownerID, actorID, noteID, attachmentID uint64) error
+	StorageUsage(ownerID, actorID uint64) (*models.StorageUsage, error)
+}
+
+type AttachmentsDelivery struct {
+	Usecase AttachmentsUsecase
+	MaxSize int64
+}
+
+func NewAttachmentsDelivery(usecase AttachmentsUsecase, maxSize int64) *AttachmentsDelivery {
+	return &AttachmentsDelivery{
+		Usecase: usecase,
+		MaxSize: maxSize,
+	}
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseOwnerVars читает владельца из пути и пользователя сессии, при ошибке
+// сам пишет ответ.
+func parseOwnerVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID uint64, ok bool) {
+	ownerID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+	return ownerID, actorID, true
+}
+
+// parseNoteVars дополняет parseOwnerVars идентификатором заметки.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID, noteID uint64, ok bool) {
+	ownerID, actorID, ok = parseOwnerVars(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	return ownerID, actorID, noteID, true
+}
+
+// parseAttachmentVars дополняет parseNoteVars идентификатором вложения.
+func parseAttachmentVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID, noteID, attachmentID uint64, ok bool) {
+	ownerID, actorID, noteID, ok = parseNoteVars(w, r)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	attachmentID, err := parseUintVar(r, "attachment_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid attachment ID")
+		return 0, 0, 0, 0, false
+	}
+	return ownerID, actorID, noteID, attachmentID, true
+}
+
+// Upload принимает multipart/form-data с файлом в поле file.
+func (d *AttachmentsDelivery) Upload(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, d.MaxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "expected multipart/form-data")
+		return
+	}
+
+	var attachment *models.Attachment
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			apiutils.WriteError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "file is too large")
+			return
+		}
+		if err != nil {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err = d.Usecase.Upload(r.Context(), ownerID, actorID, noteID, part.FileName(), part)
+		part.Close()
+		if errors.Is(err, namederrors.ErrForbidden) {
+			apiutils.WriteError(w, http.StatusForbidden, "access denied")
+			return
+		}
+		if errors.Is(err, namederrors.ErrNotFound) {
+			apiutils.WriteError(w, http.StatusNotFound, "note not found")
+			return
+		}
+		if errors.Is(err, namederrors.ErrFileTooLarge) || errors.As(err, &maxBytesErr) {
+			apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "file is too large")
+			return
+		}
+		if errors.Is(err, namederrors.ErrUnsupportedMediaType) {
+			apiutils.WriteError(w, http.StatusUnsupportedMediaType, "unsupported file type")
+			return
+		}
+		if errors.Is(err, namederrors.ErrQuotaExceeded) {
+			apiutils.WriteError(w, http.StatusInsufficientStorage, "storage quota exceeded")
+			return
+		}
+		if err != nil {
+			apiutils.WriteError(w, http.StatusInternalServerError, "failed to upload attachment")
+			return
+		}
+		break
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, attachment)
+}
+
+func (d *AttachmentsDelivery) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	attachments, err := d.Usecase.ListAttachments(ownerID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list attachments")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, attachments)
+}
+
+// inlineType сообщает, можно ли показывать содержимое в браузере, а не
+// скачивать файлом.
+func inlineType(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") ||
+		strings.HasPrefix(contentType, "audio/") ||
+		strings.HasPrefix(contentType, "video/") ||
+		contentType == "application/pdf"
+}
+
+// Download отдаёт вложение. Для изображений параметр size выбирает вариант:
+// small, medium, large, original или длину большей стороны в пикселях.
+func (d *AttachmentsDelivery) Download(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, noteID, attachmentID, ok := parseAttachmentVars(w, r)
+	if !ok {
+		return
+	}
+
+	attachment, variant, content, err := d.Usecase.Download(r.Context(), ownerID, actorID, noteID, attachmentID, r.URL.Query().Get("size"))
+	if errors.Is(err, namederrors.ErrInvalidImageSize) {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid size")
+		return
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to download attachment")
+		return
+	}
+	defer content.Close()
+
+	contentType, size := attachment.ContentType, attachment.Size
+	if variant != nil {
+		contentType, size = variant.ContentType, variant.Size
+	}
+	disposition := "attachment"
+	if inlineType(contentType) {
+		disposition = "inline"
+	}
+	cacheControl := cacheImmutable
+	if attachment.Image != nil && attachment.Image.Status == models.ImagePending {
+		cacheControl = cacheRevalidate
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, content); err != nil {
+		log.Error().Err(err).Uint64("attachment_id", attachmentID).Msg("failed to stream attachment")
+	}
+}
+
+func (d *AttachmentsDelivery) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, noteID, attachmentID, ok := parseAttachmentVars(w, r)
+	if !ok {
+		return
+	}
+
+	err := d.Usecase.DeleteAttachment(r.Context(), ownerID, actorID, noteID, attachmentID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete attachment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (d *AttachmentsDelivery) StorageUsage(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := d.Usecase.StorageUsage(ownerID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get storage usage")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, usage)
+}
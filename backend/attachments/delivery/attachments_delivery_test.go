@@ -0,0 +1,110 @@
+package attachmentsDelivery
+
+import (
+	mw "backend/middleware"
+	"backend/models"
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUsecase читает загружаемый файл целиком и отдаёт вложение attachment
+// с содержимым content.
+type fakeUsecase struct {
+	AttachmentsUsecase
+	attachment models.Attachment
+	content    string
+}
+
+func (u *fakeUsecase) Upload(ctx context.Context, ownerID, actorID, noteID uint64, filename string, content io.Reader) (*models.Attachment, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Attachment{ID: 1, NoteID: noteID, Filename: filename, Size: int64(len(data))}, nil
+}
+
+func (u *fakeUsecase) Download(ctx context.Context, ownerID, actorID, noteID, attachmentID uint64, size string) (*models.Attachment, *models.ImageVariant, io.ReadCloser, error) {
+	attachment := u.attachment
+	return &attachment, nil, io.NopCloser(strings.NewReader(u.content)), nil
+}
+
+func newRequest(method string, body io.Reader) *http.Request {
+	r := httptest.NewRequest(method, "/api/user/1/notes/1/attachments/1", body)
+	r = mux.SetURLVars(r, map[string]string{"user_id": "1", "note_id": "1", "attachment_id": "1"})
+	return r.WithContext(mw.WithUserID(r.Context(), 1))
+}
+
+func TestUploadSize(t *testing.T) {
+	d := NewAttachmentsDelivery(&fakeUsecase{}, 10)
+
+	tests := []struct {
+		name string
+		size int
+		code int
+	}{
+		{name: "within limit", size: 10, code: http.StatusCreated},
+		{name: "body over limit", size: 10 + multipartOverhead + 1, code: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, err := writer.CreateFormFile("file", "notes.txt")
+			require.NoError(t, err)
+			_, err = part.Write(bytes.Repeat([]byte("a"), test.size))
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			r := newRequest("POST", &body)
+			r.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+
+			d.Upload(w, r)
+			require.Equal(t, test.code, w.Code)
+		})
+	}
+}
+
+func TestDownloadHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachment  models.Attachment
+		disposition string
+	}{
+		{
+			name:        "text is downloaded",
+			attachment:  models.Attachment{Filename: "notes.txt", ContentType: "text/plain", Size: 5},
+			disposition: `attachment; filename=notes.txt`,
+		},
+		{
+			name:        "image is shown inline",
+			attachment:  models.Attachment{Filename: "my photo.png", ContentType: "image/png", Size: 5},
+			disposition: `inline; filename="my photo.png"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := NewAttachmentsDelivery(&fakeUsecase{attachment: test.attachment, content: "hello"}, 10)
+			w := httptest.NewRecorder()
+
+			d.Download(w, newRequest("GET", nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+			require.Equal(t, test.disposition, w.Header().Get("Content-Disposition"))
+			require.Equal(t, test.attachment.ContentType, w.Header().Get("Content-Type"))
+			require.Equal(t, "5", w.Header().Get("Content-Length"))
+			require.Equal(t, "hello", w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package attachmentsRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type AttachmentsRepository struct {
+	Store *store.Store
+}
+
+func NewAttachmentsRepository(store *store.Store) *AttachmentsRepository {
+	return &AttachmentsRepository{
+		Store: store,
+	}
+}
+
+func (r *AttachmentsRepository) CreateAttachment(attachment models.Attachment, quota int64) (*models.Attachment, error) {
+	created, err := r.Store.CreateAttachment(attachment, quota)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+	return &created, nil
+}
+
+func (r *AttachmentsRepository) GetAttachment(attachmentID uint64) (*models.Attachment, error) {
+	attachment, err := r.Store.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentsRepository) ListAttachments(noteID uint64) ([]models.Attachment, error) {
+	attachments := r.Store.ListAttachments(noteID)
+	return attachments, nil
+}
+
+func (r *AttachmentsRepository) DeleteAttachment(attachmentID uint64) (*models.Attachment, error) {
+	attachment, err := r.Store.DeleteAttachment(attachmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentsRepository) SetAttachmentImage(attachmentID uint64, image models.AttachmentImage, size int64) error {
+	if err := r.Store.SetAttachmentImage(attachmentID, image, size); err != nil {
+		return fmt.Errorf("failed to set attachment image: %w", err)
+	}
+	return nil
+}
+
+func (r *AttachmentsRepository) StorageUsed(ownerID uint64) (int64, error) {
+	used := r.Store.StorageUsed(ownerID)
+	return used, nil
+}
+
+func (r *AttachmentsRepository) TakeOrphanBlobs() ([]string, error) {
+	keys := r.Store.TakeOrphanBlobs()
+	return keys, nil
+}
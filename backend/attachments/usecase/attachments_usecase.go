@@ -0,0 +1,311 @@
+package attachmentsUsecase
+
+import (
+	"backend/imaging"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultMaxSize = 10 << 20
+	DefaultQuota   = 100 << 20
+
+	maxFilenameLength = 255
+	defaultFilename   = "file"
+)
+
+// allowedTypes — типы содержимого, которые можно прикреплять. Тип
+// определяется по самому содержимому, а не по заголовкам клиента; HTML и
+// другие исполняемые браузером типы не принимаются.
+var allowedTypes = map[string]bool{
+	"image/png":                true,
+	"image/jpeg":               true,
+	"image/gif":                true,
+	"image/webp":               true,
+	"image/bmp":                true,
+	"application/pdf":          true,
+	"text/plain":               true,
+	"audio/mpeg":               true,
+	"audio/wave":               true,
+	"audio/ogg":                true,
+	"video/mp4":                true,
+	"video/webm":               true,
+	"application/zip":          true,
+	"application/x-gzip":       true,
+	"application/octet-stream": true,
+}
+
+type AttachmentsRepository interface {
+	CreateAttachment(attachment models.Attachment, quota int64) (*models.Attachment, error)
+	GetAttachment(attachmentID uint64) (*models.Attachment, error)
+	ListAttachments(noteID uint64) ([]models.Attachment, error)
+	DeleteAttachment(attachmentID uint64) (*models.Attachment, error)
+	SetAttachmentImage(attachmentID uint64, image models.AttachmentImage, size int64) error
+	StorageUsed(ownerID uint64) (int64, error)
+	TakeOrphanBlobs() ([]string, error)
+}
+
+// BlobStore — хранилище содержимого вложений.
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// TaskQueue выполняет задачи в фоне.
+type TaskQueue interface {
+	Submit(ctx context.Context, name string, run func(ctx context.Context) error) error
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// AttachmentsUsecase хранит сведения о вложениях в репозитории, а
+// содержимое — в хранилище файлов. MaxSize ограничивает размер файла,
+// Quota — суммарный размер вложений владельца. Изображения обрабатываются в
+// очереди Images; без неё они хранятся как загружены.
+type AttachmentsUsecase struct {
+	Repository AttachmentsRepository
+	Blobs      BlobStore
+	Authorizer Authorizer
+	Images     TaskQueue
+	MaxSize    int64
+	Quota      int64
+}
+
+func NewAttachmentsUsecase(repository AttachmentsRepository, blobs BlobStore, authorizer Authorizer, images TaskQueue, maxSize, quota int64) *AttachmentsUsecase {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if quota <= 0 {
+		quota = DefaultQuota
+	}
+	return &AttachmentsUsecase{
+		Repository: repository,
+		Blobs:      blobs,
+		Authorizer: authorizer,
+		Images:     images,
+		MaxSize:    maxSize,
+		Quota:      quota,
+	}
+}
+
+// DetectContentType определяет тип содержимого по его первым байтам и
+// отбрасывает параметры вроде charset.
+func DetectContentType(content []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// cleanFilename оставляет от имени файла клиента только последнюю часть пути
+// без управляющих символов.
+func cleanFilename(filename string) string {
+	filename = strings.ReplaceAll(filename, "\\", "/")
+	filename = filepath.Base(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filename))
+	filename = strings.TrimSpace(filename)
+	if filename == "" || filename == "." || filename == "/" {
+		return defaultFilename
+	}
+	if runes := []rune(filename); len(runes) > maxFilenameLength {
+		filename = string(runes[len(runes)-maxFilenameLength:])
+	}
+	return filename
+}
+
+// Upload прикрепляет файл к заметке. Прикреплять файлы могут пользователи с
+// правом правки заметки.
+func (u *AttachmentsUsecase) Upload(ctx context.Context, ownerID, actorID, noteID uint64, filename string, content io.Reader) (*models.Attachment, error) {
+	note, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleEditor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, u.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", err)
+	}
+	if int64(len(data)) > u.MaxSize {
+		return nil, fmt.Errorf("failed to upload attachment: %w", namederrors.ErrFileTooLarge)
+	}
+	contentType := DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return nil, fmt.Errorf("failed to upload attachment: %w", namederrors.ErrUnsupportedMediaType)
+	}
+
+	// Метаданные удаляются сразу, чтобы координаты и сведения об устройстве
+	// не попали в хранилище даже до обработки изображения.
+	data, orientation, err := imaging.StripMetadata(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", namederrors.ErrUnsupportedMediaType)
+	}
+	var image *models.AttachmentImage
+	if u.Images != nil && imaging.Processable(contentType) {
+		image = &models.AttachmentImage{Status: models.ImagePending}
+	}
+
+	// Квота проверяется до загрузки, чтобы не гонять заведомо лишний файл, и
+	// окончательно — при сохранении сведений о вложении.
+	used, err := u.Repository.StorageUsed(note.OwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", err)
+	}
+	if used+int64(len(data)) > u.Quota {
+		return nil, fmt.Errorf("failed to upload attachment: %w", namederrors.ErrQuotaExceeded)
+	}
+
+	key := fmt.Sprintf("%d/%d/%s", note.OwnerID, note.ID, uuid.NewString())
+	if err = u.Blobs.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, fmt.Errorf("failed to upload attachment: %w", err)
+	}
+
+	attachment, err := u.Repository.CreateAttachment(models.Attachment{
+		NoteID:      note.ID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Key:         key,
+		Image:       image,
+		UploadedBy:  actorID,
+	}, u.Quota)
+	if err != nil {
+		u.deleteBlob(ctx, key)
+		return nil, fmt.Errorf("failed to upload attachment: %w", err)
+	}
+	if image != nil {
+		u.queueImage(ctx, *attachment, orientation)
+	}
+	return attachment, nil
+}
+
+// deleteBlob удаляет содержимое вложения. Сведения о вложении уже удалены,
+// поэтому ошибка только логируется.
+func (u *AttachmentsUsecase) deleteBlob(ctx context.Context, key string) {
+	if err := u.Blobs.Delete(ctx, key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("failed to delete attachment blob")
+	}
+}
+
+func (u *AttachmentsUsecase) ListAttachments(ownerID, actorID, noteID uint64) ([]models.Attachment, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	attachments, err := u.Repository.ListAttachments(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// getNoteAttachment возвращает вложение, только если оно относится к заметке noteID.
+func (u *AttachmentsUsecase) getNoteAttachment(noteID, attachmentID uint64) (*models.Attachment, error) {
+	attachment, err := u.Repository.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if attachment.NoteID != noteID {
+		return nil, namederrors.ErrNotFound
+	}
+	return attachment, nil
+}
+
+// Download возвращает сведения о вложении, вариант изображения размера size
+// (nil — исходный файл) и содержимое; содержимое закрывает вызывающий.
+func (u *AttachmentsUsecase) Download(ctx context.Context, ownerID, actorID, noteID, attachmentID uint64, size string) (*models.Attachment, *models.ImageVariant, io.ReadCloser, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	attachment, err := u.getNoteAttachment(noteID, attachmentID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	variant, err := chooseVariant(attachment, size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to download attachment: %w", err)
+	}
+
+	key := attachment.Key
+	if variant != nil {
+		key = variant.Key
+	}
+	content, err := u.Blobs.Get(ctx, key)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to download attachment: %w", err)
+	}
+	return attachment, variant, content, nil
+}
+
+func (u *AttachmentsUsecase) DeleteAttachment(ctx context.Context, ownerID, actorID, noteID, attachmentID uint64) error {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleEditor); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	if _, err := u.getNoteAttachment(noteID, attachmentID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	deleted, err := u.Repository.DeleteAttachment(attachmentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	u.deleteBlob(ctx, deleted.Key)
+	if deleted.Image != nil {
+		for _, variant := range deleted.Image.Variants {
+			u.deleteBlob(ctx, variant.Key)
+		}
+	}
+	return nil
+}
+
+// StorageUsage возвращает занятое вложениями место и квоту владельца.
+func (u *AttachmentsUsecase) StorageUsage(ownerID, actorID uint64) (*models.StorageUsage, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	used, err := u.Repository.StorageUsed(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	return &models.StorageUsage{Used: used, Quota: u.Quota}, nil
+}
+
+// CleanupOrphans удаляет из хранилища файлов содержимое вложений удалённых
+// заметок и возвращает число удалённых объектов.
+func (u *AttachmentsUsecase) CleanupOrphans(ctx context.Context) (int, error) {
+	keys, err := u.Repository.TakeOrphanBlobs()
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean up attachments: %w", err)
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if err = u.Blobs.Delete(ctx, key); err != nil {
+			log.Error().Err(err).Str("key", key).Msg("failed to delete orphan attachment blob")
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,104 @@
+package attachmentsUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository реализует только методы, которые вызывают тесты.
+type fakeRepository struct {
+	AttachmentsRepository
+	used        int64
+	attachments []models.Attachment
+}
+
+func (r *fakeRepository) StorageUsed(ownerID uint64) (int64, error) {
+	return r.used, nil
+}
+
+func (r *fakeRepository) CreateAttachment(attachment models.Attachment, quota int64) (*models.Attachment, error) {
+	if r.used+attachment.Size > quota {
+		return nil, namederrors.ErrQuotaExceeded
+	}
+	r.used += attachment.Size
+	attachment.ID = uint64(len(r.attachments) + 1)
+	r.attachments = append(r.attachments, attachment)
+	return &attachment, nil
+}
+
+type fakeBlobs struct {
+	blobs map[string][]byte
+}
+
+func (b *fakeBlobs) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	b.blobs[key] = data
+	return nil
+}
+
+func (b *fakeBlobs) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b.blobs[key])), nil
+}
+
+func (b *fakeBlobs) Delete(ctx context.Context, key string) error {
+	delete(b.blobs, key)
+	return nil
+}
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) CheckOwner(ownerID, actorID uint64) error {
+	return nil
+}
+
+func (fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	return &models.Note{ID: noteID, OwnerID: ownerID}, models.RoleOwner, nil
+}
+
+func TestUpload(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		used        int64
+		contentType string
+		wantErr     error
+	}{
+		{name: "text", content: "plain notes", contentType: "text/plain"},
+		{name: "pdf", content: "%PDF-1.4 body", contentType: "application/pdf"},
+		{name: "html is not allowed", content: "<html><script>alert(1)</script></html>", wantErr: namederrors.ErrUnsupportedMediaType},
+		{name: "svg is not allowed", content: `<?xml version="1.0"?><svg></svg>`, wantErr: namederrors.ErrUnsupportedMediaType},
+		{name: "too large", content: strings.Repeat("a", 65), wantErr: namederrors.ErrFileTooLarge},
+		{name: "over quota", content: "plain notes", used: 95, wantErr: namederrors.ErrQuotaExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeRepository{used: test.used}
+			blobs := &fakeBlobs{blobs: make(map[string][]byte)}
+			u := NewAttachmentsUsecase(repository, blobs, fakeAuthorizer{}, nil, 64, 100)
+
+			attachment, err := u.Upload(context.Background(), 1, 1, 7, "../dir/notes.txt", strings.NewReader(test.content))
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr)
+				require.Empty(t, blobs.blobs, "rejected files are not stored")
+				require.Empty(t, repository.attachments)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.contentType, attachment.ContentType, "the type is sniffed from the content")
+			require.Equal(t, "notes.txt", attachment.Filename)
+			require.Equal(t, int64(len(test.content)), attachment.Size)
+			require.Equal(t, []byte(test.content), blobs.blobs[attachment.Key])
+		})
+	}
+}
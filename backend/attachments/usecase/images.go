@@ -0,0 +1,171 @@
+package attachmentsUsecase
+
+import (
+	"backend/imaging"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// imageVariants — уменьшенные копии изображений по длине большей стороны, от
+// меньшей к большей. Копия не создаётся, если изображение уже не больше её.
+// Копии кодируются в формат исходного изображения: в стандартной библиотеке
+// нет кодировщика WebP.
+var imageVariants = []struct {
+	Name string
+	Side int
+}{
+	{Name: "small", Side: 160},
+	{Name: "medium", Side: 640},
+	{Name: "large", Side: 1280},
+}
+
+// chooseVariant выбирает вариант изображения по имени или по длине большей
+// стороны в пикселях — наименьший не меньше неё. nil означает исходный файл:
+// он больше всех вариантов и отдаётся, пока изображение не обработано.
+func chooseVariant(attachment *models.Attachment, size string) (*models.ImageVariant, error) {
+	if size == "" || size == "original" {
+		return nil, nil
+	}
+	side := 0
+	for _, spec := range imageVariants {
+		if spec.Name == size {
+			side = spec.Side
+		}
+	}
+	if side == 0 {
+		parsed, err := strconv.Atoi(size)
+		if err != nil || parsed <= 0 {
+			return nil, namederrors.ErrInvalidImageSize
+		}
+		side = parsed
+	}
+
+	if attachment.Image == nil || attachment.Image.Status != models.ImageReady {
+		return nil, nil
+	}
+	for i, variant := range attachment.Image.Variants {
+		if max(variant.Width, variant.Height) >= side {
+			return &attachment.Image.Variants[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// queueImage ставит изображение в очередь обработки. Если очередь не приняла
+// задачу, изображение остаётся без вариантов.
+func (u *AttachmentsUsecase) queueImage(ctx context.Context, attachment models.Attachment, orientation int) {
+	err := u.Images.Submit(ctx, "image processing", func(ctx context.Context) error {
+		if err := u.processImage(ctx, attachment, orientation); err != nil {
+			u.setImage(attachment.ID, models.AttachmentImage{Status: models.ImageFailed}, attachment.Size)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Uint64("attachment_id", attachment.ID).Msg("failed to queue image processing")
+		u.setImage(attachment.ID, models.AttachmentImage{Status: models.ImageFailed}, attachment.Size)
+	}
+}
+
+func (u *AttachmentsUsecase) setImage(attachmentID uint64, image models.AttachmentImage, size int64) {
+	err := u.Repository.SetAttachmentImage(attachmentID, image, size)
+	if err != nil && !errors.Is(err, namederrors.ErrNotFound) {
+		log.Error().Err(err).Uint64("attachment_id", attachmentID).Msg("failed to save image status")
+	}
+}
+
+// processImage поворачивает изображение по ориентации из удалённых метаданных
+// и создаёт уменьшенные варианты. Повёрнутое изображение заменяет исходное.
+func (u *AttachmentsUsecase) processImage(ctx context.Context, attachment models.Attachment, orientation int) (err error) {
+	content, err := u.Blobs.Get(ctx, attachment.Key)
+	if err != nil {
+		return fmt.Errorf("failed to process image: %w", err)
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		return fmt.Errorf("failed to process image: %w", err)
+	}
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return fmt.Errorf("failed to process image: %w", err)
+	}
+	img = imaging.Orient(img, orientation)
+
+	// Записанные варианты удаляются, если вложение не удалось обновить.
+	var written []string
+	defer func() {
+		if err != nil {
+			for _, key := range written {
+				u.deleteBlob(ctx, key)
+			}
+		}
+	}()
+
+	size := attachment.Size
+	if orientation > 1 {
+		var normalized bytes.Buffer
+		if _, err = imaging.Encode(&normalized, img, attachment.ContentType); err != nil {
+			return fmt.Errorf("failed to encode image: %w", err)
+		}
+		size = int64(normalized.Len())
+		if err = u.Blobs.Put(ctx, attachment.Key, &normalized, size, attachment.ContentType); err != nil {
+			return fmt.Errorf("failed to store image: %w", err)
+		}
+	}
+
+	image := models.AttachmentImage{
+		Status: models.ImageReady,
+		Width:  img.Rect.Dx(),
+		Height: img.Rect.Dy(),
+	}
+	for _, spec := range imageVariants {
+		resized := imaging.Fit(img, spec.Side)
+		if resized == nil {
+			break
+		}
+		var encoded bytes.Buffer
+		var contentType string
+		if contentType, err = imaging.Encode(&encoded, resized, attachment.ContentType); err != nil {
+			return fmt.Errorf("failed to encode image variant: %w", err)
+		}
+		variant := models.ImageVariant{
+			Name:        spec.Name,
+			Width:       resized.Rect.Dx(),
+			Height:      resized.Rect.Dy(),
+			ContentType: contentType,
+			Size:        int64(encoded.Len()),
+			Key:         attachment.Key + "." + spec.Name,
+		}
+		if err = u.Blobs.Put(ctx, variant.Key, &encoded, variant.Size, contentType); err != nil {
+			return fmt.Errorf("failed to store image variant: %w", err)
+		}
+		written = append(written, variant.Key)
+		image.Variants = append(image.Variants, variant)
+	}
+
+	err = u.Repository.SetAttachmentImage(attachment.ID, image, size)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		// Вложение удалили во время обработки: его содержимое уже удалено или
+		// ждёт очистки, но повёрнутое изображение могло записаться заново.
+		if orientation > 1 {
+			u.deleteBlob(ctx, attachment.Key)
+		}
+		for _, key := range written {
+			u.deleteBlob(ctx, key)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to process image: %w", err)
+	}
+	return nil
+}
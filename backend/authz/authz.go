@@ -0,0 +1,155 @@
+package authz
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
+	"fmt"
+)
+
+// Repository — источник заметок и выданных к ним доступов.
+type Repository interface {
+	GetNote(noteID uint64) (*models.Note, error)
+	GetNoteShare(noteID, userID uint64) (*models.NoteShare, error)
+}
+
+// WorkspaceRepository — источник рабочих пространств и их участников.
+type WorkspaceRepository interface {
+	GetWorkspace(workspaceID uint64) (*models.Workspace, error)
+	GetWorkspaceMember(workspaceID, userID uint64) (*models.WorkspaceMember, error)
+}
+
+var roleRanks = map[string]int{
+	models.RoleViewer:    1,
+	models.RoleCommenter: 2,
+	models.RoleEditor:    3,
+	models.RoleOwner:     4,
+}
+
+var workspaceRoleRanks = map[string]int{
+	models.WorkspaceRoleGuest:  1,
+	models.WorkspaceRoleMember: 2,
+	models.WorkspaceRoleAdmin:  3,
+	models.WorkspaceRoleOwner:  4,
+}
+
+// workspaceNoteRoles задаёт роль участника пространства в его заметках. Гости
+// видят только заметки, к которым им выдан доступ.
+var workspaceNoteRoles = map[string]string{
+	models.WorkspaceRoleMember: models.RoleEditor,
+	models.WorkspaceRoleAdmin:  models.RoleOwner,
+	models.WorkspaceRoleOwner:  models.RoleOwner,
+}
+
+// Allows сообщает, даёт ли роль role права роли required.
+func Allows(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// AllowsWorkspace сообщает, даёт ли роль в рабочем пространстве права роли required.
+func AllowsWorkspace(role, required string) bool {
+	rank, ok := workspaceRoleRanks[role]
+	return ok && rank >= workspaceRoleRanks[required]
+}
+
+// CheckSelf разрешает доступ к личным данным пользователя — уведомлениям,
+// напоминаниям, доступам к чужим заметкам — только ему самому, без участников
+// рабочих пространств.
+func CheckSelf(userID, actorID uint64) error {
+	if userID != actorID {
+		return namederrors.ErrForbidden
+	}
+	return nil
+}
+
+// Authorizer проверяет права пользователя, выполняющего запрос (actor), на
+// ресурсы владельца из адреса запроса (owner) — пользователя или рабочего
+// пространства.
+type Authorizer struct {
+	Repository          Repository
+	WorkspaceRepository WorkspaceRepository
+}
+
+func NewAuthorizer(repository Repository, workspaceRepository WorkspaceRepository) *Authorizer {
+	return &Authorizer{
+		Repository:          repository,
+		WorkspaceRepository: workspaceRepository,
+	}
+}
+
+// CheckOwner разрешает доступ к пространству пользователя только ему самому,
+// а к рабочему пространству — его участникам не ниже member: списки, поиск,
+// корзина и создание заметок не выдаются по приглашению к заметке. Вид
+// владельца проверяет маршрут (middleware.UserOwner, middleware.WorkspaceOwner).
+func (a *Authorizer) CheckOwner(ownerID, actorID uint64) error {
+	if ownerID == actorID {
+		return nil
+	}
+	_, _, err := a.AuthorizeWorkspace(ownerID, actorID, models.WorkspaceRoleMember)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		return namederrors.ErrForbidden
+	}
+	return err
+}
+
+// AuthorizeWorkspace возвращает рабочее пространство и роль actorID в нём,
+// если роль не ниже required.
+func (a *Authorizer) AuthorizeWorkspace(workspaceID, actorID uint64, required string) (*models.Workspace, string, error) {
+	workspace, err := a.WorkspaceRepository.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, "", err
+	}
+	member, err := a.WorkspaceRepository.GetWorkspaceMember(workspaceID, actorID)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		return nil, "", namederrors.ErrForbidden
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get workspace member: %w", err)
+	}
+
+	if !AllowsWorkspace(member.Role, required) {
+		return nil, "", namederrors.ErrForbidden
+	}
+	return workspace, member.Role, nil
+}
+
+// AuthorizeNote возвращает заметку владельца ownerID и роль actorID в ней,
+// если роль не ниже required. Владелец имеет роль owner, участник рабочего
+// пространства — роль по своей роли в нём, остальные — роль из выданного
+// доступа. Из нескольких ролей действует старшая.
+func (a *Authorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	note, err := a.Repository.GetNote(noteID)
+	if err != nil {
+		return nil, "", err
+	}
+	if note.OwnerID != ownerID {
+		return nil, "", namederrors.ErrNotFound
+	}
+
+	role := models.RoleOwner
+	if actorID != ownerID {
+		member, err := a.WorkspaceRepository.GetWorkspaceMember(ownerID, actorID)
+		switch {
+		case err == nil:
+			role = workspaceNoteRoles[member.Role]
+		case errors.Is(err, namederrors.ErrNotFound):
+			role = ""
+		default:
+			return nil, "", fmt.Errorf("failed to get workspace member: %w", err)
+		}
+
+		share, err := a.Repository.GetNoteShare(noteID, actorID)
+		if err != nil && !errors.Is(err, namederrors.ErrNotFound) {
+			return nil, "", fmt.Errorf("failed to get note share: %w", err)
+		}
+		if err == nil && !Allows(role, share.Role) {
+			role = share.Role
+		}
+	}
+
+	if !Allows(role, required) {
+		return nil, "", namederrors.ErrForbidden
+	}
+	return note, role, nil
+}
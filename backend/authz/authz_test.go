@@ -0,0 +1,149 @@
+package authz
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	notes      map[uint64]models.Note
+	shares     map[[2]uint64]models.NoteShare
+	workspaces map[uint64]models.Workspace
+	members    map[[2]uint64]models.WorkspaceMember
+}
+
+func (r *fakeRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, ok := r.notes[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &note, nil
+}
+
+func (r *fakeRepository) GetNoteShare(noteID, userID uint64) (*models.NoteShare, error) {
+	share, ok := r.shares[[2]uint64{noteID, userID}]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &share, nil
+}
+
+func (r *fakeRepository) GetWorkspace(workspaceID uint64) (*models.Workspace, error) {
+	workspace, ok := r.workspaces[workspaceID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &workspace, nil
+}
+
+func (r *fakeRepository) GetWorkspaceMember(workspaceID, userID uint64) (*models.WorkspaceMember, error) {
+	member, ok := r.members[[2]uint64{workspaceID, userID}]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &member, nil
+}
+
+// newTestAuthorizer: заметка 1 пользователя 1 и заметка 2 пространства 10,
+// в котором 5 — администратор, 6 — участник, 7 и 2 — гости.
+func newTestAuthorizer() *Authorizer {
+	repository := &fakeRepository{
+		notes: map[uint64]models.Note{
+			1: {ID: 1, OwnerID: 1},
+			2: {ID: 2, OwnerID: 10},
+		},
+		shares: map[[2]uint64]models.NoteShare{
+			{1, 2}: {NoteID: 1, UserID: 2, Role: models.RoleViewer},
+			{1, 3}: {NoteID: 1, UserID: 3, Role: models.RoleEditor},
+			{2, 7}: {NoteID: 2, UserID: 7, Role: models.RoleCommenter},
+			{2, 6}: {NoteID: 2, UserID: 6, Role: models.RoleViewer},
+		},
+		workspaces: map[uint64]models.Workspace{10: {ID: 10}},
+		members: map[[2]uint64]models.WorkspaceMember{
+			{10, 5}: {WorkspaceID: 10, UserID: 5, Role: models.WorkspaceRoleAdmin},
+			{10, 6}: {WorkspaceID: 10, UserID: 6, Role: models.WorkspaceRoleMember},
+			{10, 7}: {WorkspaceID: 10, UserID: 7, Role: models.WorkspaceRoleGuest},
+			{10, 2}: {WorkspaceID: 10, UserID: 2, Role: models.WorkspaceRoleGuest},
+		},
+	}
+	return NewAuthorizer(repository, repository)
+}
+
+func TestAuthorizeNote(t *testing.T) {
+	a := newTestAuthorizer()
+
+	tests := []struct {
+		name     string
+		ownerID  uint64
+		actorID  uint64
+		noteID   uint64
+		required string
+		wantRole string
+		wantErr  error
+	}{
+		{name: "owner", ownerID: 1, actorID: 1, noteID: 1, required: models.RoleOwner, wantRole: models.RoleOwner},
+		{name: "viewer reads", ownerID: 1, actorID: 2, noteID: 1, required: models.RoleViewer, wantRole: models.RoleViewer},
+		{name: "viewer cannot edit", ownerID: 1, actorID: 2, noteID: 1, required: models.RoleEditor, wantErr: namederrors.ErrForbidden},
+		{name: "editor edits", ownerID: 1, actorID: 3, noteID: 1, required: models.RoleEditor, wantRole: models.RoleEditor},
+		{name: "editor cannot delete", ownerID: 1, actorID: 3, noteID: 1, required: models.RoleOwner, wantErr: namederrors.ErrForbidden},
+		{name: "no share", ownerID: 1, actorID: 4, noteID: 1, required: models.RoleViewer, wantErr: namederrors.ErrForbidden},
+		{name: "other owner in path", ownerID: 2, actorID: 2, noteID: 1, required: models.RoleViewer, wantErr: namederrors.ErrNotFound},
+		{name: "missing note", ownerID: 1, actorID: 1, noteID: 3, required: models.RoleViewer, wantErr: namederrors.ErrNotFound},
+		{name: "workspace admin deletes", ownerID: 10, actorID: 5, noteID: 2, required: models.RoleOwner, wantRole: models.RoleOwner},
+		{name: "workspace member edits", ownerID: 10, actorID: 6, noteID: 2, required: models.RoleEditor, wantRole: models.RoleEditor},
+		{name: "workspace member cannot delete", ownerID: 10, actorID: 6, noteID: 2, required: models.RoleOwner, wantErr: namederrors.ErrForbidden},
+		{name: "guest with share", ownerID: 10, actorID: 7, noteID: 2, required: models.RoleCommenter, wantRole: models.RoleCommenter},
+		{name: "guest without share", ownerID: 10, actorID: 2, noteID: 2, required: models.RoleViewer, wantErr: namederrors.ErrForbidden},
+		{name: "not a member", ownerID: 10, actorID: 3, noteID: 2, required: models.RoleViewer, wantErr: namederrors.ErrForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			note, role, err := a.AuthorizeNote(test.ownerID, test.actorID, test.noteID, test.required)
+			if test.wantErr != nil {
+				require.True(t, errors.Is(err, test.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.noteID, note.ID)
+			require.Equal(t, test.wantRole, role)
+		})
+	}
+}
+
+func TestCheckOwner(t *testing.T) {
+	a := newTestAuthorizer()
+
+	require.NoError(t, a.CheckOwner(1, 1))
+	require.True(t, errors.Is(a.CheckOwner(1, 2), namederrors.ErrForbidden))
+	require.NoError(t, a.CheckOwner(10, 5))
+	require.NoError(t, a.CheckOwner(10, 6))
+	require.True(t, errors.Is(a.CheckOwner(10, 7), namederrors.ErrForbidden))
+	require.True(t, errors.Is(a.CheckOwner(10, 3), namederrors.ErrForbidden))
+}
+
+func TestCheckSelf(t *testing.T) {
+	require.NoError(t, CheckSelf(1, 1))
+	require.True(t, errors.Is(CheckSelf(10, 5), namederrors.ErrForbidden), "workspace admins have no personal data of the workspace")
+}
+
+func TestAuthorizeWorkspace(t *testing.T) {
+	a := newTestAuthorizer()
+
+	_, role, err := a.AuthorizeWorkspace(10, 5, models.WorkspaceRoleAdmin)
+	require.NoError(t, err)
+	require.Equal(t, models.WorkspaceRoleAdmin, role)
+
+	_, _, err = a.AuthorizeWorkspace(10, 6, models.WorkspaceRoleAdmin)
+	require.True(t, errors.Is(err, namederrors.ErrForbidden))
+
+	_, _, err = a.AuthorizeWorkspace(10, 3, models.WorkspaceRoleGuest)
+	require.True(t, errors.Is(err, namederrors.ErrForbidden))
+
+	_, _, err = a.AuthorizeWorkspace(11, 5, models.WorkspaceRoleGuest)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound))
+}
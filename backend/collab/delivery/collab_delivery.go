@@ -0,0 +1,145 @@
+package collabDelivery
+
+import (
+	"backend/apiutils"
+	collabUsecase "backend/collab/usecase"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxMessageSize = 1 << 20
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+)
+
+type CollabUsecase interface {
+	Join(userID, editorID, noteID uint64) (*collabUsecase.Client, error)
+	Leave(client *collabUsecase.Client)
+	Handle(client *collabUsecase.Client, msg models.CollabMessage)
+}
+
+type CollabDelivery struct {
+	Usecase  CollabUsecase
+	upgrader websocket.Upgrader
+}
+
+func NewCollabDelivery(usecase CollabUsecase) *CollabDelivery {
+	return &CollabDelivery{
+		Usecase: usecase,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || mw.IsAllowedOrigin(origin)
+			},
+		},
+	}
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// Connect переводит запрос в WebSocket-соединение совместного редактирования заметки.
+func (d *CollabDelivery) Connect(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	editorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	client, err := d.Usecase.Join(userID, editorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to open note")
+		return
+	}
+
+	conn, err := d.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		d.Usecase.Leave(client)
+		log.Info().Err(err).Msg("websocket upgrade failed")
+		return
+	}
+
+	go writeMessages(conn, client)
+	d.readMessages(conn, client)
+}
+
+// readMessages передаёт сообщения клиента в сессию, пока соединение живо.
+func (d *CollabDelivery) readMessages(conn *websocket.Conn, client *collabUsecase.Client) {
+	defer d.Usecase.Leave(client)
+
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var msg models.CollabMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Info().Err(err).Msg("collab connection closed")
+			}
+			return
+		}
+		d.Usecase.Handle(client, msg)
+	}
+}
+
+// writeMessages — единственный писатель в соединение: отправляет сообщения
+// сессии и пинги, а после отключения клиента закрывает соединение.
+func writeMessages(conn *websocket.Conn, client *collabUsecase.Client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-client.Messages():
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
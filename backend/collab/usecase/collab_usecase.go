@@ -0,0 +1,354 @@
+package collabUsecase
+
+import (
+	"backend/authz"
+	"backend/crdt"
+	"backend/events"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PersistDelay — задержка между последней правкой и сохранением текста заметки.
+	PersistDelay = 2 * time.Second
+	// ClientBuffer — размер очереди исходящих сообщений клиента; отстающий
+	// клиент отключается и догоняет состояние при переподключении.
+	ClientBuffer = 256
+
+	serverSite      = "server"
+	persistAttempts = 3
+)
+
+type NotesRepository interface {
+	GetNote(noteID uint64) (*models.Note, error)
+	UpdateNote(note models.Note, editorID uint64) (*models.Note, error)
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// Notifier уведомляет пользователей, упомянутых в тексте сохранённой заметки.
+type Notifier interface {
+	NoteSaved(actorID uint64, note *models.Note, previousText string)
+}
+
+// Client — подключение одного пользователя (вкладки) к сессии редактирования.
+// Клиент без прав на правку получает изменения, но не может их вносить.
+// Site выдаётся сервером на подключение; чужие сайты в операциях клиента
+// отклоняются, иначе совпадающие идентификаторы молча терялись бы.
+type Client struct {
+	UserID  uint64
+	CanEdit bool
+	Site    string
+
+	session  *session
+	messages chan models.CollabMessage
+	closed   bool
+}
+
+// Messages возвращает очередь сообщений для отправки клиенту; она
+// закрывается, когда клиент отключён от сессии.
+func (c *Client) Messages() <-chan models.CollabMessage {
+	return c.messages
+}
+
+// session — общий документ заметки, открытой хотя бы одним клиентом.
+type session struct {
+	mu           sync.Mutex
+	id           string
+	noteID       uint64
+	doc          *crdt.Document
+	clients      map[*Client]struct{}
+	persisted    string
+	persistedIDs []crdt.ID
+	editorID     uint64
+	dirty        bool
+	timer        *time.Timer
+	closed       bool
+}
+
+type CollabUsecase struct {
+	Repository NotesRepository
+	Events     events.Publisher
+	Authorizer Authorizer
+	Notifier   Notifier
+
+	mu       sync.Mutex
+	sessions map[uint64]*session
+}
+
+func NewCollabUsecase(repository NotesRepository, events events.Publisher, authorizer Authorizer, notifier Notifier) *CollabUsecase {
+	return &CollabUsecase{
+		Repository: repository,
+		Events:     events,
+		Authorizer: authorizer,
+		Notifier:   notifier,
+		sessions:   make(map[uint64]*session),
+	}
+}
+
+// Join подключает пользователя userID к совместному редактированию заметки
+// владельца ownerID. Первое подключение создаёт сессию из текста заметки.
+func (u *CollabUsecase) Join(ownerID, userID, noteID uint64) (*Client, error) {
+	note, role, err := u.Authorizer.AuthorizeNote(ownerID, userID, noteID, models.RoleViewer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join note: %w", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s, ok := u.sessions[noteID]
+	if !ok {
+		doc := crdt.FromText(serverSite, note.Text)
+		s = &session{
+			id:           uuid.NewString(),
+			noteID:       noteID,
+			doc:          doc,
+			clients:      make(map[*Client]struct{}),
+			persisted:    note.Text,
+			persistedIDs: doc.IDs(),
+		}
+		u.sessions[noteID] = s
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ok {
+		// Переносим правки, сделанные в обход сессии, до выдачи состояния.
+		u.mergeExternal(s, note.Text)
+	}
+	client := &Client{
+		UserID:   userID,
+		CanEdit:  authz.Allows(role, models.RoleEditor),
+		Site:     uuid.NewString(),
+		session:  s,
+		messages: make(chan models.CollabMessage, ClientBuffer),
+	}
+	s.clients[client] = struct{}{}
+	return client, nil
+}
+
+// Leave отключает клиента. Когда сессию покидает последний клиент,
+// текст сохраняется, а сессия закрывается.
+func (u *CollabUsecase) Leave(client *Client) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := client.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disconnect(client)
+	if len(s.clients) > 0 || s.closed {
+		return
+	}
+
+	s.closed = true
+	delete(u.sessions, s.noteID)
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if s.dirty {
+		if err := u.persist(s); err != nil {
+			log.Error().Err(err).Uint64("note_id", s.noteID).Msg("failed to persist collaborative note")
+		}
+	}
+}
+
+// Handle обрабатывает сообщение клиента; ошибки отправляются ему же.
+func (u *CollabUsecase) Handle(client *Client, msg models.CollabMessage) {
+	s := client.session
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if client.closed {
+		return
+	}
+
+	switch msg.Type {
+	case models.CollabSync:
+		s.sync(client, msg.Session, msg.Vector)
+	case models.CollabOps:
+		if !client.CanEdit {
+			s.send(client, models.CollabMessage{Type: models.CollabError, Error: "read-only access"})
+			return
+		}
+		if !ownOps(client, msg.Ops) {
+			s.send(client, models.CollabMessage{Type: models.CollabError, Error: "operation from another site"})
+			return
+		}
+		applied, err := s.doc.Apply(msg.Ops)
+		if len(applied) > 0 {
+			s.broadcast(client, applied)
+			s.editorID = client.UserID
+			u.schedulePersist(s)
+		}
+		if err != nil {
+			s.send(client, models.CollabMessage{Type: models.CollabError, Error: err.Error()})
+		}
+	default:
+		s.send(client, models.CollabMessage{Type: models.CollabError, Error: "unknown message type"})
+	}
+}
+
+func ownOps(client *Client, ops []crdt.Op) bool {
+	for _, op := range ops {
+		if op.ID.Site != client.Site {
+			return false
+		}
+	}
+	return true
+}
+
+// sync отправляет клиенту операции, которых нет в его векторе состояния.
+// Если клиент знает другую сессию, его состояние устарело целиком.
+func (s *session) sync(client *Client, sessionID string, vector crdt.StateVector) {
+	reset := sessionID != s.id
+	if reset {
+		vector = nil
+	}
+	s.send(client, models.CollabMessage{
+		Type:    models.CollabSync,
+		Session: s.id,
+		Site:    client.Site,
+		Vector:  s.doc.StateVector(),
+		Clock:   s.doc.Clock(),
+		Reset:   reset,
+		Ops:     s.doc.OpsSince(vector),
+	})
+}
+
+func (s *session) broadcast(from *Client, ops []crdt.Op) {
+	msg := models.CollabMessage{Type: models.CollabOps, Ops: ops}
+	for client := range s.clients {
+		if client != from {
+			s.send(client, msg)
+		}
+	}
+}
+
+func (s *session) send(client *Client, msg models.CollabMessage) {
+	select {
+	case client.messages <- msg:
+	default:
+		s.disconnect(client)
+	}
+}
+
+func (s *session) disconnect(client *Client) {
+	if client.closed {
+		return
+	}
+	client.closed = true
+	close(client.messages)
+	delete(s.clients, client)
+}
+
+// Вызывается под блокировкой s.mu
+func (u *CollabUsecase) schedulePersist(s *session) {
+	s.dirty = true
+	if s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(PersistDelay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.timer = nil
+		if s.closed || !s.dirty {
+			return
+		}
+		if err := u.persist(s); err != nil {
+			log.Error().Err(err).Uint64("note_id", s.noteID).Msg("failed to persist collaborative note")
+		}
+	})
+}
+
+// persist сохраняет сошедшийся текст в заметку. Запись условная по версии
+// заметки, поэтому параллельная правка через API не теряется, а вливается
+// в документ перед повторной попыткой.
+// Вызывается под блокировкой s.mu
+func (u *CollabUsecase) persist(s *session) error {
+	for attempt := 0; attempt < persistAttempts; attempt++ {
+		note, err := u.Repository.GetNote(s.noteID)
+		if err != nil {
+			return fmt.Errorf("failed to persist note: %w", err)
+		}
+		u.mergeExternal(s, note.Text)
+
+		text := s.doc.Text()
+		if text != note.Text {
+			previousText := note.Text
+			note.Text = text
+			updated, err := u.Repository.UpdateNote(*note, s.editorID)
+			if errors.Is(err, namederrors.ErrVersionConflict) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to persist note: %w", err)
+			}
+			u.Events.Publish(updated.OwnerID, models.NoteEvent{Type: models.NoteUpdated, NoteID: updated.ID, Note: updated})
+			if u.Notifier != nil {
+				u.Notifier.NoteSaved(s.editorID, updated, previousText)
+			}
+		}
+
+		s.persisted = text
+		s.persistedIDs = s.doc.IDs()
+		s.dirty = false
+		return nil
+	}
+	return fmt.Errorf("failed to persist note: %w", namederrors.ErrVersionConflict)
+}
+
+// mergeExternal вливает в документ изменения текста заметки, сделанные с
+// момента последнего сохранения в обход сессии, и рассылает их клиентам.
+// Вызывается под блокировкой s.mu
+func (u *CollabUsecase) mergeExternal(s *session, text string) {
+	if text == s.persisted {
+		return
+	}
+
+	old, current := []rune(s.persisted), []rune(text)
+	prefix := 0
+	for prefix < len(old) && prefix < len(current) && old[prefix] == current[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(current)-prefix &&
+		old[len(old)-1-suffix] == current[len(current)-1-suffix] {
+		suffix++
+	}
+
+	ids := s.persistedIDs
+	ops := s.doc.DeleteIDs(serverSite, ids[prefix:len(old)-suffix])
+	var after crdt.ID
+	if prefix > 0 {
+		after = ids[prefix-1]
+	}
+	inserted := s.doc.InsertAfter(serverSite, after, string(current[prefix:len(current)-suffix]))
+	ops = append(ops, inserted...)
+
+	textIDs := make([]crdt.ID, 0, len(current))
+	textIDs = append(textIDs, ids[:prefix]...)
+	for _, op := range inserted {
+		textIDs = append(textIDs, op.ID)
+	}
+	textIDs = append(textIDs, ids[len(old)-suffix:]...)
+	s.persisted = text
+	s.persistedIDs = textIDs
+
+	if len(ops) > 0 {
+		s.broadcast(nil, ops)
+	}
+}
@@ -0,0 +1,254 @@
+package collabUsecase
+
+import (
+	"backend/crdt"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	mu      sync.Mutex
+	note    models.Note
+	updates []uint64
+	// external — правки в обход сессии: каждая следующая запись заметки
+	// проигрывает гонку с очередной из них.
+	external []string
+}
+
+func (r *fakeRepository) GetNote(noteID uint64) (*models.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if noteID != r.note.ID {
+		return nil, namederrors.ErrNotFound
+	}
+	note := r.note
+	return &note, nil
+}
+
+func (r *fakeRepository) UpdateNote(note models.Note, editorID uint64) (*models.Note, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.external) > 0 {
+		r.note.Text = r.external[0]
+		r.note.Version++
+		r.external = r.external[1:]
+	}
+	if note.Version != r.note.Version {
+		return nil, &namederrors.VersionConflictError{CurrentVersion: r.note.Version}
+	}
+	note.Version++
+	r.note = note
+	r.updates = append(r.updates, editorID)
+	updated := r.note
+	return &updated, nil
+}
+
+func (r *fakeRepository) setText(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.note.Text = text
+	r.note.Version++
+}
+
+type fakeEvents struct {
+	events []models.NoteEvent
+}
+
+func (e *fakeEvents) Publish(userID uint64, event models.NoteEvent) {
+	e.events = append(e.events, event)
+}
+
+// fakeAuthorizer даёт пользователю 1 право правки, остальным — чтение.
+type fakeAuthorizer struct {
+	repository *fakeRepository
+}
+
+func (a *fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	note, err := a.repository.GetNote(noteID)
+	if err != nil {
+		return nil, "", err
+	}
+	if actorID == 1 {
+		return note, models.RoleEditor, nil
+	}
+	return note, models.RoleViewer, nil
+}
+
+func newTestUsecase(text string) (*CollabUsecase, *fakeRepository, *fakeEvents) {
+	repository := &fakeRepository{note: models.Note{ID: 1, OwnerID: 1, Text: text, Version: 1}}
+	events := &fakeEvents{}
+	return NewCollabUsecase(repository, events, &fakeAuthorizer{repository: repository}, nil), repository, events
+}
+
+// replica подключает клиента и возвращает его копию документа.
+func replica(t *testing.T, u *CollabUsecase, client *Client) *crdt.Document {
+	u.Handle(client, models.CollabMessage{Type: models.CollabSync})
+	msg := <-client.Messages()
+	require.Equal(t, models.CollabSync, msg.Type)
+	require.Equal(t, client.Site, msg.Site)
+
+	doc := crdt.NewDocument()
+	_, err := doc.Apply(msg.Ops)
+	require.NoError(t, err)
+	return doc
+}
+
+func TestJoinLeave(t *testing.T) {
+	u, repository, events := newTestUsecase("hello")
+
+	editor, err := u.Join(1, 1, 1)
+	require.NoError(t, err)
+	viewer, err := u.Join(1, 2, 1)
+	require.NoError(t, err)
+	require.Same(t, editor.session, viewer.session)
+	require.True(t, editor.CanEdit)
+	require.False(t, viewer.CanEdit)
+	require.NotEqual(t, editor.Site, viewer.Site)
+	require.NotEqual(t, serverSite, editor.Site)
+
+	doc := replica(t, u, editor)
+	require.Equal(t, "hello", doc.Text())
+	u.Handle(editor, models.CollabMessage{Type: models.CollabOps, Ops: doc.Insert(editor.Site, 5, "!")})
+	msg := <-viewer.Messages()
+	require.Equal(t, models.CollabOps, msg.Type)
+	require.Len(t, msg.Ops, 1)
+
+	u.Leave(editor)
+	_, ok := <-editor.Messages()
+	require.False(t, ok)
+	require.Contains(t, u.sessions, uint64(1))
+	require.Empty(t, repository.updates, "session is persisted only by timer or on the last leave")
+
+	u.Leave(viewer)
+	require.NotContains(t, u.sessions, uint64(1))
+	require.Equal(t, "hello!", repository.note.Text)
+	require.Equal(t, []uint64{1}, repository.updates)
+	require.Len(t, events.events, 1)
+
+	again, err := u.Join(1, 1, 1)
+	require.NoError(t, err)
+	require.NotSame(t, editor.session, again.session)
+	u.Leave(again)
+}
+
+func TestHandleRejectsOps(t *testing.T) {
+	u, _, _ := newTestUsecase("hello")
+	editor, err := u.Join(1, 1, 1)
+	require.NoError(t, err)
+	viewer, err := u.Join(1, 2, 1)
+	require.NoError(t, err)
+	doc := replica(t, u, editor)
+
+	tests := []struct {
+		name   string
+		client *Client
+		ops    []crdt.Op
+		err    string
+	}{
+		{
+			name:   "server site",
+			client: editor,
+			ops:    doc.Insert(serverSite, 0, "x"),
+			err:    "operation from another site",
+		},
+		{
+			name:   "other client site",
+			client: editor,
+			ops:    doc.Insert(viewer.Site, 0, "x"),
+			err:    "operation from another site",
+		},
+		{
+			name:   "clock not after dependency",
+			client: editor,
+			ops:    []crdt.Op{{Type: crdt.OpInsert, ID: crdt.ID{Site: editor.Site, Clock: 1}, After: crdt.ID{Site: serverSite, Clock: 1}, Value: "x"}},
+			err:    crdt.ErrInvalidOp.Error(),
+		},
+		{
+			name:   "read-only",
+			client: viewer,
+			ops:    doc.Insert(viewer.Site, 0, "x"),
+			err:    "read-only access",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u.Handle(test.client, models.CollabMessage{Type: models.CollabOps, Ops: test.ops})
+			msg := <-test.client.Messages()
+			require.Equal(t, models.CollabError, msg.Type)
+			require.Equal(t, test.err, msg.Error)
+			require.Equal(t, "hello", editor.session.doc.Text())
+		})
+	}
+
+	u.Leave(editor)
+	u.Leave(viewer)
+}
+
+func TestPersistRetriesOnConflict(t *testing.T) {
+	u, repository, events := newTestUsecase("hello")
+	editor, err := u.Join(1, 1, 1)
+	require.NoError(t, err)
+	doc := replica(t, u, editor)
+	u.Handle(editor, models.CollabMessage{Type: models.CollabOps, Ops: doc.Insert(editor.Site, 5, "!")})
+
+	repository.external = []string{"Ahello", "ABhello"}
+	u.Leave(editor)
+
+	require.Equal(t, "ABhello!", repository.note.Text)
+	require.Equal(t, []uint64{1}, repository.updates)
+	require.Len(t, events.events, 1)
+	require.Equal(t, "ABhello!", events.events[0].Note.Text)
+}
+
+func TestPersistGivesUp(t *testing.T) {
+	u, repository, events := newTestUsecase("hello")
+	editor, err := u.Join(1, 1, 1)
+	require.NoError(t, err)
+	doc := replica(t, u, editor)
+	u.Handle(editor, models.CollabMessage{Type: models.CollabOps, Ops: doc.Insert(editor.Site, 5, "!")})
+
+	repository.external = []string{"a", "b", "c"}
+	s := editor.session
+	s.mu.Lock()
+	err = u.persist(s)
+	s.mu.Unlock()
+	require.ErrorIs(t, err, namederrors.ErrVersionConflict)
+	require.True(t, s.dirty)
+	require.Empty(t, repository.updates)
+	require.Empty(t, events.events)
+
+	u.Leave(editor)
+}
+
+func TestMergeExternal(t *testing.T) {
+	u, repository, _ := newTestUsecase("hello world")
+	editor, err := u.Join(1, 1, 1)
+	require.NoError(t, err)
+	doc := replica(t, u, editor)
+
+	repository.setText("hello, brave world")
+	viewer, err := u.Join(1, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, "hello, brave world", editor.session.doc.Text())
+
+	msg := <-editor.Messages()
+	require.Equal(t, models.CollabOps, msg.Type)
+	_, err = doc.Apply(msg.Ops)
+	require.NoError(t, err)
+	require.Equal(t, "hello, brave world", doc.Text())
+	require.Equal(t, "hello, brave world", replica(t, u, viewer).Text())
+
+	repository.setText("brave world")
+	u.Handle(editor, models.CollabMessage{Type: models.CollabOps, Ops: doc.Insert(editor.Site, 18, "!")})
+	u.Leave(viewer)
+	u.Leave(editor)
+	require.Equal(t, "brave world!", repository.note.Text)
+}
@@ -0,0 +1,313 @@
+package commentsDelivery
+
+import (
+	"backend/apiutils"
+	commentsUsecase "backend/comments/usecase"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/validation"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type CommentsUsecase interface {
+	ListThreads(userID, actorID, noteID uint64, status string) ([]models.CommentThread, error)
+	GetThread(userID, actorID, noteID, threadID uint64) (*models.CommentThread, error)
+	CreateThread(userID, actorID, noteID uint64, text string, anchor *models.CommentAnchor) (*models.CommentThread, error)
+	Reply(userID, actorID, noteID, threadID uint64, text string) (*models.Comment, error)
+	SetResolved(userID, actorID, noteID, threadID uint64, resolved bool) (*models.CommentThread, error)
+	EditComment(userID, actorID, noteID, threadID, commentID uint64, text string) (*models.Comment, error)
+	DeleteComment(userID, actorID, noteID, threadID, commentID uint64) (bool, error)
+}
+
+type CommentsDelivery struct {
+	Usecase CommentsUsecase
+}
+
+func NewCommentsDelivery(usecase CommentsUsecase) *CommentsDelivery {
+	return &CommentsDelivery{
+		Usecase: usecase,
+	}
+}
+
+type anchorRequest struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type threadRequest struct {
+	Text   string         `json:"text" valid:"required"`
+	Anchor *anchorRequest `json:"anchor,omitempty"`
+}
+
+type commentRequest struct {
+	Text string `json:"text" valid:"required"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseNoteVars читает владельца и note_id из пути и пользователя сессии,
+// при ошибке сам пишет ответ.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (userID, actorID, noteID uint64, ok bool) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, 0, false
+	}
+	noteID, err = parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, 0, false
+	}
+	return userID, actorID, noteID, true
+}
+
+// parseThreadVars дополняет parseNoteVars идентификатором обсуждения.
+func parseThreadVars(w http.ResponseWriter, r *http.Request) (userID, actorID, noteID, threadID uint64, ok bool) {
+	userID, actorID, noteID, ok = parseNoteVars(w, r)
+	if !ok {
+		return 0, 0, 0, 0, false
+	}
+	threadID, err := parseUintVar(r, "thread_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid thread ID")
+		return 0, 0, 0, 0, false
+	}
+	return userID, actorID, noteID, threadID, true
+}
+
+func (d *CommentsDelivery) ListThreads(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", commentsUsecase.StatusAll, commentsUsecase.StatusUnresolved, commentsUsecase.StatusResolved:
+	default:
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	threads, err := d.Usecase.ListThreads(userID, actorID, noteID, status)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list threads")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, threads)
+}
+
+func (d *CommentsDelivery) CreateThread(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req threadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+	var anchor *models.CommentAnchor
+	if req.Anchor != nil {
+		anchor = &models.CommentAnchor{Start: req.Anchor.Start, End: req.Anchor.End}
+	}
+
+	thread, err := d.Usecase.CreateThread(userID, actorID, noteID, req.Text, anchor)
+	if errors.Is(err, namederrors.ErrInvalidAnchor) {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid comment anchor")
+		return
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create thread")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, thread)
+}
+
+func (d *CommentsDelivery) GetThread(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, threadID, ok := parseThreadVars(w, r)
+	if !ok {
+		return
+	}
+
+	thread, err := d.Usecase.GetThread(userID, actorID, noteID, threadID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "thread not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get thread")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, thread)
+}
+
+func (d *CommentsDelivery) Reply(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, threadID, ok := parseThreadVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	comment, err := d.Usecase.Reply(userID, actorID, noteID, threadID, req.Text)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "thread not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to reply")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, comment)
+}
+
+func (d *CommentsDelivery) ResolveThread(w http.ResponseWriter, r *http.Request) {
+	d.setResolved(w, r, true)
+}
+
+func (d *CommentsDelivery) ReopenThread(w http.ResponseWriter, r *http.Request) {
+	d.setResolved(w, r, false)
+}
+
+func (d *CommentsDelivery) setResolved(w http.ResponseWriter, r *http.Request, resolved bool) {
+	userID, actorID, noteID, threadID, ok := parseThreadVars(w, r)
+	if !ok {
+		return
+	}
+
+	thread, err := d.Usecase.SetResolved(userID, actorID, noteID, threadID, resolved)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "thread not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to update thread")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, thread)
+}
+
+func (d *CommentsDelivery) EditComment(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, threadID, ok := parseThreadVars(w, r)
+	if !ok {
+		return
+	}
+	commentID, err := parseUintVar(r, "comment_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	var req commentRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	comment, err := d.Usecase.EditComment(userID, actorID, noteID, threadID, commentID, req.Text)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to edit comment")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, comment)
+}
+
+func (d *CommentsDelivery) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, threadID, ok := parseThreadVars(w, r)
+	if !ok {
+		return
+	}
+	commentID, err := parseUintVar(r, "comment_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	threadDeleted, err := d.Usecase.DeleteComment(userID, actorID, noteID, threadID, commentID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete comment")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]interface{}{"status": "deleted", "thread_deleted": threadDeleted})
+}
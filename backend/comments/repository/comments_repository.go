@@ -0,0 +1,70 @@
+package commentsRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type CommentsRepository struct {
+	Store *store.Store
+}
+
+func NewCommentsRepository(store *store.Store) *CommentsRepository {
+	return &CommentsRepository{
+		Store: store,
+	}
+}
+
+func (r *CommentsRepository) CreateThread(thread models.CommentThread, comment models.Comment) (*models.CommentThread, error) {
+	created, err := r.Store.CreateThread(thread, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create thread: %w", err)
+	}
+	return &created, nil
+}
+
+func (r *CommentsRepository) GetThread(threadID uint64) (*models.CommentThread, error) {
+	thread, err := r.Store.GetThread(threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+	return &thread, nil
+}
+
+func (r *CommentsRepository) ListThreads(noteID uint64) ([]models.CommentThread, error) {
+	threads := r.Store.ListThreads(noteID)
+	return threads, nil
+}
+
+func (r *CommentsRepository) AddComment(threadID uint64, comment models.Comment) (*models.Comment, error) {
+	added, err := r.Store.AddComment(threadID, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add comment: %w", err)
+	}
+	return &added, nil
+}
+
+func (r *CommentsRepository) UpdateComment(threadID, commentID uint64, text string) (*models.Comment, error) {
+	comment, err := r.Store.UpdateComment(threadID, commentID, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	return &comment, nil
+}
+
+func (r *CommentsRepository) DeleteComment(threadID, commentID uint64) (bool, error) {
+	threadDeleted, err := r.Store.DeleteComment(threadID, commentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return threadDeleted, nil
+}
+
+func (r *CommentsRepository) SetThreadResolved(threadID uint64, resolved bool, userID uint64) (*models.CommentThread, error) {
+	thread, err := r.Store.SetThreadResolved(threadID, resolved, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set thread resolved: %w", err)
+	}
+	return &thread, nil
+}
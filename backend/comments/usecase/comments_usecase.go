@@ -0,0 +1,220 @@
+package commentsUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Отбор обсуждений по статусу
+const (
+	StatusAll        = "all"
+	StatusUnresolved = "unresolved"
+	StatusResolved   = "resolved"
+)
+
+type CommentsRepository interface {
+	CreateThread(thread models.CommentThread, comment models.Comment) (*models.CommentThread, error)
+	GetThread(threadID uint64) (*models.CommentThread, error)
+	ListThreads(noteID uint64) ([]models.CommentThread, error)
+	AddComment(threadID uint64, comment models.Comment) (*models.Comment, error)
+	UpdateComment(threadID, commentID uint64, text string) (*models.Comment, error)
+	DeleteComment(threadID, commentID uint64) (bool, error)
+	SetThreadResolved(threadID uint64, resolved bool, userID uint64) (*models.CommentThread, error)
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// Notifier уведомляет упомянутых в комментарии пользователей и участников
+// обсуждения.
+type Notifier interface {
+	CommentPosted(actorID uint64, thread *models.CommentThread, comment *models.Comment, previousText string)
+}
+
+type CommentsUsecase struct {
+	Repository CommentsRepository
+	Authorizer Authorizer
+	Notifier   Notifier
+}
+
+func NewCommentsUsecase(repository CommentsRepository, authorizer Authorizer, notifier Notifier) *CommentsUsecase {
+	return &CommentsUsecase{
+		Repository: repository,
+		Authorizer: authorizer,
+		Notifier:   notifier,
+	}
+}
+
+// notify сообщает о новом или изменённом комментарии. thread передаётся в
+// состоянии до добавления комментария.
+func (u *CommentsUsecase) notify(actorID uint64, thread *models.CommentThread, comment *models.Comment, previousText string) {
+	if u.Notifier == nil {
+		return
+	}
+	u.Notifier.CommentPosted(actorID, thread, comment, previousText)
+}
+
+// ListThreads возвращает обсуждения заметки, отобранные по статусу.
+func (u *CommentsUsecase) ListThreads(ownerID, actorID, noteID uint64, status string) ([]models.CommentThread, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+
+	threads, err := u.Repository.ListThreads(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list threads: %w", err)
+	}
+	if status == "" || status == StatusAll {
+		return threads, nil
+	}
+	return slices.DeleteFunc(threads, func(thread models.CommentThread) bool {
+		return thread.Resolved != (status == StatusResolved)
+	}), nil
+}
+
+// getNoteThread возвращает обсуждение, только если оно относится к заметке noteID.
+func (u *CommentsUsecase) getNoteThread(noteID, threadID uint64) (*models.CommentThread, error) {
+	thread, err := u.Repository.GetThread(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.NoteID != noteID {
+		return nil, namederrors.ErrNotFound
+	}
+	return thread, nil
+}
+
+func (u *CommentsUsecase) GetThread(ownerID, actorID, noteID, threadID uint64) (*models.CommentThread, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+
+	thread, err := u.getNoteThread(noteID, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread: %w", err)
+	}
+	return thread, nil
+}
+
+// CreateThread открывает обсуждение всей заметки или, если задан anchor,
+// фрагмента её текста.
+func (u *CommentsUsecase) CreateThread(ownerID, actorID, noteID uint64, text string, anchor *models.CommentAnchor) (*models.CommentThread, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleCommenter); err != nil {
+		return nil, fmt.Errorf("failed to create thread: %w", err)
+	}
+
+	thread := models.CommentThread{
+		NoteID:    noteID,
+		Anchor:    anchor,
+		CreatedBy: actorID,
+	}
+	comment := models.Comment{
+		AuthorID: actorID,
+		Text:     strings.TrimSpace(text),
+	}
+	created, err := u.Repository.CreateThread(thread, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create thread: %w", err)
+	}
+	first := created.Comments[0]
+	u.notify(actorID, &models.CommentThread{ID: created.ID, NoteID: created.NoteID}, &first, "")
+	return created, nil
+}
+
+func (u *CommentsUsecase) Reply(ownerID, actorID, noteID, threadID uint64, text string) (*models.Comment, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleCommenter); err != nil {
+		return nil, fmt.Errorf("failed to reply: %w", err)
+	}
+	thread, err := u.getNoteThread(noteID, threadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reply: %w", err)
+	}
+
+	comment, err := u.Repository.AddComment(threadID, models.Comment{
+		AuthorID: actorID,
+		Text:     strings.TrimSpace(text),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reply: %w", err)
+	}
+	u.notify(actorID, thread, comment, "")
+	return comment, nil
+}
+
+// SetResolved закрывает обсуждение или открывает его снова.
+func (u *CommentsUsecase) SetResolved(ownerID, actorID, noteID, threadID uint64, resolved bool) (*models.CommentThread, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleCommenter); err != nil {
+		return nil, fmt.Errorf("failed to resolve thread: %w", err)
+	}
+	if _, err := u.getNoteThread(noteID, threadID); err != nil {
+		return nil, fmt.Errorf("failed to resolve thread: %w", err)
+	}
+
+	thread, err := u.Repository.SetThreadResolved(threadID, resolved, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve thread: %w", err)
+	}
+	return thread, nil
+}
+
+// findComment возвращает комментарий обсуждения заметки noteID.
+func (u *CommentsUsecase) findComment(noteID, threadID, commentID uint64) (*models.Comment, error) {
+	thread, err := u.getNoteThread(noteID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range thread.Comments {
+		if comment.ID == commentID {
+			return &comment, nil
+		}
+	}
+	return nil, namederrors.ErrNotFound
+}
+
+// EditComment меняет текст комментария. Править комментарий может только автор.
+func (u *CommentsUsecase) EditComment(ownerID, actorID, noteID, threadID, commentID uint64, text string) (*models.Comment, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleCommenter); err != nil {
+		return nil, fmt.Errorf("failed to edit comment: %w", err)
+	}
+	comment, err := u.findComment(noteID, threadID, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit comment: %w", err)
+	}
+	if comment.AuthorID != actorID {
+		return nil, fmt.Errorf("failed to edit comment: %w", namederrors.ErrForbidden)
+	}
+
+	previousText := comment.Text
+	comment, err = u.Repository.UpdateComment(threadID, commentID, strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit comment: %w", err)
+	}
+	u.notify(actorID, &models.CommentThread{ID: threadID, NoteID: noteID}, comment, previousText)
+	return comment, nil
+}
+
+// DeleteComment удаляет комментарий автора; владелец заметки может удалить
+// любой. Вместе с последним комментарием удаляется и обсуждение.
+func (u *CommentsUsecase) DeleteComment(ownerID, actorID, noteID, threadID, commentID uint64) (bool, error) {
+	_, role, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleCommenter)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	comment, err := u.findComment(noteID, threadID, commentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if comment.AuthorID != actorID && role != models.RoleOwner {
+		return false, fmt.Errorf("failed to delete comment: %w", namederrors.ErrForbidden)
+	}
+
+	threadDeleted, err := u.Repository.DeleteComment(threadID, commentID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return threadDeleted, nil
+}
@@ -14,9 +14,106 @@ type CookieConfig struct {
 	SessionDuration int `mapstructure:"session_duration"`
 }
 
+type HistoryConfig struct {
+	MaxRevisions  int `mapstructure:"max_revisions"`
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
+type TrashConfig struct {
+	RetentionDays        int `mapstructure:"retention_days"`
+	PurgeIntervalMinutes int `mapstructure:"purge_interval_minutes"`
+}
+
+type PresenceConfig struct {
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+}
+
+type ChangesConfig struct {
+	LogSize int `mapstructure:"log_size"`
+}
+
+type PublishConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+}
+
+type NotificationsConfig struct {
+	ReminderIntervalSeconds int `mapstructure:"reminder_interval_seconds"`
+}
+
+// AttachmentsConfig задаёт ограничения вложений и хранилище их содержимого:
+// "local" — каталог LocalDir, "s3" — S3-совместимое хранилище.
+type AttachmentsConfig struct {
+	MaxSizeMB              int      `mapstructure:"max_size_mb"`
+	QuotaMB                int      `mapstructure:"quota_mb"`
+	Storage                string   `mapstructure:"storage"`
+	LocalDir               string   `mapstructure:"local_dir"`
+	S3                     S3Config `mapstructure:"s3"`
+	CleanupIntervalMinutes int      `mapstructure:"cleanup_interval_minutes"`
+}
+
+// S3Config — S3-совместимое хранилище вложений. PathStyle адресует бакет
+// путём (endpoint/bucket/key), как принято у MinIO.
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PathStyle bool   `mapstructure:"path_style"`
+}
+
+type ImagesConfig struct {
+	Workers   int `mapstructure:"workers"`
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// ImportsConfig задаёт очередь переноса заметок из других приложений и
+// предельный размер загружаемой выгрузки.
+type ImportsConfig struct {
+	Workers   int `mapstructure:"workers"`
+	QueueSize int `mapstructure:"queue_size"`
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+}
+
+// ExportConfig задаёт выгрузку документов. PDFFont — путь к шрифту TrueType
+// для PDF: без него текст выводится стандартными шрифтами PDF, в которых нет,
+// например, кириллицы.
+type ExportConfig struct {
+	PDFFont string `mapstructure:"pdf_font"`
+}
+
+// NoteTemplateConfig — шаблон заметки. В заголовок, текст и папку
+// подставляются переменные вида {{date}} и {{user}}.
+type NoteTemplateConfig struct {
+	Name     string   `mapstructure:"name"`
+	Title    string   `mapstructure:"title"`
+	Text     string   `mapstructure:"text"`
+	Folder   string   `mapstructure:"folder"`
+	Tags     []string `mapstructure:"tags"`
+	Favorite bool     `mapstructure:"favorite"`
+}
+
+// TemplatesConfig задаёт шаблоны стартовых заметок нового пользователя. Без
+// раздела onboarding используются встроенные, пустой список отключает их.
+type TemplatesConfig struct {
+	Onboarding []NoteTemplateConfig `mapstructure:"onboarding"`
+}
+
 type Config struct {
-	Cors   CorsConfig   `mapstructure:"cors"`
-	Cookie CookieConfig `mapstructure:"cookie"`
+	Cors     CorsConfig     `mapstructure:"cors"`
+	Cookie   CookieConfig   `mapstructure:"cookie"`
+	History  HistoryConfig  `mapstructure:"history"`
+	Trash    TrashConfig    `mapstructure:"trash"`
+	Presence PresenceConfig `mapstructure:"presence"`
+	Changes  ChangesConfig  `mapstructure:"changes"`
+	Publish  PublishConfig  `mapstructure:"publish"`
+
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Attachments   AttachmentsConfig   `mapstructure:"attachments"`
+	Images        ImagesConfig        `mapstructure:"images"`
+	Imports       ImportsConfig       `mapstructure:"imports"`
+	Export        ExportConfig        `mapstructure:"export"`
+	Templates     TemplatesConfig     `mapstructure:"templates"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -55,3 +55,14 @@ func ReadConfigPath() (string, error) {
 
 	return configPath, nil
 }
+
+// ReadS3Credentials читает ключи S3 из S3_ACCESS_KEY и S3_SECRET_KEY, чтобы
+// не хранить их в config.yaml.
+func ReadS3Credentials() (accessKey, secretKey string, err error) {
+	err = loadEnvFile()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load env file: %w", err)
+	}
+
+	return viper.GetString("S3_ACCESS_KEY"), viper.GetString("S3_SECRET_KEY"), nil
+}
@@ -0,0 +1,311 @@
+package crdt
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPending ограничивает число операций, ожидающих своих зависимостей.
+const maxPending = 10000
+
+var (
+	ErrInvalidOp      = errors.New("invalid operation")
+	ErrTooManyPending = errors.New("too many pending operations")
+)
+
+// ID — уникальный идентификатор операции: сайт (клиент) и лэмпортовы часы.
+type ID struct {
+	Site  string `json:"site"`
+	Clock uint64 `json:"clock"`
+}
+
+func (id ID) IsZero() bool {
+	return id.Clock == 0
+}
+
+// Less упорядочивает идентификаторы по часам, при равенстве — по сайту.
+func (id ID) Less(other ID) bool {
+	if id.Clock != other.Clock {
+		return id.Clock < other.Clock
+	}
+	return id.Site < other.Site
+}
+
+type OpType string
+
+const (
+	OpInsert OpType = "insert"
+	OpDelete OpType = "delete"
+)
+
+// Op — операция над последовательностью. Вставка кладёт символ Value сразу после
+// элемента After (нулевой After — начало текста), удаление помечает Target.
+type Op struct {
+	Type   OpType `json:"type"`
+	ID     ID     `json:"id"`
+	After  ID     `json:"after"`
+	Target ID     `json:"target"`
+	Value  string `json:"value,omitempty"`
+}
+
+// StateVector хранит для каждого сайта максимальные часы применённых операций.
+type StateVector map[string]uint64
+
+type element struct {
+	id      ID
+	value   string
+	deleted bool
+	next    *element
+}
+
+// Document — реплика текста в виде RGA (Replicated Growable Array).
+// Клиенты обязаны выдавать операциям лэмпортовы часы: больше любых
+// уже виденных ими, тогда все реплики сходятся к одному тексту
+// независимо от порядка доставки операций.
+type Document struct {
+	head     *element
+	elements map[ID]*element
+	deletes  map[ID]bool
+	log      []Op
+	pending  []Op
+	vector   StateVector
+	clock    uint64
+}
+
+func NewDocument() *Document {
+	return &Document{
+		head:     &element{},
+		elements: make(map[ID]*element),
+		deletes:  make(map[ID]bool),
+		vector:   make(StateVector),
+	}
+}
+
+// FromText создаёт документ с текстом, вставленным от имени site.
+func FromText(site, text string) *Document {
+	d := NewDocument()
+	d.Insert(site, 0, text)
+	return d
+}
+
+// Apply применяет операции и возвращает те, что были применены впервые,
+// включая отложенные ранее, у которых появились зависимости. Повторная
+// доставка операции ничего не меняет.
+func (d *Document) Apply(ops []Op) ([]Op, error) {
+	var applied []Op
+	for _, op := range ops {
+		if err := validate(op); err != nil {
+			return applied, err
+		}
+		if d.has(op.ID) {
+			continue
+		}
+		if !d.ready(op) {
+			if len(d.pending) >= maxPending {
+				return applied, ErrTooManyPending
+			}
+			d.pending = append(d.pending, op)
+			continue
+		}
+		d.integrate(op)
+		applied = append(applied, op)
+		applied = d.flushPending(applied)
+	}
+	return applied, nil
+}
+
+// validate проверяет форму операции. Часы операции должны быть больше часов
+// элемента, от которого она зависит: автор видел этот элемент, когда её создавал.
+func validate(op Op) error {
+	if op.ID.IsZero() {
+		return ErrInvalidOp
+	}
+	switch op.Type {
+	case OpInsert:
+		if utf8.RuneCountInString(op.Value) != 1 || op.ID.Clock <= op.After.Clock {
+			return ErrInvalidOp
+		}
+	case OpDelete:
+		if op.Target.IsZero() || op.ID.Clock <= op.Target.Clock {
+			return ErrInvalidOp
+		}
+	default:
+		return ErrInvalidOp
+	}
+	return nil
+}
+
+func (d *Document) has(id ID) bool {
+	_, ok := d.elements[id]
+	return ok || d.deletes[id]
+}
+
+func (d *Document) ready(op Op) bool {
+	if op.Type == OpDelete {
+		_, ok := d.elements[op.Target]
+		return ok
+	}
+	if op.After.IsZero() {
+		return true
+	}
+	_, ok := d.elements[op.After]
+	return ok
+}
+
+func (d *Document) flushPending(applied []Op) []Op {
+	for progress := true; progress; {
+		progress = false
+		rest := d.pending[:0]
+		for _, op := range d.pending {
+			switch {
+			case d.has(op.ID):
+			case d.ready(op):
+				d.integrate(op)
+				applied = append(applied, op)
+				progress = true
+			default:
+				rest = append(rest, op)
+			}
+		}
+		d.pending = rest
+	}
+	return applied
+}
+
+func (d *Document) integrate(op Op) {
+	switch op.Type {
+	case OpInsert:
+		prev := d.head
+		if !op.After.IsZero() {
+			prev = d.elements[op.After]
+		}
+		// Параллельные вставки после одного элемента упорядочиваются по
+		// убыванию идентификатора; их потомки имеют большие часы и
+		// пропускаются вместе с ними.
+		for prev.next != nil && op.ID.Less(prev.next.id) {
+			prev = prev.next
+		}
+		el := &element{id: op.ID, value: op.Value, next: prev.next}
+		prev.next = el
+		d.elements[op.ID] = el
+	case OpDelete:
+		d.elements[op.Target].deleted = true
+		d.deletes[op.ID] = true
+	}
+
+	d.log = append(d.log, op)
+	if op.ID.Clock > d.vector[op.ID.Site] {
+		d.vector[op.ID.Site] = op.ID.Clock
+	}
+	if op.ID.Clock > d.clock {
+		d.clock = op.ID.Clock
+	}
+}
+
+// Text возвращает видимый текст документа.
+func (d *Document) Text() string {
+	var b strings.Builder
+	for el := d.head.next; el != nil; el = el.next {
+		if !el.deleted {
+			b.WriteString(el.value)
+		}
+	}
+	return b.String()
+}
+
+// Clock возвращает максимальные часы среди применённых операций.
+func (d *Document) Clock() uint64 {
+	return d.clock
+}
+
+func (d *Document) StateVector() StateVector {
+	vector := make(StateVector, len(d.vector))
+	for site, clock := range d.vector {
+		vector[site] = clock
+	}
+	return vector
+}
+
+// OpsSince возвращает в порядке применения операции, которых нет у реплики
+// с вектором состояния vector.
+func (d *Document) OpsSince(vector StateVector) []Op {
+	var ops []Op
+	for _, op := range d.log {
+		if op.ID.Clock > vector[op.ID.Site] {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// IDs возвращает идентификаторы видимых символов по порядку.
+func (d *Document) IDs() []ID {
+	var ids []ID
+	for el := d.head.next; el != nil; el = el.next {
+		if !el.deleted {
+			ids = append(ids, el.id)
+		}
+	}
+	return ids
+}
+
+// Insert вставляет text перед видимой позицией pos (в символах) от имени
+// site и возвращает созданные операции.
+func (d *Document) Insert(site string, pos int, text string) []Op {
+	return d.InsertAfter(site, d.visibleID(pos), text)
+}
+
+// InsertAfter вставляет text сразу после элемента after от имени site.
+func (d *Document) InsertAfter(site string, after ID, text string) []Op {
+	if _, ok := d.elements[after]; !ok && !after.IsZero() {
+		return nil
+	}
+	ops := make([]Op, 0, utf8.RuneCountInString(text))
+	for _, r := range text {
+		op := Op{Type: OpInsert, ID: ID{Site: site, Clock: d.clock + 1}, After: after, Value: string(r)}
+		d.integrate(op)
+		ops = append(ops, op)
+		after = op.ID
+	}
+	return ops
+}
+
+// Delete удаляет count видимых символов начиная с позиции pos от имени
+// site и возвращает созданные операции.
+func (d *Document) Delete(site string, pos, count int) []Op {
+	ids := d.IDs()
+	if pos >= len(ids) {
+		return nil
+	}
+	return d.DeleteIDs(site, ids[pos:min(pos+count, len(ids))])
+}
+
+// DeleteIDs удаляет ещё видимые символы с идентификаторами ids от имени site.
+func (d *Document) DeleteIDs(site string, ids []ID) []Op {
+	ops := make([]Op, 0, len(ids))
+	for _, target := range ids {
+		el, ok := d.elements[target]
+		if !ok || el.deleted {
+			continue
+		}
+		op := Op{Type: OpDelete, ID: ID{Site: site, Clock: d.clock + 1}, Target: target}
+		d.integrate(op)
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// visibleID возвращает идентификатор видимого символа, стоящего перед
+// позицией pos; для начала текста — нулевой идентификатор.
+func (d *Document) visibleID(pos int) ID {
+	var id ID
+	index := 0
+	for el := d.head.next; el != nil && index < pos; el = el.next {
+		if !el.deleted {
+			id = el.id
+			index++
+		}
+	}
+	return id
+}
@@ -0,0 +1,104 @@
+package crdt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertDelete(t *testing.T) {
+	d := FromText("server", "hello world")
+	require.Equal(t, "hello world", d.Text())
+
+	d.Delete("a", 5, 6)
+	d.Insert("a", 5, ", мир")
+	require.Equal(t, "hello, мир", d.Text())
+	require.Equal(t, uint64(22), d.Clock())
+}
+
+func TestConcurrentEditsConverge(t *testing.T) {
+	base := FromText("server", "note")
+	a := NewDocument()
+	b := NewDocument()
+	_, err := a.Apply(base.OpsSince(nil))
+	require.NoError(t, err)
+	_, err = b.Apply(base.OpsSince(nil))
+	require.NoError(t, err)
+
+	opsA := a.Insert("a", 4, " A")
+	opsA = append(opsA, a.Delete("a", 0, 1)...)
+	opsB := b.Insert("b", 4, " B")
+	opsB = append(opsB, b.Insert("b", 0, "my ")...)
+
+	_, err = a.Apply(opsB)
+	require.NoError(t, err)
+	_, err = b.Apply(opsA)
+	require.NoError(t, err)
+	_, err = base.Apply(opsB)
+	require.NoError(t, err)
+	_, err = base.Apply(opsA)
+	require.NoError(t, err)
+
+	require.Equal(t, a.Text(), b.Text())
+	require.Equal(t, a.Text(), base.Text())
+	require.Equal(t, "my ote B A", a.Text())
+}
+
+func TestCausalBuffering(t *testing.T) {
+	source := NewDocument()
+	ops := source.Insert("a", 0, "abc")
+	ops = append(ops, source.Delete("a", 1, 1)...)
+
+	d := NewDocument()
+	reversed := make([]Op, 0, len(ops))
+	for i := len(ops) - 1; i >= 0; i-- {
+		reversed = append(reversed, ops[i])
+	}
+	applied, err := d.Apply(reversed)
+	require.NoError(t, err)
+	require.Len(t, applied, len(ops))
+	require.Equal(t, "ac", d.Text())
+
+	applied, err = d.Apply(ops)
+	require.NoError(t, err)
+	require.Empty(t, applied, "redelivery is idempotent")
+}
+
+func TestOpsSince(t *testing.T) {
+	server := FromText("server", "ab")
+	client := NewDocument()
+	_, err := client.Apply(server.OpsSince(client.StateVector()))
+	require.NoError(t, err)
+
+	server.Insert("other", 2, "c")
+	missing := server.OpsSince(client.StateVector())
+	require.Len(t, missing, 1)
+
+	_, err = client.Apply(missing)
+	require.NoError(t, err)
+	require.Equal(t, "abc", client.Text())
+	require.Empty(t, server.OpsSince(client.StateVector()))
+}
+
+func TestInvalidOp(t *testing.T) {
+	d := NewDocument()
+	_, err := d.Apply([]Op{{Type: OpInsert, ID: ID{Site: "a"}}})
+	require.ErrorIs(t, err, ErrInvalidOp)
+	_, err = d.Apply([]Op{{Type: OpDelete, ID: ID{Site: "a", Clock: 1}}})
+	require.ErrorIs(t, err, ErrInvalidOp)
+}
+
+func TestOpClockOrder(t *testing.T) {
+	d := FromText("server", "ab")
+	after := ID{Site: "server", Clock: 2}
+
+	_, err := d.Apply([]Op{{Type: OpInsert, ID: ID{Site: "a", Clock: 2}, After: after, Value: "c"}})
+	require.ErrorIs(t, err, ErrInvalidOp)
+	_, err = d.Apply([]Op{{Type: OpDelete, ID: ID{Site: "a", Clock: 1}, Target: after}})
+	require.ErrorIs(t, err, ErrInvalidOp)
+	require.Equal(t, "ab", d.Text())
+
+	_, err = d.Apply([]Op{{Type: OpInsert, ID: ID{Site: "a", Clock: 3}, After: after, Value: "c"}})
+	require.NoError(t, err)
+	require.Equal(t, "abc", d.Text())
+}
@@ -0,0 +1,60 @@
+// Package document собирает заметку с подстраницами в самостоятельный
+// документ HTML или PDF с оглавлением.
+package document
+
+import (
+	"backend/render"
+)
+
+// Section — заметка документа.
+type Section struct {
+	// Anchor — id раздела для ссылок и оглавления. Заголовки раздела получают
+	// id с этим префиксом.
+	Anchor string
+	Title  string
+	// Depth — вложенность подстраницы, у самой заметки 0.
+	Depth  int
+	Blocks []render.Block
+}
+
+// Image — изображение вложения.
+type Image struct {
+	ContentType string
+	Data        []byte
+}
+
+// Document — заметка с подстраницами.
+type Document struct {
+	Title    string
+	Sections []Section
+	// Images — изображения вложений по адресам из текста. Остальные
+	// изображения в HTML загружаются по адресу, а в PDF заменяются подписью.
+	Images map[string]Image
+}
+
+// entry — строка оглавления.
+type entry struct {
+	level  int
+	text   string
+	anchor string
+}
+
+func headingPrefix(section Section) string {
+	return section.Anchor + "-"
+}
+
+// contents возвращает оглавление: заголовки заметок и их разделов.
+// Оглавление из одной строки не нужно, тогда возвращается nil.
+func (d Document) contents() []entry {
+	var entries []entry
+	for _, section := range d.Sections {
+		entries = append(entries, entry{level: section.Depth, text: section.Title, anchor: section.Anchor})
+		for _, heading := range render.Headings(section.Blocks, headingPrefix(section)) {
+			entries = append(entries, entry{level: section.Depth + heading.Level, text: heading.Text, anchor: heading.ID})
+		}
+	}
+	if len(entries) < 2 {
+		return nil
+	}
+	return entries
+}
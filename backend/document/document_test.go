@@ -0,0 +1,93 @@
+package document
+
+import (
+	"backend/pdf"
+	"backend/render"
+	"bytes"
+	"image"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDocument(t *testing.T) Document {
+	t.Helper()
+	var picture bytes.Buffer
+	require.NoError(t, png.Encode(&picture, image.NewGray(image.Rect(0, 0, 40, 20))))
+	return Document{
+		Title: "Trip",
+		Sections: []Section{
+			{
+				Anchor: "note-5",
+				Title:  "Trip",
+				Blocks: render.Parse("## Plan\n- pack\n- see [list](#note-6)\n\n![map](/api/user/1/notes/5/attachments/2)\n```\ncode <b>\n```\n> quote\n\n![missing](other.png) <script>"),
+			},
+			{
+				Anchor: "note-6",
+				Title:  "Packing list",
+				Depth:  1,
+				Blocks: render.Parse("Socks " + strings.Repeat("word ", 400)),
+			},
+		},
+		Images: map[string]Image{
+			"/api/user/1/notes/5/attachments/2": {ContentType: "image/png", Data: picture.Bytes()},
+		},
+	}
+}
+
+func TestContents(t *testing.T) {
+	require.Equal(t, []entry{
+		{level: 0, text: "Trip", anchor: "note-5"},
+		{level: 2, text: "Plan", anchor: "note-5-1"},
+		{level: 1, text: "Packing list", anchor: "note-6"},
+	}, testDocument(t).contents())
+
+	single := Document{Sections: []Section{{Anchor: "note-1", Title: "Alone", Blocks: render.Parse("text")}}}
+	require.Nil(t, single.contents())
+}
+
+func TestHTML(t *testing.T) {
+	data, err := HTML(testDocument(t))
+	require.NoError(t, err)
+	page := string(data)
+
+	require.Contains(t, page, "<title>Trip</title>")
+	require.Contains(t, page, "Content-Security-Policy")
+	require.Contains(t, page, `<li style="padding-left:2.5em"><a href="#note-5-1">Plan</a></li>`)
+	require.Contains(t, page, `<section id="note-6">`)
+	require.Contains(t, page, `<h2 id="note-5-1">Plan</h2>`)
+	require.Contains(t, page, `<a href="#note-6" rel="nofollow noopener">list</a>`)
+	require.Contains(t, page, `<img src="data:image/png;base64,`)
+	require.Contains(t, page, `<img src="other.png" alt="missing">`)
+	require.Contains(t, page, "&lt;script&gt;")
+	require.NotContains(t, page, "<script>")
+}
+
+func TestPDF(t *testing.T) {
+	data, err := PDF(testDocument(t), pdf.Standard)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+	require.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+
+	text := string(data)
+	// Оглавление, заметка и подстраница, каждая с новой страницы.
+	require.Contains(t, text, "/Count 3 >>")
+	require.Equal(t, 1, strings.Count(text, "/Subtype /Image"))
+	require.Contains(t, text, "/Type /Outlines")
+	// Ссылки оглавления и ссылка на подстраницу ведут внутрь документа.
+	require.Len(t, regexp.MustCompile(`/Subtype /Link [^>]*/Dest \[`).FindAllString(text, -1), 4)
+
+	long := Document{Title: "Long", Sections: []Section{{Anchor: "note-1", Title: "Long", Blocks: render.Parse(strings.Repeat("word ", 3000))}}}
+	data, err = PDF(long, pdf.Standard)
+	require.NoError(t, err)
+	pages := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindStringSubmatch(string(data))
+	require.NotNil(t, pages)
+	require.Equal(t, "4", pages[1])
+
+	empty, err := PDF(Document{Title: "Empty"}, pdf.Standard)
+	require.NoError(t, err)
+	require.Contains(t, string(empty), "/Count 1 >>")
+}
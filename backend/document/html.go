@@ -0,0 +1,109 @@
+package document
+
+import (
+	"backend/render"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+)
+
+// htmlTemplate — самостоятельная страница без внешних ресурсов. Политика CSP
+// запрещает скрипты и разрешает только встроенные стили и изображения.
+var htmlTemplate = template.Must(template.New("document").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="Content-Security-Policy" content="default-src 'none'; img-src data: https: http:; style-src 'unsafe-inline'">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{max-width:760px;margin:0 auto;padding:32px 20px;font:16px/1.6 -apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;color:#1f2328}
+h1{font-size:2em;line-height:1.2}
+section+section{margin-top:3em;padding-top:1em;border-top:1px solid #d0d7de}
+nav.toc{margin-bottom:2em;padding:12px 16px;background:#f6f8fa;border-radius:6px}
+nav.toc h2{margin:0 0 .5em;font-size:1.1em}
+nav.toc ul{margin:0;padding:0;list-style:none}
+nav.toc li{margin:2px 0}
+nav.toc a{color:#1f2328;text-decoration:none}
+nav.toc a:hover{text-decoration:underline}
+pre{background:#f6f8fa;padding:12px 16px;border-radius:6px;overflow:auto}
+code{font-family:SFMono-Regular,Consolas,monospace;font-size:.9em}
+blockquote{margin:0;padding:0 1em;border-left:4px solid #d0d7de;color:#57606a}
+img{max-width:100%}
+a{color:#0969da}
+@media print{nav.toc{background:none;padding:0}section+section{break-before:page;border:0}}
+</style>
+</head>
+<body>
+{{- if .Contents}}
+<nav class="toc">
+<h2>Contents</h2>
+<ul>
+{{- range .Contents}}
+<li style="padding-left:{{.Indent}}em"><a href="#{{.Anchor}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+</nav>
+{{- end}}
+{{- range .Sections}}
+<section id="{{.Anchor}}">
+<h1>{{.Title}}</h1>
+{{.Content}}</section>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlEntry struct {
+	Indent float64
+	Text   string
+	Anchor string
+}
+
+type htmlSection struct {
+	Anchor  string
+	Title   string
+	Content template.HTML
+}
+
+// HTML собирает самостоятельную страницу HTML. Текст очищается при разборе
+// render, изображения вложений встраиваются как data URL.
+func HTML(doc Document) ([]byte, error) {
+	data := struct {
+		Title    string
+		Contents []htmlEntry
+		Sections []htmlSection
+	}{Title: doc.Title}
+
+	for _, entry := range doc.contents() {
+		data.Contents = append(data.Contents, htmlEntry{
+			Indent: 1.25 * float64(min(entry.level, 6)),
+			Text:   entry.text,
+			Anchor: entry.anchor,
+		})
+	}
+
+	options := render.Options{Image: func(src string) string {
+		img, ok := doc.Images[src]
+		if !ok {
+			return src
+		}
+		return "data:" + img.ContentType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+	}}
+	for _, section := range doc.Sections {
+		options.IDPrefix = headingPrefix(section)
+		data.Sections = append(data.Sections, htmlSection{
+			Anchor: section.Anchor,
+			Title:  section.Title,
+			// Разметку уже экранировал render.
+			Content: template.HTML(render.RenderHTML(section.Blocks, options)),
+		})
+	}
+
+	var out bytes.Buffer
+	if err := htmlTemplate.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to render document: %w", err)
+	}
+	return out.Bytes(), nil
+}
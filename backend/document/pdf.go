@@ -0,0 +1,492 @@
+package document
+
+import (
+	"backend/pdf"
+	"backend/render"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Вёрстка PDF в пунктах.
+const (
+	margin      = 56.0
+	bodySize    = 11.0
+	codeSize    = 9.0
+	titleSize   = 22.0
+	lineSpacing = 1.45
+	listIndent  = 18.0
+	quoteIndent = 14.0
+	tocIndent   = 14.0
+	// maxImageHeight — доля высоты полосы набора, которую может занять
+	// изображение.
+	maxImageHeight = 0.6
+)
+
+var (
+	linkColor  = pdf.Color{R: 0.04, G: 0.41, B: 0.85}
+	mutedColor = pdf.Color{R: 0.34, G: 0.38, B: 0.42}
+	codeColor  = pdf.Color{R: 0.965, G: 0.973, B: 0.98}
+	ruleColor  = pdf.Color{R: 0.82, G: 0.84, B: 0.87}
+)
+
+var headingSizes = [...]float64{0, 18, 15, 13, 12, 11, 11}
+
+// token — неразрывный кусок строки одного начертания, пробел или
+// изображение.
+type token struct {
+	text  string
+	font  *pdf.Font
+	color pdf.Color
+	link  string
+	image *pdf.Image
+	space bool
+}
+
+// placed — кусок строки на странице.
+type placed struct {
+	token
+	x, width float64
+}
+
+type pendingLink struct {
+	page                *pdf.Page
+	x, y, width, height float64
+	anchor              string
+}
+
+type layout struct {
+	source  Document
+	doc     *pdf.Document
+	fonts   pdf.Family
+	images  map[string]*pdf.Image
+	page    *pdf.Page
+	y       float64
+	anchors map[string]pdf.Destination
+	links   []pendingLink
+}
+
+// PDF вёрстает документ на страницах A4: оглавление со ссылками и номерами
+// страниц, каждая подстраница с новой страницы, закладки по заголовкам и
+// номера страниц внизу.
+func PDF(doc Document, fonts pdf.Family) ([]byte, error) {
+	l := &layout{
+		source:  doc,
+		doc:     pdf.New(doc.Title),
+		fonts:   fonts,
+		images:  make(map[string]*pdf.Image),
+		anchors: make(map[string]pdf.Destination),
+	}
+	for _, section := range doc.Sections {
+		l.newPage()
+		l.section(section)
+	}
+	if l.page == nil {
+		l.newPage()
+	}
+	l.contents(doc.contents())
+
+	for _, link := range l.links {
+		if dest, ok := l.anchors[link.anchor]; ok {
+			link.page.LinkTo(link.x, link.y, link.width, link.height, dest)
+		}
+	}
+	pages := l.doc.Pages()
+	for i, page := range pages {
+		label := strconv.Itoa(i+1) + " / " + strconv.Itoa(len(pages))
+		width := l.fonts.Regular.Width(label, 9)
+		page.Text((pdf.PageWidth-width)/2, margin/2, l.fonts.Regular, 9, mutedColor, label)
+	}
+
+	var out bytes.Buffer
+	if _, err := l.doc.WriteTo(&out); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = pdf.PageHeight - margin
+}
+
+// ensure начинает новую страницу, если на текущей нет места высотой height.
+func (l *layout) ensure(height float64) {
+	if l.y-height < margin && l.y < pdf.PageHeight-margin {
+		l.newPage()
+	}
+}
+
+func (l *layout) here() pdf.Destination {
+	return pdf.Destination{Page: l.page, Y: l.y}
+}
+
+func (l *layout) section(section Section) {
+	l.ensure(titleSize * lineSpacing * 2)
+	l.anchors[section.Anchor] = l.here()
+	l.doc.AddBookmark(section.Title, section.Depth, l.here())
+	l.paragraph(l.plain(section.Title, l.fonts.Bold, pdf.Black), titleSize, 0, nil)
+	l.y -= bodySize / 2
+
+	prefix := headingPrefix(section)
+	heading := 0
+	for _, block := range section.Blocks {
+		switch block.Kind {
+		case render.HeadingBlock:
+			heading++
+			size := headingSizes[block.Level]
+			l.y -= size * 0.6
+			l.ensure(size*lineSpacing + bodySize*lineSpacing)
+			id := prefix + strconv.Itoa(heading)
+			l.anchors[id] = l.here()
+			l.doc.AddBookmark(render.PlainText(block.Lines[0]), section.Depth+block.Level, l.here())
+			l.paragraph(l.tokens(block.Lines[0], true, false), size, 0, nil)
+		case render.ParagraphBlock:
+			for _, line := range block.Lines {
+				l.paragraph(l.tokens(line, false, false), bodySize, 0, nil)
+			}
+		case render.BulletListBlock, render.OrderedListBlock:
+			for i, item := range block.Lines {
+				marker := "•"
+				if !l.fonts.Regular.Has('•') {
+					marker = "-"
+				}
+				if block.Kind == render.OrderedListBlock {
+					marker = strconv.Itoa(i+1) + "."
+				}
+				l.paragraph(l.tokens(item, false, false), bodySize, listIndent, func(page *pdf.Page, baseline float64, first bool) {
+					if first {
+						width := l.fonts.Regular.Width(marker, bodySize)
+						page.Text(margin+listIndent-width-5, baseline, l.fonts.Regular, bodySize, pdf.Black, marker)
+					}
+				})
+			}
+		case render.QuoteBlock:
+			l.paragraph(l.tokens(block.Lines[0], false, true), bodySize, quoteIndent, func(page *pdf.Page, baseline float64, first bool) {
+				height := bodySize * lineSpacing
+				page.Rect(margin, baseline-height*0.25, 3, height, ruleColor)
+			})
+		case render.CodeBlock:
+			l.code(block.Code)
+		}
+		l.y -= bodySize * 0.5
+	}
+}
+
+// plain возвращает токены простого текста.
+func (l *layout) plain(text string, font *pdf.Font, color pdf.Color) []token {
+	return split(text, token{font: font, color: color})
+}
+
+// tokens раскладывает строку на токены с начертаниями, ссылками и
+// изображениями.
+func (l *layout) tokens(spans []render.Span, bold, italic bool) []token {
+	var tokens []token
+	var walk func(spans []render.Span, bold, italic bool, link string)
+	walk = func(spans []render.Span, bold, italic bool, link string) {
+		for i := range spans {
+			span := &spans[i]
+			base := token{font: l.face(bold, italic), color: pdf.Black, link: link}
+			if link != "" {
+				base.color = linkColor
+			}
+			switch span.Kind {
+			case render.TextSpan:
+				tokens = append(tokens, split(span.Text, base)...)
+			case render.CodeSpan:
+				base.font = l.fonts.Mono
+				tokens = append(tokens, split(span.Text, base)...)
+			case render.StrongSpan:
+				walk(span.Children, true, italic, link)
+			case render.EmphasisSpan:
+				walk(span.Children, bold, true, link)
+			case render.LinkSpan:
+				walk(span.Children, bold, italic, span.URL)
+			case render.ImageSpan:
+				if img := l.image(span.URL); img != nil {
+					tokens = append(tokens, token{image: img, font: base.font})
+					continue
+				}
+				alt := span.Text
+				if alt == "" {
+					alt = "image"
+				}
+				base.color = mutedColor
+				tokens = append(tokens, split("["+alt+"]", base)...)
+			}
+		}
+	}
+	walk(spans, bold, italic, "")
+	return tokens
+}
+
+func (l *layout) face(bold, italic bool) *pdf.Font {
+	switch {
+	case bold && italic:
+		return l.fonts.BoldItalic
+	case bold:
+		return l.fonts.Bold
+	case italic:
+		return l.fonts.Italic
+	}
+	return l.fonts.Regular
+}
+
+// printable заменяет табуляцию пробелом, а символы, которых нет в шрифте, —
+// знаком «?».
+func printable(font *pdf.Font, text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		if !font.Has(r) {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+// split делит текст на слова и пробелы.
+func split(text string, base token) []token {
+	text = printable(base.font, text)
+
+	var tokens []token
+	for text != "" {
+		end := strings.IndexByte(text, ' ')
+		if end == 0 {
+			end = len(text) - len(strings.TrimLeft(text, " "))
+			space := base
+			space.text, space.space = " ", true
+			tokens = append(tokens, space)
+		} else {
+			if end < 0 {
+				end = len(text)
+			}
+			word := base
+			word.text = text[:end]
+			tokens = append(tokens, word)
+		}
+		text = text[end:]
+	}
+	return tokens
+}
+
+// paragraph переносит токены по строкам шириной полосы за вычетом indent.
+// decorate рисует оформление строки: маркер списка или черту цитаты.
+func (l *layout) paragraph(tokens []token, size, indent float64, decorate func(page *pdf.Page, baseline float64, first bool)) {
+	width := pdf.PageWidth - 2*margin - indent
+	first := true
+	var line []placed
+	x := 0.0
+
+	flush := func() {
+		for len(line) > 0 && line[len(line)-1].space {
+			line = line[:len(line)-1]
+		}
+		if len(line) == 0 && !first {
+			return
+		}
+		height := size * lineSpacing
+		l.ensure(height)
+		baseline := l.y - height*0.75
+		if decorate != nil {
+			decorate(l.page, baseline, first)
+		}
+		for _, piece := range line {
+			left := margin + indent + piece.x
+			l.page.Text(left, baseline, piece.font, size, piece.color, piece.text)
+			l.link(piece.link, left, baseline-size*0.25, piece.width, size*1.2)
+		}
+		l.y -= height
+		line, x, first = nil, 0, false
+	}
+
+	for _, tok := range tokens {
+		if tok.image != nil {
+			if len(line) > 0 {
+				flush()
+			}
+			l.picture(tok.image, indent)
+			first = false
+			continue
+		}
+		if tok.space && len(line) == 0 {
+			continue
+		}
+		w := tok.font.Width(tok.text, size)
+		if x+w > width && len(line) > 0 {
+			flush()
+			if tok.space {
+				continue
+			}
+		}
+		// Слово длиннее строки переносится по символам.
+		for w > width {
+			cut := fit(tok, size, width-x)
+			piece := tok
+			piece.text = tok.text[:cut]
+			line = append(line, placed{token: piece, x: x, width: tok.font.Width(piece.text, size)})
+			flush()
+			tok.text = tok.text[cut:]
+			w = tok.font.Width(tok.text, size)
+		}
+		line = append(line, placed{token: tok, x: x, width: w})
+		x += w
+	}
+	if len(line) > 0 || first {
+		flush()
+	}
+}
+
+// fit возвращает длину в байтах самого длинного начала слова, которое
+// помещается в ширину width, но не меньше одного символа.
+func fit(tok token, size, width float64) int {
+	total := 0.0
+	for i, r := range tok.text {
+		total += tok.font.Width(string(r), size)
+		if total > width {
+			if i == 0 {
+				_, n := utf8.DecodeRuneInString(tok.text)
+				return n
+			}
+			return i
+		}
+	}
+	return len(tok.text)
+}
+
+// link делает прямоугольник ссылкой: внешние адреса открываются, ссылки
+// #id ведут на заметки и заголовки документа.
+func (l *layout) link(target string, x, y, width, height float64) {
+	switch {
+	case target == "":
+	case strings.HasPrefix(target, "#"):
+		l.links = append(l.links, pendingLink{page: l.page, x: x, y: y, width: width, height: height, anchor: target[1:]})
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"), strings.HasPrefix(target, "mailto:"):
+		l.page.LinkURI(x, y, width, height, target)
+	}
+}
+
+// image возвращает изображение вложения или nil, если его нет в документе
+// или его не удалось прочитать.
+func (l *layout) image(src string) *pdf.Image {
+	if img, ok := l.images[src]; ok {
+		return img
+	}
+	var img *pdf.Image
+	if source, ok := l.source.Images[src]; ok {
+		img, _ = pdf.NewImage(source.Data)
+	}
+	l.images[src] = img
+	return img
+}
+
+// picture выводит изображение в натуральную величину при 96 точках на дюйм,
+// уменьшая до ширины полосы и доли её высоты.
+func (l *layout) picture(img *pdf.Image, indent float64) {
+	width := float64(img.Width) * 0.75
+	height := float64(img.Height) * 0.75
+	maxWidth := pdf.PageWidth - 2*margin - indent
+	maxHeight := (pdf.PageHeight - 2*margin) * maxImageHeight
+	scale := math.Min(1, math.Min(maxWidth/width, maxHeight/height))
+	width, height = width*scale, height*scale
+
+	l.y -= bodySize * 0.25
+	l.ensure(height)
+	l.page.Image(img, margin+indent, l.y-height, width, height)
+	l.y -= height + bodySize*0.25
+}
+
+// code выводит блок кода моноширинным шрифтом на сером фоне. Длинные строки
+// переносятся по символам.
+func (l *layout) code(text string) {
+	height := codeSize * lineSpacing
+	width := pdf.PageWidth - 2*margin - 16
+	l.y -= codeSize * 0.5
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		text := printable(l.fonts.Mono, line)
+		for first := true; first || text != ""; first = false {
+			piece := token{text: text, font: l.fonts.Mono}
+			cut := len(text)
+			if l.fonts.Mono.Width(text, codeSize) > width {
+				cut = fit(piece, codeSize, width)
+			}
+			l.ensure(height)
+			l.page.Rect(margin, l.y-height, pdf.PageWidth-2*margin, height, codeColor)
+			l.page.Text(margin+8, l.y-height*0.75, l.fonts.Mono, codeSize, pdf.Black, text[:cut])
+			l.y -= height
+			text = text[cut:]
+		}
+	}
+}
+
+// contents вставляет в начало документа оглавление с номерами страниц.
+// Число страниц оглавления считается заранее, чтобы номера страниц разделов
+// были известны при его выводе.
+func (l *layout) contents(entries []entry) {
+	if len(entries) == 0 {
+		return
+	}
+	height := bodySize * lineSpacing
+	heading := titleSize*lineSpacing + bodySize
+	perPage := int((pdf.PageHeight - 2*margin) / height)
+	firstPage := int((pdf.PageHeight - 2*margin - heading) / height)
+	count := 1
+	if len(entries) > firstPage {
+		count += (len(entries) - firstPage + perPage - 1) / perPage
+	}
+	pages := make([]*pdf.Page, count)
+	for i := range pages {
+		pages[i] = l.doc.InsertPage(i)
+	}
+
+	minLevel := entries[0].level
+	for _, e := range entries {
+		minLevel = min(minLevel, e.level)
+	}
+
+	page, y := pages[0], pdf.PageHeight-margin
+	page.Text(margin, y-titleSize, l.fonts.Bold, titleSize, pdf.Black, "Contents")
+	y -= heading
+	next := 1
+	for _, e := range entries {
+		if y-height < margin {
+			page, y = pages[next], pdf.PageHeight-margin
+			next++
+		}
+		dest := l.anchors[e.anchor]
+		number := strconv.Itoa(l.doc.PageNumber(dest.Page))
+		numberWidth := l.fonts.Regular.Width(number, bodySize)
+		indent := tocIndent * float64(min(e.level-minLevel, 6))
+		font := l.fonts.Regular
+		if e.level == minLevel {
+			font = l.fonts.Bold
+		}
+		text := truncate(printable(font, e.text), font, bodySize, pdf.PageWidth-2*margin-indent-numberWidth-12)
+
+		baseline := y - height*0.75
+		page.Text(margin+indent, baseline, font, bodySize, pdf.Black, text)
+		page.Text(pdf.PageWidth-margin-numberWidth, baseline, l.fonts.Regular, bodySize, mutedColor, number)
+		page.LinkTo(margin, y-height, pdf.PageWidth-2*margin, height, dest)
+		y -= height
+	}
+}
+
+// truncate укорачивает текст до ширины width с многоточием.
+func truncate(text string, font *pdf.Font, size, width float64) string {
+	if font.Width(text, size) <= width {
+		return text
+	}
+	ellipsis := "…"
+	if !font.Has('…') {
+		ellipsis = "..."
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && font.Width(string(runes)+ellipsis, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + ellipsis
+}
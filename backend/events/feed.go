@@ -0,0 +1,148 @@
+package events
+
+import (
+	"backend/models"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLogSize — сколько последних событий каждого пользователя хранится для возобновления.
+	DefaultLogSize = 500
+	// subscriberBuffer — запас очереди подписчика сверх журнала.
+	subscriberBuffer = 64
+)
+
+// Publisher публикует события изменения заметок. Его реализует Feed;
+// пакеты, которые только сообщают об изменениях, зависят от Publisher.
+type Publisher interface {
+	Publish(userID uint64, event models.NoteEvent)
+}
+
+// Subscription — подписка на ленту пользователя. Канал C закрывается при
+// отписке или если подписчик не успевает читать события.
+type Subscription struct {
+	C <-chan models.NoteEvent
+	// Reset означает, что часть событий после запрошенного ID уже вытеснена
+	// из журнала и клиенту нужно перечитать список заметок целиком.
+	Reset bool
+	// LastID — ID последнего события ленты на момент подписки.
+	LastID uint64
+
+	feed   *Feed
+	userID uint64
+	ch     chan models.NoteEvent
+}
+
+// Close отписывается от ленты.
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+
+	s.feed.unsubscribe(s)
+}
+
+type userFeed struct {
+	nextID      uint64
+	log         []models.NoteEvent
+	subscribers map[*Subscription]struct{}
+}
+
+// Feed — внутренняя шина событий изменения заметок с ограниченным
+// журналом на пользователя.
+type Feed struct {
+	mu      sync.Mutex
+	logSize int
+	users   map[uint64]*userFeed
+}
+
+func NewFeed(logSize int) *Feed {
+	if logSize <= 0 {
+		logSize = DefaultLogSize
+	}
+	return &Feed{
+		logSize: logSize,
+		users:   make(map[uint64]*userFeed),
+	}
+}
+
+// Вызывается под блокировкой f.mu
+func (f *Feed) user(userID uint64) *userFeed {
+	uf, ok := f.users[userID]
+	if !ok {
+		uf = &userFeed{
+			nextID:      1,
+			subscribers: make(map[*Subscription]struct{}),
+		}
+		f.users[userID] = uf
+	}
+	return uf
+}
+
+// Publish присваивает событию очередной ID, сохраняет его в журнал
+// пользователя userID и рассылает подписчикам.
+func (f *Feed) Publish(userID uint64, event models.NoteEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	uf := f.user(userID)
+	event.ID = uf.nextID
+	uf.nextID++
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	if len(uf.log) == f.logSize {
+		copy(uf.log, uf.log[1:])
+		uf.log = uf.log[:len(uf.log)-1]
+	}
+	uf.log = append(uf.log, event)
+
+	for sub := range uf.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			f.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe подписывается на ленту пользователя. События с ID больше
+// lastEventID, оставшиеся в журнале, сразу попадают в очередь подписки.
+func (f *Feed) Subscribe(userID, lastEventID uint64) *Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	uf := f.user(userID)
+	ch := make(chan models.NoteEvent, f.logSize+subscriberBuffer)
+	sub := &Subscription{C: ch, LastID: uf.nextID - 1, feed: f, userID: userID, ch: ch}
+
+	if lastEventID > 0 {
+		oldest := uf.nextID
+		if len(uf.log) > 0 {
+			oldest = uf.log[0].ID
+		}
+		// ID из будущего означает, что журнал начат заново (перезапуск сервера).
+		sub.Reset = lastEventID+1 < oldest || lastEventID > sub.LastID
+		if !sub.Reset {
+			for _, event := range uf.log {
+				if event.ID > lastEventID {
+					ch <- event
+				}
+			}
+		}
+	}
+
+	uf.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Вызывается под блокировкой f.mu
+func (f *Feed) unsubscribe(sub *Subscription) {
+	uf := f.users[sub.userID]
+	if _, ok := uf.subscribers[sub]; !ok {
+		return
+	}
+	delete(uf.subscribers, sub)
+	close(sub.ch)
+}
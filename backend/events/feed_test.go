@@ -0,0 +1,46 @@
+package events
+
+import (
+	"backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func drain(sub *Subscription) []uint64 {
+	var ids []uint64
+	for {
+		select {
+		case event := <-sub.C:
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	feed := NewFeed(3)
+
+	live := feed.Subscribe(1, 0)
+	for i := 0; i < 5; i++ {
+		feed.Publish(1, models.NoteEvent{Type: models.NoteUpdated, NoteID: 10})
+	}
+	feed.Publish(2, models.NoteEvent{Type: models.NoteCreated, NoteID: 20})
+	require.Equal(t, []uint64{1, 2, 3, 4, 5}, drain(live))
+
+	resumed := feed.Subscribe(1, 3)
+	require.False(t, resumed.Reset)
+	require.Equal(t, []uint64{4, 5}, drain(resumed))
+
+	expired := feed.Subscribe(1, 1)
+	require.True(t, expired.Reset, "event 2 is no longer in the log")
+	require.Equal(t, uint64(5), expired.LastID)
+	require.Empty(t, drain(expired))
+
+	require.True(t, feed.Subscribe(1, 42).Reset, "log restarted")
+
+	live.Close()
+	_, ok := <-live.C
+	require.False(t, ok)
+}
@@ -0,0 +1,279 @@
+// Package imaging обрабатывает изображения-вложения средствами стандартной
+// библиотеки: удаляет метаданные, поворачивает по EXIF и уменьшает.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	jpegQuality = 85
+	// maxPixels ограничивает размер декодируемого изображения, чтобы
+	// небольшой файл не развернулся в гигабайты памяти.
+	maxPixels = 50_000_000
+)
+
+var ErrInvalidImage = errors.New("invalid image")
+
+// Processable сообщает, умеет ли пакет обрабатывать изображения этого типа.
+func Processable(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// StripMetadata удаляет из JPEG и PNG метаданные — EXIF, XMP, IPTC и
+// комментарии — не перекодируя изображение. Возвращает очищенные данные и
+// ориентацию из EXIF (1, если её нет). Остальные типы возвращаются как есть.
+func StripMetadata(data []byte, contentType string) ([]byte, int, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	}
+	return data, 1, nil
+}
+
+// stripJPEG удаляет сегменты APP1 (EXIF, XMP), APP13 (IPTC) и COM. Сегменты
+// JFIF, ICC-профиль и Adobe влияют на цвета и остаются.
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, ErrInvalidImage
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	orientation := 1
+
+	i := 2
+	for {
+		if i+1 >= len(data) || data[i] != 0xFF {
+			return nil, 0, ErrInvalidImage
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Байт-заполнитель перед маркером.
+			i++
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// Дальше идут сжатые данные: метаданных в них нет.
+			out.Write(data[i:])
+			return out.Bytes(), orientation, nil
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD7:
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, 0, ErrInvalidImage
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			return nil, 0, ErrInvalidImage
+		}
+		payload := data[i+4 : end]
+		switch marker {
+		case 0xE1:
+			if exif, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00")); ok {
+				orientation = tiffOrientation(exif)
+			}
+		case 0xED, 0xFE:
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG удаляет чанки eXIf, tEXt, zTXt, iTXt и tIME. Контрольные суммы
+// остальных чанков от этого не меняются.
+func stripPNG(data []byte) ([]byte, int, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, 0, ErrInvalidImage
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	orientation := 1
+
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, 0, ErrInvalidImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) || end < i {
+			return nil, 0, ErrInvalidImage
+		}
+		switch string(data[i+4 : i+8]) {
+		case "eXIf":
+			orientation = tiffOrientation(data[i+8 : i+8+length])
+		case "tEXt", "zTXt", "iTXt", "tIME":
+		case "IEND":
+			out.Write(data[i:end])
+			return out.Bytes(), orientation, nil
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return nil, 0, ErrInvalidImage
+}
+
+// tiffOrientation читает тег Orientation из первого каталога данных EXIF в
+// формате TIFF. При любой ошибке возвращает 1.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	offset := int64(order.Uint32(tiff[4:]))
+	if offset+2 > int64(len(tiff)) {
+		return 1
+	}
+	count := int64(order.Uint16(tiff[offset:]))
+	for n := range count {
+		entry := offset + 2 + n*12
+		if entry+12 > int64(len(tiff)) {
+			return 1
+		}
+		const tagOrientation, typeShort = 0x0112, 3
+		if order.Uint16(tiff[entry:]) != tagOrientation || order.Uint16(tiff[entry+2:]) != typeShort {
+			continue
+		}
+		if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+			return value
+		}
+		return 1
+	}
+	return 1
+}
+
+// Decode декодирует JPEG, PNG или первый кадр GIF.
+func Decode(data []byte) (*image.RGBA, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, ErrInvalidImage
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba, nil
+}
+
+// Orient поворачивает и отражает изображение так, чтобы оно выглядело
+// правильно без тега Orientation.
+func Orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		for x := range dw {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], img.Pix[img.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
+
+// Fit уменьшает изображение так, чтобы большая сторона не превышала side,
+// усредняя исходные пиксели. Если изображение уже помещается, возвращает nil.
+func Fit(img *image.RGBA, side int) *image.RGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w <= side && h <= side {
+		return nil
+	}
+	dw, dh := side, max(1, (h*side+w/2)/w)
+	if h > w {
+		dw, dh = max(1, (w*side+h/2)/h), side
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0, y1 := y*h/dh, (y+1)*h/dh
+		for x := range dw {
+			x0, x1 := x*w/dw, (x+1)*w/dw
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := img.Pix[img.PixOffset(x0, sy):]
+				for sx := 0; sx < x1-x0; sx++ {
+					for c := range 4 {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			pixel := dst.Pix[dst.PixOffset(x, y):]
+			for c := range 4 {
+				pixel[c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// Encode кодирует изображение в формат, подходящий исходному типу: JPEG
+// остаётся JPEG, PNG и GIF кодируются в PNG, чтобы сохранить прозрачность.
+// Кодировщики не записывают метаданные. Возвращает тип результата.
+func Encode(w io.Writer, img image.Image, contentType string) (string, error) {
+	if contentType == "image/jpeg" {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return "image/png", png.Encode(w, img)
+}
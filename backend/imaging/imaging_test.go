@@ -0,0 +1,158 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// exifWithOrientation собирает данные EXIF в формате TIFF с тегом Orientation
+// и строкой, изображающей личные данные.
+func exifWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8))
+	binary.Write(&tiff, order, uint16(1))
+	binary.Write(&tiff, order, uint16(0x0112))
+	binary.Write(&tiff, order, uint16(3))
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, orientation)
+	binary.Write(&tiff, order, uint16(0))
+	binary.Write(&tiff, order, uint32(0))
+	tiff.WriteString("GPS 55.7558N 37.6173E")
+	return tiff.Bytes()
+}
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x * 40), G: uint8(y * 40), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func testJPEG(t *testing.T, exif []byte) []byte {
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, testImage(4, 2), nil))
+	data := encoded.Bytes()
+
+	segment := append([]byte("Exif\x00\x00"), exif...)
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write([]byte{0xFF, 0xFE, 0x00, 0x07})
+	out.WriteString("owner")
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func pngChunk(kind string, payload []byte) []byte {
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(len(payload)))
+	chunk.WriteString(kind)
+	chunk.Write(payload)
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(kind), payload...)))
+	return chunk.Bytes()
+}
+
+func TestStripJPEG(t *testing.T) {
+	data := testJPEG(t, exifWithOrientation(binary.BigEndian, 6))
+
+	stripped, orientation, err := StripMetadata(data, "image/jpeg")
+	require.NoError(t, err)
+	require.Equal(t, 6, orientation)
+	require.NotContains(t, string(stripped), "GPS")
+	require.NotContains(t, string(stripped), "owner")
+	require.Less(t, len(stripped), len(data))
+
+	img, err := Decode(stripped)
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 4, 2), img.Bounds())
+
+	_, orientation, err = StripMetadata(testJPEG(t, exifWithOrientation(binary.LittleEndian, 3)), "image/jpeg")
+	require.NoError(t, err)
+	require.Equal(t, 3, orientation)
+
+	_, _, err = StripMetadata([]byte("not a jpeg"), "image/jpeg")
+	require.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestStripPNG(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, testImage(2, 2)))
+	data := encoded.Bytes()
+
+	// Метаданные вставляются сразу после IHDR.
+	ihdrEnd := len(pngSignature) + 12 + 13
+	var withMetadata bytes.Buffer
+	withMetadata.Write(data[:ihdrEnd])
+	withMetadata.Write(pngChunk("eXIf", exifWithOrientation(binary.BigEndian, 8)))
+	withMetadata.Write(pngChunk("tEXt", []byte("Author\x00owner")))
+	withMetadata.Write(data[ihdrEnd:])
+
+	stripped, orientation, err := StripMetadata(withMetadata.Bytes(), "image/png")
+	require.NoError(t, err)
+	require.Equal(t, 8, orientation)
+	require.Equal(t, data, stripped)
+}
+
+func TestOrient(t *testing.T) {
+	img := testImage(3, 2)
+
+	require.Same(t, img, Orient(img, 1))
+
+	rotated := Orient(img, 6)
+	require.Equal(t, image.Rect(0, 0, 2, 3), rotated.Bounds())
+	// При повороте на 90° по часовой левый нижний угол становится левым верхним.
+	require.Equal(t, img.RGBAAt(0, 1), rotated.RGBAAt(0, 0))
+	require.Equal(t, img.RGBAAt(0, 0), rotated.RGBAAt(1, 0))
+
+	// Отражения и транспонирование обратны сами себе, повороты 6 и 8 — друг другу.
+	for _, orientation := range []int{2, 3, 4, 5, 7} {
+		require.Equal(t, img, Orient(Orient(img, orientation), orientation), "orientation %d", orientation)
+	}
+	require.Equal(t, img, Orient(Orient(img, 6), 8))
+}
+
+func TestFit(t *testing.T) {
+	img := testImage(8, 4)
+	require.Nil(t, Fit(img, 8))
+
+	small := Fit(img, 4)
+	require.Equal(t, image.Rect(0, 0, 4, 2), small.Bounds())
+	// Каждый пиксель — среднее квадрата 2×2 исходных.
+	require.Equal(t, color.RGBA{R: 20, G: 20, B: 100, A: 255}, small.RGBAAt(0, 0))
+
+	tall := Fit(testImage(4, 8), 2)
+	require.Equal(t, image.Rect(0, 0, 1, 2), tall.Bounds())
+}
+
+func TestEncode(t *testing.T) {
+	var out bytes.Buffer
+	contentType, err := Encode(&out, testImage(2, 2), "image/gif")
+	require.NoError(t, err)
+	require.Equal(t, "image/png", contentType)
+
+	out.Reset()
+	contentType, err = Encode(&out, testImage(2, 2), "image/jpeg")
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", contentType)
+	_, err = Decode(out.Bytes())
+	require.NoError(t, err)
+}
@@ -0,0 +1,181 @@
+package importers
+
+import (
+	"backend/markdown"
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// enexNote — заметка файла .enex. Текст записан в ENML — XHTML с элементами
+// en-media для вложений и en-todo для задач.
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+// enexResource — вложение заметки в base64. В тексте на него ссылаются по
+// MD5 содержимого.
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	Filename string `xml:"resource-attributes>file-name"`
+}
+
+// parseEvernote разбирает файлы .enex. Каждый файл — выгрузка блокнота, его
+// заметки попадают в папку с именем файла.
+func parseEvernote(files []markdown.File) (*Result, error) {
+	result := &Result{}
+	for _, file := range files {
+		if !strings.EqualFold(path.Ext(file.Path), ".enex") {
+			result.fail(file.Path, "file is not an Evernote export")
+			continue
+		}
+		if err := parseNotebook(file, result); err != nil {
+			if len(files) == 1 {
+				return nil, err
+			}
+			result.fail(file.Path, "invalid Evernote export")
+		}
+	}
+	return result, nil
+}
+
+func parseNotebook(file markdown.File, result *Result) error {
+	notebook := strings.TrimSpace(strings.TrimSuffix(path.Base(file.Path), path.Ext(file.Path)))
+	folder := strings.Trim(folderOf(file.Path, strings.TrimSpace)+"/"+notebook, "/")
+
+	decoder := xml.NewDecoder(bytes.NewReader(file.Data))
+	count := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+		count++
+		var note enexNote
+		if err = decoder.DecodeElement(&note, &start); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+		}
+
+		title := strings.TrimSpace(note.Title)
+		if title == "" {
+			title = "Untitled " + strconv.Itoa(count)
+		}
+		itemPath := file.Path + "/" + title
+		page, err := evernotePage(note, itemPath, result)
+		if err != nil {
+			result.fail(itemPath, "invalid note content")
+			continue
+		}
+		page.Note.Title = title
+		page.Note.Folder = folder
+		result.Pages = append(result.Pages, page)
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: no notes found", ErrInvalidExport)
+	}
+	return nil
+}
+
+// evernotePage переводит заметку в страницу. Вложения, на которые текст не
+// ссылается, перечисляются ссылками в конце текста.
+func evernotePage(note enexNote, itemPath string, result *Result) (Page, error) {
+	page := Page{Path: itemPath + markdown.Extension}
+	page.Note.Tags = note.Tags
+
+	byHash := make(map[string]int)
+	names := make(map[string]bool)
+	for _, resource := range note.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(resource.Data), ""))
+		name := resourceName(resource, names)
+		if err != nil {
+			result.fail(itemPath+"/"+name, "invalid attachment data")
+			continue
+		}
+		sum := md5.Sum(data)
+		byHash[hex.EncodeToString(sum[:])] = len(page.Assets)
+		page.Assets = append(page.Assets, Asset{
+			Path:     itemPath + "/" + name,
+			Ref:      url.PathEscape(name),
+			Filename: name,
+			Data:     data,
+		})
+	}
+
+	doc, err := html.Parse(strings.NewReader(note.Content))
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to parse note content: %w", err)
+	}
+	root := findElement(doc, func(n *html.Node) bool { return n.Data == "en-note" })
+	if root == nil {
+		root = doc
+	}
+
+	linked := make([]bool, len(page.Assets))
+	converter := &htmlConverter{media: func(n *html.Node) string {
+		i, ok := byHash[strings.ToLower(attr(n, "hash"))]
+		if !ok {
+			return ""
+		}
+		linked[i] = true
+		return assetLink(page.Assets[i], strings.HasPrefix(attr(n, "type"), "image/"))
+	}}
+	page.Note.Text = converter.convert(root)
+
+	var rest []string
+	for i, asset := range page.Assets {
+		if !linked[i] {
+			rest = append(rest, assetLink(asset, false))
+		}
+	}
+	if len(rest) > 0 {
+		page.Note.Text = strings.TrimLeft(page.Note.Text+"\n\n"+strings.Join(rest, "\n"), "\n")
+	}
+	return page, nil
+}
+
+func assetLink(asset Asset, image bool) string {
+	link := markdown.Link(asset.Filename, asset.Ref)
+	if image {
+		return "!" + link
+	}
+	return link
+}
+
+// resourceName возвращает имя файла вложения, не повторяющееся в заметке.
+func resourceName(resource enexResource, taken map[string]bool) string {
+	name := strings.TrimSpace(path.Base(strings.ReplaceAll(resource.Filename, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+		if extensions, _ := mime.ExtensionsByType(resource.Mime); len(extensions) > 0 {
+			name += extensions[0]
+		}
+	}
+	base, ext := strings.TrimSuffix(name, path.Ext(name)), path.Ext(name)
+	for n := 2; taken[strings.ToLower(name)]; n++ {
+		name = base + " (" + strconv.Itoa(n) + ")" + ext
+	}
+	taken[strings.ToLower(name)] = true
+	return name
+}
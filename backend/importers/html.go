@@ -0,0 +1,422 @@
+package importers
+
+import (
+	"backend/markdown"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"dd": true, "details": true, "div": true, "dl": true, "dt": true, "en-note": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "html": true, "li": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "summary": true, "table": true,
+	"ul": true,
+}
+
+var skippedElements = map[string]bool{
+	"head": true, "noscript": true, "script": true, "style": true, "svg": true,
+	"template": true, "title": true,
+}
+
+// voidElements — элементы ENML, которые записываются без содержимого, но
+// разбор HTML вкладывает в них всё, что идёт следом.
+var voidElements = map[string]bool{"en-media": true, "en-todo": true}
+
+// htmlConverter переводит HTML в Markdown: заголовки, абзацы, списки и
+// задачи, цитаты, блоки кода, таблицы, выделение, ссылки и изображения.
+// Остальная разметка отбрасывается, текст сохраняется.
+type htmlConverter struct {
+	// link возвращает адрес ссылки или изображения в тексте заметки.
+	link func(href string, image bool) string
+	// media возвращает разметку вложения Evernote.
+	media func(n *html.Node) string
+}
+
+// convert возвращает Markdown содержимого узла root.
+func (c *htmlConverter) convert(root *html.Node) string {
+	unnestVoid(root)
+	return strings.Join(c.blocks(root), "\n\n")
+}
+
+// unnestVoid поднимает содержимое пустых элементов ENML на уровень выше.
+func unnestVoid(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && voidElements[child.Data] {
+			for child.LastChild != nil {
+				moved := child.LastChild
+				child.RemoveChild(moved)
+				n.InsertBefore(moved, child.NextSibling)
+			}
+		}
+		unnestVoid(child)
+	}
+}
+
+func attr(n *html.Node, name string) string {
+	value, _ := attrValue(n, name)
+	return value
+}
+
+func attrValue(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func hasClass(n *html.Node, class string) bool {
+	return strings.Contains(" "+attr(n, "class")+" ", " "+class+" ")
+}
+
+// textContent возвращает текст узла без разметки, переводы строк <br>
+// сохраняются.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.Data == "br":
+			b.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// blocks переводит содержимое узла в блоки Markdown. Текст и строчные
+// элементы между блочными собираются в абзацы.
+func (c *htmlConverter) blocks(parent *html.Node) []string {
+	var out []string
+	var run strings.Builder
+	flush := func() {
+		if text := trimLines(run.String()); text != "" {
+			out = append(out, text)
+		}
+		run.Reset()
+	}
+	for n := parent.FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode && skippedElements[n.Data] {
+			continue
+		}
+		if n.Type == html.ElementNode && blockElements[n.Data] {
+			flush()
+			out = append(out, c.block(n)...)
+			continue
+		}
+		run.WriteString(c.inline(n))
+	}
+	flush()
+	return out
+}
+
+func (c *htmlConverter) block(n *html.Node) []string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.Join(strings.Fields(c.inlineChildren(n)), " ")
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", int(n.Data[1]-'0')) + " " + text}
+	case "ul", "ol":
+		if list := c.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case "li":
+		return []string{indent("- "+strings.Join(c.blocks(n), "\n"), "  ")}
+	case "blockquote":
+		inner := strings.Join(c.blocks(n), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return []string{strings.Join(lines, "\n")}
+	case "pre":
+		return []string{codeBlock(n)}
+	case "hr":
+		return []string{"---"}
+	case "table":
+		if table := c.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	}
+	return c.blocks(n)
+}
+
+// codeBlock возвращает блок кода с языком из класса language-* и ограждением
+// длиннее любой последовательности «`» в коде.
+func codeBlock(n *html.Node) string {
+	code := strings.Trim(textContent(n), "\n")
+	language := ""
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "code" {
+			continue
+		}
+		for _, class := range strings.Fields(attr(child, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				language = lang
+			}
+		}
+	}
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// list переводит список в строки с маркерами. Продолжения пунктов и вложенные
+// списки сдвигаются на ширину маркера.
+func (c *htmlConverter) list(n *html.Node) string {
+	ordered := n.Data == "ol"
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && ordered {
+		number = start
+	}
+
+	var items []string
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode {
+			continue
+		}
+		if item.Data == "ul" || item.Data == "ol" {
+			// Вложенный список прямо внутри списка относится к предыдущему пункту.
+			if nested := c.list(item); nested != "" && len(items) > 0 {
+				items[len(items)-1] += "\n" + indent(nested, "  ")
+			}
+			continue
+		}
+		if item.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		body := strings.Join(c.blocks(item), "\n")
+		if checked, ok := checkbox(item); ok && checked {
+			body = "[x] " + body
+		} else if ok {
+			body = "[ ] " + body
+		}
+		items = append(items, marker+indent(body, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// checkbox находит в пункте списка флажок задачи: поле ввода или отметку
+// списка дел Notion.
+func checkbox(item *html.Node) (checked, ok bool) {
+	var walk func(*html.Node) bool
+	walk = func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "ul" || n.Data == "ol":
+				return false
+			case n.Data == "input" && attr(n, "type") == "checkbox":
+				_, checked = attrValue(n, "checked")
+				return true
+			case hasClass(n, "checkbox"):
+				checked = hasClass(n, "checkbox-on")
+				return true
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	ok = walk(item)
+	return checked, ok
+}
+
+// table переводит таблицу в таблицу Markdown, первая строка — заголовок.
+func (c *htmlConverter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode || child.Data == "table" {
+				continue
+			}
+			if child.Data != "tr" {
+				walk(child)
+				continue
+			}
+			var row []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+					text := strings.Join(strings.Fields(c.inlineChildren(cell)), " ")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *htmlConverter) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+func (c *htmlConverter) inline(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return collapseSpace(n.Data)
+	}
+	if n.Type != html.ElementNode || skippedElements[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "\n"
+	case "strong", "b":
+		return wrap("**", c.inlineChildren(n))
+	case "em", "i":
+		return wrap("*", c.inlineChildren(n))
+	case "s", "del", "strike":
+		return wrap("~~", c.inlineChildren(n))
+	case "code", "kbd", "samp", "tt":
+		code := strings.Join(strings.Fields(textContent(n)), " ")
+		if code == "" {
+			return ""
+		}
+		if strings.Contains(code, "`") {
+			return "`` " + code + " ``"
+		}
+		return "`" + code + "`"
+	case "a":
+		label := c.inlineChildren(n)
+		href := attr(n, "href")
+		trimmed := strings.TrimSpace(label)
+		// Изображение-ссылка на само себя, как в выгрузке Notion.
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(trimmed, "![") && strings.HasSuffix(trimmed, ")") {
+			return label
+		}
+		if trimmed == "" {
+			trimmed = href
+		}
+		return "[" + trimmed + "](" + markdown.Destination(c.destination(href, false)) + ")"
+	case "img":
+		src := attr(n, "src")
+		if src == "" {
+			return ""
+		}
+		return "![" + strings.Join(strings.Fields(attr(n, "alt")), " ") + "](" + markdown.Destination(c.destination(src, true)) + ")"
+	case "en-media":
+		if c.media == nil {
+			return ""
+		}
+		return c.media(n)
+	case "en-todo":
+		if attr(n, "checked") == "true" {
+			return "- [x] "
+		}
+		return "- [ ] "
+	}
+	return c.inlineChildren(n)
+}
+
+func (c *htmlConverter) destination(href string, image bool) string {
+	if c.link == nil {
+		return href
+	}
+	return c.link(href, image)
+}
+
+// collapseSpace сводит пробельные символы к одному пробелу, как при показе
+// HTML.
+func collapseSpace(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\u00a0' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// wrap выделяет текст маркером, оставляя пробелы по краям снаружи.
+func wrap(marker, text string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + marker + trimmed + marker + text[start+len(trimmed):]
+}
+
+// trimLines убирает пробелы по краям строк и пустые строки в начале и конце.
+func trimLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// indent сдвигает все строки, кроме первой.
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
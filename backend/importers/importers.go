@@ -0,0 +1,250 @@
+// Package importers разбирает выгрузки Notion, Evernote и Obsidian в
+// страницы — будущие заметки с папками, тегами и вложениями.
+package importers
+
+import (
+	"backend/markdown"
+	"backend/models"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	SourceNotion   = "notion"
+	SourceEvernote = "evernote"
+	SourceObsidian = "obsidian"
+
+	// MaxFiles и MaxSize ограничивают число файлов и их размер после
+	// распаковки выгрузки.
+	MaxFiles = 10000
+	MaxSize  = 512 << 20
+)
+
+var (
+	ErrUnsupportedSource = errors.New("unsupported source")
+	ErrInvalidExport     = errors.New("invalid export")
+	ErrExportTooLarge    = errors.New("export too large")
+)
+
+// Page — страница выгрузки. Path — путь страницы в выгрузке: относительные
+// ссылки на файлы .md в тексте ведут к другим страницам по этому пути.
+type Page struct {
+	Path   string
+	Note   models.Note
+	Assets []Asset
+}
+
+// Asset — файл выгрузки, на который ссылается текст страницы по адресу Ref.
+type Asset struct {
+	Path     string
+	Ref      string
+	Filename string
+	Data     []byte
+}
+
+// Result — страницы выгрузки и ошибки элементов, которые не удалось разобрать.
+type Result struct {
+	Pages  []Page
+	Errors []models.ImportItemError
+}
+
+func (r *Result) fail(itemPath, message string) {
+	r.Errors = append(r.Errors, models.ImportItemError{Path: itemPath, Error: message})
+}
+
+// Supported сообщает, умеет ли пакет разбирать выгрузки source.
+func Supported(source string) bool {
+	switch source {
+	case SourceNotion, SourceEvernote, SourceObsidian:
+		return true
+	}
+	return false
+}
+
+// Parse разбирает выгрузку source из файла filename: ZIP-архив Notion или
+// хранилища Obsidian, файл .enex Evernote или ZIP-архив таких файлов.
+func Parse(source, filename string, data []byte) (*Result, error) {
+	switch source {
+	case SourceNotion:
+		files, err := readArchive(data)
+		if err != nil {
+			return nil, err
+		}
+		return parseNotion(files), nil
+	case SourceObsidian:
+		files, err := readArchive(data)
+		if err != nil {
+			return nil, err
+		}
+		return parseObsidian(files), nil
+	case SourceEvernote:
+		if !strings.EqualFold(path.Ext(filename), ".zip") {
+			return parseEvernote([]markdown.File{{Path: path.Base(filename), Data: data}})
+		}
+		files, err := readArchive(data)
+		if err != nil {
+			return nil, err
+		}
+		return parseEvernote(files)
+	}
+	return nil, ErrUnsupportedSource
+}
+
+// readArchive распаковывает ZIP-архив вместе с вложенными архивами — так
+// Notion делит большие выгрузки на части. Общий для всех файлов каталог
+// верхнего уровня отбрасывается.
+func readArchive(data []byte) ([]markdown.File, error) {
+	files, err := markdown.ReadZip(data, MaxFiles, MaxSize)
+	if err != nil {
+		return nil, archiveError(err)
+	}
+
+	var unpacked []markdown.File
+	var total int64
+	for _, file := range files {
+		total += int64(len(file.Data))
+	}
+	for _, file := range files {
+		if !strings.EqualFold(path.Ext(file.Path), ".zip") {
+			unpacked = append(unpacked, file)
+			continue
+		}
+		total -= int64(len(file.Data))
+		nested, err := markdown.ReadZip(file.Data, MaxFiles-len(files), MaxSize-total)
+		if err != nil {
+			return nil, archiveError(err)
+		}
+		for _, nestedFile := range nested {
+			total += int64(len(nestedFile.Data))
+		}
+		unpacked = append(unpacked, nested...)
+	}
+	if len(unpacked) > MaxFiles {
+		return nil, ErrExportTooLarge
+	}
+
+	if root, _, ok := strings.Cut(firstPath(unpacked), "/"); ok {
+		common := !slices.ContainsFunc(unpacked, func(file markdown.File) bool {
+			return !strings.HasPrefix(file.Path, root+"/")
+		})
+		if common {
+			for i := range unpacked {
+				unpacked[i].Path = strings.TrimPrefix(unpacked[i].Path, root+"/")
+			}
+		}
+	}
+	slices.SortStableFunc(unpacked, func(a, b markdown.File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return unpacked, nil
+}
+
+func firstPath(files []markdown.File) string {
+	if len(files) == 0 {
+		return ""
+	}
+	return files[0].Path
+}
+
+func archiveError(err error) error {
+	if errors.Is(err, markdown.ErrArchiveTooLarge) {
+		return ErrExportTooLarge
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidExport, err)
+}
+
+// archive ищет файлы выгрузки по пути и отмечает те, что попали на страницы.
+type archive struct {
+	files  []markdown.File
+	byPath map[string]int
+	used   []bool
+}
+
+func newArchive(files []markdown.File) *archive {
+	a := &archive{
+		files:  files,
+		byPath: make(map[string]int, len(files)),
+		used:   make([]bool, len(files)),
+	}
+	for i, file := range files {
+		a.byPath[strings.ToLower(file.Path)] = i
+	}
+	return a
+}
+
+// find ищет файл по пути без учёта регистра.
+func (a *archive) find(filePath string) (int, bool) {
+	i, ok := a.byPath[strings.ToLower(path.Clean(filePath))]
+	return i, ok
+}
+
+// attach добавляет на страницу файлы, на которые ссылаются встроенные ссылки
+// и изображения её текста. resolve находит файл по адресу ссылки; страницы
+// вложениями не становятся.
+func (a *archive) attach(page *Page, resolve func(from, target string) (int, bool), isPage func(filePath string) bool) {
+	refs := make(map[string]bool)
+	markdown.ReplaceDestinations(page.Note.Text, func(destination string, image bool) (string, bool) {
+		target, ok := markdown.LocalPath(destination)
+		if !ok || refs[destination] {
+			return "", false
+		}
+		i, ok := resolve(page.Path, target)
+		if !ok || isPage(a.files[i].Path) {
+			return "", false
+		}
+		refs[destination] = true
+		a.used[i] = true
+		page.Assets = append(page.Assets, Asset{
+			Path:     a.files[i].Path,
+			Ref:      destination,
+			Filename: path.Base(a.files[i].Path),
+			Data:     a.files[i].Data,
+		})
+		return "", false
+	})
+}
+
+// unused возвращает файлы, которые не стали ни страницами, ни вложениями.
+func (a *archive) unused() []string {
+	var paths []string
+	for i, file := range a.files {
+		if !a.used[i] {
+			paths = append(paths, file.Path)
+		}
+	}
+	return paths
+}
+
+// relative находит файл по пути относительно страницы from.
+func (a *archive) relative(from, target string) (int, bool) {
+	return a.find(path.Join(path.Dir(from), target))
+}
+
+// text проверяет, что файл страницы — текст в UTF-8.
+func text(file markdown.File, result *Result) (string, bool) {
+	if !utf8.Valid(file.Data) {
+		result.fail(file.Path, "file is not valid UTF-8 text")
+		return "", false
+	}
+	return strings.TrimPrefix(string(file.Data), "\ufeff"), true
+}
+
+// folderOf склеивает каталоги пути файла в папку заметки, приводя каждую
+// часть через clean.
+func folderOf(filePath string, clean func(string) string) string {
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return ""
+	}
+	var parts []string
+	for _, part := range strings.Split(dir, "/") {
+		if part = strings.TrimSpace(clean(part)); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
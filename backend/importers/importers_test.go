@@ -0,0 +1,212 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return archive.Bytes()
+}
+
+func pageByTitle(t *testing.T, result *Result, title string) Page {
+	t.Helper()
+	for _, page := range result.Pages {
+		if page.Note.Title == title {
+			return page
+		}
+	}
+	t.Fatalf("page %q not found", title)
+	return Page{}
+}
+
+func TestHTMLConverter(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "blocks and inline formatting",
+			html: "<h2>Plan</h2><p>Some <b>bold</b> and <i>italic </i>text<br>next line</p><hr><blockquote><p>quote</p></blockquote>",
+			want: "## Plan\n\nSome **bold** and *italic* text\nnext line\n\n---\n\n> quote",
+		},
+		{
+			name: "nested and task lists",
+			html: `<ul><li>one<ul><li>inner</li></ul></li><li><input type="checkbox" checked>done</li></ul><ol start="3"><li>three</li><li>four</li></ol>`,
+			want: "- one\n  - inner\n- [x] done\n\n3. three\n4. four",
+		},
+		{
+			name: "code, links and images",
+			html: `<pre><code class="language-go">fmt.Println("hi")</code></pre><p><a href="https://example.com">site</a> <a href="a.png"><img src="a.png" alt="chart"></a> <code>x</code></p>`,
+			want: "```go\nfmt.Println(\"hi\")\n```\n\n[site](https://example.com) ![chart](a.png) `x`",
+		},
+		{
+			name: "table",
+			html: "<table><tr><th>Name</th><th>Qty</th></tr><tr><td>Milk</td><td>1|2</td></tr><tr><td>Eggs</td></tr></table>",
+			want: "| Name | Qty |\n| --- | --- |\n| Milk | 1\\|2 |\n| Eggs |  |",
+		},
+		{
+			name: "scripts are dropped",
+			html: "<style>p{}</style><div>text<script>alert(1)</script></div>",
+			want: "text",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(test.html))
+			require.NoError(t, err)
+			require.Equal(t, test.want, (&htmlConverter{}).convert(doc))
+		})
+	}
+}
+
+func TestNotionMarkdown(t *testing.T) {
+	const work = "Work 0123456789abcdef0123456789abcdef"
+	const plan = "Plan fedcba9876543210fedcba9876543210"
+	data := zipFiles(t, map[string]string{
+		"Export-1/" + work + ".md":                            "# Work\n\nTags: q3, #urgent\nStatus: Done\n\nSee [Plan](Work%200123456789abcdef0123456789abcdef/Plan%20fedcba9876543210fedcba9876543210.md)\n\n![](Work%200123456789abcdef0123456789abcdef/diagram.png)\n",
+		"Export-1/" + work + "/" + plan + ".md":               "# Plan\n\nSteps",
+		"Export-1/" + work + "/diagram.png":                   "png",
+		"Export-1/" + work + "/stray.bin":                     "bin",
+		"Export-1/Tasks 00000000000000000000000000000000.csv": "Name,Tags\n",
+	})
+
+	result, err := Parse(SourceNotion, "export.zip", data)
+	require.NoError(t, err)
+	require.Len(t, result.Pages, 2)
+
+	page := pageByTitle(t, result, "Work")
+	require.Equal(t, work+".md", page.Path)
+	require.Empty(t, page.Note.Folder)
+	require.Equal(t, []string{"q3", "urgent"}, page.Note.Tags)
+	require.True(t, strings.HasPrefix(page.Note.Text, "Status: Done\n\nSee [Plan]"))
+	require.Len(t, page.Assets, 1)
+	require.Equal(t, "diagram.png", page.Assets[0].Filename)
+	require.Equal(t, "Work%200123456789abcdef0123456789abcdef/diagram.png", page.Assets[0].Ref)
+
+	sub := pageByTitle(t, result, "Plan")
+	require.Equal(t, "Work", sub.Note.Folder)
+	require.Equal(t, "Steps", sub.Note.Text)
+
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, work+"/stray.bin", result.Errors[0].Path)
+}
+
+func TestNotionHTML(t *testing.T) {
+	const id = " 0123456789abcdef0123456789abcdef"
+	page := `<html><head><title>Trip</title></head><body><article>
+<header><h1 class="page-title">Trip</h1><table class="properties"><tbody>
+<tr><th>Tags</th><td><span class="selected-value">travel</span><span class="selected-value">summer</span></td></tr>
+</tbody></table></header>
+<div class="page-body"><p>Read <a href="Trip%200123456789abcdef0123456789abcdef/Packing%20list.html">Packing list</a></p>
+<ul class="to-do-list"><li><div class="checkbox checkbox-on"></div> <span>Book hotel</span></li></ul>
+<figure class="image"><a href="Trip%200123456789abcdef0123456789abcdef/map.png"><img src="Trip%200123456789abcdef0123456789abcdef/map.png"></a></figure>
+</div></article></body></html>`
+	data := zipFiles(t, map[string]string{
+		"Trip" + id + ".html":              page,
+		"Trip" + id + "/Packing list.html": "<html><body><div class=\"page-body\"><p>Socks</p></div></body></html>",
+		"Trip" + id + "/map.png":           "png",
+	})
+
+	result, err := Parse(SourceNotion, "export.zip", data)
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+
+	trip := pageByTitle(t, result, "Trip")
+	require.Equal(t, "Trip"+id+".md", trip.Path)
+	require.Equal(t, []string{"travel", "summer"}, trip.Note.Tags)
+	require.Equal(t, "Read [Packing list](Trip%200123456789abcdef0123456789abcdef/Packing%20list.md)\n\n- [x] Book hotel\n\n![](Trip%200123456789abcdef0123456789abcdef/map.png)", trip.Note.Text)
+	require.Len(t, trip.Assets, 1)
+
+	packing := pageByTitle(t, result, "Packing list")
+	require.Equal(t, "Trip", packing.Note.Folder)
+	require.Equal(t, "Socks", packing.Note.Text)
+}
+
+func TestEvernote(t *testing.T) {
+	// Вложение ссылается на ресурс по MD5 содержимого «png».
+	const enex = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export>
+<note><title>Groceries</title><content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div><en-todo checked="true"/>Milk</div><div><en-todo/>Bread</div><div><en-media hash="bff139fa05ac583f685a523ab3d110a0" type="image/png"/></div></en-note>]]></content>
+<tag>home</tag><tag>shopping</tag>
+<resource><data encoding="base64">cG5n</data><mime>image/png</mime><resource-attributes><file-name>receipt.png</file-name></resource-attributes></resource>
+<resource><data encoding="base64">cGRm</data><mime>application/pdf</mime></resource>
+</note>
+<note><title></title><content><![CDATA[<en-note>Empty title</en-note>]]></content></note>
+</en-export>`
+
+	result, err := Parse(SourceEvernote, "Personal.enex", []byte(enex))
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Pages, 2)
+
+	groceries := result.Pages[0]
+	require.Equal(t, "Groceries", groceries.Note.Title)
+	require.Equal(t, "Personal", groceries.Note.Folder)
+	require.Equal(t, []string{"home", "shopping"}, groceries.Note.Tags)
+	require.Equal(t, "- [x] Milk\n\n- [ ] Bread\n\n![receipt.png](receipt.png)\n\n[attachment.pdf](attachment.pdf)", groceries.Note.Text)
+	require.Len(t, groceries.Assets, 2)
+	require.Equal(t, []byte("png"), groceries.Assets[0].Data)
+
+	require.Equal(t, "Untitled 2", result.Pages[1].Note.Title)
+
+	_, err = Parse(SourceEvernote, "broken.enex", []byte("<en-export><note>"))
+	require.ErrorIs(t, err, ErrInvalidExport)
+}
+
+func TestObsidian(t *testing.T) {
+	data := zipFiles(t, map[string]string{
+		"Vault/Projects/Launch.md":     "---\ntags: [work]\n---\n#project kickoff, see [[Budget]], [[Budget#Q3|the numbers]] and [[Missing]].\n![[chart.png|300]] ![[spec.pdf]]\n```\n#include <stdio.h>\n```\n#2024",
+		"Vault/Budget.md":              "Numbers",
+		"Vault/attachments/chart.png":  "png",
+		"Vault/attachments/spec.pdf":   "pdf",
+		"Vault/attachments/unused.png": "png",
+		"Vault/.obsidian/app.json":     "{}",
+	})
+
+	result, err := Parse(SourceObsidian, "vault.zip", data)
+	require.NoError(t, err)
+	require.Len(t, result.Pages, 2)
+
+	launch := pageByTitle(t, result, "Launch")
+	require.Equal(t, "Projects/Launch.md", launch.Path)
+	require.Equal(t, "Projects", launch.Note.Folder)
+	require.Equal(t, []string{"work", "project"}, launch.Note.Tags)
+	require.Equal(t, "#project kickoff, see [Budget](../Budget.md), [the numbers](../Budget.md) and [[Missing]].\n![chart.png](../attachments/chart.png) [spec.pdf](../attachments/spec.pdf)\n```\n#include <stdio.h>\n```\n#2024", launch.Note.Text)
+	require.Len(t, launch.Assets, 2)
+	require.Equal(t, "attachments/chart.png", launch.Assets[0].Path)
+	require.Equal(t, "../attachments/chart.png", launch.Assets[0].Ref)
+
+	budget := pageByTitle(t, result, "Budget")
+	require.Empty(t, budget.Note.Folder)
+
+	require.Len(t, result.Errors, 1)
+	require.Equal(t, "attachments/unused.png", result.Errors[0].Path)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("onenote", "export.zip", nil)
+	require.ErrorIs(t, err, ErrUnsupportedSource)
+	_, err = Parse(SourceNotion, "export.zip", []byte("not a zip"))
+	require.ErrorIs(t, err, ErrInvalidExport)
+}
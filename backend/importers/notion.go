@@ -0,0 +1,221 @@
+package importers
+
+import (
+	"backend/markdown"
+	"backend/models"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// notionID — идентификатор страницы, который Notion дописывает к именам
+// файлов и каталогов выгрузки.
+var notionID = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+
+// propertyLine — строка свойства страницы базы данных в выгрузке Markdown.
+var propertyLine = regexp.MustCompile(`^([^:\n]{1,40}): (.*)$`)
+
+func notionName(name string) string {
+	return notionID.ReplaceAllString(name, "")
+}
+
+// parseNotion разбирает выгрузку Notion в Markdown или HTML. Подстраницы лежат
+// в каталоге с именем родительской страницы и попадают в одноимённую папку.
+// Таблицы CSV баз данных пропускаются: их строки выгружаются страницами.
+func parseNotion(files []markdown.File) *Result {
+	result := &Result{}
+	a := newArchive(files)
+	isPage := func(filePath string) bool {
+		ext := strings.ToLower(path.Ext(filePath))
+		return ext == markdown.Extension || ext == ".html"
+	}
+
+	for i, file := range files {
+		ext := strings.ToLower(path.Ext(file.Path))
+		if ext == ".csv" || file.Path == "index.html" {
+			a.used[i] = true
+			continue
+		}
+		if !isPage(file.Path) {
+			continue
+		}
+		a.used[i] = true
+		content, ok := text(file, result)
+		if !ok {
+			continue
+		}
+
+		page := Page{Path: strings.TrimSuffix(file.Path, path.Ext(file.Path)) + markdown.Extension}
+		if ext == ".html" {
+			note, err := notionHTML(content)
+			if err != nil {
+				result.fail(file.Path, "invalid HTML page")
+				continue
+			}
+			page.Note = note
+		} else {
+			page.Note = notionMarkdown(content)
+		}
+		if page.Note.Title == "" {
+			page.Note.Title = notionName(strings.TrimSuffix(path.Base(file.Path), path.Ext(file.Path)))
+		}
+		page.Note.Folder = folderOf(file.Path, notionName)
+		a.attach(&page, a.relative, isPage)
+		result.Pages = append(result.Pages, page)
+	}
+
+	for _, unused := range a.unused() {
+		result.fail(unused, "file is not referenced by any page")
+	}
+	return result
+}
+
+// notionMarkdown читает страницу из выгрузки Markdown: заголовок первой
+// строкой и свойства страницы базы данных, из которых берутся теги.
+func notionMarkdown(content string) models.Note {
+	var note models.Note
+	if rest, ok := strings.CutPrefix(content, "# "); ok {
+		title, body, _ := strings.Cut(rest, "\n")
+		note.Title = strings.TrimSpace(title)
+		content = strings.TrimLeft(body, "\r\n")
+	}
+	note.Tags, note.Text = notionTags(content)
+	return note
+}
+
+// notionTags находит свойство Tags в первом абзаце страницы, если весь абзац
+// состоит из свойств, и убирает его из текста.
+func notionTags(text string) ([]string, string) {
+	block, rest, separated := strings.Cut(text, "\n\n")
+	lines := strings.Split(block, "\n")
+	tagLine := -1
+	for i, line := range lines {
+		groups := propertyLine.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+		if groups == nil {
+			return nil, text
+		}
+		if name := strings.ToLower(groups[1]); name == "tags" || name == "tag" {
+			tagLine = i
+		}
+	}
+	if tagLine < 0 {
+		return nil, text
+	}
+
+	tags := splitTags(propertyLine.FindStringSubmatch(strings.TrimSuffix(lines[tagLine], "\r"))[2])
+	lines = slices.Delete(lines, tagLine, tagLine+1)
+	switch {
+	case len(lines) == 0:
+		return tags, rest
+	case separated:
+		return tags, strings.Join(lines, "\n") + "\n\n" + rest
+	}
+	return tags, strings.Join(lines, "\n")
+}
+
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notionHTML читает страницу из выгрузки HTML. Ссылки на другие страницы
+// переписываются на файлы .md, как в выгрузке Markdown.
+func notionHTML(content string) (models.Note, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return models.Note{}, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	var note models.Note
+	if title := findElement(doc, func(n *html.Node) bool { return hasClass(n, "page-title") }); title != nil {
+		note.Title = strings.Join(strings.Fields(textContent(title)), " ")
+	} else if title := findElement(doc, func(n *html.Node) bool { return n.Data == "title" }); title != nil {
+		note.Title = strings.Join(strings.Fields(textContent(title)), " ")
+	}
+	if properties := findElement(doc, func(n *html.Node) bool { return n.Data == "table" && hasClass(n, "properties") }); properties != nil {
+		note.Tags = notionHTMLTags(properties)
+	}
+
+	body := findElement(doc, func(n *html.Node) bool { return hasClass(n, "page-body") })
+	if body == nil {
+		body = findElement(doc, func(n *html.Node) bool { return n.Data == "body" })
+	}
+	if body == nil {
+		return note, nil
+	}
+	converter := &htmlConverter{link: func(href string, image bool) string {
+		target, fragment, _ := strings.Cut(href, "#")
+		if _, ok := markdown.LocalPath(href); !ok || image || !strings.EqualFold(path.Ext(target), ".html") {
+			return href
+		}
+		target = strings.TrimSuffix(target, path.Ext(target)) + markdown.Extension
+		if fragment != "" {
+			target += "#" + fragment
+		}
+		return target
+	}}
+	note.Text = converter.convert(body)
+	return note, nil
+}
+
+// notionHTMLTags читает теги из таблицы свойств страницы базы данных.
+func notionHTMLTags(properties *html.Node) []string {
+	row := findElement(properties, func(n *html.Node) bool {
+		if n.Data != "tr" {
+			return false
+		}
+		name := findElement(n, func(n *html.Node) bool { return n.Data == "th" })
+		if name == nil {
+			return false
+		}
+		key := strings.ToLower(strings.TrimSpace(textContent(name)))
+		return key == "tags" || key == "tag"
+	})
+	if row == nil {
+		return nil
+	}
+	value := findElement(row, func(n *html.Node) bool { return n.Data == "td" })
+	if value == nil {
+		return nil
+	}
+
+	var tags []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && hasClass(n, "selected-value") {
+			tags = append(tags, splitTags(textContent(n))...)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(value)
+	if tags == nil {
+		tags = splitTags(textContent(value))
+	}
+	return tags
+}
+
+// findElement возвращает первый элемент поддерева, для которого match
+// возвращает true.
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package importers
+
+import (
+	"backend/markdown"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// obsidianLink — вики-ссылка Obsidian [[Заметка#Заголовок|подпись]] или
+	// встраивание ![[файл]].
+	obsidianLink = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+)\]\]`)
+	// inlineTag — тег #тег в тексте заметки.
+	inlineTag  = regexp.MustCompile(`(?:^|[\s(])#([\p{L}\p{N}_/-]+)`)
+	inlineCode = regexp.MustCompile("`[^`\n]*`")
+)
+
+var imageExtensions = map[string]bool{
+	".bmp": true, ".gif": true, ".jpeg": true, ".jpg": true, ".png": true, ".svg": true, ".webp": true,
+}
+
+// vault — хранилище Obsidian. Файлы ищутся, как в Obsidian: относительно
+// заметки, от корня хранилища и по кратчайшему пути с тем же именем.
+type vault struct {
+	*archive
+	byName map[string][]int
+}
+
+func isMarkdown(filePath string) bool {
+	return strings.EqualFold(path.Ext(filePath), markdown.Extension)
+}
+
+// parseObsidian разбирает ZIP-архив хранилища Obsidian. Папки хранилища
+// становятся папками заметок, теги берутся из front matter и текста, а
+// вики-ссылки на файлы хранилища — ссылками на заметки и вложения.
+func parseObsidian(files []markdown.File) *Result {
+	result := &Result{}
+	v := &vault{archive: newArchive(files), byName: make(map[string][]int)}
+	for i, file := range files {
+		name := strings.ToLower(path.Base(file.Path))
+		v.byName[name] = append(v.byName[name], i)
+	}
+
+	for i, file := range files {
+		if !isMarkdown(file.Path) {
+			continue
+		}
+		v.used[i] = true
+		content, ok := text(file, result)
+		if !ok {
+			continue
+		}
+
+		note := markdown.Unmarshal([]byte(content))
+		if note.Title == "" {
+			note.Title = strings.TrimSuffix(path.Base(file.Path), path.Ext(file.Path))
+		}
+		if folder := folderOf(file.Path, strings.TrimSpace); folder != "" {
+			note.Folder = folder
+		}
+		note.Tags = mergeTags(note.Tags, inlineTags(note.Text))
+
+		page := Page{Path: file.Path, Note: note}
+		page.Note.Text = v.convertLinks(page.Note.Text, page.Path)
+		v.attach(&page, v.resolve, isMarkdown)
+		result.Pages = append(result.Pages, page)
+	}
+
+	for _, unused := range v.unused() {
+		result.fail(unused, "file is not referenced by any page")
+	}
+	return result
+}
+
+func (v *vault) resolve(from, target string) (int, bool) {
+	if i, ok := v.relative(from, target); ok {
+		return i, true
+	}
+	if i, ok := v.find(target); ok {
+		return i, true
+	}
+	suffix := strings.ToLower("/" + path.Clean(target))
+	best := -1
+	for _, i := range v.byName[strings.ToLower(path.Base(target))] {
+		if !strings.HasSuffix(strings.ToLower("/"+v.files[i].Path), suffix) {
+			continue
+		}
+		if best < 0 || len(v.files[i].Path) < len(v.files[best].Path) {
+			best = i
+		}
+	}
+	return best, best >= 0
+}
+
+// resolveLink находит файл вики-ссылки: заметку без расширения или файл.
+func (v *vault) resolveLink(from, name string) (int, bool) {
+	if !isMarkdown(name) {
+		if i, ok := v.resolve(from, name+markdown.Extension); ok {
+			return i, true
+		}
+	}
+	return v.resolve(from, name)
+}
+
+// convertLinks заменяет вики-ссылки на файлы хранилища относительными ссылками
+// Markdown: при загрузке ссылки на заметки станут ссылками [[#ID]], а файлы —
+// вложениями. Ссылки на заголовки и блоки ведут на заметку целиком, ссылки
+// на отсутствующие файлы остаются ссылками по заголовку.
+func (v *vault) convertLinks(text, from string) string {
+	return obsidianLink.ReplaceAllStringFunc(text, func(match string) string {
+		groups := obsidianLink.FindStringSubmatch(match)
+		embed := groups[1] == "!"
+		target, label, labeled := strings.Cut(groups[2], "|")
+		name, _, _ := strings.Cut(target, "#")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return match
+		}
+		i, ok := v.resolveLink(from, name)
+		if !ok {
+			return match
+		}
+
+		filePath := v.files[i].Path
+		destination := markdown.RelativeURL(from, filePath)
+		if isMarkdown(filePath) {
+			if !labeled {
+				label = strings.TrimSuffix(path.Base(name), path.Ext(filePath))
+			}
+			return markdown.Link(strings.TrimSpace(label), destination)
+		}
+		// У встраиваемых изображений после черты записывают размер.
+		if !labeled || embed {
+			label = path.Base(filePath)
+		}
+		link := markdown.Link(strings.TrimSpace(label), destination)
+		if embed && imageExtensions[strings.ToLower(path.Ext(filePath))] {
+			return "!" + link
+		}
+		return link
+	})
+}
+
+// inlineTags возвращает теги из текста вне блоков и фрагментов кода. Тег из
+// одних цифр тегом не считается.
+func inlineTags(text string) []string {
+	var tags []string
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		line = inlineCode.ReplaceAllString(line, "")
+		for _, groups := range inlineTag.FindAllStringSubmatch(line, -1) {
+			tag := strings.Trim(groups[1], "/")
+			if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) >= 0 {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// mergeTags объединяет теги без повторов без учёта регистра.
+func mergeTags(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, list := range lists {
+		for _, tag := range list {
+			if key := strings.ToLower(tag); tag != "" && !seen[key] {
+				seen[key] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
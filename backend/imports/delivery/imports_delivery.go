@@ -0,0 +1,198 @@
+package importsDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultMaxSize ограничивает размер выгрузки, если он не задан.
+	DefaultMaxSize = 200 << 20
+
+	// multipartOverhead — запас на заголовки частей, границы и поля формы
+	// поверх максимального размера выгрузки.
+	multipartOverhead = 1 << 20
+	maxFieldSize      = 1 << 10
+)
+
+type ImportsUsecase interface {
+	StartImport(ownerID, actorID uint64, source, folder, filename string, data []byte) (*models.ImportJob, error)
+	GetImport(ownerID, actorID, jobID uint64) (*models.ImportJob, error)
+	ListImports(ownerID, actorID uint64) ([]models.ImportJob, error)
+}
+
+type ImportsDelivery struct {
+	Usecase ImportsUsecase
+	MaxSize int64
+}
+
+func NewImportsDelivery(usecase ImportsUsecase, maxSize int64) *ImportsDelivery {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &ImportsDelivery{
+		Usecase: usecase,
+		MaxSize: maxSize,
+	}
+}
+
+// parseOwnerVars читает владельца из пути и пользователя сессии, при ошибке
+// сам пишет ответ.
+func parseOwnerVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID uint64, ok bool) {
+	ownerID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+	return ownerID, actorID, true
+}
+
+// StartImport принимает multipart/form-data с выгрузкой в поле file,
+// приложением в поле source (notion, evernote или obsidian) и необязательной
+// папкой для заметок в поле folder. Перенос выполняется в фоне: ответ —
+// задача, ход которой можно запрашивать. При заполненной очереди возвращает
+// 503, не дожидаясь места в ней.
+func (d *ImportsDelivery) StartImport(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, d.MaxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "expected multipart/form-data")
+		return
+	}
+
+	var source, folder, filename string
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "export is too large")
+			return
+		}
+		if err != nil {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+			return
+		}
+
+		switch part.FormName() {
+		case "source", "folder":
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize))
+			if err != nil {
+				apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+				return
+			}
+			if part.FormName() == "source" {
+				source = string(value)
+			} else {
+				folder = string(value)
+			}
+		case "file":
+			filename = part.FileName()
+			data, err = io.ReadAll(io.LimitReader(part, d.MaxSize+1))
+			if errors.As(err, &maxBytesErr) || int64(len(data)) > d.MaxSize {
+				apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "export is too large")
+				return
+			}
+			if err != nil {
+				apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+				return
+			}
+		}
+		part.Close()
+	}
+	if data == nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	job, err := d.Usecase.StartImport(ownerID, actorID, source, folder, filename, data)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrUnsupportedSource) {
+		apiutils.WriteError(w, http.StatusBadRequest, "source must be notion, evernote or obsidian")
+		return
+	}
+	if errors.Is(err, namederrors.ErrQueueFull) {
+		w.Header().Set("Retry-After", "60")
+		apiutils.WriteError(w, http.StatusServiceUnavailable, "import queue is full, try again later")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to start import")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusAccepted, job)
+}
+
+func (d *ImportsDelivery) ListImports(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+
+	jobs, err := d.Usecase.ListImports(ownerID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list imports")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, jobs)
+}
+
+// GetImport отдаёт задачу переноса: состояние, число обработанных страниц из
+// общего числа и ошибки отдельных страниц и файлов.
+func (d *ImportsDelivery) GetImport(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+	jobID, err := strconv.ParseUint(mux.Vars(r)["job_id"], 10, 64)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid import ID")
+		return
+	}
+
+	job, err := d.Usecase.GetImport(ownerID, actorID, jobID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "import not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get import")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, job)
+}
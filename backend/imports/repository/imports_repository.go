@@ -0,0 +1,42 @@
+package importsRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type ImportsRepository struct {
+	Store *store.Store
+}
+
+func NewImportsRepository(store *store.Store) *ImportsRepository {
+	return &ImportsRepository{
+		Store: store,
+	}
+}
+
+func (r *ImportsRepository) CreateImportJob(job models.ImportJob) (*models.ImportJob, error) {
+	created := r.Store.CreateImportJob(job)
+	return &created, nil
+}
+
+func (r *ImportsRepository) GetImportJob(jobID uint64) (*models.ImportJob, error) {
+	job, err := r.Store.GetImportJob(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *ImportsRepository) ListImportJobs(ownerID uint64) ([]models.ImportJob, error) {
+	jobs := r.Store.ListImportJobs(ownerID)
+	return jobs, nil
+}
+
+func (r *ImportsRepository) UpdateImportJob(job models.ImportJob) error {
+	if err := r.Store.UpdateImportJob(job); err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+	return nil
+}
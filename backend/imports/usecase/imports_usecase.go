@@ -0,0 +1,273 @@
+package importsUsecase
+
+import (
+	"backend/importers"
+	"backend/markdown"
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// attachmentURL — адрес скачивания вложения, которым заменяются ссылки на
+// файлы выгрузки в тексте заметок.
+const attachmentURL = "/api/user/%d/notes/%d/attachments/%d"
+
+type ImportsRepository interface {
+	CreateImportJob(job models.ImportJob) (*models.ImportJob, error)
+	GetImportJob(jobID uint64) (*models.ImportJob, error)
+	ListImportJobs(ownerID uint64) ([]models.ImportJob, error)
+	UpdateImportJob(job models.ImportJob) error
+}
+
+// NotesUsecase создаёт заметки с проверкой прав, лентой изменений и
+// уведомлениями, как при правке через API заметок.
+type NotesUsecase interface {
+	CreateNote(ownerID, editorID uint64, note models.Note) (*models.Note, error)
+	UpdateNote(ownerID, editorID uint64, note models.Note, match notesUsecase.VersionMatch) (*models.Note, error)
+}
+
+// AttachmentsUsecase прикрепляет файлы к заметкам с проверкой типа, размера и
+// квоты.
+type AttachmentsUsecase interface {
+	Upload(ctx context.Context, ownerID, actorID, noteID uint64, filename string, content io.Reader) (*models.Attachment, error)
+}
+
+// TaskQueue выполняет задачи в фоне.
+type TaskQueue interface {
+	TrySubmit(name string, run func(ctx context.Context) error) error
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+}
+
+// ImportsUsecase переносит заметки из выгрузок других приложений в фоновых
+// задачах очереди Jobs. Ход работы и ошибки задачи сохраняются в репозитории
+// после каждой страницы.
+type ImportsUsecase struct {
+	Repository  ImportsRepository
+	Notes       NotesUsecase
+	Attachments AttachmentsUsecase
+	Authorizer  Authorizer
+	Jobs        TaskQueue
+}
+
+func NewImportsUsecase(repository ImportsRepository, notes NotesUsecase, attachments AttachmentsUsecase, authorizer Authorizer, jobs TaskQueue) *ImportsUsecase {
+	return &ImportsUsecase{
+		Repository:  repository,
+		Notes:       notes,
+		Attachments: attachments,
+		Authorizer:  authorizer,
+		Jobs:        jobs,
+	}
+}
+
+// joinFolders склеивает папки через «/», пропуская пустые части.
+func joinFolders(folders ...string) string {
+	var parts []string
+	for _, folder := range folders {
+		for _, part := range strings.Split(folder, "/") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// StartImport ставит в очередь перенос выгрузки source из файла filename в
+// папку folder. Заметки создаются от имени actorID.
+func (u *ImportsUsecase) StartImport(ownerID, actorID uint64, source, folder, filename string, data []byte) (*models.ImportJob, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to start import: %w", err)
+	}
+	if !importers.Supported(source) {
+		return nil, fmt.Errorf("failed to start import: %w", namederrors.ErrUnsupportedSource)
+	}
+
+	job, err := u.Repository.CreateImportJob(models.ImportJob{
+		OwnerID:   ownerID,
+		Source:    source,
+		Filename:  filename,
+		Folder:    joinFolders(folder),
+		NoteIDs:   []uint64{},
+		Errors:    []models.ImportItemError{},
+		CreatedBy: actorID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start import: %w", err)
+	}
+
+	queued := *job
+	err = u.Jobs.TrySubmit("import", func(ctx context.Context) error {
+		return u.run(ctx, queued, data)
+	})
+	if err != nil {
+		u.finish(job, models.ImportFailed, "import queue is full")
+		return nil, fmt.Errorf("failed to start import: %w", err)
+	}
+	return job, nil
+}
+
+// GetImport возвращает задачу переноса с ходом работы и ошибками.
+func (u *ImportsUsecase) GetImport(ownerID, actorID, jobID uint64) (*models.ImportJob, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	job, err := u.Repository.GetImportJob(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	if job.OwnerID != ownerID {
+		return nil, fmt.Errorf("failed to get import: %w", namederrors.ErrNotFound)
+	}
+	return job, nil
+}
+
+// ListImports возвращает задачи переноса владельца, новые первыми.
+func (u *ImportsUsecase) ListImports(ownerID, actorID uint64) ([]models.ImportJob, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list imports: %w", err)
+	}
+	jobs, err := u.Repository.ListImportJobs(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imports: %w", err)
+	}
+	return jobs, nil
+}
+
+func (u *ImportsUsecase) save(job *models.ImportJob) {
+	if err := u.Repository.UpdateImportJob(*job); err != nil {
+		log.Error().Err(err).Uint64("job_id", job.ID).Msg("failed to save import progress")
+	}
+}
+
+func (u *ImportsUsecase) finish(job *models.ImportJob, status, message string) {
+	now := time.Now().UTC()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &now
+	u.save(job)
+}
+
+// itemError описывает ошибку элемента выгрузки для отчёта.
+func itemError(err error) string {
+	switch {
+	case errors.Is(err, namederrors.ErrFileTooLarge):
+		return "file is too large"
+	case errors.Is(err, namederrors.ErrUnsupportedMediaType):
+		return "unsupported file type"
+	case errors.Is(err, namederrors.ErrQuotaExceeded):
+		return "storage quota exceeded"
+	case errors.Is(err, namederrors.ErrForbidden):
+		return "access denied"
+	case errors.Is(err, namederrors.ErrVersionConflict):
+		return "note was changed during import"
+	case errors.Is(err, namederrors.ErrNotFound):
+		return "note was deleted during import"
+	}
+	return "internal error"
+}
+
+// run переносит страницы выгрузки: сначала создаёт заметки и прикрепляет к
+// ним файлы, затем, когда у всех заметок есть ID, переписывает ссылки на
+// файлы и другие страницы.
+func (u *ImportsUsecase) run(ctx context.Context, job models.ImportJob, data []byte) error {
+	job.Status = models.ImportRunning
+	u.save(&job)
+
+	result, err := importers.Parse(job.Source, job.Filename, data)
+	if errors.Is(err, importers.ErrExportTooLarge) {
+		u.finish(&job, models.ImportFailed, "export is too large")
+		return nil
+	}
+	if err != nil {
+		u.finish(&job, models.ImportFailed, "invalid "+job.Source+" export")
+		return nil
+	}
+	job.Total = len(result.Pages)
+	job.Errors = append(job.Errors, result.Errors...)
+	u.save(&job)
+
+	notes := make([]*models.Note, len(result.Pages))
+	urls := make([]map[string]string, len(result.Pages))
+	for i, page := range result.Pages {
+		if ctx.Err() != nil {
+			u.finish(&job, models.ImportFailed, "import was interrupted")
+			return fmt.Errorf("failed to import %s export: %w", job.Source, ctx.Err())
+		}
+		note := page.Note
+		note.Folder = joinFolders(job.Folder, note.Folder)
+		created, err := u.Notes.CreateNote(job.OwnerID, job.CreatedBy, note)
+		if err != nil {
+			job.Errors = append(job.Errors, models.ImportItemError{Path: page.Path, Error: itemError(err)})
+		} else {
+			notes[i] = created
+			job.NoteIDs = append(job.NoteIDs, created.ID)
+			urls[i] = u.attach(ctx, &job, created, page.Assets)
+		}
+		job.Processed++
+		u.save(&job)
+	}
+
+	byPath := make(map[string]int, len(result.Pages))
+	for i, page := range result.Pages {
+		if notes[i] != nil {
+			byPath[strings.ToLower(page.Path)] = i
+		}
+	}
+	for i, note := range notes {
+		if note == nil {
+			continue
+		}
+		text := markdown.ReplaceDestinations(note.Text, func(destination string, image bool) (string, bool) {
+			url, ok := urls[i][destination]
+			return url, ok
+		})
+		text = markdown.ResolveLinks(text, result.Pages[i].Path, func(filePath string) (uint64, string, bool) {
+			j, ok := byPath[strings.ToLower(filePath)]
+			if !ok {
+				return 0, "", false
+			}
+			return notes[j].ID, notes[j].Title, true
+		})
+		if text == note.Text {
+			continue
+		}
+		note.Text = text
+		if _, err := u.Notes.UpdateNote(job.OwnerID, job.CreatedBy, *note, notesUsecase.VersionMatch{Versions: []uint64{note.Version}}); err != nil {
+			job.Errors = append(job.Errors, models.ImportItemError{Path: result.Pages[i].Path, Error: itemError(err)})
+		}
+	}
+
+	if len(job.NoteIDs) == 0 {
+		u.finish(&job, models.ImportFailed, "no pages were imported")
+		return nil
+	}
+	u.finish(&job, models.ImportCompleted, "")
+	return nil
+}
+
+// attach прикрепляет файлы страницы к заметке и возвращает адреса вложений по
+// адресам ссылок в тексте.
+func (u *ImportsUsecase) attach(ctx context.Context, job *models.ImportJob, note *models.Note, assets []importers.Asset) map[string]string {
+	urls := make(map[string]string, len(assets))
+	for _, asset := range assets {
+		attachment, err := u.Attachments.Upload(ctx, job.OwnerID, job.CreatedBy, note.ID, asset.Filename, bytes.NewReader(asset.Data))
+		if err != nil {
+			job.Errors = append(job.Errors, models.ImportItemError{Path: asset.Path, Error: itemError(err)})
+			continue
+		}
+		urls[asset.Ref] = fmt.Sprintf(attachmentURL, job.OwnerID, note.ID, attachment.ID)
+	}
+	return urls
+}
@@ -1,27 +1,166 @@
 package initialize
 
 import (
+	attachmentsDelivery "backend/attachments/delivery"
+	attachmentsRepository "backend/attachments/repository"
+	attachmentsUsecase "backend/attachments/usecase"
 	authDelivery "backend/auth/delivery"
 	authRepository "backend/auth/repository"
 	authUsecase "backend/auth/usecase"
+	"backend/authz"
+	"backend/blobstore"
+	collabDelivery "backend/collab/delivery"
+	collabUsecase "backend/collab/usecase"
+	commentsDelivery "backend/comments/delivery"
+	commentsRepository "backend/comments/repository"
+	commentsUsecase "backend/comments/usecase"
 	"backend/config"
+	"backend/events"
+	importsDelivery "backend/imports/delivery"
+	importsRepository "backend/imports/repository"
+	importsUsecase "backend/imports/usecase"
+	"backend/jobs"
+	linksDelivery "backend/links/delivery"
+	linksRepository "backend/links/repository"
+	linksUsecase "backend/links/usecase"
+	"backend/models"
+	noteLinksDelivery "backend/notelinks/delivery"
+	noteLinksRepository "backend/notelinks/repository"
+	noteLinksUsecase "backend/notelinks/usecase"
 	notesDelivery "backend/notes/delivery"
 	notesRepository "backend/notes/repository"
 	notesUsecase "backend/notes/usecase"
+	noteTemplatesDelivery "backend/notetemplates/delivery"
+	noteTemplatesRepository "backend/notetemplates/repository"
+	noteTemplatesUsecase "backend/notetemplates/usecase"
+	notificationsDelivery "backend/notifications/delivery"
+	notificationsRepository "backend/notifications/repository"
+	notificationsUsecase "backend/notifications/usecase"
+	"backend/pdf"
+	presenceDelivery "backend/presence/delivery"
+	presenceUsecase "backend/presence/usecase"
+	publishDelivery "backend/publish/delivery"
+	publishRepository "backend/publish/repository"
+	publishUsecase "backend/publish/usecase"
+	"backend/pubsub"
+	revisionsDelivery "backend/revisions/delivery"
+	revisionsRepository "backend/revisions/repository"
+	revisionsUsecase "backend/revisions/usecase"
+	savedSearchDelivery "backend/savedsearch/delivery"
+	savedSearchRepository "backend/savedsearch/repository"
+	savedSearchUsecase "backend/savedsearch/usecase"
+	sharingDelivery "backend/sharing/delivery"
+	sharingRepository "backend/sharing/repository"
+	sharingUsecase "backend/sharing/usecase"
 	"backend/store"
+	syncDelivery "backend/sync/delivery"
+	syncRepository "backend/sync/repository"
+	syncUsecase "backend/sync/usecase"
+	transferDelivery "backend/transfer/delivery"
+	transferUsecase "backend/transfer/usecase"
 	userDelivery "backend/user/delivery"
 	userRepository "backend/user/repository"
 	userUsecase "backend/user/usecase"
+	workspacesDelivery "backend/workspaces/delivery"
+	workspacesRepository "backend/workspaces/repository"
+	workspacesUsecase "backend/workspaces/usecase"
+	"context"
+	"fmt"
+	"os"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type Deliveries struct {
-	AuthDelivery  *authDelivery.AuthDelivery
-	UserDelivery  *userDelivery.UserDelivery
-	NotesDelivery *notesDelivery.NotesDelivery
+	AuthDelivery        *authDelivery.AuthDelivery
+	UserDelivery        *userDelivery.UserDelivery
+	NotesDelivery       *notesDelivery.NotesDelivery
+	SavedSearchDelivery *savedSearchDelivery.SavedSearchDelivery
+	RevisionsDelivery   *revisionsDelivery.RevisionsDelivery
+	CollabDelivery      *collabDelivery.CollabDelivery
+	PresenceDelivery    *presenceDelivery.PresenceDelivery
+	SyncDelivery        *syncDelivery.SyncDelivery
+	SharingDelivery     *sharingDelivery.SharingDelivery
+	LinksDelivery       *linksDelivery.LinksDelivery
+	PublishDelivery     *publishDelivery.PublishDelivery
+	WorkspacesDelivery  *workspacesDelivery.WorkspacesDelivery
+	CommentsDelivery    *commentsDelivery.CommentsDelivery
+
+	NotificationsDelivery *notificationsDelivery.NotificationsDelivery
+	NoteLinksDelivery     *noteLinksDelivery.NoteLinksDelivery
+	AttachmentsDelivery   *attachmentsDelivery.AttachmentsDelivery
+	TransferDelivery      *transferDelivery.TransferDelivery
+	ImportsDelivery       *importsDelivery.ImportsDelivery
+	NoteTemplatesDelivery *noteTemplatesDelivery.NoteTemplatesDelivery
+}
+
+// InitBlobStore создаёт хранилище содержимого вложений по конфигурации.
+func InitBlobStore(conf *config.Config) (blobstore.Store, error) {
+	switch conf.Attachments.Storage {
+	case "", "local":
+		return blobstore.NewLocal(conf.Attachments.LocalDir)
+	case "s3":
+		s3Config := blobstore.S3Config{
+			Endpoint:  conf.Attachments.S3.Endpoint,
+			Region:    conf.Attachments.S3.Region,
+			Bucket:    conf.Attachments.S3.Bucket,
+			AccessKey: conf.Attachments.S3.AccessKey,
+			SecretKey: conf.Attachments.S3.SecretKey,
+			PathStyle: conf.Attachments.S3.PathStyle,
+		}
+		if s3Config.AccessKey == "" || s3Config.SecretKey == "" {
+			accessKey, secretKey, err := config.ReadS3Credentials()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read s3 credentials: %w", err)
+			}
+			s3Config.AccessKey, s3Config.SecretKey = accessKey, secretKey
+		}
+		return blobstore.NewS3(s3Config)
+	}
+	return nil, fmt.Errorf("unknown attachments storage %q", conf.Attachments.Storage)
+}
+
+// InitPDFFonts загружает шрифт документов PDF из конфигурации или возвращает
+// стандартные шрифты PDF, если шрифт не задан.
+func InitPDFFonts(conf *config.Config) (pdf.Family, error) {
+	if conf.Export.PDFFont == "" {
+		return pdf.Standard, nil
+	}
+	data, err := os.ReadFile(conf.Export.PDFFont)
+	if err != nil {
+		return pdf.Family{}, fmt.Errorf("failed to read pdf font: %w", err)
+	}
+	font, err := pdf.LoadTrueType(data)
+	if err != nil {
+		return pdf.Family{}, fmt.Errorf("failed to load pdf font: %w", err)
+	}
+	return pdf.Synthetic(font), nil
+}
+
+// InitOnboardingTemplates возвращает шаблоны стартовых заметок из
+// конфигурации.
+func InitOnboardingTemplates(conf *config.Config) []models.NoteTemplate {
+	onboarding := make([]models.NoteTemplate, 0, len(conf.Templates.Onboarding))
+	for _, template := range conf.Templates.Onboarding {
+		onboarding = append(onboarding, models.NoteTemplate{
+			Name:      template.Name,
+			Title:     template.Title,
+			Text:      template.Text,
+			Favourite: template.Favorite,
+			Folder:    template.Folder,
+			Tags:      template.Tags,
+		})
+	}
+	return onboarding
 }
 
-func InitDeliveries(s *store.Store, conf *config.Config) *Deliveries {
+// InitDeliveries собирает слои приложения. Шина bus общая для присутствия и
+// уведомлений, а также для фоновых задач из InitJobs; хранилище blobs — общее
+// для вложений и их очистки. Изображения-вложения обрабатываются в пуле images,
+// перенос заметок из других приложений — в пуле imports. Документы PDF
+// верстаются шрифтами fonts. Лента изменений noteEvents общая с InitJobs.
+func InitDeliveries(s *store.Store, conf *config.Config, bus pubsub.PubSub, noteEvents *events.Feed, blobs blobstore.Store, images, imports *jobs.Pool, fonts pdf.Family) *Deliveries {
 	layers := &Deliveries{}
 
 	authR := authRepository.NewAuthRepository(s)
@@ -32,9 +171,135 @@ func InitDeliveries(s *store.Store, conf *config.Config) *Deliveries {
 	userUC := userUsecase.NewUserUsecase(userR)
 	layers.UserDelivery = userDelivery.NewUserDelivery(userUC)
 
+	sharingR := sharingRepository.NewSharingRepository(s)
+	workspacesR := workspacesRepository.NewWorkspacesRepository(s)
+	authorizer := authz.NewAuthorizer(sharingR, workspacesR)
+
+	notificationsR := notificationsRepository.NewNotificationsRepository(s)
+	notificationsUC := notificationsUsecase.NewNotificationsUsecase(notificationsR, authorizer, bus)
+	layers.NotificationsDelivery = notificationsDelivery.NewNotificationsDelivery(notificationsUC)
+
+	workspacesUC := workspacesUsecase.NewWorkspacesUsecase(workspacesR, noteEvents, authorizer)
+	layers.WorkspacesDelivery = workspacesDelivery.NewWorkspacesDelivery(workspacesUC)
+
+	sharingUC := sharingUsecase.NewSharingUsecase(sharingR, authorizer, notificationsUC)
+	layers.SharingDelivery = sharingDelivery.NewSharingDelivery(sharingUC)
+
+	linksR := linksRepository.NewLinksRepository(s)
+	linksUC := linksUsecase.NewLinksUsecase(linksR, authorizer)
+	layers.LinksDelivery = linksDelivery.NewLinksDelivery(linksUC)
+
+	commentsR := commentsRepository.NewCommentsRepository(s)
+	commentsUC := commentsUsecase.NewCommentsUsecase(commentsR, authorizer, notificationsUC)
+	layers.CommentsDelivery = commentsDelivery.NewCommentsDelivery(commentsUC)
+
+	publishR := publishRepository.NewPublishRepository(s)
+	publishUC := publishUsecase.NewPublishUsecase(publishR, authorizer)
+	layers.PublishDelivery = publishDelivery.NewPublishDelivery(publishUC, conf.Publish.BaseURL)
+
 	notesR := notesRepository.NewNotesRepository(s)
-	notesUC := notesUsecase.NewNotesUsecase(notesR)
+	notesUC := notesUsecase.NewNotesUsecase(notesR, noteEvents, authorizer, notificationsUC)
 	layers.NotesDelivery = notesDelivery.NewNotesDelivery(notesUC)
 
+	noteLinksR := noteLinksRepository.NewNoteLinksRepository(s)
+	noteLinksUC := noteLinksUsecase.NewNoteLinksUsecase(noteLinksR, authorizer)
+	layers.NoteLinksDelivery = noteLinksDelivery.NewNoteLinksDelivery(noteLinksUC)
+
+	attachmentsR := attachmentsRepository.NewAttachmentsRepository(s)
+	attachmentsUC := attachmentsUsecase.NewAttachmentsUsecase(attachmentsR, blobs, authorizer, images, megabytes(conf.Attachments.MaxSizeMB), megabytes(conf.Attachments.QuotaMB))
+	layers.AttachmentsDelivery = attachmentsDelivery.NewAttachmentsDelivery(attachmentsUC, attachmentsUC.MaxSize)
+
+	transferUC := transferUsecase.NewTransferUsecase(notesUC, attachmentsUC, authorizer, fonts)
+	layers.TransferDelivery = transferDelivery.NewTransferDelivery(transferUC)
+
+	importsR := importsRepository.NewImportsRepository(s)
+	importsUC := importsUsecase.NewImportsUsecase(importsR, notesUC, attachmentsUC, authorizer, imports)
+	layers.ImportsDelivery = importsDelivery.NewImportsDelivery(importsUC, megabytes(conf.Imports.MaxSizeMB))
+
+	savedSearchR := savedSearchRepository.NewSavedSearchRepository(s)
+	savedSearchUC := savedSearchUsecase.NewSavedSearchUsecase(savedSearchR, notesUC, authorizer)
+	layers.SavedSearchDelivery = savedSearchDelivery.NewSavedSearchDelivery(savedSearchUC)
+
+	noteTemplatesR := noteTemplatesRepository.NewNoteTemplatesRepository(s)
+	noteTemplatesUC := noteTemplatesUsecase.NewNoteTemplatesUsecase(noteTemplatesR, notesUC, authorizer)
+	layers.NoteTemplatesDelivery = noteTemplatesDelivery.NewNoteTemplatesDelivery(noteTemplatesUC)
+
+	revisionsR := revisionsRepository.NewRevisionsRepository(s)
+	revisionsUC := revisionsUsecase.NewRevisionsUsecase(revisionsR, noteEvents, authorizer)
+	layers.RevisionsDelivery = revisionsDelivery.NewRevisionsDelivery(revisionsUC)
+
+	collabUC := collabUsecase.NewCollabUsecase(notesR, noteEvents, authorizer, notificationsUC)
+	layers.CollabDelivery = collabDelivery.NewCollabDelivery(collabUC)
+
+	presenceUC := presenceUsecase.NewPresenceUsecase(authorizer, bus, time.Duration(conf.Presence.TimeoutSeconds)*time.Second)
+	layers.PresenceDelivery = presenceDelivery.NewPresenceDelivery(presenceUC)
+
+	syncR := syncRepository.NewSyncRepository(s)
+	syncUC := syncUsecase.NewSyncUsecase(syncR, notesR, noteEvents, authorizer, notificationsUC)
+	layers.SyncDelivery = syncDelivery.NewSyncDelivery(syncUC)
+
 	return layers
 }
+
+func megabytes(n int) int64 {
+	return int64(n) << 20
+}
+
+// InitJobs собирает фоновые задачи приложения.
+func InitJobs(s *store.Store, conf *config.Config, bus pubsub.PubSub, noteEvents *events.Feed, blobs blobstore.Store) []jobs.Job {
+	// Очистка корзины касается уже удалённых заметок и не проверяет права: она
+	// выполняется от имени сервера. Удаление попадает в ленту изменений.
+	notesUC := notesUsecase.NewNotesUsecase(notesRepository.NewNotesRepository(s), noteEvents, nil, nil)
+	trashRetention := time.Duration(conf.Trash.RetentionDays) * 24 * time.Hour
+
+	// Напоминание доставляется, только если заметка всё ещё доступна пользователю.
+	authorizer := authz.NewAuthorizer(sharingRepository.NewSharingRepository(s), workspacesRepository.NewWorkspacesRepository(s))
+	notificationsUC := notificationsUsecase.NewNotificationsUsecase(notificationsRepository.NewNotificationsRepository(s), authorizer, bus)
+
+	// Содержимое вложений удалённых заметок удаляется из хранилища отдельно от
+	// сведений о них, чтобы очистка корзины не ждала хранилище.
+	attachmentsUC := attachmentsUsecase.NewAttachmentsUsecase(attachmentsRepository.NewAttachmentsRepository(s), blobs, nil, nil, 0, 0)
+
+	background := []jobs.Job{
+		{
+			Name:     "reminders",
+			Interval: time.Duration(conf.Notifications.ReminderIntervalSeconds) * time.Second,
+			Run: func(ctx context.Context) error {
+				_, err := notificationsUC.FireDueReminders(time.Now().UTC())
+				return err
+			},
+		},
+		{
+			Name:     "attachment cleanup",
+			Interval: time.Duration(conf.Attachments.CleanupIntervalMinutes) * time.Minute,
+			Run: func(ctx context.Context) error {
+				deleted, err := attachmentsUC.CleanupOrphans(ctx)
+				if err != nil {
+					return err
+				}
+				if deleted > 0 {
+					log.Info().Int("blobs", deleted).Msg("deleted orphan attachment blobs")
+				}
+				return nil
+			},
+		},
+	}
+	// Нулевой срок хранения, как и у истории изменений, отключает очистку.
+	if trashRetention > 0 {
+		background = append(background, jobs.Job{
+			Name:     "trash purge",
+			Interval: time.Duration(conf.Trash.PurgeIntervalMinutes) * time.Minute,
+			Run: func(ctx context.Context) error {
+				purged, err := notesUC.PurgeExpiredTrash(trashRetention)
+				if err != nil {
+					return err
+				}
+				if purged > 0 {
+					log.Info().Int("notes", purged).Msg("purged expired trash")
+				}
+				return nil
+			},
+		})
+	}
+	return background
+}
@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Job — фоновая задача, выполняемая с постоянным интервалом.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Start запускает каждую задачу в отдельной горутине. Задачи выполняются сразу
+// и затем раз в Interval, пока не будет отменён ctx. Задачи с нулевым
+// интервалом не запускаются.
+func Start(ctx context.Context, jobs []Job) {
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			log.Info().Str("job", job.Name).Msg("job disabled")
+			continue
+		}
+		go run(ctx, job)
+	}
+}
+
+func run(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			log.Error().Err(err).Str("job", job.Name).Msg("job failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	namederrors "backend/named_errors"
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+type task struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Pool выполняет разовые задачи из очереди фиксированным числом горутин.
+// Ошибки задач логируются.
+type Pool struct {
+	workers int
+	tasks   chan task
+}
+
+// NewPool создаёт пул из workers горутин с очередью на queueSize задач.
+func NewPool(workers, queueSize int) *Pool {
+	return &Pool{
+		workers: max(workers, 1),
+		tasks:   make(chan task, max(queueSize, 0)),
+	}
+}
+
+// Start запускает горутины пула. Они завершаются при отмене ctx, а задачи,
+// оставшиеся в очереди, не выполняются.
+func (p *Pool) Start(ctx context.Context) {
+	for range p.workers {
+		go p.work(ctx)
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-p.tasks:
+			if err := t.run(ctx); err != nil {
+				log.Error().Err(err).Str("task", t.name).Msg("task failed")
+			}
+		}
+	}
+}
+
+// Submit ставит задачу в очередь и ждёт места в ней, пока не отменён ctx.
+func (p *Pool) Submit(ctx context.Context, name string, run func(ctx context.Context) error) error {
+	select {
+	case p.tasks <- task{name: name, run: run}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to submit task %q: %w", name, ctx.Err())
+	}
+}
+
+// TrySubmit ставит задачу в очередь, не дожидаясь места в ней: при заполненной
+// очереди возвращает namederrors.ErrQueueFull.
+func (p *Pool) TrySubmit(name string, run func(ctx context.Context) error) error {
+	select {
+	case p.tasks <- task{name: name, run: run}:
+		return nil
+	default:
+		return fmt.Errorf("failed to submit task %q: %w", name, namederrors.ErrQueueFull)
+	}
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	namederrors "backend/named_errors"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool := NewPool(2, 1)
+	pool.Start(ctx)
+
+	var done atomic.Int32
+	finished := make(chan struct{}, 4)
+	for range 4 {
+		require.NoError(t, pool.Submit(ctx, "count", func(ctx context.Context) error {
+			done.Add(1)
+			finished <- struct{}{}
+			return nil
+		}))
+	}
+	for range 4 {
+		select {
+		case <-finished:
+		case <-time.After(time.Second):
+			t.Fatal("task was not run")
+		}
+	}
+	require.Equal(t, int32(4), done.Load())
+}
+
+func TestPoolSubmitWaitsForRoom(t *testing.T) {
+	// Пул не запущен, поэтому очередь из одной задачи сразу заполняется.
+	pool := NewPool(1, 1)
+	require.NoError(t, pool.Submit(context.Background(), "first", func(ctx context.Context) error { return nil }))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := pool.Submit(ctx, "second", func(ctx context.Context) error { return nil })
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestPoolTrySubmit(t *testing.T) {
+	// Пул не запущен, поэтому очередь из одной задачи сразу заполняется.
+	pool := NewPool(1, 1)
+	require.NoError(t, pool.TrySubmit("first", func(ctx context.Context) error { return nil }))
+
+	err := pool.TrySubmit("second", func(ctx context.Context) error { return nil })
+	require.ErrorIs(t, err, namederrors.ErrQueueFull)
+}
@@ -0,0 +1,195 @@
+package linksDelivery
+
+import (
+	"backend/apiutils"
+	linksUsecase "backend/links/usecase"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// PasswordHeader — заголовок с паролем защищённой публичной ссылки.
+const PasswordHeader = "X-Link-Password"
+
+type LinksUsecase interface {
+	CreateLink(userID, actorID, noteID uint64, options linksUsecase.LinkOptions) (*models.ShareLink, error)
+	ListLinks(userID, actorID, noteID uint64) ([]models.ShareLink, error)
+	RevokeLink(userID, actorID, noteID, linkID uint64) (*models.ShareLink, error)
+	OpenLink(token, password string) (*models.PublicNote, error)
+}
+
+type LinksDelivery struct {
+	Usecase LinksUsecase
+}
+
+func NewLinksDelivery(usecase LinksUsecase) *LinksDelivery {
+	return &LinksDelivery{
+		Usecase: usecase,
+	}
+}
+
+type linkRequest struct {
+	Password  string     `json:"password"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxViews  int        `json:"max_views"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseNoteVars читает владельца и note_id из пути и пользователя сессии,
+// при ошибке сам пишет ответ.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (userID, actorID, noteID uint64, ok bool) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, 0, false
+	}
+	noteID, err = parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, 0, false
+	}
+	return userID, actorID, noteID, true
+}
+
+func (d *LinksDelivery) CreateLink(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req linkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		apiutils.WriteError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+	if req.MaxViews < 0 {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid max_views")
+		return
+	}
+
+	link, err := d.Usecase.CreateLink(userID, actorID, noteID, linksUsecase.LinkOptions{
+		Password:  req.Password,
+		ExpiresAt: req.ExpiresAt,
+		MaxViews:  req.MaxViews,
+	})
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create link")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, link)
+}
+
+func (d *LinksDelivery) ListLinks(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := d.Usecase.ListLinks(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list links")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, links)
+}
+
+func (d *LinksDelivery) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	linkID, err := parseUintVar(r, "link_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid link ID")
+		return
+	}
+
+	link, err := d.Usecase.RevokeLink(userID, actorID, noteID, linkID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "link not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to revoke link")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, link)
+}
+
+// OpenLink отдаёт заметку по публичной ссылке без сессии. Ответ не
+// кешируется и не индексируется: ссылка может истечь или быть отозвана.
+func (d *LinksDelivery) OpenLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	note, err := d.Usecase.OpenLink(mux.Vars(r)["token"], r.Header.Get(PasswordHeader))
+	if errors.Is(err, namederrors.ErrLinkPasswordRequired) {
+		apiutils.WriteError(w, http.StatusUnauthorized, "password required")
+		return
+	}
+	if errors.Is(err, namederrors.ErrInvalidLinkPassword) {
+		apiutils.WriteError(w, http.StatusUnauthorized, "invalid password")
+		return
+	}
+	if errors.Is(err, namederrors.ErrTooManyAttempts) {
+		apiutils.WriteError(w, http.StatusTooManyRequests, "too many password attempts")
+		return
+	}
+	if errors.Is(err, namederrors.ErrLinkExpired) {
+		apiutils.WriteError(w, http.StatusGone, "link expired")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "link not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to open link")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, note)
+}
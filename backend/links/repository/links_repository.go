@@ -0,0 +1,76 @@
+package linksRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+	"time"
+)
+
+type LinksRepository struct {
+	Store *store.Store
+}
+
+func NewLinksRepository(store *store.Store) *LinksRepository {
+	return &LinksRepository{
+		Store: store,
+	}
+}
+
+func (r *LinksRepository) CreateShareLink(link models.ShareLink) (*models.ShareLink, error) {
+	created, err := r.Store.CreateShareLink(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	return &created, nil
+}
+
+func (r *LinksRepository) GetShareLink(linkID uint64) (*models.ShareLink, error) {
+	link, err := r.Store.GetShareLink(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	return &link, nil
+}
+
+func (r *LinksRepository) GetShareLinkByToken(token string) (*models.ShareLink, error) {
+	link, err := r.Store.GetShareLinkByToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	return &link, nil
+}
+
+func (r *LinksRepository) ListShareLinks(noteID uint64) ([]models.ShareLink, error) {
+	links := r.Store.ListShareLinks(noteID)
+	return links, nil
+}
+
+func (r *LinksRepository) RevokeShareLink(linkID uint64) (*models.ShareLink, error) {
+	link, err := r.Store.RevokeShareLink(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return &link, nil
+}
+
+func (r *LinksRepository) ViewShareLink(token string) (*models.ShareLink, *models.Note, error) {
+	link, note, err := r.Store.ViewShareLink(token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to view share link: %w", err)
+	}
+	return &link, &note, nil
+}
+
+func (r *LinksRepository) StartLinkAttempt(linkID uint64, now time.Time, limit int, lockout time.Duration) error {
+	err := r.Store.StartLinkAttempt(linkID, now, limit, lockout)
+	if err != nil {
+		return fmt.Errorf("failed to start link attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *LinksRepository) ResetLinkAttempts(linkID uint64) error {
+	r.Store.ResetLinkAttempts(linkID)
+	return nil
+}
@@ -0,0 +1,169 @@
+package linksUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// tokenBytes — длина случайной части публичной ссылки.
+	tokenBytes = 32
+	// passwordAttempts — число неверных паролей подряд, после которого
+	// ссылка блокируется на passwordLockout.
+	passwordAttempts = 5
+	passwordLockout  = 15 * time.Minute
+)
+
+type LinksRepository interface {
+	CreateShareLink(link models.ShareLink) (*models.ShareLink, error)
+	GetShareLink(linkID uint64) (*models.ShareLink, error)
+	GetShareLinkByToken(token string) (*models.ShareLink, error)
+	ListShareLinks(noteID uint64) ([]models.ShareLink, error)
+	RevokeShareLink(linkID uint64) (*models.ShareLink, error)
+	ViewShareLink(token string) (*models.ShareLink, *models.Note, error)
+	StartLinkAttempt(linkID uint64, now time.Time, limit int, lockout time.Duration) error
+	ResetLinkAttempts(linkID uint64) error
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// LinkOptions — ограничения публичной ссылки; нулевые значения их снимают.
+type LinkOptions struct {
+	Password  string
+	ExpiresAt *time.Time
+	MaxViews  int
+}
+
+type LinksUsecase struct {
+	Repository LinksRepository
+	Authorizer Authorizer
+}
+
+func NewLinksUsecase(repository LinksRepository, authorizer Authorizer) *LinksUsecase {
+	return &LinksUsecase{
+		Repository: repository,
+		Authorizer: authorizer,
+	}
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CreateLink публикует заметку по новой ссылке. Публиковать заметку может
+// только владелец.
+func (u *LinksUsecase) CreateLink(ownerID, actorID, noteID uint64, options LinkOptions) (*models.ShareLink, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to create link: %w", err)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate link token: %w", err)
+	}
+	link := models.ShareLink{
+		Token:     token,
+		NoteID:    noteID,
+		OwnerID:   ownerID,
+		ExpiresAt: options.ExpiresAt,
+		MaxViews:  options.MaxViews,
+		CreatedBy: actorID,
+	}
+	if options.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(options.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("cannot hash link password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+		link.HasPassword = true
+	}
+
+	created, err := u.Repository.CreateShareLink(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link: %w", err)
+	}
+	return created, nil
+}
+
+func (u *LinksUsecase) ListLinks(ownerID, actorID, noteID uint64) ([]models.ShareLink, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	links, err := u.Repository.ListShareLinks(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	return links, nil
+}
+
+// RevokeLink отзывает ссылку: по ней больше нельзя открыть заметку.
+func (u *LinksUsecase) RevokeLink(ownerID, actorID, noteID, linkID uint64) (*models.ShareLink, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to revoke link: %w", err)
+	}
+	link, err := u.Repository.GetShareLink(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke link: %w", err)
+	}
+	if link.NoteID != noteID {
+		return nil, fmt.Errorf("failed to revoke link: %w", namederrors.ErrNotFound)
+	}
+
+	revoked, err := u.Repository.RevokeShareLink(linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke link: %w", err)
+	}
+	return revoked, nil
+}
+
+// OpenLink открывает заметку по публичной ссылке. Пароль проверяется до
+// подсчёта просмотра, чтобы неверные попытки не расходовали лимит; вместо
+// этого после passwordAttempts неверных паролей ссылка временно блокируется.
+func (u *LinksUsecase) OpenLink(token, password string) (*models.PublicNote, error) {
+	link, err := u.Repository.GetShareLinkByToken(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open link: %w", err)
+	}
+	if link.RevokedAt != nil {
+		return nil, fmt.Errorf("failed to open link: %w", namederrors.ErrNotFound)
+	}
+	if link.HasPassword {
+		if password == "" {
+			return nil, fmt.Errorf("failed to open link: %w", namederrors.ErrLinkPasswordRequired)
+		}
+		if err = u.Repository.StartLinkAttempt(link.ID, time.Now(), passwordAttempts, passwordLockout); err != nil {
+			return nil, fmt.Errorf("failed to open link: %w", err)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			return nil, fmt.Errorf("failed to open link: %w", namederrors.ErrInvalidLinkPassword)
+		}
+		if err = u.Repository.ResetLinkAttempts(link.ID); err != nil {
+			return nil, fmt.Errorf("failed to open link: %w", err)
+		}
+	}
+
+	link, note, err := u.Repository.ViewShareLink(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open link: %w", err)
+	}
+	return &models.PublicNote{
+		Title:     note.Title,
+		Text:      note.Text,
+		Tags:      note.Tags,
+		UpdatedAt: note.UpdatedAt,
+		ExpiresAt: link.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package linksUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeRepository struct {
+	link     models.ShareLink
+	note     models.Note
+	failures int
+	locked   bool
+}
+
+func (r *fakeRepository) CreateShareLink(link models.ShareLink) (*models.ShareLink, error) {
+	return &link, nil
+}
+
+func (r *fakeRepository) GetShareLink(linkID uint64) (*models.ShareLink, error) {
+	link := r.link
+	return &link, nil
+}
+
+func (r *fakeRepository) GetShareLinkByToken(token string) (*models.ShareLink, error) {
+	if token != r.link.Token {
+		return nil, namederrors.ErrNotFound
+	}
+	link := r.link
+	return &link, nil
+}
+
+func (r *fakeRepository) ListShareLinks(noteID uint64) ([]models.ShareLink, error) {
+	return []models.ShareLink{r.link}, nil
+}
+
+func (r *fakeRepository) RevokeShareLink(linkID uint64) (*models.ShareLink, error) {
+	link := r.link
+	return &link, nil
+}
+
+func (r *fakeRepository) ViewShareLink(token string) (*models.ShareLink, *models.Note, error) {
+	r.link.Views++
+	link, note := r.link, r.note
+	return &link, &note, nil
+}
+
+func (r *fakeRepository) StartLinkAttempt(linkID uint64, now time.Time, limit int, lockout time.Duration) error {
+	if r.locked {
+		return namederrors.ErrTooManyAttempts
+	}
+	r.failures++
+	r.locked = r.failures >= limit
+	return nil
+}
+
+func (r *fakeRepository) ResetLinkAttempts(linkID uint64) error {
+	r.failures = 0
+	r.locked = false
+	return nil
+}
+
+func newTestUsecase(t *testing.T) (*LinksUsecase, *fakeRepository) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	repository := &fakeRepository{
+		link: models.ShareLink{ID: 1, Token: "token", NoteID: 1, PasswordHash: string(hash), HasPassword: true},
+		note: models.Note{ID: 1, Title: "Secret"},
+	}
+	return NewLinksUsecase(repository, nil), repository
+}
+
+func TestOpenLinkAttempts(t *testing.T) {
+	u, repository := newTestUsecase(t)
+
+	_, err := u.OpenLink("token", "")
+	require.ErrorIs(t, err, namederrors.ErrLinkPasswordRequired)
+	require.Zero(t, repository.failures, "a missing password is not an attempt")
+
+	for i := 0; i < passwordAttempts; i++ {
+		_, err = u.OpenLink("token", "guess")
+		require.ErrorIs(t, err, namederrors.ErrInvalidLinkPassword)
+	}
+	_, err = u.OpenLink("token", "secret")
+	require.ErrorIs(t, err, namederrors.ErrTooManyAttempts, "even the right password waits for the lockout")
+	require.Zero(t, repository.link.Views)
+
+	repository.locked = false
+	note, err := u.OpenLink("token", "secret")
+	require.NoError(t, err)
+	require.Equal(t, "Secret", note.Title)
+	require.Zero(t, repository.failures, "the right password resets the counter")
+	require.Equal(t, 1, repository.link.Views)
+}
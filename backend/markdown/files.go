@@ -0,0 +1,283 @@
+package markdown
+
+import (
+	"archive/zip"
+	"backend/wikilinks"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxNameLength = 100
+	untitled      = "Untitled"
+)
+
+var (
+	ErrInvalidArchive  = errors.New("invalid archive")
+	ErrArchiveTooLarge = errors.New("archive too large")
+)
+
+// File — файл архива с путём через «/» относительно корня архива.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// segment делает из заголовка или имени папки допустимое имя файла.
+func segment(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = strings.TrimSpace(string(runes[:maxNameLength]))
+	}
+	if name == "" {
+		return untitled
+	}
+	return name
+}
+
+// FilePath возвращает путь файла заметки: папки заметки и заголовок с
+// расширением .md.
+func FilePath(folder, title string) string {
+	var parts []string
+	for _, part := range strings.Split(folder, "/") {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, segment(part))
+		}
+	}
+	return path.Join(append(parts, segment(title)+Extension)...)
+}
+
+// Paths подбирает заметкам пути файлов так, чтобы они не совпадали без учёта
+// регистра: повторяющиеся имена получают номер.
+func Paths(folders, titles []string) []string {
+	taken := make(map[string]bool, len(titles))
+	paths := make([]string, len(titles))
+	for i := range titles {
+		base := strings.TrimSuffix(FilePath(folders[i], titles[i]), Extension)
+		candidate := base + Extension
+		for n := 2; taken[strings.ToLower(candidate)]; n++ {
+			candidate = base + " (" + strconv.Itoa(n) + ")" + Extension
+		}
+		taken[strings.ToLower(candidate)] = true
+		paths[i] = candidate
+	}
+	return paths
+}
+
+// WriteZip записывает файлы в ZIP-архив.
+func WriteZip(w io.Writer, files []File) error {
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := archive.Create(file.Path)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", file.Path, err)
+		}
+		if _, err = entry.Write(file.Data); err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", file.Path, err)
+		}
+	}
+	return archive.Close()
+}
+
+// ReadZip читает файлы из ZIP-архива, пропуская каталоги, скрытые файлы и
+// служебные каталоги macOS. Число файлов и их суммарный размер после
+// распаковки ограничены, чтобы архив не развернулся в гигабайты.
+func ReadZip(data []byte, maxFiles int, maxSize int64) ([]File, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	var files []File
+	var total int64
+	for _, entry := range archive.File {
+		name := strings.TrimPrefix(strings.ReplaceAll(entry.Name, "\\", "/"), "./")
+		if entry.FileInfo().IsDir() || strings.HasSuffix(name, "/") {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return nil, ErrInvalidArchive
+		}
+		if hidden(name) {
+			continue
+		}
+		if len(files) == maxFiles {
+			return nil, ErrArchiveTooLarge
+		}
+
+		content, err := entry.Open()
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+		fileData, err := io.ReadAll(io.LimitReader(content, maxSize-total+1))
+		content.Close()
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+		total += int64(len(fileData))
+		if total > maxSize {
+			return nil, ErrArchiveTooLarge
+		}
+		files = append(files, File{Path: name, Data: fileData})
+	}
+	return files, nil
+}
+
+func hidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// relativePath возвращает путь к файлу target относительно каталога файла from.
+func relativePath(from, target string) string {
+	fromDirs := strings.Split(path.Dir(from), "/")
+	if path.Dir(from) == "." {
+		fromDirs = nil
+	}
+	targetParts := strings.Split(target, "/")
+	common := 0
+	for common < len(fromDirs) && common < len(targetParts)-1 && fromDirs[common] == targetParts[common] {
+		common++
+	}
+	parts := make([]string, 0, len(fromDirs)-common+len(targetParts)-common)
+	for range len(fromDirs) - common {
+		parts = append(parts, "..")
+	}
+	return strings.Join(append(parts, targetParts[common:]...), "/")
+}
+
+// RelativeURL возвращает адрес ссылки из файла from на файл target.
+func RelativeURL(from, target string) string {
+	return escapeURLPath(relativePath(from, target))
+}
+
+func escapeURLPath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+
+// Link возвращает встроенную ссылку Markdown с подписью label.
+func Link(label, destination string) string {
+	return "[" + labelEscaper.Replace(label) + "](" + Destination(destination) + ")"
+}
+
+// LinkFiles заменяет ссылки [[#ID]] на заметки, выгружаемые вместе с заметкой
+// из файла from, относительными ссылками Markdown на их файлы: ID заметок при
+// загрузке будут другими. file возвращает путь файла и заголовок заметки.
+func LinkFiles(text, from string, file func(noteID uint64) (filePath, title string, ok bool)) string {
+	return wikilinks.ReplaceFunc(text, func(link wikilinks.Link, label string, labeled bool) (string, bool) {
+		if link.NoteID == 0 {
+			return "", false
+		}
+		target, title, ok := file(link.NoteID)
+		if !ok {
+			return "", false
+		}
+		if !labeled {
+			label = title
+		}
+		return Link(label, RelativeURL(from, target)), true
+	})
+}
+
+// markdownLink — встроенная ссылка [подпись](адрес "заголовок"), в том числе
+// на изображение, если перед ней «!». Адрес может быть в угловых скобках.
+var markdownLink = regexp.MustCompile(`(!?)\[((?:[^\[\]\\\n]|\\.)*)\]\(\s*(<[^<>\n]*>|[^\s()<>]+)(?:\s+(?:"[^"\n]*"|'[^'\n]*'))?\s*\)`)
+
+var labelUnescaper = strings.NewReplacer(`\\`, `\`, `\[`, `[`, `\]`, `]`)
+
+// ResolveLinks заменяет относительные ссылки Markdown из файла from на другие
+// файлы .md ссылками [[#ID]]. note возвращает заметку, созданную из файла по
+// пути относительно корня архива. Подпись, совпадающая с заголовком,
+// опускается — это обратное преобразование к LinkFiles.
+func ResolveLinks(text, from string, note func(filePath string) (noteID uint64, title string, ok bool)) string {
+	return markdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		groups := markdownLink.FindStringSubmatch(match)
+		if groups[1] == "!" {
+			return match
+		}
+		target, ok := LocalPath(groups[3])
+		if !ok || !strings.EqualFold(path.Ext(target), Extension) {
+			return match
+		}
+		noteID, title, ok := note(path.Join(path.Dir(from), target))
+		if !ok {
+			return match
+		}
+
+		label := labelUnescaper.Replace(groups[2])
+		if label == title || strings.TrimSpace(label) == "" {
+			return "[[#" + strconv.FormatUint(noteID, 10) + "]]"
+		}
+		if strings.ContainsAny(label, "|[]\n") {
+			return match
+		}
+		return "[[#" + strconv.FormatUint(noteID, 10) + "|" + label + "]]"
+	})
+}
+
+// ReplaceDestinations заменяет адреса встроенных ссылок и изображений
+// результатом replace. image сообщает, что ссылка — изображение. Адрес
+// передаётся без угловых скобок; если replace возвращает false, ссылка
+// остаётся как есть.
+func ReplaceDestinations(text string, replace func(destination string, image bool) (string, bool)) string {
+	return markdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		indexes := markdownLink.FindStringSubmatchIndex(match)
+		start, end := indexes[6], indexes[7]
+		destination := strings.TrimSuffix(strings.TrimPrefix(match[start:end], "<"), ">")
+		replaced, ok := replace(destination, indexes[3] > indexes[2])
+		if !ok {
+			return match
+		}
+		return match[:start] + Destination(replaced) + match[end:]
+	})
+}
+
+// Destination записывает адрес ссылки в угловых скобках, если в нём есть
+// пробелы или скобки.
+func Destination(destination string) string {
+	if strings.ContainsAny(destination, " ()<>") {
+		return "<" + destination + ">"
+	}
+	return destination
+}
+
+// LocalPath возвращает путь файла из адреса ссылки без якоря и кодирования.
+// Абсолютные пути и внешние адреса не считаются локальными.
+func LocalPath(destination string) (string, bool) {
+	destination = strings.TrimSuffix(strings.TrimPrefix(destination, "<"), ">")
+	destination, _, _ = strings.Cut(destination, "#")
+	parsed, err := url.Parse(destination)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" || parsed.RawQuery != "" {
+		return "", false
+	}
+	target := parsed.Path
+	if target == "" || strings.HasPrefix(target, "/") {
+		return "", false
+	}
+	return target, true
+}
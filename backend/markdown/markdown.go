@@ -0,0 +1,131 @@
+// Package markdown переводит заметки в файлы CommonMark с front matter и
+// обратно, а также собирает и разбирает ZIP-архивы таких файлов.
+package markdown
+
+import (
+	"backend/models"
+	"bytes"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	Extension   = ".md"
+	ContentType = "text/markdown; charset=utf-8"
+
+	frontMatterDelimiter = "---"
+)
+
+// frontMatter — метаданные заметки в начале файла. Даты создания и изменения
+// выгружаются для справки и при загрузке не читаются: их ведёт хранилище.
+type frontMatter struct {
+	Title     string  `yaml:"title"`
+	Folder    string  `yaml:"folder,omitempty"`
+	Favourite bool    `yaml:"favorite,omitempty"`
+	Tags      tagList `yaml:"tags,flow,omitempty"`
+	Created   string  `yaml:"created,omitempty"`
+	Updated   string  `yaml:"updated,omitempty"`
+}
+
+// tagList читает теги и списком, и строкой через запятую или пробел, как их
+// записывают другие редакторы заметок.
+type tagList []string
+
+func (t *tagList) UnmarshalYAML(node *yaml.Node) error {
+	var tags []string
+	if node.Kind == yaml.ScalarNode {
+		tags = strings.FieldsFunc(node.Value, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	} else if err := node.Decode(&tags); err != nil {
+		return err
+	}
+	for i, tag := range tags {
+		tags[i] = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	}
+	*t = tags
+	return nil
+}
+
+// Marshal возвращает заметку файлом Markdown: front matter с заголовком,
+// папкой, избранным и тегами, затем текст без изменений.
+func Marshal(note models.Note) []byte {
+	meta := frontMatter{
+		Title:     note.Title,
+		Folder:    note.Folder,
+		Favourite: note.Favourite,
+		Tags:      note.Tags,
+	}
+	if !note.CreatedAt.IsZero() {
+		meta.Created = note.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !note.UpdatedAt.IsZero() {
+		meta.Updated = note.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	// Структура из строк, флага и списка строк кодируется всегда.
+	encoded, _ := yaml.Marshal(meta)
+
+	var out bytes.Buffer
+	out.WriteString(frontMatterDelimiter + "\n")
+	out.Write(encoded)
+	out.WriteString(frontMatterDelimiter + "\n")
+	out.WriteString(note.Text)
+	return out.Bytes()
+}
+
+// Unmarshal читает заметку из файла Markdown. Заголовок и папка остаются
+// пустыми, если их нет во front matter. Файл без front matter или с
+// неразборчивым front matter целиком становится текстом.
+func Unmarshal(data []byte) models.Note {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	raw, body, ok := splitFrontMatter(text)
+	if !ok {
+		return models.Note{Text: text}
+	}
+	var meta frontMatter
+	if err := yaml.Unmarshal([]byte(raw), &meta); err != nil {
+		return models.Note{Text: text}
+	}
+	return models.Note{
+		Title:     strings.TrimSpace(meta.Title),
+		Folder:    strings.Trim(strings.TrimSpace(meta.Folder), "/"),
+		Favourite: meta.Favourite,
+		Tags:      meta.Tags,
+		Text:      body,
+	}
+}
+
+// splitFrontMatter отделяет front matter между строками «---» от текста.
+// Закрыть front matter можно и строкой «...», как документ YAML.
+func splitFrontMatter(text string) (raw, body string, ok bool) {
+	rest, ok := cutLine(text, frontMatterDelimiter)
+	if !ok {
+		return "", "", false
+	}
+	for offset := 0; ; {
+		line, next := rest[offset:], len(rest)
+		newline := strings.IndexByte(line, '\n')
+		if newline >= 0 {
+			line, next = line[:newline], offset+newline+1
+		}
+		if line = strings.TrimSuffix(line, "\r"); line == frontMatterDelimiter || line == "..." {
+			return rest[:offset], rest[next:], true
+		}
+		if newline < 0 {
+			return "", "", false
+		}
+		offset = next
+	}
+}
+
+// cutLine отрезает от текста первую строку, если она равна line.
+func cutLine(text, line string) (string, bool) {
+	for _, newline := range []string{"\n", "\r\n"} {
+		if rest, ok := strings.CutPrefix(text, line+newline); ok {
+			return rest, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,174 @@
+package markdown
+
+import (
+	"archive/zip"
+	"backend/models"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	notes := []models.Note{
+		{Title: "Homework", Text: "# Maths\n\n- [ ] exercise 3\n", Folder: "University/Year 2", Favourite: true, Tags: []string{"study", "urgent"}},
+		{Title: "yes: no — «кавычки» \"quotes\"", Text: "---\nnot front matter\n---\n"},
+		{Title: "Empty"},
+		{Title: "No trailing newline", Text: "last line", Tags: []string{"a b"}},
+	}
+
+	for _, note := range notes {
+		note.CreatedAt = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+		got := Unmarshal(Marshal(note))
+		require.Equal(t, note.Title, got.Title)
+		require.Equal(t, note.Text, got.Text)
+		require.Equal(t, note.Folder, got.Folder)
+		require.Equal(t, note.Favourite, got.Favourite)
+		require.Equal(t, len(note.Tags), len(got.Tags))
+		if len(note.Tags) > 0 {
+			require.Equal(t, note.Tags, got.Tags)
+		}
+	}
+
+	require.Contains(t, string(Marshal(notes[0])), "tags: [study, urgent]\n")
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want models.Note
+	}{
+		{
+			name: "no front matter",
+			data: "# Title\ntext",
+			want: models.Note{Text: "# Title\ntext"},
+		},
+		{
+			name: "tags as a string",
+			data: "---\ntitle: Trip\ntags: travel, summer\naliases: [Holiday]\n---\nPack bags",
+			want: models.Note{Title: "Trip", Tags: []string{"travel", "summer"}, Text: "Pack bags"},
+		},
+		{
+			name: "tags with hashes",
+			data: "---\ntitle: Beach\ntags:\n  - \"#travel\"\n---\n",
+			want: models.Note{Title: "Beach", Tags: []string{"travel"}, Text: ""},
+		},
+		{
+			name: "crlf and byte order mark",
+			data: "\ufeff---\r\ntitle: Windows\r\n---\r\nline\r\n",
+			want: models.Note{Title: "Windows", Text: "line\r\n"},
+		},
+		{
+			name: "closed with dots",
+			data: "---\ntitle: Dots\n...\ntext",
+			want: models.Note{Title: "Dots", Text: "text"},
+		},
+		{
+			name: "invalid front matter is text",
+			data: "---\ntitle: [unclosed\n---\ntext",
+			want: models.Note{Text: "---\ntitle: [unclosed\n---\ntext"},
+		},
+		{
+			name: "unclosed front matter is text",
+			data: "---\ntitle: Open\n",
+			want: models.Note{Text: "---\ntitle: Open\n"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, Unmarshal([]byte(test.data)))
+		})
+	}
+}
+
+func TestPaths(t *testing.T) {
+	require.Equal(t, "University/Year 2/Homework.md", FilePath("University/Year 2/", "Homework"))
+	require.Equal(t, "a_b/Untitled.md", FilePath("a:b", " .. "))
+
+	paths := Paths(
+		[]string{"", "", "Work", ""},
+		[]string{"Plan", "plan", "Plan", "What? Why/How"},
+	)
+	require.Equal(t, []string{"Plan.md", "plan (2).md", "Work/Plan.md", "What_ Why_How.md"}, paths)
+}
+
+func TestZip(t *testing.T) {
+	files := []File{
+		{Path: "Plan.md", Data: []byte("plan")},
+		{Path: "Work/Notes.md", Data: []byte("notes")},
+	}
+	var archive bytes.Buffer
+	require.NoError(t, WriteZip(&archive, files))
+
+	got, err := ReadZip(archive.Bytes(), 10, 100)
+	require.NoError(t, err)
+	require.Equal(t, files, got)
+
+	_, err = ReadZip(archive.Bytes(), 1, 100)
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+	_, err = ReadZip(archive.Bytes(), 10, 5)
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+	_, err = ReadZip([]byte("not a zip"), 10, 100)
+	require.ErrorIs(t, err, ErrInvalidArchive)
+
+	var unsafe bytes.Buffer
+	writer := zip.NewWriter(&unsafe)
+	for _, name := range []string{"__MACOSX/._Plan.md", ".obsidian/app.json", "../escape.md"} {
+		entry, err := writer.Create(name)
+		require.NoError(t, err)
+		entry.Write([]byte("x"))
+	}
+	require.NoError(t, writer.Close())
+	_, err = ReadZip(unsafe.Bytes(), 10, 100)
+	require.ErrorIs(t, err, ErrInvalidArchive)
+}
+
+func TestLinks(t *testing.T) {
+	paths := map[uint64]string{1: "Work/Plan.md", 2: "Work/Q3 [draft].md", 3: "Home/Shopping list.md"}
+	titles := map[uint64]string{1: "Plan", 2: "Q3 [draft]", 3: "Shopping list"}
+	file := func(noteID uint64) (string, string, bool) {
+		p, ok := paths[noteID]
+		return p, titles[noteID], ok
+	}
+
+	text := "See [[#2]], [[#3|groceries]], [[#99]] and [[Plan]]. ![chart](chart.md) [site](https://example.com/a.md)"
+	linked := LinkFiles(text, paths[1], file)
+	require.Equal(t, "See [Q3 \\[draft\\]](Q3%20%5Bdraft%5D.md), [groceries](../Home/Shopping%20list.md), [[#99]] and [[Plan]]. ![chart](chart.md) [site](https://example.com/a.md)", linked)
+
+	// При загрузке заметки получают новые ID.
+	newIDs := map[string]uint64{"Work/Q3 [draft].md": 12, "Home/Shopping list.md": 13}
+	resolved := ResolveLinks(linked, paths[1], func(filePath string) (uint64, string, bool) {
+		noteID, ok := newIDs[filePath]
+		for oldID, p := range paths {
+			if p == filePath {
+				return noteID, titles[oldID], ok
+			}
+		}
+		return 0, "", false
+	})
+	require.Equal(t, "See [[#12]], [[#13|groceries]], [[#99]] and [[Plan]]. ![chart](chart.md) [site](https://example.com/a.md)", resolved)
+
+	other := ResolveLinks(`[up](<../Home/Shopping list.md#Fruit> "title") [missing](Nope.md)`, "Work/Plan.md", func(filePath string) (uint64, string, bool) {
+		return 13, "Shopping list", strings.EqualFold(filePath, "Home/Shopping list.md")
+	})
+	require.Equal(t, "[[#13|up]] [missing](Nope.md)", other)
+}
+
+func TestReplaceDestinations(t *testing.T) {
+	text := `![chart](<img/Q3 chart.png> "Q3") and [spec](spec.pdf#page=2), [site](https://example.com)`
+	replaced := ReplaceDestinations(text, func(destination string, image bool) (string, bool) {
+		target, ok := LocalPath(destination)
+		if !ok {
+			return "", false
+		}
+		if image {
+			return "/files/" + target + " (copy)", true
+		}
+		return "/files/" + target, true
+	})
+	require.Equal(t, `![chart](</files/img/Q3 chart.png (copy)> "Q3") and [spec](/files/spec.pdf), [site](https://example.com)`, replaced)
+}
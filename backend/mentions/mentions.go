@@ -0,0 +1,42 @@
+package mentions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionPattern — упоминание пользователя в виде @email. Перед @ не должно
+// быть буквы или цифры, чтобы не принимать за упоминание сам email.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.])@([A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,})`)
+
+// Parse возвращает email упомянутых в тексте пользователей в порядке первого
+// упоминания, без повторов и в нижнем регистре.
+func Parse(text string) []string {
+	seen := make(map[string]bool)
+	var emails []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		email := strings.ToLower(match[1])
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// Added возвращает упоминания из text, которых не было в previous: о них
+// пользователей нужно уведомить после сохранения.
+func Added(previous, text string) []string {
+	known := make(map[string]bool)
+	for _, email := range Parse(previous) {
+		known[email] = true
+	}
+
+	var added []string
+	for _, email := range Parse(text) {
+		if !known[email] {
+			added = append(added, email)
+		}
+	}
+	return added
+}
@@ -0,0 +1,35 @@
+package mentions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "none", text: "plain text", want: nil},
+		{name: "single", text: "ask @alice@example.com please", want: []string{"alice@example.com"}},
+		{name: "start of text", text: "@bob@example.org: done?", want: []string{"bob@example.org"}},
+		{name: "trailing punctuation", text: "thanks, @bob@example.org.", want: []string{"bob@example.org"}},
+		{name: "duplicates and case", text: "@Bob@Example.org and @bob@example.org", want: []string{"bob@example.org"}},
+		{name: "plain email is not a mention", text: "write to alice@example.com", want: nil},
+		{name: "order", text: "@b@x.io then @a@x.io", want: []string{"b@x.io", "a@x.io"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, Parse(test.text))
+		})
+	}
+}
+
+func TestAdded(t *testing.T) {
+	require.Equal(t, []string{"c@x.io"}, Added("hi @a@x.io", "hi @a@x.io and @c@x.io"))
+	require.Nil(t, Added("@a@x.io", "bye @a@x.io"))
+	require.Equal(t, []string{"a@x.io"}, Added("", "@a@x.io"))
+}
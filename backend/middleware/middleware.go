@@ -29,19 +29,73 @@ func GetUserID(ctx context.Context) (uint64, bool) {
 	return id, ok
 }
 
+// OwnerID возвращает владельца ресурсов из адреса запроса: рабочее
+// пространство для маршрутов /workspaces/{workspace_id}/..., иначе
+// пользователя из /user/{user_id}/....
+func OwnerID(r *http.Request) (uint64, error) {
+	vars := mux.Vars(r)
+	if workspaceID, ok := vars["workspace_id"]; ok {
+		return strconv.ParseUint(workspaceID, 10, 64)
+	}
+	return strconv.ParseUint(vars["user_id"], 10, 64)
+}
+
+// UserOwner пропускает запросы к ресурсам владельца /user/{user_id}/...,
+// только если владелец — пользователь. ID пользователей и рабочих пространств
+// выдаются из одной последовательности, а проверки доступа различают их по
+// ID, поэтому вид владельца задаёт маршрут.
+func UserOwner(s *store.Store) mux.MiddlewareFunc {
+	return ownerKind(func(ownerID uint64) bool {
+		_, ok := s.GetUser(ownerID)
+		return ok
+	})
+}
+
+// WorkspaceOwner пропускает запросы к ресурсам владельца
+// /workspaces/{workspace_id}/..., только если владелец — рабочее пространство.
+func WorkspaceOwner(s *store.Store) mux.MiddlewareFunc {
+	return ownerKind(func(ownerID uint64) bool {
+		_, err := s.GetWorkspace(ownerID)
+		return err == nil
+	})
+}
+
+func ownerKind(exists func(ownerID uint64) bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ownerID, err := OwnerID(r)
+			if err != nil {
+				apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+				return
+			}
+			if !exists(ownerID) {
+				apiutils.WriteError(w, http.StatusNotFound, "owner not found")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 var allowed = map[string]bool{
 	"http://localhost:8030":      true,
 	"http://127.0.0.1:8030":      true,
 	"http://89.208.210.115:8030": true,
 }
 
+// IsAllowedOrigin сообщает, разрешены ли запросы фронтенда с origin.
+func IsAllowedOrigin(origin string) bool {
+	return allowed[origin]
+}
+
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
 		if allowed[origin] {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match, X-Link-Password")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 		}
 
@@ -80,35 +134,3 @@ func AuthMiddleware(s *store.Store) mux.MiddlewareFunc {
 		})
 	}
 }
-
-func UserAccessMiddleware() mux.MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			userID, ok := GetUserID(r.Context())
-			if !ok {
-				apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
-				return
-			}
-
-			vars := mux.Vars(r)
-			idStr := vars["user_id"]
-			if idStr == "" {
-				apiutils.WriteError(w, http.StatusBadRequest, "missing user id")
-				return
-			}
-
-			requestedUserID, err := strconv.ParseUint(idStr, 10, 64)
-			if err != nil {
-				apiutils.WriteError(w, http.StatusBadRequest, "invalid user id")
-				return
-			}
-
-			if userID != requestedUserID {
-				apiutils.WriteError(w, http.StatusForbidden, "access denied")
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
-}
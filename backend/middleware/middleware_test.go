@@ -4,6 +4,7 @@ import (
 	"backend/store"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -59,87 +60,39 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 }
 
-func TestUserAccessMiddleware(t *testing.T) {
+func TestOwnerKind(t *testing.T) {
 	s := store.NewStore()
 	user, err := s.CreateUser("test@example.com", "password")
 	require.NoError(t, err)
+	workspace, err := s.CreateWorkspace("Team", user.ID)
+	require.NoError(t, err)
 
-	t.Run("valid access", func(t *testing.T) {
-		router := mux.NewRouter()
-		router.HandleFunc("/user/{user_id}/notes", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-		}).Methods("GET")
-		
-		req := httptest.NewRequest("GET", "/user/1/notes", nil)
-		req = req.WithContext(WithUserID(req.Context(), user.ID))
-		rr := httptest.NewRecorder()
-
-		req = mux.SetURLVars(req, map[string]string{"user_id": "1"})
-		handler := UserAccessMiddleware()(router)
-		handler.ServeHTTP(rr, req)
-		require.Equal(t, http.StatusOK, rr.Code)
-	})
-
-	t.Run("no user in context", func(t *testing.T) {
-		router := mux.NewRouter()
-		router.HandleFunc("/user/{user_id}/notes", func(w http.ResponseWriter, r *http.Request) {
-			t.Fatal("handler should not be called")
-		}).Methods("GET")
-		
-		req := httptest.NewRequest("GET", "/user/1/notes", nil)
-		req = mux.SetURLVars(req, map[string]string{"user_id": "1"})
-		rr := httptest.NewRecorder()
-
-		handler := UserAccessMiddleware()(router)
-		handler.ServeHTTP(rr, req)
-		require.Equal(t, http.StatusUnauthorized, rr.Code)
-	})
-
-	t.Run("access denied", func(t *testing.T) {
-		router := mux.NewRouter()
-		router.HandleFunc("/user/{user_id}/notes", func(w http.ResponseWriter, r *http.Request) {
-			t.Fatal("handler should not be called")
-		}).Methods("GET")
-		
-		req := httptest.NewRequest("GET", "/user/2/notes", nil)
-		req = req.WithContext(WithUserID(req.Context(), user.ID))
-		req = mux.SetURLVars(req, map[string]string{"user_id": "2"})
-		rr := httptest.NewRecorder()
-
-		handler := UserAccessMiddleware()(router)
-		handler.ServeHTTP(rr, req)
-		require.Equal(t, http.StatusForbidden, rr.Code)
-	})
-
-	t.Run("missing user id", func(t *testing.T) {
-		router := mux.NewRouter()
-		router.HandleFunc("/user/{user_id}/notes", func(w http.ResponseWriter, r *http.Request) {
-			t.Fatal("handler should not be called")
-		}).Methods("GET")
-		
-		req := httptest.NewRequest("GET", "/user//notes", nil)
-		req = req.WithContext(WithUserID(req.Context(), user.ID))
-		req = mux.SetURLVars(req, map[string]string{"user_id": ""})
-		rr := httptest.NewRecorder()
-
-		handler := UserAccessMiddleware()(router)
-		handler.ServeHTTP(rr, req)
-		require.Equal(t, http.StatusBadRequest, rr.Code)
-	})
-
-	t.Run("invalid user id", func(t *testing.T) {
-		router := mux.NewRouter()
-		router.HandleFunc("/user/{user_id}/notes", func(w http.ResponseWriter, r *http.Request) {
-			t.Fatal("handler should not be called")
-		}).Methods("GET")
-		
-		req := httptest.NewRequest("GET", "/user/invalid/notes", nil)
-		req = req.WithContext(WithUserID(req.Context(), user.ID))
-		req = mux.SetURLVars(req, map[string]string{"user_id": "invalid"})
-		rr := httptest.NewRecorder()
-
-		handler := UserAccessMiddleware()(router)
-		handler.ServeHTTP(rr, req)
-		require.Equal(t, http.StatusBadRequest, rr.Code)
-	})
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		wantCode int
+	}{
+		{name: "user route with user", vars: map[string]string{"user_id": strconv.FormatUint(user.ID, 10)}, wantCode: http.StatusOK},
+		{name: "user route with workspace", vars: map[string]string{"user_id": strconv.FormatUint(workspace.ID, 10)}, wantCode: http.StatusNotFound},
+		{name: "workspace route with workspace", vars: map[string]string{"workspace_id": strconv.FormatUint(workspace.ID, 10)}, wantCode: http.StatusOK},
+		{name: "workspace route with user", vars: map[string]string{"workspace_id": strconv.FormatUint(user.ID, 10)}, wantCode: http.StatusNotFound},
+		{name: "invalid ID", vars: map[string]string{"user_id": "abc"}, wantCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			check := UserOwner(s)
+			if _, ok := test.vars["workspace_id"]; ok {
+				check = WorkspaceOwner(s)
+			}
+			handler := check(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := mux.SetURLVars(httptest.NewRequest("GET", "/test", nil), test.vars)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			require.Equal(t, test.wantCode, rr.Code)
+		})
+	}
 }
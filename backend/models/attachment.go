@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	ImagePending = "pending"
+	ImageReady   = "ready"
+	ImageFailed  = "failed"
+)
+
+// Attachment представляет файл, прикреплённый к заметке. Содержимое лежит в
+// хранилище файлов под ключом Key, размер учитывается в квоте владельца заметки.
+// Image заполняется для изображений, которые обрабатываются после загрузки.
+type Attachment struct {
+	ID          uint64           `json:"id"`
+	NoteID      uint64           `json:"note_id"`
+	OwnerID     uint64           `json:"owner_id"`
+	Filename    string           `json:"filename"`
+	ContentType string           `json:"content_type"`
+	Size        int64            `json:"size"`
+	Key         string           `json:"-"`
+	Image       *AttachmentImage `json:"image,omitempty"`
+	UploadedBy  uint64           `json:"uploaded_by"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// AttachmentImage описывает обработку изображения: размеры после поворота и
+// уменьшенные варианты. Варианты в квоте не учитываются.
+type AttachmentImage struct {
+	Status   string         `json:"status"`
+	Width    int            `json:"width,omitempty"`
+	Height   int            `json:"height,omitempty"`
+	Variants []ImageVariant `json:"variants,omitempty"`
+}
+
+// ImageVariant представляет уменьшенную копию изображения
+type ImageVariant struct {
+	Name        string `json:"name"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Key         string `json:"-"`
+}
+
+// StorageUsage представляет занятое вложениями место и квоту владельца в байтах
+type StorageUsage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "backend/crdt"
+
+// Типы сообщений протокола совместного редактирования
+const (
+	CollabSync  = "sync"
+	CollabOps   = "ops"
+	CollabError = "error"
+)
+
+// CollabMessage представляет сообщение WebSocket-протокола совместного редактирования.
+// Клиент начинает с sync, передавая известную ему сессию и вектор состояния;
+// сервер отвечает недостающими операциями, а при смене сессии — полным
+// состоянием с признаком reset. В ответе на sync сервер сообщает сайт,
+// от имени которого клиент создаёт операции в этом подключении.
+type CollabMessage struct {
+	Type    string           `json:"type"`
+	Session string           `json:"session,omitempty"`
+	Site    string           `json:"site,omitempty"`
+	Vector  crdt.StateVector `json:"state_vector,omitempty"`
+	Clock   uint64           `json:"clock,omitempty"`
+	Reset   bool             `json:"reset,omitempty"`
+	Ops     []crdt.Op        `json:"ops,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
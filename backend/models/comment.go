@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// CommentAnchor привязывает обсуждение к фрагменту текста заметки: диапазону
+// [Start, End) в рунах и его цитате, по которой фрагмент ищется после правок.
+type CommentAnchor struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Quote string `json:"quote"`
+}
+
+// Comment представляет комментарий в обсуждении
+type Comment struct {
+	ID        uint64     `json:"id"`
+	ThreadID  uint64     `json:"thread_id"`
+	AuthorID  uint64     `json:"author_id"`
+	Text      string     `json:"text"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+}
+
+// CommentThread представляет обсуждение заметки или её фрагмента. Обсуждение
+// без Anchor относится ко всей заметке; Outdated отмечает обсуждение, чей
+// фрагмент удалён из текста.
+type CommentThread struct {
+	ID         uint64         `json:"id"`
+	NoteID     uint64         `json:"note_id"`
+	OwnerID    uint64         `json:"owner_id"`
+	Anchor     *CommentAnchor `json:"anchor,omitempty"`
+	Outdated   bool           `json:"outdated"`
+	Resolved   bool           `json:"resolved"`
+	ResolvedBy uint64         `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time     `json:"resolved_at,omitempty"`
+	CreatedBy  uint64         `json:"created_by"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	Comments   []Comment      `json:"comments"`
+}
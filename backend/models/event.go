@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Типы событий ленты изменений заметок
+const (
+	NoteCreated = "note.created"
+	NoteUpdated = "note.updated"
+	NoteDeleted = "note.deleted"
+	NoteMoved   = "note.moved"
+)
+
+// NoteEvent представляет изменение заметки в ленте пользователя. ID
+// возрастает в пределах ленты одного пользователя.
+type NoteEvent struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	NoteID     uint64    `json:"note_id"`
+	Note       *Note     `json:"note,omitempty"`
+	FromFolder string    `json:"from_folder,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
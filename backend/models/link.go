@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ShareLink представляет публичную ссылку на заметку, открываемую без сессии.
+// Ссылка может быть защищена паролем, ограничена сроком и числом просмотров.
+type ShareLink struct {
+	ID           uint64     `json:"id"`
+	Token        string     `json:"token"`
+	NoteID       uint64     `json:"note_id"`
+	OwnerID      uint64     `json:"owner_id"`
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxViews     int        `json:"max_views,omitempty"`
+	Views        int        `json:"views"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy    uint64     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// PublicNote представляет заметку, открытую по публичной ссылке, только для чтения
+type PublicNote struct {
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	Tags      []string   `json:"tags"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
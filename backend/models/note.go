@@ -1,11 +1,41 @@
 package models
 
-// Note представляет заметку пользователя
+import "time"
+
+// Note представляет заметку пользователя или рабочего пространства
 type Note struct {
-	ID        uint64 `json:"id"`
-	OwnerID   uint64 `json:"owner_id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	Favourite bool   `json:"favorite"`
-	Folder    string `json:"folder"`
+	ID      uint64 `json:"id"`
+	Version uint64 `json:"version"`
+	// OwnerID — пользователь или рабочее пространство, которому принадлежит заметка
+	OwnerID   uint64    `json:"owner_id"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	Favourite bool      `json:"favorite"`
+	Folder    string    `json:"folder"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	CreatedBy uint64    `json:"created_by"`
+	UpdatedBy uint64    `json:"updated_by"`
+	// DeletedAt заполнено у заметок в корзине
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy uint64     `json:"deleted_by,omitempty"`
+	// ChangeSeq — номер последнего изменения заметки в общей последовательности хранилища
+	ChangeSeq uint64 `json:"-"`
+}
+
+// Folder представляет папку с заметками пользователя
+type Folder struct {
+	Name      string `json:"name"`
+	NoteCount int    `json:"note_count"`
+}
+
+// NotesFilter описывает отбор заметок; незаполненные поля выборку не ограничивают
+type NotesFilter struct {
+	Query         string     `json:"query,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	Folder        string     `json:"folder,omitempty"`
+	Favourite     *bool      `json:"favorite,omitempty"`
+	UpdatedAfter  *time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore *time.Time `json:"updated_before,omitempty"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Виды уведомлений
+const (
+	NotificationMention      = "mention"
+	NotificationShare        = "share"
+	NotificationCommentReply = "comment_reply"
+	NotificationReminder     = "reminder"
+)
+
+// Notification представляет уведомление пользователя. ActorID — пользователь,
+// действие которого вызвало уведомление; у напоминаний он не заполнен.
+type Notification struct {
+	ID        uint64     `json:"id"`
+	UserID    uint64     `json:"user_id"`
+	Type      string     `json:"type"`
+	ActorID   uint64     `json:"actor_id,omitempty"`
+	OwnerID   uint64     `json:"owner_id"`
+	NoteID    uint64     `json:"note_id"`
+	NoteTitle string     `json:"note_title"`
+	ThreadID  uint64     `json:"thread_id,omitempty"`
+	CommentID uint64     `json:"comment_id,omitempty"`
+	Excerpt   string     `json:"excerpt,omitempty"`
+	Read      bool       `json:"read"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+// NotificationList представляет страницу уведомлений и число непрочитанных
+type NotificationList struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int            `json:"unread"`
+}
+
+// Reminder представляет напоминание пользователю о заметке в момент RemindAt
+type Reminder struct {
+	ID        uint64    `json:"id"`
+	UserID    uint64    `json:"user_id"`
+	OwnerID   uint64    `json:"owner_id"`
+	NoteID    uint64    `json:"note_id"`
+	RemindAt  time.Time `json:"remind_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
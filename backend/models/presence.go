@@ -0,0 +1,38 @@
+package models
+
+import "backend/crdt"
+
+// Типы событий присутствия
+const (
+	PresenceSnapshot  = "snapshot"
+	PresenceJoin      = "join"
+	PresenceLeave     = "leave"
+	PresenceCursor    = "cursor"
+	PresenceHeartbeat = "heartbeat"
+)
+
+// Cursor представляет курсор или выделение пользователя. Границы задаются
+// идентификаторами символов совместного документа, поэтому не сдвигаются
+// от чужих правок; нулевой идентификатор — начало текста.
+type Cursor struct {
+	Anchor crdt.ID `json:"anchor"`
+	Head   crdt.ID `json:"head"`
+}
+
+// PresenceMember представляет одно подключение пользователя к открытой заметке
+type PresenceMember struct {
+	ConnectionID string  `json:"connection_id"`
+	UserID       uint64  `json:"user_id"`
+	Cursor       *Cursor `json:"cursor,omitempty"`
+}
+
+// PresenceEvent представляет событие присутствия. Клиент отправляет
+// heartbeat и cursor, сервер рассылает snapshot, join, leave и cursor.
+type PresenceEvent struct {
+	Type         string           `json:"type"`
+	NoteID       uint64           `json:"note_id,omitempty"`
+	ConnectionID string           `json:"connection_id,omitempty"`
+	UserID       uint64           `json:"user_id,omitempty"`
+	Cursor       *Cursor          `json:"cursor,omitempty"`
+	Members      []PresenceMember `json:"members,omitempty"`
+}
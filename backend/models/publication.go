@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Publication представляет заметку, опубликованную как публичная веб-страница.
+// Если включён IncludeSubpages, подстраницами сайта служат подстраницы заметки
+// на момент публикации (PageIDs); заметки, появившиеся в её папке позже,
+// публикуются только при повторной публикации.
+type Publication struct {
+	NoteID          uint64    `json:"note_id"`
+	OwnerID         uint64    `json:"owner_id"`
+	Slug            string    `json:"slug"`
+	IncludeSubpages bool      `json:"include_subpages"`
+	PageIDs         []uint64  `json:"page_ids,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PublishedPage представляет страницу опубликованного сайта: саму заметку и
+// навигацию по подстраницам
+type PublishedPage struct {
+	Publication Publication
+	Note        Note
+	Root        Note
+	Pages       []Note
+}
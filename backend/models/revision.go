@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// NoteRevision представляет сохранённое состояние заметки после очередного изменения
+type NoteRevision struct {
+	ID           uint64    `json:"id"`
+	NoteID       uint64    `json:"note_id"`
+	Number       uint64    `json:"number"`
+	AuthorID     uint64    `json:"author_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Title        string    `json:"title"`
+	Text         string    `json:"text"`
+	Favourite    bool      `json:"favorite"`
+	Folder       string    `json:"folder"`
+	Tags         []string  `json:"tags"`
+	RestoredFrom uint64    `json:"restored_from,omitempty"`
+}
+
+// RevisionDiff представляет различия между двумя ревизиями заметки
+type RevisionDiff struct {
+	From      uint64             `json:"from"`
+	To        uint64             `json:"to"`
+	OldTitle  string             `json:"old_title"`
+	NewTitle  string             `json:"new_title"`
+	OldFolder string             `json:"old_folder"`
+	NewFolder string             `json:"new_folder"`
+	Lines     []RevisionDiffLine `json:"lines"`
+}
+
+// RevisionDiffLine представляет строку построчного сравнения текста
+type RevisionDiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SavedSearch представляет сохранённый поиск — «умную папку», содержимое которой вычисляется по фильтру
+type SavedSearch struct {
+	ID        uint64      `json:"id"`
+	OwnerID   uint64      `json:"owner_id"`
+	Name      string      `json:"name"`
+	Filter    NotesFilter `json:"filter"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// FolderList представляет боковую панель: обычные папки и умные папки пользователя
+type FolderList struct {
+	Folders      []Folder      `json:"folders"`
+	SmartFolders []SavedSearch `json:"smart_folders"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// NoteSearchResult представляет найденную заметку с релевантностью и подсвеченными фрагментами
+type NoteSearchResult struct {
+	Note           Note    `json:"note"`
+	Score          float64 `json:"score"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
+
+// QuickSwitchResult представляет заметку или папку в результатах быстрого перехода
+type QuickSwitchResult struct {
+	Type      string  `json:"type"`
+	NoteID    uint64  `json:"note_id,omitempty"`
+	Title     string  `json:"title"`
+	Folder    string  `json:"folder"`
+	Favourite bool    `json:"favorite"`
+	Score     float64 `json:"score"`
+}
+
+const (
+	QuickSwitchNote   = "note"
+	QuickSwitchFolder = "folder"
+)
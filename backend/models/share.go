@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Роли доступа к заметке по возрастанию прав
+const (
+	RoleViewer    = "viewer"
+	RoleCommenter = "commenter"
+	RoleEditor    = "editor"
+	RoleOwner     = "owner"
+)
+
+// NoteShare представляет доступ к заметке, выданный другому пользователю.
+// Приглашение незарегистрированного email хранится с нулевым UserID и
+// привязывается к пользователю при регистрации.
+type NoteShare struct {
+	ID        uint64    `json:"id"`
+	NoteID    uint64    `json:"note_id"`
+	OwnerID   uint64    `json:"owner_id"`
+	UserID    uint64    `json:"user_id,omitempty"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Pending   bool      `json:"pending"`
+	CreatedBy uint64    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SharedNote представляет заметку другого пользователя, доступную по приглашению
+type SharedNote struct {
+	Note    Note   `json:"note"`
+	Role    string `json:"role"`
+	ShareID uint64 `json:"share_id"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Типы мутаций офлайн-синхронизации
+const (
+	MutationCreate = "create"
+	MutationUpdate = "update"
+	MutationDelete = "delete"
+)
+
+// Статусы применения мутаций
+const (
+	MutationApplied  = "applied"
+	MutationMerged   = "merged"
+	MutationConflict = "conflict"
+	MutationRejected = "rejected"
+)
+
+// Tombstone представляет безвозвратно удалённую заметку в журнале синхронизации
+type Tombstone struct {
+	NoteID    uint64    `json:"note_id"`
+	OwnerID   uint64    `json:"-"`
+	ChangeSeq uint64    `json:"-"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// NotePatch представляет изменённые поля заметки; nil означает, что поле не менялось
+type NotePatch struct {
+	Title     *string   `json:"title,omitempty"`
+	Text      *string   `json:"text,omitempty"`
+	Favourite *bool     `json:"favorite,omitempty"`
+	Folder    *string   `json:"folder,omitempty"`
+	Tags      *[]string `json:"tags,omitempty"`
+}
+
+// Mutation представляет локальное изменение клиента. ID задаёт клиент, повтор
+// мутации с тем же ID не применяется дважды. Base содержит значения изменённых
+// полей в версии BaseVersion, от которой клиент начинал правку.
+type Mutation struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	NoteID      uint64    `json:"note_id,omitempty"`
+	CreateID    string    `json:"create_id,omitempty"`
+	BaseVersion uint64    `json:"base_version,omitempty"`
+	Base        NotePatch `json:"base"`
+	Patch       NotePatch `json:"patch"`
+}
+
+// MutationResult представляет итог применения мутации
+type MutationResult struct {
+	MutationID     string   `json:"mutation_id"`
+	Status         string   `json:"status"`
+	NoteID         uint64   `json:"note_id,omitempty"`
+	Note           *Note    `json:"note,omitempty"`
+	Conflicts      []string `json:"conflicts,omitempty"`
+	ConflictNoteID uint64   `json:"conflict_note_id,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// NoteChanges представляет изменения заметок пользователя после курсора.
+// Reset означает, что курсор устарел и в Notes передан полный список.
+type NoteChanges struct {
+	Cursor     uint64      `json:"cursor"`
+	Reset      bool        `json:"reset,omitempty"`
+	Notes      []Note      `json:"notes"`
+	Tombstones []Tombstone `json:"tombstones"`
+}
+
+// SyncRequest представляет запрос синхронизации: курсор и пачку локальных мутаций
+type SyncRequest struct {
+	Cursor    uint64     `json:"cursor"`
+	Mutations []Mutation `json:"mutations"`
+}
+
+// SyncResponse представляет итоги мутаций и изменения на сервере после курсора
+type SyncResponse struct {
+	NoteChanges
+	Results []MutationResult `json:"results"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// NoteTemplate представляет шаблон заметки. Встроенные шаблоны (BuiltIn) не
+// принадлежат владельцу и доступны всем только для чтения. В заголовок, текст
+// и папку при создании заметки подставляются переменные вида {{date}}.
+type NoteTemplate struct {
+	ID        uint64    `json:"id"`
+	OwnerID   uint64    `json:"owner_id"`
+	BuiltIn   bool      `json:"built_in"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	Favourite bool      `json:"favorite"`
+	Folder    string    `json:"folder"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ImportResult представляет заметки, созданные при загрузке файлов, и пути
+// пропущенных файлов
+type ImportResult struct {
+	Notes   []Note   `json:"notes"`
+	Skipped []string `json:"skipped"`
+}
+
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportJob представляет фоновый перенос заметок из выгрузки другого
+// приложения. Processed из Total показывает ход работы, Errors — элементы
+// выгрузки, которые не удалось перенести.
+type ImportJob struct {
+	ID         uint64            `json:"id"`
+	OwnerID    uint64            `json:"owner_id"`
+	Source     string            `json:"source"`
+	Filename   string            `json:"filename"`
+	Folder     string            `json:"folder,omitempty"`
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	NoteIDs    []uint64          `json:"note_ids"`
+	Errors     []ImportItemError `json:"errors"`
+	Error      string            `json:"error,omitempty"`
+	CreatedBy  uint64            `json:"created_by"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// ImportItemError представляет ошибку переноса страницы или файла выгрузки
+type ImportItemError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// WikiLink представляет ссылку из текста заметки на другую заметку. Target —
+// цель так, как она записана: заголовок или #ID. Broken отмечает ссылку, для
+// которой не нашлось заметки.
+type WikiLink struct {
+	Target string `json:"target"`
+	NoteID uint64 `json:"note_id,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Broken bool   `json:"broken"`
+}
+
+// Backlink представляет заметку, ссылающуюся на данную
+type Backlink struct {
+	NoteID    uint64    `json:"note_id"`
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BrokenLink представляет ссылку, для которой не нашлось заметки
+type BrokenLink struct {
+	NoteID uint64 `json:"note_id"`
+	Title  string `json:"title"`
+	Target string `json:"target"`
+}
+
+// GraphNode представляет заметку в графе связей
+type GraphNode struct {
+	ID        uint64   `json:"id"`
+	Title     string   `json:"title"`
+	Folder    string   `json:"folder"`
+	Tags      []string `json:"tags"`
+	Links     int      `json:"links"`
+	Backlinks int      `json:"backlinks"`
+}
+
+// GraphEdge представляет ссылку заметки Source на заметку Target
+type GraphEdge struct {
+	Source uint64 `json:"source"`
+	Target uint64 `json:"target"`
+}
+
+// NoteGraph представляет заметки владельца и ссылки между ними
+type NoteGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Роли участников рабочего пространства по возрастанию прав
+const (
+	WorkspaceRoleGuest  = "guest"
+	WorkspaceRoleMember = "member"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleOwner  = "owner"
+)
+
+// Workspace представляет рабочее пространство команды. Заметки пространства
+// хранятся с OwnerID, равным ID пространства: идентификаторы пространств и
+// пользователей выдаются из общей последовательности.
+type Workspace struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy uint64    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkspaceMember представляет участника рабочего пространства
+type WorkspaceMember struct {
+	WorkspaceID uint64    `json:"workspace_id"`
+	UserID      uint64    `json:"user_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// WorkspaceInvitation представляет приглашение в рабочее пространство по email.
+// Участником пользователь становится, приняв приглашение.
+type WorkspaceInvitation struct {
+	ID            uint64    `json:"id"`
+	WorkspaceID   uint64    `json:"workspace_id"`
+	WorkspaceName string    `json:"workspace_name"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	InvitedBy     uint64    `json:"invited_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// UserWorkspace представляет рабочее пространство с ролью в нём пользователя
+type UserWorkspace struct {
+	Workspace Workspace `json:"workspace"`
+	Role      string    `json:"role"`
+}
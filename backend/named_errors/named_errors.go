@@ -1,6 +1,9 @@
 package namederrors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUserExists             = errors.New("user already exists")
@@ -8,4 +11,35 @@ var (
 	ErrNotFound               = errors.New("not found")
 	ErrNoCookie               = errors.New("no cookie")
 	ErrInvalidSession         = errors.New("invalid session")
-)
\ No newline at end of file
+	ErrPreconditionRequired   = errors.New("precondition required")
+	ErrVersionConflict        = errors.New("version conflict")
+	ErrForbidden              = errors.New("access denied")
+	ErrSelfShare              = errors.New("cannot share note with its owner")
+	ErrLinkExpired            = errors.New("link expired")
+	ErrLinkPasswordRequired   = errors.New("link password required")
+	ErrInvalidLinkPassword    = errors.New("invalid link password")
+	ErrTooManyAttempts        = errors.New("too many attempts")
+	ErrSlugTaken              = errors.New("slug already taken")
+	ErrAlreadyMember          = errors.New("user is already a workspace member")
+	ErrInvalidAnchor          = errors.New("invalid comment anchor")
+	ErrFileTooLarge           = errors.New("file too large")
+	ErrUnsupportedMediaType   = errors.New("unsupported media type")
+	ErrQuotaExceeded          = errors.New("storage quota exceeded")
+	ErrInvalidImageSize       = errors.New("invalid image size")
+	ErrInvalidImport          = errors.New("invalid import file")
+	ErrUnsupportedSource      = errors.New("unsupported import source")
+	ErrQueueFull              = errors.New("task queue is full")
+)
+
+// VersionConflictError сообщает о попытке изменить устаревшую версию ресурса.
+type VersionConflictError struct {
+	CurrentVersion uint64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s: current version is %d", ErrVersionConflict, e.CurrentVersion)
+}
+
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
@@ -0,0 +1,144 @@
+package noteLinksDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type NoteLinksUsecase interface {
+	ListLinks(ownerID, actorID, noteID uint64) ([]models.WikiLink, error)
+	ListBacklinks(ownerID, actorID, noteID uint64) ([]models.Backlink, error)
+	ListBrokenLinks(ownerID, actorID uint64) ([]models.BrokenLink, error)
+	Graph(ownerID, actorID uint64) (*models.NoteGraph, error)
+}
+
+type NoteLinksDelivery struct {
+	Usecase NoteLinksUsecase
+}
+
+func NewNoteLinksDelivery(usecase NoteLinksUsecase) *NoteLinksDelivery {
+	return &NoteLinksDelivery{
+		Usecase: usecase,
+	}
+}
+
+// parseOwnerVars читает владельца из пути и пользователя сессии, при ошибке
+// сам пишет ответ.
+func parseOwnerVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID uint64, ok bool) {
+	ownerID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+	return ownerID, actorID, true
+}
+
+// parseNoteVars дополняет parseOwnerVars идентификатором заметки.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID, noteID uint64, ok bool) {
+	ownerID, actorID, ok = parseOwnerVars(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	noteID, err := strconv.ParseUint(mux.Vars(r)["note_id"], 10, 64)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	return ownerID, actorID, noteID, true
+}
+
+func (d *NoteLinksDelivery) ListLinks(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := d.Usecase.ListLinks(ownerID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list links")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, links)
+}
+
+func (d *NoteLinksDelivery) ListBacklinks(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	backlinks, err := d.Usecase.ListBacklinks(ownerID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list backlinks")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, backlinks)
+}
+
+func (d *NoteLinksDelivery) ListBrokenLinks(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+
+	broken, err := d.Usecase.ListBrokenLinks(ownerID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list broken links")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, broken)
+}
+
+func (d *NoteLinksDelivery) Graph(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+
+	graph, err := d.Usecase.Graph(ownerID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to build note graph")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, graph)
+}
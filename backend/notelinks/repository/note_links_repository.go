@@ -0,0 +1,43 @@
+package noteLinksRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type NoteLinksRepository struct {
+	Store *store.Store
+}
+
+func NewNoteLinksRepository(store *store.Store) *NoteLinksRepository {
+	return &NoteLinksRepository{
+		Store: store,
+	}
+}
+
+func (r *NoteLinksRepository) ListWikiLinks(noteID uint64) ([]models.WikiLink, error) {
+	links, err := r.Store.ListWikiLinks(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wiki links: %w", err)
+	}
+	return links, nil
+}
+
+func (r *NoteLinksRepository) ListBacklinks(noteID uint64) ([]models.Backlink, error) {
+	backlinks, err := r.Store.ListBacklinks(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backlinks: %w", err)
+	}
+	return backlinks, nil
+}
+
+func (r *NoteLinksRepository) ListBrokenWikiLinks(ownerID uint64) ([]models.BrokenLink, error) {
+	broken := r.Store.ListBrokenWikiLinks(ownerID)
+	return broken, nil
+}
+
+func (r *NoteLinksRepository) NoteGraph(ownerID uint64) (*models.NoteGraph, error) {
+	graph := r.Store.NoteGraph(ownerID)
+	return &graph, nil
+}
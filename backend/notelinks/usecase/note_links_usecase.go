@@ -0,0 +1,106 @@
+package noteLinksUsecase
+
+import (
+	"backend/models"
+	"fmt"
+	"slices"
+)
+
+type NoteLinksRepository interface {
+	ListWikiLinks(noteID uint64) ([]models.WikiLink, error)
+	ListBacklinks(noteID uint64) ([]models.Backlink, error)
+	ListBrokenWikiLinks(ownerID uint64) ([]models.BrokenLink, error)
+	NoteGraph(ownerID uint64) (*models.NoteGraph, error)
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// NoteLinksUsecase отдаёт связи между заметками владельца: ссылки заметки,
+// обратные ссылки, битые ссылки и граф.
+type NoteLinksUsecase struct {
+	Repository NoteLinksRepository
+	Authorizer Authorizer
+}
+
+func NewNoteLinksUsecase(repository NoteLinksRepository, authorizer Authorizer) *NoteLinksUsecase {
+	return &NoteLinksUsecase{
+		Repository: repository,
+		Authorizer: authorizer,
+	}
+}
+
+// canView возвращает проверку доступа пользователя к заметкам владельца.
+// Пользователю, которому открыта лишь часть заметок, не показываются
+// заголовки остальных.
+func (u *NoteLinksUsecase) canView(ownerID, actorID uint64) func(noteID uint64) bool {
+	if u.Authorizer.CheckOwner(ownerID, actorID) == nil {
+		return func(uint64) bool { return true }
+	}
+	return func(noteID uint64) bool {
+		_, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer)
+		return err == nil
+	}
+}
+
+// ListLinks возвращает ссылки из текста заметки. У ссылок на недоступные
+// пользователю заметки скрыты заголовки.
+func (u *NoteLinksUsecase) ListLinks(ownerID, actorID, noteID uint64) ([]models.WikiLink, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+
+	links, err := u.Repository.ListWikiLinks(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	canView := u.canView(ownerID, actorID)
+	for i := range links {
+		if !links[i].Broken && !canView(links[i].NoteID) {
+			links[i].Title = ""
+		}
+	}
+	return links, nil
+}
+
+// ListBacklinks возвращает доступные пользователю заметки, ссылающиеся на заметку.
+func (u *NoteLinksUsecase) ListBacklinks(ownerID, actorID, noteID uint64) ([]models.Backlink, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to list backlinks: %w", err)
+	}
+
+	backlinks, err := u.Repository.ListBacklinks(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backlinks: %w", err)
+	}
+	canView := u.canView(ownerID, actorID)
+	return slices.DeleteFunc(backlinks, func(backlink models.Backlink) bool {
+		return !canView(backlink.NoteID)
+	}), nil
+}
+
+func (u *NoteLinksUsecase) ListBrokenLinks(ownerID, actorID uint64) ([]models.BrokenLink, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list broken links: %w", err)
+	}
+
+	broken, err := u.Repository.ListBrokenWikiLinks(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list broken links: %w", err)
+	}
+	return broken, nil
+}
+
+func (u *NoteLinksUsecase) Graph(ownerID, actorID uint64) (*models.NoteGraph, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to build note graph: %w", err)
+	}
+
+	graph, err := u.Repository.NoteGraph(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build note graph: %w", err)
+	}
+	return graph, nil
+}
@@ -2,16 +2,44 @@ package notesDelivery
 
 import (
 	"backend/apiutils"
+	"backend/events"
+	mw "backend/middleware"
 	"backend/models"
-	"github.com/gorilla/mux"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"backend/validation"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 )
 
 type NotesUsecase interface {
-	GetAllNotes(userID uint64) ([]models.Note, error)
+	GetAllNotes(userID, actorID uint64, filter models.NotesFilter) ([]models.Note, error)
+	OpenNote(userID, actorID, noteID uint64) (*models.Note, error)
+	CreateNote(userID, editorID uint64, note models.Note) (*models.Note, error)
+	UpdateNote(userID, editorID uint64, note models.Note, match notesUsecase.VersionMatch) (*models.Note, error)
+	DeleteNote(userID, editorID, noteID uint64) error
+	ListTrash(userID, actorID uint64) ([]models.Note, error)
+	RestoreNote(userID, editorID, noteID uint64) (*models.Note, error)
+	PurgeNote(userID, actorID, noteID uint64) error
+	EmptyTrash(userID, actorID uint64) (int, error)
+	SearchNotes(userID, actorID uint64, query string, limit int) ([]models.NoteSearchResult, error)
+	QuickSwitch(userID, actorID uint64, query string, limit int) ([]models.QuickSwitchResult, error)
+	SubscribeChanges(userID, actorID, lastEventID uint64) (*events.Subscription, error)
 }
 
+const (
+	changesRetry     = 3 * time.Second
+	changesKeepAlive = 25 * time.Second
+)
+
 type NotesDelivery struct {
 	Usecase NotesUsecase
 }
@@ -22,15 +50,199 @@ func NewNotesDelivery(usecase NotesUsecase) *NotesDelivery {
 	}
 }
 
+type noteRequest struct {
+	Title     string   `json:"title" valid:"required"`
+	Text      string   `json:"text"`
+	Favourite bool     `json:"favorite"`
+	Folder    string   `json:"folder"`
+	Tags      []string `json:"tags"`
+}
+
+func (req noteRequest) toNote() models.Note {
+	return models.Note{
+		Title:     req.Title,
+		Text:      req.Text,
+		Favourite: req.Favourite,
+		Folder:    req.Folder,
+		Tags:      req.Tags,
+	}
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// versionETag формирует ETag заметки по её версии.
+func versionETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// entityTag — сущностный тег без кавычек; Weak — тег был слабым (W/).
+type entityTag struct {
+	Value string
+	Weak  bool
+}
+
+// parseETags разбирает список сущностных тегов заголовков If-Match и
+// If-None-Match (RFC 7232): «*» или теги через запятую, возможно слабые (W/).
+func parseETags(value string) (tags []entityTag, any bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return nil, true, nil
+	}
+	for value != "" {
+		var tag entityTag
+		value, tag.Weak = strings.CutPrefix(value, "W/")
+		if !strings.HasPrefix(value, `"`) {
+			return nil, false, errors.New("invalid entity tag")
+		}
+		end := strings.IndexByte(value[1:], '"')
+		if end < 0 {
+			return nil, false, errors.New("invalid entity tag")
+		}
+		tag.Value = value[1 : end+1]
+		tags = append(tags, tag)
+		value = strings.TrimSpace(value[end+2:])
+		if value == "" {
+			break
+		}
+		rest, ok := strings.CutPrefix(value, ",")
+		if !ok {
+			return nil, false, errors.New("invalid entity tag")
+		}
+		value = strings.TrimLeft(rest, " \t,")
+	}
+	return tags, false, nil
+}
+
+// parseIfMatch читает допустимые версии заметки из заголовка If-Match;
+// отсутствие заголовка даёт пустое условие. Теги, не похожие на версию
+// заметки, ни с чем не совпадают, как и слабые теги: RFC 7232 требует для
+// If-Match сильного сравнения.
+func parseIfMatch(r *http.Request) (notesUsecase.VersionMatch, error) {
+	var match notesUsecase.VersionMatch
+	value := r.Header.Get("If-Match")
+	if strings.TrimSpace(value) == "" {
+		return match, nil
+	}
+	tags, any, err := parseETags(value)
+	if err != nil {
+		return match, err
+	}
+	match.Any = any
+	for _, tag := range tags {
+		if tag.Weak {
+			continue
+		}
+		if version, err := strconv.ParseUint(tag.Value, 10, 64); err == nil && version != 0 {
+			match.Versions = append(match.Versions, version)
+		}
+	}
+	if !match.Any && len(match.Versions) == 0 {
+		return match, errors.New("invalid If-Match")
+	}
+	return match, nil
+}
+
+// noneMatch сообщает, совпадает ли версия заметки с заголовком If-None-Match.
+// Сравнение слабое, как требует RFC 7232 для этого заголовка.
+func noneMatch(r *http.Request, version uint64) bool {
+	value := r.Header.Get("If-None-Match")
+	if strings.TrimSpace(value) == "" {
+		return false
+	}
+	tags, any, err := parseETags(value)
+	if err != nil {
+		return false
+	}
+	return any || slices.ContainsFunc(tags, func(tag entityTag) bool {
+		return tag.Value == strconv.FormatUint(version, 10)
+	})
+}
+
+type versionConflictResponse struct {
+	Error          string `json:"error"`
+	CurrentVersion uint64 `json:"current_version"`
+}
+
+// parseLimit читает необязательный параметр limit; отсутствие параметра даёт 0.
+func parseLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		return 0, errors.New("invalid limit")
+	}
+	return limit, nil
+}
+
+// parseNotesFilter читает фильтр списка заметок из параметров запроса:
+// q, tag (можно повторять), folder, favorite, updated_since и updated_before.
+func parseNotesFilter(r *http.Request) (models.NotesFilter, error) {
+	values := r.URL.Query()
+	filter := models.NotesFilter{
+		Query:  values.Get("q"),
+		Tags:   values["tag"],
+		Folder: values.Get("folder"),
+	}
+
+	if favourite := values.Get("favorite"); favourite != "" {
+		value, err := strconv.ParseBool(favourite)
+		if err != nil {
+			return filter, errors.New("invalid favorite, expected boolean")
+		}
+		filter.Favourite = &value
+	}
+
+	parseTime := func(name string) (*time.Time, error) {
+		raw := values.Get(name)
+		if raw == "" {
+			return nil, nil
+		}
+		value, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, errors.Errorf("invalid %s, expected RFC3339 timestamp", name)
+		}
+		return &value, nil
+	}
+
+	var err error
+	if filter.UpdatedAfter, err = parseTime("updated_since"); err != nil {
+		return filter, err
+	}
+	if filter.UpdatedBefore, err = parseTime("updated_before"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
 func (d *NotesDelivery) GetAllNotes(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.ParseUint(vars["user_id"], 10, 64)
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	filter, err := parseNotesFilter(r)
 	if err != nil {
-		apiutils.WriteError(w, http.StatusBadRequest, "invalid user ID")
+		apiutils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	notes, err := d.Usecase.GetAllNotes(userID)
+	notes, err := d.Usecase.GetAllNotes(userID, actorID, filter)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
 	if err != nil {
 		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get notes")
 		return
@@ -38,3 +250,464 @@ func (d *NotesDelivery) GetAllNotes(w http.ResponseWriter, r *http.Request) {
 
 	apiutils.WriteJSON(w, http.StatusOK, notes)
 }
+
+func (d *NotesDelivery) GetNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	note, err := d.Usecase.OpenNote(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get note")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(note.Version))
+	if noneMatch(r, note.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	apiutils.WriteJSON(w, http.StatusOK, note)
+}
+
+func (d *NotesDelivery) CreateNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	editorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req noteRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	note, err := d.Usecase.CreateNote(userID, editorID, req.toNote())
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create note")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(note.Version))
+	apiutils.WriteJSON(w, http.StatusCreated, note)
+}
+
+func (d *NotesDelivery) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	editorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	match, err := parseIfMatch(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid If-Match header")
+		return
+	}
+
+	var req noteRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	note := req.toNote()
+	note.ID = noteID
+
+	updated, err := d.Usecase.UpdateNote(userID, editorID, note, match)
+	var conflict *namederrors.VersionConflictError
+	if errors.As(err, &conflict) {
+		w.Header().Set("ETag", versionETag(conflict.CurrentVersion))
+		apiutils.WriteJSON(w, http.StatusPreconditionFailed, versionConflictResponse{
+			Error:          "note was modified",
+			CurrentVersion: conflict.CurrentVersion,
+		})
+		return
+	}
+	if errors.Is(err, namederrors.ErrPreconditionRequired) {
+		apiutils.WriteError(w, http.StatusPreconditionRequired, "If-Match header required")
+		return
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to update note")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(updated.Version))
+	apiutils.WriteJSON(w, http.StatusOK, updated)
+}
+
+func (d *NotesDelivery) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+
+	editorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	err = d.Usecase.DeleteNote(userID, editorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete note")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "moved to trash"})
+}
+
+func (d *NotesDelivery) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		apiutils.WriteError(w, http.StatusBadRequest, "missing search query")
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	results, err := d.Usecase.SearchNotes(userID, actorID, query, limit)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to search notes")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, results)
+}
+
+func (d *NotesDelivery) QuickSwitch(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	results, err := d.Usecase.QuickSwitch(userID, actorID, r.URL.Query().Get("q"), limit)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to search notes")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, results)
+}
+
+func (d *NotesDelivery) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	notes, err := d.Usecase.ListTrash(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get trash")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, notes)
+}
+
+func (d *NotesDelivery) RestoreNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	editorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	note, err := d.Usecase.RestoreNote(userID, editorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found in trash")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to restore note")
+		return
+	}
+
+	w.Header().Set("ETag", versionETag(note.Version))
+	apiutils.WriteJSON(w, http.StatusOK, note)
+}
+
+func (d *NotesDelivery) PurgeNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	err = d.Usecase.PurgeNote(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found in trash")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete note")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (d *NotesDelivery) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	purged, err := d.Usecase.EmptyTrash(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to empty trash")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]int{"deleted": purged})
+}
+
+// parseLastEventID читает ID последнего полученного события из заголовка
+// Last-Event-ID (его шлёт EventSource при переподключении) или из параметра
+// last_event_id; отсутствие даёт 0.
+func parseLastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func writeEvent(w http.ResponseWriter, id uint64, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, eventType, payload)
+	return err
+}
+
+// StreamChanges отдаёт ленту изменений заметок пользователя как Server-Sent Events.
+// Если запрошенные события уже вытеснены из журнала, первым приходит событие
+// reset: клиенту нужно заново загрузить список заметок.
+func (d *NotesDelivery) StreamChanges(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apiutils.WriteError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	sub, err := d.Usecase.SubscribeChanges(userID, actorID, lastEventID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to subscribe to changes")
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", changesRetry.Milliseconds())
+	if sub.Reset {
+		if err = writeEvent(w, sub.LastID, "reset", struct{}{}); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(changesKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err = writeEvent(w, event.ID, event.Type, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package notesDelivery
+
+import (
+	"backend/events"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    notesUsecase.VersionMatch
+		wantErr bool
+	}{
+		{name: "absent", header: "", want: notesUsecase.VersionMatch{}},
+		{name: "version", header: `"3"`, want: notesUsecase.VersionMatch{Versions: []uint64{3}}},
+		{name: "weak", header: `W/"3"`, wantErr: true},
+		{name: "any", header: " * ", want: notesUsecase.VersionMatch{Any: true}},
+		{name: "list", header: `"2", W/"5" ,"abc",, "7"`, want: notesUsecase.VersionMatch{Versions: []uint64{2, 7}}},
+		{name: "comma inside tag", header: `"a,b", "4"`, want: notesUsecase.VersionMatch{Versions: []uint64{4}}},
+		{name: "unquoted", header: "3", wantErr: true},
+		{name: "unterminated", header: `"3`, wantErr: true},
+		{name: "missing comma", header: `"3" "4"`, wantErr: true},
+		{name: "zero version", header: `"0"`, wantErr: true},
+		{name: "no versions", header: `"abc"`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/", nil)
+			r.Header.Set("If-Match", test.header)
+			match, err := parseIfMatch(r)
+			if test.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, match)
+		})
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: `"4"`, want: true},
+		{header: `W/"4"`, want: true},
+		{header: `"1", W/"4"`, want: true},
+		{header: `"1", "2"`, want: false},
+		{header: "*", want: true},
+		{header: "4", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("If-None-Match", test.header)
+			require.Equal(t, test.want, noneMatch(r, 4))
+		})
+	}
+}
+
+// updateUsecase сохраняет заметку, только если её версия 3 подходит под условие.
+type updateUsecase struct {
+	NotesUsecase
+}
+
+func (u *updateUsecase) UpdateNote(ownerID, editorID uint64, note models.Note, match notesUsecase.VersionMatch) (*models.Note, error) {
+	if !match.Any && !slices.Contains(match.Versions, 3) {
+		return nil, &namederrors.VersionConflictError{CurrentVersion: 3}
+	}
+	note.Version = 4
+	return &note, nil
+}
+
+func TestUpdateNoteIfMatch(t *testing.T) {
+	d := NewNotesDelivery(&updateUsecase{})
+
+	tests := []struct {
+		name   string
+		header string
+		code   int
+	}{
+		{name: "strong", header: `"3"`, code: http.StatusOK},
+		{name: "weak", header: `W/"3"`, code: http.StatusBadRequest},
+		{name: "weak in list", header: `"2", W/"3"`, code: http.StatusPreconditionFailed},
+		{name: "any", header: "*", code: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/api/user/1/notes/1", strings.NewReader(`{"title":"Plan"}`))
+			r.Header.Set("If-Match", test.header)
+			r = mux.SetURLVars(r, map[string]string{"user_id": "1", "note_id": "1"})
+			r = r.WithContext(mw.WithUserID(r.Context(), 1))
+			w := httptest.NewRecorder()
+
+			d.UpdateNote(w, r)
+			require.Equal(t, test.code, w.Code)
+		})
+	}
+}
+
+// changesUsecase отдаёт подписку на настоящую ленту и сразу закрывает её,
+// поэтому поток заканчивается, отдав события из журнала.
+type changesUsecase struct {
+	NotesUsecase
+	feed *events.Feed
+}
+
+func (u *changesUsecase) SubscribeChanges(userID, actorID, lastEventID uint64) (*events.Subscription, error) {
+	sub := u.feed.Subscribe(userID, lastEventID)
+	sub.Close()
+	return sub, nil
+}
+
+var streamedEvent = regexp.MustCompile(`id: (\d+)\nevent: (\S+)\n`)
+
+func TestStreamChanges(t *testing.T) {
+	// Журнал из двух событий: после четырёх публикаций в нём остаются 3 и 4.
+	feed := events.NewFeed(2)
+	for noteID := uint64(1); noteID <= 4; noteID++ {
+		feed.Publish(1, models.NoteEvent{Type: models.NoteUpdated, NoteID: noteID})
+	}
+	d := NewNotesDelivery(&changesUsecase{feed: feed})
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		code   int
+		want   []string
+	}{
+		{name: "new stream", code: http.StatusOK},
+		{name: "resume", header: "2", code: http.StatusOK, want: []string{"3 note.updated", "4 note.updated"}},
+		{name: "resume from query", query: "?last_event_id=3", code: http.StatusOK, want: []string{"4 note.updated"}},
+		{name: "up to date", header: "4", code: http.StatusOK},
+		{name: "evicted", header: "1", code: http.StatusOK, want: []string{"4 reset"}},
+		{name: "from the future", header: "9", code: http.StatusOK, want: []string{"4 reset"}},
+		{name: "invalid", header: "abc", code: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/user/1/notes/changes"+test.query, nil)
+			if test.header != "" {
+				r.Header.Set("Last-Event-ID", test.header)
+			}
+			r = mux.SetURLVars(r, map[string]string{"user_id": "1"})
+			r = r.WithContext(mw.WithUserID(r.Context(), 1))
+			w := httptest.NewRecorder()
+
+			d.StreamChanges(w, r)
+			require.Equal(t, test.code, w.Code)
+			if test.code != http.StatusOK {
+				return
+			}
+			require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+			var got []string
+			for _, match := range streamedEvent.FindAllStringSubmatch(w.Body.String(), -1) {
+				got = append(got, fmt.Sprintf("%s %s", match[1], match[2]))
+			}
+			require.Equal(t, test.want, got)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package notesRepository
 import (
 	"backend/models"
 	"backend/store"
+	"fmt"
+	"time"
 )
 
 type NotesRepository struct {
@@ -19,3 +21,96 @@ func (r *NotesRepository) GetNotes(ownerID uint64) ([]models.Note, error) {
 	notes := r.Store.ListNotes(ownerID)
 	return notes, nil
 }
+
+func (r *NotesRepository) GetNotesUpdatedSince(ownerID uint64, since time.Time) ([]models.Note, error) {
+	notes := r.Store.ListNotesUpdatedSince(ownerID, since)
+	return notes, nil
+}
+
+func (r *NotesRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, err := r.Store.GetNote(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *NotesRepository) CreateNote(note models.Note) (*models.Note, error) {
+	created := r.Store.CreateNote(note)
+	return &created, nil
+}
+
+func (r *NotesRepository) UpdateNote(note models.Note, editorID uint64) (*models.Note, error) {
+	updated, err := r.Store.UpdateNote(note, editorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	return &updated, nil
+}
+
+func (r *NotesRepository) TrashNote(noteID, userID uint64) (*models.Note, error) {
+	note, err := r.Store.TrashNote(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to trash note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *NotesRepository) RestoreNote(noteID, userID uint64) (*models.Note, error) {
+	note, err := r.Store.RestoreNote(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *NotesRepository) GetTrashedNote(noteID uint64) (*models.Note, error) {
+	note, err := r.Store.GetTrashedNote(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *NotesRepository) ListTrash(ownerID uint64) ([]models.Note, error) {
+	notes := r.Store.ListTrash(ownerID)
+	return notes, nil
+}
+
+func (r *NotesRepository) PurgeTrash(ownerID uint64, before time.Time) ([]models.Note, error) {
+	purged := r.Store.PurgeTrash(ownerID, before)
+	return purged, nil
+}
+
+func (r *NotesRepository) DeleteNote(noteID uint64) error {
+	err := r.Store.DeleteNote(noteID)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	return nil
+}
+
+func (r *NotesRepository) SearchNotes(ownerID uint64, query string, limit int) ([]models.NoteSearchResult, error) {
+	results := r.Store.SearchNotes(ownerID, query, limit)
+	return results, nil
+}
+
+func (r *NotesRepository) QuickSwitch(ownerID, actorID uint64, query string, limit int) ([]models.QuickSwitchResult, error) {
+	results := r.Store.QuickSwitch(ownerID, actorID, query, limit)
+	return results, nil
+}
+
+func (r *NotesRepository) MarkNoteOpened(userID, noteID uint64) error {
+	r.Store.MarkNoteOpened(userID, noteID)
+	return nil
+}
+
+func (r *NotesRepository) ListFolders(ownerID uint64) ([]models.Folder, error) {
+	folders := r.Store.ListFolders(ownerID)
+	return folders, nil
+}
+
+func (r *NotesRepository) RenameWikiLinks(ownerID uint64, oldTitle, newTitle string, editorID uint64) ([]models.Note, error) {
+	notes := r.Store.RenameWikiLinks(ownerID, oldTitle, newTitle, editorID)
+	return notes, nil
+}
@@ -1,28 +1,464 @@
 package notesUsecase
 
 import (
+	"backend/events"
 	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type NotesUsecase struct {
 	Repository NotesRepository
+	Events     NoteEvents
+	Authorizer Authorizer
+	Notifier   Notifier
 }
 
 type NotesRepository interface {
 	GetNotes(userID uint64) ([]models.Note, error)
+	GetNotesUpdatedSince(userID uint64, since time.Time) ([]models.Note, error)
+	GetNote(noteID uint64) (*models.Note, error)
+	CreateNote(note models.Note) (*models.Note, error)
+	UpdateNote(note models.Note, editorID uint64) (*models.Note, error)
+	DeleteNote(noteID uint64) error
+	TrashNote(noteID, userID uint64) (*models.Note, error)
+	RestoreNote(noteID, userID uint64) (*models.Note, error)
+	GetTrashedNote(noteID uint64) (*models.Note, error)
+	ListTrash(userID uint64) ([]models.Note, error)
+	PurgeTrash(userID uint64, before time.Time) ([]models.Note, error)
+	SearchNotes(userID uint64, query string, limit int) ([]models.NoteSearchResult, error)
+	QuickSwitch(ownerID, actorID uint64, query string, limit int) ([]models.QuickSwitchResult, error)
+	MarkNoteOpened(userID, noteID uint64) error
+	ListFolders(userID uint64) ([]models.Folder, error)
+	RenameWikiLinks(ownerID uint64, oldTitle, newTitle string, editorID uint64) ([]models.Note, error)
+}
+
+// NoteEvents — лента изменений заметок пользователя.
+type NoteEvents interface {
+	events.Publisher
+	Subscribe(userID, lastEventID uint64) *events.Subscription
+}
+
+// Authorizer проверяет права пользователя сессии на заметки и пространство
+// пользователя из адреса запроса.
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+	AuthorizeWorkspace(workspaceID, actorID uint64, required string) (*models.Workspace, string, error)
 }
 
-func NewNotesUsecase(Repository NotesRepository) *NotesUsecase {
+// Notifier уведомляет пользователей, упомянутых в тексте сохранённой заметки.
+type Notifier interface {
+	NoteSaved(actorID uint64, note *models.Note, previousText string)
+}
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	DefaultQuickSwitchLimit = 10
+)
+
+func NewNotesUsecase(Repository NotesRepository, events NoteEvents, authorizer Authorizer, notifier Notifier) *NotesUsecase {
 	return &NotesUsecase{
 		Repository: Repository,
+		Events:     events,
+		Authorizer: authorizer,
+		Notifier:   notifier,
 	}
 }
 
-func (u *NotesUsecase) GetAllNotes(ownerID uint64) ([]models.Note, error) {
-	notes, err := u.Repository.GetNotes(ownerID)
+// emit публикует изменение заметки в ленту владельца.
+func (u *NotesUsecase) emit(eventType string, ownerID, noteID uint64, note *models.Note, fromFolder string) {
+	if u.Events == nil {
+		return
+	}
+	event := models.NoteEvent{Type: eventType, NoteID: noteID, FromFolder: fromFolder}
+	if note != nil {
+		copied := *note
+		event.Note = &copied
+	}
+	u.Events.Publish(ownerID, event)
+}
+
+// notify сообщает об упоминаниях, появившихся в тексте заметки.
+func (u *NotesUsecase) notify(actorID uint64, note *models.Note, previousText string) {
+	if u.Notifier == nil {
+		return
+	}
+	u.Notifier.NoteSaved(actorID, note, previousText)
+}
+
+// SubscribeChanges подписывает на ленту изменений заметок пользователя,
+// начиная с события после lastEventID.
+func (u *NotesUsecase) SubscribeChanges(ownerID, actorID, lastEventID uint64) (*events.Subscription, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to changes: %w", err)
+	}
+	return u.Events.Subscribe(ownerID, lastEventID), nil
+}
+
+// GetAllNotes возвращает заметки пользователя, подходящие под фильтр. Если задан
+// текстовый запрос, заметки упорядочены по релевантности, если задан только
+// UpdatedAfter — по времени изменения.
+func (u *NotesUsecase) GetAllNotes(ownerID, actorID uint64, filter models.NotesFilter) ([]models.Note, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	var notes []models.Note
+	var err error
+	switch {
+	case strings.TrimSpace(filter.Query) != "":
+		var results []models.NoteSearchResult
+		results, err = u.Repository.SearchNotes(ownerID, filter.Query, 0)
+		notes = make([]models.Note, 0, len(results))
+		for _, result := range results {
+			notes = append(notes, result.Note)
+		}
+	case filter.UpdatedAfter != nil:
+		notes, err = u.Repository.GetNotesUpdatedSince(ownerID, *filter.UpdatedAfter)
+	default:
+		notes, err = u.Repository.GetNotes(ownerID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get notes: %w", err)
 	}
+
+	filtered := notes[:0]
+	for _, note := range notes {
+		if matchesFilter(note, filter) {
+			filtered = append(filtered, note)
+		}
+	}
+	return filtered, nil
+}
+
+func matchesFilter(note models.Note, filter models.NotesFilter) bool {
+	if filter.Folder != "" && note.Folder != filter.Folder {
+		return false
+	}
+	if filter.Favourite != nil && note.Favourite != *filter.Favourite {
+		return false
+	}
+	if filter.UpdatedAfter != nil && !note.UpdatedAt.After(*filter.UpdatedAfter) {
+		return false
+	}
+	if filter.UpdatedBefore != nil && !note.UpdatedAt.Before(*filter.UpdatedBefore) {
+		return false
+	}
+	for _, tag := range filter.Tags {
+		if !slices.ContainsFunc(note.Tags, func(noteTag string) bool {
+			return strings.EqualFold(noteTag, tag)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTags убирает пустые теги и повторы без учёта регистра, сохраняя порядок.
+func NormalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func (u *NotesUsecase) ListFolders(ownerID, actorID uint64) ([]models.Folder, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+
+	folders, err := u.Repository.ListFolders(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return folders, nil
+}
+
+func (u *NotesUsecase) GetNote(ownerID, actorID, noteID uint64) (*models.Note, error) {
+	note, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	return note, nil
+}
+
+func (u *NotesUsecase) CreateNote(ownerID, editorID uint64, note models.Note) (*models.Note, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, editorID); err != nil {
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+
+	note.OwnerID = ownerID
+	note.CreatedBy = editorID
+	note.Tags = NormalizeTags(note.Tags)
+
+	created, err := u.Repository.CreateNote(note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+	u.emit(models.NoteCreated, ownerID, created.ID, created, "")
+	u.notify(editorID, created, "")
+	return created, nil
+}
+
+// VersionMatch — условие If-Match на версию заметки: любая версия
+// существующей заметки (Any) или одна из Versions.
+type VersionMatch struct {
+	Any      bool
+	Versions []uint64
+}
+
+// UpdateNote изменяет заметку, если её текущая версия подходит под match.
+// Без ожидаемой версии изменение отклоняется, чтобы не затереть чужие правки.
+// Править заметку могут владелец и пользователи с ролью editor.
+func (u *NotesUsecase) UpdateNote(ownerID, editorID uint64, note models.Note, match VersionMatch) (*models.Note, error) {
+	if !match.Any && len(match.Versions) == 0 {
+		return nil, fmt.Errorf("failed to update note: %w", namederrors.ErrPreconditionRequired)
+	}
+	current, _, err := u.Authorizer.AuthorizeNote(ownerID, editorID, note.ID, models.RoleEditor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	// Хранилище повторно сверяет версию под блокировкой; для «*» подходит любая.
+	note.Version = 0
+	if !match.Any {
+		if !slices.Contains(match.Versions, current.Version) {
+			return nil, fmt.Errorf("failed to update note: %w", &namederrors.VersionConflictError{CurrentVersion: current.Version})
+		}
+		note.Version = current.Version
+	}
+	note.Tags = NormalizeTags(note.Tags)
+
+	updated, err := u.Repository.UpdateNote(note, editorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update note: %w", err)
+	}
+	if updated.Folder != current.Folder {
+		u.emit(models.NoteMoved, ownerID, updated.ID, updated, current.Folder)
+	} else {
+		u.emit(models.NoteUpdated, ownerID, updated.ID, updated, "")
+	}
+	u.notify(editorID, updated, current.Text)
+	if updated.Title != current.Title {
+		u.renameLinks(ownerID, editorID, current.Title, updated.Title)
+	}
+	return updated, nil
+}
+
+// renameLinks переписывает ссылки на прежний заголовок заметки в заметках
+// владельца. Правит их только тот, кому доступны все заметки владельца:
+// редактор по приглашению к одной заметке чужие заметки не меняет. Сама правка
+// уже сохранена, поэтому ошибка только логируется.
+func (u *NotesUsecase) renameLinks(ownerID, editorID uint64, oldTitle, newTitle string) {
+	if err := u.Authorizer.CheckOwner(ownerID, editorID); err != nil {
+		if !errors.Is(err, namederrors.ErrForbidden) {
+			log.Error().Err(err).Uint64("owner_id", ownerID).Msg("failed to rename wiki links")
+		}
+		return
+	}
+	renamed, err := u.Repository.RenameWikiLinks(ownerID, oldTitle, newTitle, editorID)
+	if err != nil {
+		log.Error().Err(err).Uint64("owner_id", ownerID).Msg("failed to rename wiki links")
+		return
+	}
+	for i := range renamed {
+		u.emit(models.NoteUpdated, ownerID, renamed[i].ID, &renamed[i], "")
+	}
+}
+
+// DeleteNote перемещает заметку в корзину. Удалить заметку может только владелец.
+func (u *NotesUsecase) DeleteNote(ownerID, editorID, noteID uint64) error {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, editorID, noteID, models.RoleOwner); err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+
+	if _, err := u.Repository.TrashNote(noteID, editorID); err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	u.emit(models.NoteDeleted, ownerID, noteID, nil, "")
+	return nil
+}
+
+func (u *NotesUsecase) getTrashedNote(ownerID, actorID, noteID uint64) (*models.Note, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, err
+	}
+	return u.trashedNote(ownerID, noteID)
+}
+
+func (u *NotesUsecase) trashedNote(ownerID, noteID uint64) (*models.Note, error) {
+	note, err := u.Repository.GetTrashedNote(noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note.OwnerID != ownerID {
+		return nil, namederrors.ErrNotFound
+	}
+	return note, nil
+}
+
+func (u *NotesUsecase) ListTrash(ownerID, actorID uint64) ([]models.Note, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	notes, err := u.Repository.ListTrash(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
 	return notes, nil
 }
+
+// RestoreNote возвращает заметку из корзины в папку, из которой она была удалена.
+func (u *NotesUsecase) RestoreNote(ownerID, editorID, noteID uint64) (*models.Note, error) {
+	if _, err := u.getTrashedNote(ownerID, editorID, noteID); err != nil {
+		return nil, fmt.Errorf("failed to restore note: %w", err)
+	}
+
+	note, err := u.Repository.RestoreNote(noteID, editorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore note: %w", err)
+	}
+	// Для ленты заметка, вернувшаяся из корзины, появляется заново.
+	u.emit(models.NoteCreated, ownerID, note.ID, note, "")
+	return note, nil
+}
+
+// checkPurge разрешает безвозвратное удаление из корзины владельцу или, для
+// рабочего пространства, администратору — как и перенос заметки в корзину.
+func (u *NotesUsecase) checkPurge(ownerID, actorID uint64) error {
+	if ownerID == actorID {
+		return nil
+	}
+	_, _, err := u.Authorizer.AuthorizeWorkspace(ownerID, actorID, models.WorkspaceRoleAdmin)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		return namederrors.ErrForbidden
+	}
+	return err
+}
+
+// PurgeNote безвозвратно удаляет заметку из корзины.
+func (u *NotesUsecase) PurgeNote(ownerID, actorID, noteID uint64) error {
+	if err := u.checkPurge(ownerID, actorID); err != nil {
+		return fmt.Errorf("failed to purge note: %w", err)
+	}
+	if _, err := u.trashedNote(ownerID, noteID); err != nil {
+		return fmt.Errorf("failed to purge note: %w", err)
+	}
+
+	if err := u.Repository.DeleteNote(noteID); err != nil {
+		return fmt.Errorf("failed to purge note: %w", err)
+	}
+	u.emit(models.NoteDeleted, ownerID, noteID, nil, "")
+	return nil
+}
+
+// EmptyTrash безвозвратно удаляет все заметки из корзины пользователя.
+func (u *NotesUsecase) EmptyTrash(ownerID, actorID uint64) (int, error) {
+	if err := u.checkPurge(ownerID, actorID); err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", err)
+	}
+
+	purged, err := u.Repository.PurgeTrash(ownerID, time.Now().UTC().Add(time.Second))
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", err)
+	}
+	u.emitPurged(purged)
+	return len(purged), nil
+}
+
+// PurgeExpiredTrash безвозвратно удаляет заметки, пролежавшие в корзине дольше
+// retention. Нулевой срок отключает очистку.
+func (u *NotesUsecase) PurgeExpiredTrash(retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	purged, err := u.Repository.PurgeTrash(0, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+	u.emitPurged(purged)
+	return len(purged), nil
+}
+
+// emitPurged сообщает в ленты владельцев об удалённых из корзины заметках.
+func (u *NotesUsecase) emitPurged(purged []models.Note) {
+	for _, note := range purged {
+		u.emit(models.NoteDeleted, note.OwnerID, note.ID, nil, "")
+	}
+}
+
+// SearchNotes выполняет полнотекстовый поиск по заметкам пользователя.
+// limit приводится к диапазону [1, MaxSearchLimit], нулевое значение заменяется на DefaultSearchLimit.
+func (u *NotesUsecase) SearchNotes(ownerID, actorID uint64, query string, limit int) ([]models.NoteSearchResult, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to search notes: %w", err)
+	}
+	if strings.TrimSpace(query) == "" {
+		return []models.NoteSearchResult{}, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	results, err := u.Repository.SearchNotes(ownerID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes: %w", err)
+	}
+	return results, nil
+}
+
+// OpenNote возвращает заметку и отмечает её как недавно открытую для быстрого перехода.
+func (u *NotesUsecase) OpenNote(ownerID, actorID, noteID uint64) (*models.Note, error) {
+	note, err := u.GetNote(ownerID, actorID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.Repository.MarkNoteOpened(actorID, noteID); err != nil {
+		return nil, fmt.Errorf("failed to mark note opened: %w", err)
+	}
+	return note, nil
+}
+
+// QuickSwitch нечётко ищет заметки и папки по названию для быстрого перехода.
+func (u *NotesUsecase) QuickSwitch(ownerID, actorID uint64, query string, limit int) ([]models.QuickSwitchResult, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to quick switch: %w", err)
+	}
+	if strings.TrimSpace(query) == "" {
+		return []models.QuickSwitchResult{}, nil
+	}
+	if limit <= 0 {
+		limit = DefaultQuickSwitchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	results, err := u.Repository.QuickSwitch(ownerID, actorID, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quick switch: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,148 @@
+package notesUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository реализует только методы, которые вызывают тесты.
+type fakeRepository struct {
+	NotesRepository
+	notes   map[uint64]models.Note
+	trash   map[uint64]models.Note
+	renamed []string
+}
+
+func (r *fakeRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, ok := r.notes[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &note, nil
+}
+
+func (r *fakeRepository) UpdateNote(note models.Note, editorID uint64) (*models.Note, error) {
+	r.notes[note.ID] = note
+	return &note, nil
+}
+
+func (r *fakeRepository) RenameWikiLinks(ownerID uint64, oldTitle, newTitle string, editorID uint64) ([]models.Note, error) {
+	r.renamed = append(r.renamed, oldTitle+" -> "+newTitle)
+	return nil, nil
+}
+
+func (r *fakeRepository) GetTrashedNote(noteID uint64) (*models.Note, error) {
+	note, ok := r.trash[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &note, nil
+}
+
+func (r *fakeRepository) DeleteNote(noteID uint64) error {
+	delete(r.trash, noteID)
+	return nil
+}
+
+func (r *fakeRepository) PurgeTrash(userID uint64, before time.Time) ([]models.Note, error) {
+	var purged []models.Note
+	for id, note := range r.trash {
+		if note.OwnerID == userID {
+			purged = append(purged, note)
+			delete(r.trash, id)
+		}
+	}
+	return purged, nil
+}
+
+// fakeAuthorizer — рабочее пространство 10: пользователь 1 — администратор,
+// 2 — участник, 3 — редактор заметки 1 по приглашению.
+type fakeAuthorizer struct {
+	repository *fakeRepository
+}
+
+var workspaceRoles = map[uint64]string{
+	1: models.WorkspaceRoleAdmin,
+	2: models.WorkspaceRoleMember,
+}
+
+func (a *fakeAuthorizer) CheckOwner(ownerID, actorID uint64) error {
+	if _, ok := workspaceRoles[actorID]; ok || ownerID == actorID {
+		return nil
+	}
+	return namederrors.ErrForbidden
+}
+
+func (a *fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	note, err := a.repository.GetNote(noteID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := a.CheckOwner(ownerID, actorID); err != nil && !(actorID == 3 && noteID == 1) {
+		return nil, "", err
+	}
+	return note, models.RoleEditor, nil
+}
+
+func (a *fakeAuthorizer) AuthorizeWorkspace(workspaceID, actorID uint64, required string) (*models.Workspace, string, error) {
+	role, ok := workspaceRoles[actorID]
+	if !ok || (required == models.WorkspaceRoleAdmin && role != models.WorkspaceRoleAdmin) {
+		return nil, "", namederrors.ErrForbidden
+	}
+	return &models.Workspace{ID: workspaceID}, role, nil
+}
+
+func newTestUsecase() (*NotesUsecase, *fakeRepository) {
+	repository := &fakeRepository{
+		notes: map[uint64]models.Note{
+			1: {ID: 1, OwnerID: 10, Title: "Plan", Version: 1},
+		},
+		trash: map[uint64]models.Note{
+			2: {ID: 2, OwnerID: 10, Title: "Old"},
+			3: {ID: 3, OwnerID: 10, Title: "Older"},
+		},
+	}
+	return NewNotesUsecase(repository, nil, &fakeAuthorizer{repository: repository}, nil), repository
+}
+
+func TestUpdateNoteRenamesLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		editorID uint64
+		renamed  []string
+	}{
+		{name: "workspace member", editorID: 2, renamed: []string{"Plan -> Roadmap"}},
+		{name: "shared editor", editorID: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, repository := newTestUsecase()
+			updated, err := u.UpdateNote(10, test.editorID, models.Note{ID: 1, OwnerID: 10, Title: "Roadmap"}, VersionMatch{Any: true})
+			require.NoError(t, err)
+			require.Equal(t, "Roadmap", updated.Title)
+			require.Equal(t, test.renamed, repository.renamed)
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	u, repository := newTestUsecase()
+
+	err := u.PurgeNote(10, 2, 2)
+	require.ErrorIs(t, err, namederrors.ErrForbidden, "a member cannot trash notes, so cannot purge them")
+	_, err = u.EmptyTrash(10, 2)
+	require.ErrorIs(t, err, namederrors.ErrForbidden)
+	require.Len(t, repository.trash, 2)
+
+	require.NoError(t, u.PurgeNote(10, 1, 2))
+	require.Len(t, repository.trash, 1)
+	purged, err := u.EmptyTrash(10, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, purged)
+	require.Empty(t, repository.trash)
+}
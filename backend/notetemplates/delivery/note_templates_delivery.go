@@ -0,0 +1,290 @@
+package noteTemplatesDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	noteTemplatesUsecase "backend/notetemplates/usecase"
+	"backend/validation"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type NoteTemplatesUsecase interface {
+	ListTemplates(userID, actorID uint64) ([]models.NoteTemplate, error)
+	GetTemplate(userID, actorID, templateID uint64) (*models.NoteTemplate, error)
+	CreateTemplate(userID, actorID uint64, template models.NoteTemplate) (*models.NoteTemplate, error)
+	UpdateTemplate(userID, actorID, templateID uint64, template models.NoteTemplate) (*models.NoteTemplate, error)
+	DeleteTemplate(userID, actorID, templateID uint64) error
+	CreateNote(userID, actorID, templateID uint64, options noteTemplatesUsecase.NoteOptions) (*models.Note, error)
+}
+
+type NoteTemplatesDelivery struct {
+	Usecase NoteTemplatesUsecase
+}
+
+func NewNoteTemplatesDelivery(usecase NoteTemplatesUsecase) *NoteTemplatesDelivery {
+	return &NoteTemplatesDelivery{
+		Usecase: usecase,
+	}
+}
+
+type templateRequest struct {
+	Name      string   `json:"name" valid:"required"`
+	Title     string   `json:"title"`
+	Text      string   `json:"text"`
+	Favourite bool     `json:"favorite"`
+	Folder    string   `json:"folder"`
+	Tags      []string `json:"tags"`
+}
+
+func (req templateRequest) toTemplate() models.NoteTemplate {
+	return models.NoteTemplate{
+		Name:      req.Name,
+		Title:     req.Title,
+		Text:      req.Text,
+		Favourite: req.Favourite,
+		Folder:    req.Folder,
+		Tags:      req.Tags,
+	}
+}
+
+// noteRequest — необязательные уточнения заметки, создаваемой по шаблону.
+// Timezone — имя зоны IANA для переменных даты.
+type noteRequest struct {
+	Title    string `json:"title"`
+	Folder   string `json:"folder"`
+	Timezone string `json:"timezone"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+func (d *NoteTemplatesDelivery) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	list, err := d.Usecase.ListTemplates(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get templates")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, list)
+}
+
+func (d *NoteTemplatesDelivery) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req templateRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := d.Usecase.CreateTemplate(userID, actorID, req.toTemplate())
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create template")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, template)
+}
+
+func (d *NoteTemplatesDelivery) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	templateID, err := parseUintVar(r, "template_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid template ID")
+		return
+	}
+
+	template, err := d.Usecase.GetTemplate(userID, actorID, templateID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "template not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get template")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, template)
+}
+
+func (d *NoteTemplatesDelivery) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	templateID, err := parseUintVar(r, "template_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid template ID")
+		return
+	}
+
+	var req templateRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := d.Usecase.UpdateTemplate(userID, actorID, templateID, req.toTemplate())
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "template not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to update template")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, template)
+}
+
+func (d *NoteTemplatesDelivery) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	templateID, err := parseUintVar(r, "template_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid template ID")
+		return
+	}
+
+	err = d.Usecase.DeleteTemplate(userID, actorID, templateID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "template not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete template")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// CreateNote создаёт заметку по шаблону. Тело запроса необязательно.
+func (d *NoteTemplatesDelivery) CreateNote(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	templateID, err := parseUintVar(r, "template_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid template ID")
+		return
+	}
+
+	var req noteRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	options := noteTemplatesUsecase.NoteOptions{Title: req.Title, Folder: req.Folder}
+	if req.Timezone != "" {
+		options.Location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid timezone")
+			return
+		}
+	}
+
+	note, err := d.Usecase.CreateNote(userID, actorID, templateID, options)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "template not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create note")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, note)
+}
@@ -0,0 +1,60 @@
+package noteTemplatesRepository
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/store"
+	"fmt"
+)
+
+type NoteTemplatesRepository struct {
+	Store *store.Store
+}
+
+func NewNoteTemplatesRepository(store *store.Store) *NoteTemplatesRepository {
+	return &NoteTemplatesRepository{
+		Store: store,
+	}
+}
+
+func (r *NoteTemplatesRepository) GetUser(userID uint64) (*models.User, error) {
+	user, ok := r.Store.GetUser(userID)
+	if !ok {
+		return nil, fmt.Errorf("failed to get user: %w", namederrors.ErrNotFound)
+	}
+	return &user, nil
+}
+
+func (r *NoteTemplatesRepository) CreateTemplate(template models.NoteTemplate) (*models.NoteTemplate, error) {
+	created := r.Store.CreateTemplate(template)
+	return &created, nil
+}
+
+func (r *NoteTemplatesRepository) GetTemplate(templateID uint64) (*models.NoteTemplate, error) {
+	template, err := r.Store.GetTemplate(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return &template, nil
+}
+
+func (r *NoteTemplatesRepository) ListTemplates(ownerID uint64) ([]models.NoteTemplate, error) {
+	templates := r.Store.ListTemplates(ownerID)
+	return templates, nil
+}
+
+func (r *NoteTemplatesRepository) UpdateTemplate(template models.NoteTemplate) (*models.NoteTemplate, error) {
+	updated, err := r.Store.UpdateTemplate(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	return &updated, nil
+}
+
+func (r *NoteTemplatesRepository) DeleteTemplate(templateID uint64) error {
+	err := r.Store.DeleteTemplate(templateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package noteTemplatesUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"backend/templates"
+	"fmt"
+	"time"
+)
+
+type NoteTemplatesRepository interface {
+	GetUser(userID uint64) (*models.User, error)
+	CreateTemplate(template models.NoteTemplate) (*models.NoteTemplate, error)
+	GetTemplate(templateID uint64) (*models.NoteTemplate, error)
+	ListTemplates(ownerID uint64) ([]models.NoteTemplate, error)
+	UpdateTemplate(template models.NoteTemplate) (*models.NoteTemplate, error)
+	DeleteTemplate(templateID uint64) error
+}
+
+// NotesUsecase создаёт заметки по шаблонам с обычными проверками, событиями
+// и уведомлениями.
+type NotesUsecase interface {
+	CreateNote(ownerID, editorID uint64, note models.Note) (*models.Note, error)
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+}
+
+// NoteOptions уточняет заметку, создаваемую по шаблону. Непустые Title и
+// Folder заменяют заданные в шаблоне, Location — временная зона для
+// переменных даты, по умолчанию UTC.
+type NoteOptions struct {
+	Title    string
+	Folder   string
+	Location *time.Location
+}
+
+// NoteTemplatesUsecase управляет шаблонами заметок. Встроенные шаблоны видны
+// всем и не изменяются, свои шаблоны есть у пользователя и у рабочего
+// пространства, работать с ними могут владелец и участники пространства.
+type NoteTemplatesUsecase struct {
+	Repository NoteTemplatesRepository
+	Notes      NotesUsecase
+	Authorizer Authorizer
+}
+
+func NewNoteTemplatesUsecase(repository NoteTemplatesRepository, notes NotesUsecase, authorizer Authorizer) *NoteTemplatesUsecase {
+	return &NoteTemplatesUsecase{
+		Repository: repository,
+		Notes:      notes,
+		Authorizer: authorizer,
+	}
+}
+
+func (u *NoteTemplatesUsecase) ListTemplates(ownerID, actorID uint64) ([]models.NoteTemplate, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	list, err := u.Repository.ListTemplates(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	return list, nil
+}
+
+func (u *NoteTemplatesUsecase) GetTemplate(ownerID, actorID, templateID uint64) (*models.NoteTemplate, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	template, err := u.Repository.GetTemplate(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	if !template.BuiltIn && template.OwnerID != ownerID {
+		return nil, fmt.Errorf("failed to get template: %w", namederrors.ErrNotFound)
+	}
+	return template, nil
+}
+
+func (u *NoteTemplatesUsecase) CreateTemplate(ownerID, actorID uint64, template models.NoteTemplate) (*models.NoteTemplate, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	template.OwnerID = ownerID
+	template.Tags = notesUsecase.NormalizeTags(template.Tags)
+
+	created, err := u.Repository.CreateTemplate(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateTemplate изменяет шаблон владельца; встроенные шаблоны изменить
+// нельзя.
+func (u *NoteTemplatesUsecase) UpdateTemplate(ownerID, actorID, templateID uint64, template models.NoteTemplate) (*models.NoteTemplate, error) {
+	if _, err := u.GetTemplate(ownerID, actorID, templateID); err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	template.ID = templateID
+	template.Tags = notesUsecase.NormalizeTags(template.Tags)
+
+	updated, err := u.Repository.UpdateTemplate(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+	return updated, nil
+}
+
+func (u *NoteTemplatesUsecase) DeleteTemplate(ownerID, actorID, templateID uint64) error {
+	if _, err := u.GetTemplate(ownerID, actorID, templateID); err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	err := u.Repository.DeleteTemplate(templateID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return nil
+}
+
+// CreateNote создаёт заметку по шаблону, подставляя в него дату в зоне
+// options.Location и адрес пользователя, создающего заметку.
+func (u *NoteTemplatesUsecase) CreateNote(ownerID, actorID, templateID uint64, options NoteOptions) (*models.Note, error) {
+	template, err := u.GetTemplate(ownerID, actorID, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note from template: %w", err)
+	}
+	actor, err := u.Repository.GetUser(actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note from template: %w", err)
+	}
+
+	if options.Title != "" {
+		template.Title = options.Title
+	}
+	if options.Folder != "" {
+		template.Folder = options.Folder
+	}
+	location := options.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	note := templates.Apply(*template, templates.Vars{
+		Now:  time.Now().In(location),
+		User: actor.Email,
+	})
+	created, err := u.Notes.CreateNote(ownerID, actorID, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create note from template: %w", err)
+	}
+	return created, nil
+}
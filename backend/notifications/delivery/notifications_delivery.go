@@ -0,0 +1,342 @@
+package notificationsDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	notificationsUsecase "backend/notifications/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	streamRetry     = 3 * time.Second
+	streamKeepAlive = 25 * time.Second
+)
+
+type NotificationsUsecase interface {
+	List(userID, actorID uint64, unreadOnly bool, limit int) (*models.NotificationList, error)
+	UnreadCount(userID, actorID uint64) (int, error)
+	MarkRead(userID, actorID, notificationID uint64) (*models.Notification, error)
+	MarkAllRead(userID, actorID uint64) (int, error)
+	Subscribe(userID, actorID uint64) (*notificationsUsecase.Subscription, error)
+	CreateReminder(ownerID, actorID, noteID uint64, remindAt time.Time) (*models.Reminder, error)
+	ListReminders(userID, actorID uint64) ([]models.Reminder, error)
+	DeleteReminder(userID, actorID, reminderID uint64) error
+}
+
+type NotificationsDelivery struct {
+	Usecase NotificationsUsecase
+}
+
+func NewNotificationsDelivery(usecase NotificationsUsecase) *NotificationsDelivery {
+	return &NotificationsDelivery{
+		Usecase: usecase,
+	}
+}
+
+type reminderRequest struct {
+	RemindAt *time.Time `json:"remind_at"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseUserVars читает user_id из пути и пользователя сессии, при ошибке сам
+// пишет ответ.
+func parseUserVars(w http.ResponseWriter, r *http.Request) (userID, actorID uint64, ok bool) {
+	userID, err := parseUintVar(r, "user_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid user ID")
+		return 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+	return userID, actorID, true
+}
+
+func (d *NotificationsDelivery) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	unreadOnly := false
+	if raw := query.Get("unread"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid unread")
+			return
+		}
+		unreadOnly = parsed
+	}
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	list, err := d.Usecase.List(userID, actorID, unreadOnly, limit)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list notifications")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, list)
+}
+
+func (d *NotificationsDelivery) UnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+
+	unread, err := d.Usecase.UnreadCount(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to count notifications")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]int{"unread": unread})
+}
+
+func (d *NotificationsDelivery) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+	notificationID, err := parseUintVar(r, "notification_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid notification ID")
+		return
+	}
+
+	notification, err := d.Usecase.MarkRead(userID, actorID, notificationID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "notification not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to mark notification read")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, notification)
+}
+
+func (d *NotificationsDelivery) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+
+	marked, err := d.Usecase.MarkAllRead(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to mark notifications read")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]int{"marked": marked})
+}
+
+func writeEvent(w http.ResponseWriter, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, payload)
+	return err
+}
+
+// Stream доставляет новые уведомления пользователю онлайн как Server-Sent
+// Events. Первым приходит событие unread с числом непрочитанных уведомлений.
+func (d *NotificationsDelivery) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apiutils.WriteError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	sub, err := d.Usecase.Subscribe(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to subscribe to notifications")
+		return
+	}
+	defer sub.Close()
+
+	// Подписка оформлена до подсчёта, поэтому уведомления между ними не теряются.
+	unread, err := d.Usecase.UnreadCount(userID, actorID)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to count notifications")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if err = writeEvent(w, "unread", map[string]int{"unread": unread}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case notification, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err = writeEvent(w, "notification", notification); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (d *NotificationsDelivery) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req reminderRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.RemindAt == nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "remind_at is required")
+		return
+	}
+	if !req.RemindAt.After(time.Now()) {
+		apiutils.WriteError(w, http.StatusBadRequest, "remind_at must be in the future")
+		return
+	}
+
+	reminder, err := d.Usecase.CreateReminder(ownerID, actorID, noteID, *req.RemindAt)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create reminder")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, reminder)
+}
+
+func (d *NotificationsDelivery) ListReminders(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+
+	reminders, err := d.Usecase.ListReminders(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list reminders")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, reminders)
+}
+
+func (d *NotificationsDelivery) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, ok := parseUserVars(w, r)
+	if !ok {
+		return
+	}
+	reminderID, err := parseUintVar(r, "reminder_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid reminder ID")
+		return
+	}
+
+	err = d.Usecase.DeleteReminder(userID, actorID, reminderID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "reminder not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete reminder")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
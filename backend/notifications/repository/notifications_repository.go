@@ -0,0 +1,94 @@
+package notificationsRepository
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/store"
+	"fmt"
+	"time"
+)
+
+type NotificationsRepository struct {
+	Store *store.Store
+}
+
+func NewNotificationsRepository(store *store.Store) *NotificationsRepository {
+	return &NotificationsRepository{
+		Store: store,
+	}
+}
+
+func (r *NotificationsRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, err := r.Store.GetNote(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *NotificationsRepository) GetUserByEmail(email string) (*models.User, error) {
+	user, ok := r.Store.GetUserByEmail(email)
+	if !ok {
+		return nil, fmt.Errorf("failed to get user by email: %w", namederrors.ErrNotFound)
+	}
+	return &user, nil
+}
+
+func (r *NotificationsRepository) AddNotification(notification models.Notification) (*models.Notification, error) {
+	added := r.Store.AddNotification(notification)
+	return &added, nil
+}
+
+func (r *NotificationsRepository) ListNotifications(userID uint64, unreadOnly bool, limit int) ([]models.Notification, error) {
+	notifications := r.Store.ListNotifications(userID, unreadOnly, limit)
+	return notifications, nil
+}
+
+func (r *NotificationsRepository) CountUnreadNotifications(userID uint64) (int, error) {
+	return r.Store.CountUnreadNotifications(userID), nil
+}
+
+func (r *NotificationsRepository) MarkNotificationRead(userID, notificationID uint64) (*models.Notification, error) {
+	notification, err := r.Store.MarkNotificationRead(userID, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return &notification, nil
+}
+
+func (r *NotificationsRepository) MarkAllNotificationsRead(userID uint64) (int, error) {
+	return r.Store.MarkAllNotificationsRead(userID), nil
+}
+
+func (r *NotificationsRepository) CreateReminder(reminder models.Reminder) (*models.Reminder, error) {
+	created, err := r.Store.CreateReminder(reminder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	return &created, nil
+}
+
+func (r *NotificationsRepository) GetReminder(reminderID uint64) (*models.Reminder, error) {
+	reminder, err := r.Store.GetReminder(reminderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder: %w", err)
+	}
+	return &reminder, nil
+}
+
+func (r *NotificationsRepository) ListReminders(userID uint64) ([]models.Reminder, error) {
+	reminders := r.Store.ListReminders(userID)
+	return reminders, nil
+}
+
+func (r *NotificationsRepository) DeleteReminder(reminderID uint64) error {
+	if err := r.Store.DeleteReminder(reminderID); err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	return nil
+}
+
+func (r *NotificationsRepository) TakeDueReminders(now time.Time) ([]models.Reminder, error) {
+	reminders := r.Store.TakeDueReminders(now)
+	return reminders, nil
+}
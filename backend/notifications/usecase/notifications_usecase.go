@@ -0,0 +1,394 @@
+package notificationsUsecase
+
+import (
+	"backend/authz"
+	"backend/mentions"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/pubsub"
+	"backend/render"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+
+	// SubscriptionBuffer — размер очереди уведомлений подключения; при
+	// переполнении уведомления пропускаются, клиент перечитывает список.
+	SubscriptionBuffer = 32
+
+	excerptLength = 140
+)
+
+type NotificationsRepository interface {
+	GetNote(noteID uint64) (*models.Note, error)
+	GetUserByEmail(email string) (*models.User, error)
+	AddNotification(notification models.Notification) (*models.Notification, error)
+	ListNotifications(userID uint64, unreadOnly bool, limit int) ([]models.Notification, error)
+	CountUnreadNotifications(userID uint64) (int, error)
+	MarkNotificationRead(userID, notificationID uint64) (*models.Notification, error)
+	MarkAllNotificationsRead(userID uint64) (int, error)
+	CreateReminder(reminder models.Reminder) (*models.Reminder, error)
+	GetReminder(reminderID uint64) (*models.Reminder, error)
+	ListReminders(userID uint64) ([]models.Reminder, error)
+	DeleteReminder(reminderID uint64) error
+	TakeDueReminders(now time.Time) ([]models.Reminder, error)
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// NotificationsUsecase сохраняет уведомления пользователей и рассылает их
+// через шину подключениям, открытым на любом экземпляре сервера.
+type NotificationsUsecase struct {
+	Repository NotificationsRepository
+	Authorizer Authorizer
+	Bus        pubsub.PubSub
+}
+
+func NewNotificationsUsecase(repository NotificationsRepository, authorizer Authorizer, bus pubsub.PubSub) *NotificationsUsecase {
+	return &NotificationsUsecase{
+		Repository: repository,
+		Authorizer: authorizer,
+		Bus:        bus,
+	}
+}
+
+func topic(userID uint64) string {
+	return fmt.Sprintf("notifications:%d", userID)
+}
+
+// notify сохраняет уведомление и отправляет его подключениям пользователя.
+// Уведомления — побочный результат действия, поэтому ошибки только логируются.
+func (u *NotificationsUsecase) notify(notification models.Notification) {
+	if notification.ActorID != 0 && notification.ActorID == notification.UserID {
+		return
+	}
+
+	added, err := u.Repository.AddNotification(notification)
+	if err != nil {
+		log.Error().Err(err).Uint64("user_id", notification.UserID).Msg("failed to add notification")
+		return
+	}
+	if u.Bus == nil {
+		return
+	}
+	payload, err := json.Marshal(added)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode notification")
+		return
+	}
+	if err = u.Bus.Publish(topic(added.UserID), payload); err != nil {
+		log.Error().Err(err).Uint64("user_id", added.UserID).Msg("failed to publish notification")
+	}
+}
+
+// canView сообщает, может ли пользователь открыть заметку: уведомления о
+// недоступных заметках не отправляются.
+func (u *NotificationsUsecase) canView(userID uint64, note *models.Note) bool {
+	_, _, err := u.Authorizer.AuthorizeNote(note.OwnerID, userID, note.ID, models.RoleViewer)
+	return err == nil
+}
+
+// mentioned возвращает email и идентификаторы пользователей, впервые
+// упомянутых в text по сравнению с previous и имеющих доступ к заметке.
+func (u *NotificationsUsecase) mentioned(note *models.Note, previous, text string) map[string]uint64 {
+	users := make(map[string]uint64)
+	for _, email := range mentions.Added(previous, text) {
+		user, err := u.Repository.GetUserByEmail(email)
+		if errors.Is(err, namederrors.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Str("email", email).Msg("failed to resolve mention")
+			continue
+		}
+		if u.canView(user.ID, note) {
+			users[email] = user.ID
+		}
+	}
+	return users
+}
+
+// mentionExcerpt возвращает строку текста с упоминанием email.
+func mentionExcerpt(text, email string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.Contains(strings.ToLower(line), "@"+email) {
+			return render.Summary(line, excerptLength)
+		}
+	}
+	return ""
+}
+
+// NoteSaved уведомляет пользователей, упомянутых в тексте заметки после
+// сохранения и не упомянутых в previousText.
+func (u *NotificationsUsecase) NoteSaved(actorID uint64, note *models.Note, previousText string) {
+	for email, userID := range u.mentioned(note, previousText, note.Text) {
+		u.notify(models.Notification{
+			UserID:    userID,
+			Type:      models.NotificationMention,
+			ActorID:   actorID,
+			OwnerID:   note.OwnerID,
+			NoteID:    note.ID,
+			NoteTitle: note.Title,
+			Excerpt:   mentionExcerpt(note.Text, email),
+		})
+	}
+}
+
+// NoteShared уведомляет пользователя о выданном ему доступе к заметке.
+// Приглашения на email без аккаунта пропускаются.
+func (u *NotificationsUsecase) NoteShared(actorID uint64, note *models.Note, share *models.NoteShare) {
+	if share.UserID == 0 {
+		return
+	}
+	u.notify(models.Notification{
+		UserID:    share.UserID,
+		Type:      models.NotificationShare,
+		ActorID:   actorID,
+		OwnerID:   note.OwnerID,
+		NoteID:    note.ID,
+		NoteTitle: note.Title,
+		Excerpt:   share.Role,
+	})
+}
+
+// CommentPosted уведомляет упомянутых в комментарии пользователей, а для
+// нового ответа (previousText пуст) — ещё и остальных участников обсуждения.
+// thread передаётся в состоянии до добавления комментария.
+func (u *NotificationsUsecase) CommentPosted(actorID uint64, thread *models.CommentThread, comment *models.Comment, previousText string) {
+	note, err := u.Repository.GetNote(thread.NoteID)
+	if err != nil {
+		log.Error().Err(err).Uint64("note_id", thread.NoteID).Msg("failed to get note for comment notifications")
+		return
+	}
+
+	base := models.Notification{
+		ActorID:   actorID,
+		OwnerID:   note.OwnerID,
+		NoteID:    note.ID,
+		NoteTitle: note.Title,
+		ThreadID:  thread.ID,
+		CommentID: comment.ID,
+		Excerpt:   render.Summary(comment.Text, excerptLength),
+	}
+
+	notified := make(map[uint64]bool)
+	for _, userID := range u.mentioned(note, previousText, comment.Text) {
+		notified[userID] = true
+		notification := base
+		notification.UserID = userID
+		notification.Type = models.NotificationMention
+		u.notify(notification)
+	}
+	if previousText != "" {
+		return
+	}
+
+	for _, participant := range thread.Comments {
+		userID := participant.AuthorID
+		if notified[userID] || userID == actorID || !u.canView(userID, note) {
+			continue
+		}
+		notified[userID] = true
+		notification := base
+		notification.UserID = userID
+		notification.Type = models.NotificationCommentReply
+		u.notify(notification)
+	}
+}
+
+// FireDueReminders отправляет наступившие напоминания и возвращает их число.
+// Напоминания о заметках, ставших недоступными, отбрасываются.
+func (u *NotificationsUsecase) FireDueReminders(now time.Time) (int, error) {
+	reminders, err := u.Repository.TakeDueReminders(now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fire reminders: %w", err)
+	}
+
+	fired := 0
+	for _, reminder := range reminders {
+		note, _, err := u.Authorizer.AuthorizeNote(reminder.OwnerID, reminder.UserID, reminder.NoteID, models.RoleViewer)
+		if err != nil {
+			continue
+		}
+		u.notify(models.Notification{
+			UserID:    reminder.UserID,
+			Type:      models.NotificationReminder,
+			OwnerID:   note.OwnerID,
+			NoteID:    note.ID,
+			NoteTitle: note.Title,
+		})
+		fired++
+	}
+	return fired, nil
+}
+
+func (u *NotificationsUsecase) List(userID, actorID uint64, unreadOnly bool, limit int) (*models.NotificationList, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	notifications, err := u.Repository.ListNotifications(userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	unread, err := u.Repository.CountUnreadNotifications(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return &models.NotificationList{Notifications: notifications, Unread: unread}, nil
+}
+
+func (u *NotificationsUsecase) UnreadCount(userID, actorID uint64) (int, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	unread, err := u.Repository.CountUnreadNotifications(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return unread, nil
+}
+
+func (u *NotificationsUsecase) MarkRead(userID, actorID, notificationID uint64) (*models.Notification, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	notification, err := u.Repository.MarkNotificationRead(userID, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return notification, nil
+}
+
+func (u *NotificationsUsecase) MarkAllRead(userID, actorID uint64) (int, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	marked, err := u.Repository.MarkAllNotificationsRead(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return marked, nil
+}
+
+// Subscription — поток новых уведомлений пользователя.
+type Subscription struct {
+	C <-chan models.Notification
+
+	mu          sync.Mutex
+	ch          chan models.Notification
+	closed      bool
+	unsubscribe func()
+}
+
+func (s *Subscription) deliver(notification models.Notification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- notification:
+	default:
+	}
+}
+
+// Close отписывает от уведомлений и закрывает C.
+func (s *Subscription) Close() {
+	s.unsubscribe()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Subscribe подписывает на новые уведомления пользователя.
+func (u *NotificationsUsecase) Subscribe(userID, actorID uint64) (*Subscription, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to notifications: %w", err)
+	}
+
+	ch := make(chan models.Notification, SubscriptionBuffer)
+	sub := &Subscription{C: ch, ch: ch}
+	sub.unsubscribe = u.Bus.Subscribe(topic(userID), func(payload []byte) {
+		var notification models.Notification
+		if err := json.Unmarshal(payload, &notification); err != nil {
+			log.Error().Err(err).Msg("failed to decode notification")
+			return
+		}
+		sub.deliver(notification)
+	})
+	return sub, nil
+}
+
+// CreateReminder ставит пользователю напоминание о заметке, доступной ему
+// хотя бы на чтение.
+func (u *NotificationsUsecase) CreateReminder(ownerID, actorID, noteID uint64, remindAt time.Time) (*models.Reminder, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	reminder, err := u.Repository.CreateReminder(models.Reminder{
+		UserID:   actorID,
+		OwnerID:  ownerID,
+		NoteID:   noteID,
+		RemindAt: remindAt.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+func (u *NotificationsUsecase) ListReminders(userID, actorID uint64) ([]models.Reminder, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+
+	reminders, err := u.Repository.ListReminders(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+func (u *NotificationsUsecase) DeleteReminder(userID, actorID, reminderID uint64) error {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	reminder, err := u.Repository.GetReminder(reminderID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if reminder.UserID != userID {
+		return fmt.Errorf("failed to delete reminder: %w", namederrors.ErrNotFound)
+	}
+
+	if err = u.Repository.DeleteReminder(reminderID); err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// syntheticBoldWidth — толщина обводки глифов полужирного начертания,
+	// которое рисуется из обычного, в долях размера шрифта.
+	syntheticBoldWidth = 0.03
+	// syntheticItalicShear — наклон курсива, который рисуется из обычного
+	// начертания.
+	syntheticItalicShear = 0.2
+)
+
+// Font — шрифт текста: стандартный шрифт PDF или встроенный TrueType.
+// Полужирное и курсивное начертания TrueType рисуются из обычного.
+type Font struct {
+	face         *face
+	bold, italic bool
+}
+
+// Family — начертания, которыми верстается текст.
+type Family struct {
+	Regular, Bold, Italic, BoldItalic, Mono *Font
+}
+
+// face — шрифт в файле PDF. Стандартные шрифты выводят текст в кодировке
+// WinAnsi, TrueType — номерами глифов.
+type face struct {
+	name string
+	// widths — ширины символов ASCII от пробела до «~» в тысячных долях
+	// кегля; остальные символы WinAnsi получают ширину fallback.
+	widths   []int
+	fallback int
+	ttf      *trueType
+}
+
+var (
+	helvetica = &face{
+		name:     "Helvetica",
+		widths:   helveticaWidths,
+		fallback: 556,
+	}
+	helveticaBold = &face{
+		name:     "Helvetica-Bold",
+		widths:   helveticaBoldWidths,
+		fallback: 611,
+	}
+	helveticaOblique     = &face{name: "Helvetica-Oblique", widths: helveticaWidths, fallback: 556}
+	helveticaBoldOblique = &face{name: "Helvetica-BoldOblique", widths: helveticaBoldWidths, fallback: 611}
+	courier              = &face{name: "Courier", fallback: 600}
+)
+
+// Standard — стандартные шрифты PDF. Они есть в любой программе просмотра,
+// но выводят только символы кодировки WinAnsi, остальные заменяются на «?».
+var Standard = Family{
+	Regular:    &Font{face: helvetica},
+	Bold:       &Font{face: helveticaBold},
+	Italic:     &Font{face: helveticaOblique},
+	BoldItalic: &Font{face: helveticaBoldOblique},
+	Mono:       &Font{face: courier},
+}
+
+// Synthetic возвращает начертания одного шрифта: полужирное рисуется с
+// обводкой, курсив — с наклоном. Моноширинным остаётся тот же шрифт.
+func Synthetic(font *Font) Family {
+	return Family{
+		Regular:    font,
+		Bold:       &Font{face: font.face, bold: true},
+		Italic:     &Font{face: font.face, italic: true},
+		BoldItalic: &Font{face: font.face, bold: true, italic: true},
+		Mono:       font,
+	}
+}
+
+// Width возвращает ширину строки в пунктах.
+func (f *Font) Width(text string, size float64) float64 {
+	total := 0
+	for _, r := range text {
+		total += f.face.runeWidth(r)
+	}
+	return float64(total) * size / 1000
+}
+
+// Has сообщает, может ли шрифт вывести символ.
+func (f *Font) Has(r rune) bool {
+	if f.face.ttf != nil {
+		_, ok := f.face.ttf.glyphs[r]
+		return ok
+	}
+	_, ok := winAnsi(r)
+	return ok
+}
+
+func (f *face) runeWidth(r rune) int {
+	if f.ttf != nil {
+		return f.ttf.width(f.ttf.glyphs[r])
+	}
+	code, ok := winAnsi(r)
+	if !ok {
+		code = '?'
+	}
+	if code >= 32 && code <= 126 && f.widths != nil {
+		return f.widths[code-32]
+	}
+	if width, ok := specialWidths[code]; ok && f.widths != nil {
+		return width
+	}
+	return f.fallback
+}
+
+// encode записывает текст операндом оператора Tj и запоминает глифы
+// TrueType для таблицы ширин и ToUnicode.
+func (f *face) encode(text string, glyphs map[uint16]rune) string {
+	if f.ttf == nil {
+		var b strings.Builder
+		for _, r := range text {
+			code, ok := winAnsi(r)
+			if !ok {
+				code = '?'
+			}
+			b.WriteByte(code)
+		}
+		return literal(b.String())
+	}
+
+	var b strings.Builder
+	b.Grow(2 + 4*utf8.RuneCountInString(text))
+	b.WriteByte('<')
+	for _, r := range text {
+		glyph := f.ttf.glyphs[r]
+		if _, ok := glyphs[glyph]; !ok && glyph != 0 {
+			glyphs[glyph] = r
+		}
+		fmt.Fprintf(&b, "%04X", glyph)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// write записывает объекты шрифта и возвращает номер словаря шрифта.
+func (f *face) write(w *writer, glyphs map[uint16]rune) (int, error) {
+	if f.ttf != nil {
+		return f.ttf.write(w, glyphs)
+	}
+	id := w.alloc()
+	w.object(id, "<< /Type /Font /Subtype /Type1 /BaseFont /"+f.name+" /Encoding /WinAnsiEncoding >>")
+	return id, nil
+}
+
+// winAnsiSpecial — символы WinAnsi в диапазоне 0x80–0x9F, которые не
+// совпадают с Latin-1.
+var winAnsiSpecial = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// specialWidths — ширины символов winAnsiSpecial в Helvetica.
+var specialWidths = map[byte]int{
+	0x80: 556, 0x82: 222, 0x83: 556, 0x84: 333, 0x85: 1000, 0x86: 556, 0x87: 556,
+	0x88: 333, 0x89: 1000, 0x8A: 667, 0x8B: 333, 0x8C: 1000, 0x8E: 611,
+	0x91: 222, 0x92: 222, 0x93: 333, 0x94: 333, 0x95: 350, 0x96: 556, 0x97: 1000,
+	0x98: 333, 0x99: 1000, 0x9A: 500, 0x9B: 333, 0x9C: 944, 0x9E: 500, 0x9F: 667,
+}
+
+// winAnsi возвращает код символа в кодировке WinAnsi.
+func winAnsi(r rune) (byte, bool) {
+	switch {
+	case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+		return byte(r), true
+	case r == '\t':
+		return ' ', true
+	}
+	code, ok := winAnsiSpecial[r]
+	return code, ok
+}
+
+// Ширины символов ASCII от пробела до «~» из метрик AFM.
+var (
+	helveticaWidths = []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = []int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
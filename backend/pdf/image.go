@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"backend/imaging"
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+)
+
+// Image — изображение документа. JPEG встраивается без перекодирования,
+// остальные форматы — несжатыми пикселями RGB под Flate с маской
+// прозрачности.
+type Image struct {
+	Width, Height int
+	dict          string
+	data          []byte
+	compress      bool
+	mask          *Image
+}
+
+// NewImage готовит изображение JPEG, PNG или GIF к выводу.
+func NewImage(data []byte) (*Image, error) {
+	if bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return newJPEG(data)
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	pixels := make([]byte, 0, 3*width*height)
+	alpha := make([]byte, 0, width*height)
+	opaque := true
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := img.Pix[i+3]
+		for _, c := range img.Pix[i : i+3] {
+			// Пиксели image.RGBA хранятся умноженными на прозрачность.
+			if a != 0 && a != 0xFF {
+				c = byte(int(c) * 0xFF / int(a))
+			}
+			pixels = append(pixels, c)
+		}
+		alpha = append(alpha, a)
+		opaque = opaque && a == 0xFF
+	}
+
+	result := &Image{
+		Width:    width,
+		Height:   height,
+		dict:     "/ColorSpace /DeviceRGB /BitsPerComponent 8",
+		data:     pixels,
+		compress: true,
+	}
+	if !opaque {
+		result.mask = &Image{
+			Width:    width,
+			Height:   height,
+			dict:     "/ColorSpace /DeviceGray /BitsPerComponent 8",
+			data:     alpha,
+			compress: true,
+		}
+	}
+	return result, nil
+}
+
+func newJPEG(data []byte) (*Image, error) {
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", imaging.ErrInvalidImage)
+	}
+	space := "/DeviceRGB"
+	switch config.ColorModel {
+	case color.GrayModel:
+		space = "/DeviceGray"
+	case color.CMYKModel:
+		// Adobe записывает CMYK в JPEG инвертированным.
+		space = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+	}
+	return &Image{
+		Width:  config.Width,
+		Height: config.Height,
+		dict:   "/ColorSpace " + space + " /BitsPerComponent 8 /Filter /DCTDecode",
+		data:   data,
+	}, nil
+}
+
+// write записывает изображение с маской и возвращает номер объекта.
+func (img *Image) write(w *writer) (int, error) {
+	id := w.alloc()
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d %s", img.Width, img.Height, img.dict)
+	if img.mask != nil {
+		maskID, err := img.mask.write(w)
+		if err != nil {
+			return 0, err
+		}
+		dict += " /SMask " + ref(maskID)
+	}
+	if err := w.stream(id, dict, img.data, img.compress); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,397 @@
+// Package pdf собирает PDF-документы без внешних зависимостей: страницы с
+// текстом, прямоугольниками и изображениями, ссылки и закладки. Текст
+// выводится стандартными шрифтами PDF или встроенным шрифтом TrueType.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Размер страницы A4 в пунктах.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color — цвет в RGB, каждая составляющая от 0 до 1.
+type Color struct {
+	R, G, B float64
+}
+
+var Black = Color{}
+
+// Destination — место в документе: страница и высота от её нижнего края.
+type Destination struct {
+	Page *Page
+	Y    float64
+}
+
+// Document — PDF-документ. Страницы можно добавлять в любом порядке, ссылки и
+// закладки указывают на сами страницы, а не на их номера.
+type Document struct {
+	Title   string
+	pages   []*Page
+	fonts   []*fontUse
+	byFace  map[*face]*fontUse
+	images  []*Image
+	outline []outlineItem
+}
+
+// Page — страница документа. Координаты отсчитываются от левого нижнего угла.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	links   []link
+}
+
+type link struct {
+	x, y, width, height float64
+	uri                 string
+	dest                Destination
+}
+
+type outlineItem struct {
+	title string
+	level int
+	dest  Destination
+}
+
+// fontUse — шрифт, использованный в документе, и его глифы.
+type fontUse struct {
+	face   *face
+	name   string
+	glyphs map[uint16]rune
+}
+
+func New(title string) *Document {
+	return &Document{Title: title, byFace: make(map[*face]*fontUse)}
+}
+
+// AddPage добавляет страницу в конец документа.
+func (d *Document) AddPage() *Page {
+	return d.InsertPage(len(d.pages))
+}
+
+// InsertPage вставляет страницу перед страницей с номером index, считая с 0.
+func (d *Document) InsertPage(index int) *Page {
+	page := &Page{doc: d}
+	d.pages = append(d.pages, nil)
+	copy(d.pages[index+1:], d.pages[index:])
+	d.pages[index] = page
+	return page
+}
+
+// Pages возвращает страницы документа по порядку.
+func (d *Document) Pages() []*Page {
+	return slices.Clone(d.pages)
+}
+
+// PageNumber возвращает номер страницы, считая с 1, или 0, если страница из
+// другого документа.
+func (d *Document) PageNumber(page *Page) int {
+	for i, p := range d.pages {
+		if p == page {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// AddBookmark добавляет закладку. Закладка вкладывается в предыдущую закладку
+// меньшего уровня.
+func (d *Document) AddBookmark(title string, level int, dest Destination) {
+	d.outline = append(d.outline, outlineItem{title: title, level: level, dest: dest})
+}
+
+func (d *Document) use(f *face) *fontUse {
+	if used, ok := d.byFace[f]; ok {
+		return used
+	}
+	used := &fontUse{face: f, name: "F" + strconv.Itoa(len(d.fonts)+1), glyphs: make(map[uint16]rune)}
+	d.fonts = append(d.fonts, used)
+	d.byFace[f] = used
+	return used
+}
+
+func (d *Document) imageName(img *Image) string {
+	for i, known := range d.images {
+		if known == img {
+			return "Im" + strconv.Itoa(i+1)
+		}
+	}
+	d.images = append(d.images, img)
+	return "Im" + strconv.Itoa(len(d.images))
+}
+
+// number записывает число с точностью до тысячной пункта.
+func number(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+func (c Color) operands() string {
+	return number(c.R) + " " + number(c.G) + " " + number(c.B)
+}
+
+// Text выводит строку шрифтом font размера size; (x, y) — начало базовой
+// линии.
+func (p *Page) Text(x, y float64, font *Font, size float64, color Color, text string) {
+	used := p.doc.use(font.face)
+	fmt.Fprintf(&p.content, "BT\n/%s %s Tf\n%s rg\n", used.name, number(size), color.operands())
+	if font.bold {
+		fmt.Fprintf(&p.content, "%s RG\n%s w\n2 Tr\n", color.operands(), number(size*syntheticBoldWidth))
+	}
+	shear := 0.0
+	if font.italic {
+		shear = syntheticItalicShear
+	}
+	fmt.Fprintf(&p.content, "1 0 %s 1 %s %s Tm\n%s Tj\nET\n", number(shear), number(x), number(y), font.face.encode(text, used.glyphs))
+}
+
+// Rect закрашивает прямоугольник с левым нижним углом (x, y).
+func (p *Page) Rect(x, y, width, height float64, color Color) {
+	fmt.Fprintf(&p.content, "q\n%s rg\n%s %s %s %s re\nf\nQ\n", color.operands(), number(x), number(y), number(width), number(height))
+}
+
+// Line проводит отрезок толщиной width.
+func (p *Page) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(&p.content, "q\n%s RG\n%s w\n%s %s m\n%s %s l\nS\nQ\n", color.operands(), number(width), number(x1), number(y1), number(x2), number(y2))
+}
+
+// Image выводит изображение в прямоугольник с левым нижним углом (x, y).
+func (p *Page) Image(img *Image, x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "q\n%s 0 0 %s %s %s cm\n/%s Do\nQ\n", number(width), number(height), number(x), number(y), p.doc.imageName(img))
+}
+
+// LinkURI делает прямоугольник ссылкой на внешний адрес.
+func (p *Page) LinkURI(x, y, width, height float64, uri string) {
+	p.links = append(p.links, link{x: x, y: y, width: width, height: height, uri: uri})
+}
+
+// LinkTo делает прямоугольник ссылкой на место в документе.
+func (p *Page) LinkTo(x, y, width, height float64, dest Destination) {
+	p.links = append(p.links, link{x: x, y: y, width: width, height: height, dest: dest})
+}
+
+// literal записывает строку байтов в синтаксисе строк PDF.
+func literal(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// textString записывает строку метаданных или закладки в UTF-16BE.
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// writer нумерует объекты и запоминает их смещения для таблицы xref.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) alloc() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func ref(id int) string {
+	return strconv.Itoa(id) + " 0 R"
+}
+
+func (w *writer) object(id int, body string) {
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream записывает поток, при compress — сжатый Flate.
+func (w *writer) stream(id int, dict string, data []byte, compress bool) error {
+	if compress {
+		var packed bytes.Buffer
+		zw := zlib.NewWriter(&packed)
+		if _, err := zw.Write(data); err != nil {
+			return fmt.Errorf("failed to compress stream: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress stream: %w", err)
+		}
+		data = packed.Bytes()
+		dict += " /Filter /FlateDecode"
+	}
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s /Length %d >>\nstream\n", id, strings.TrimSpace(dict), len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+// WriteTo записывает документ. Пустой документ получает одну пустую страницу.
+func (d *Document) WriteTo(out io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	catalogID, pagesID, resourcesID, infoID := w.alloc(), w.alloc(), w.alloc(), w.alloc()
+
+	pageIDs := make(map[*Page]int, len(d.pages))
+	for _, page := range d.pages {
+		pageIDs[page] = w.alloc()
+	}
+	destination := func(dest Destination) string {
+		id, ok := pageIDs[dest.Page]
+		if !ok {
+			id = pageIDs[d.pages[0]]
+		}
+		return "[" + ref(id) + " /XYZ null " + number(dest.Y) + " null]"
+	}
+
+	kids := make([]string, 0, len(d.pages))
+	for _, page := range d.pages {
+		pageID := pageIDs[page]
+		kids = append(kids, ref(pageID))
+		contentID := w.alloc()
+		if err := w.stream(contentID, "", page.content.Bytes(), true); err != nil {
+			return 0, err
+		}
+		var annots []string
+		for _, l := range page.links {
+			id := w.alloc()
+			action := "/Dest " + destination(l.dest)
+			if l.uri != "" {
+				action = "/A << /S /URI /URI " + literal(l.uri) + " >>"
+			}
+			w.object(id, fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect [%s %s %s %s] /Border [0 0 0] %s >>",
+				number(l.x), number(l.y), number(l.x+l.width), number(l.y+l.height), action))
+			annots = append(annots, ref(id))
+		}
+		body := fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %s %s] /Resources %s /Contents %s",
+			ref(pagesID), number(PageWidth), number(PageHeight), ref(resourcesID), ref(contentID))
+		if len(annots) > 0 {
+			body += " /Annots [" + strings.Join(annots, " ") + "]"
+		}
+		w.object(pageID, body+" >>")
+	}
+	w.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	var fonts, images []string
+	for _, used := range d.fonts {
+		id, err := used.face.write(w, used.glyphs)
+		if err != nil {
+			return 0, err
+		}
+		fonts = append(fonts, "/"+used.name+" "+ref(id))
+	}
+	for i, img := range d.images {
+		id, err := img.write(w)
+		if err != nil {
+			return 0, err
+		}
+		images = append(images, "/Im"+strconv.Itoa(i+1)+" "+ref(id))
+	}
+	w.object(resourcesID, fmt.Sprintf("<< /ProcSet [/PDF /Text /ImageB /ImageC] /Font << %s >> /XObject << %s >> >>",
+		strings.Join(fonts, " "), strings.Join(images, " ")))
+
+	catalog := "<< /Type /Catalog /Pages " + ref(pagesID)
+	if len(d.outline) > 0 {
+		catalog += " /Outlines " + ref(d.writeOutline(w, destination)) + " /PageMode /UseOutlines"
+	}
+	w.object(catalogID, catalog+" >>")
+	w.object(infoID, "<< /Title "+textString(d.Title)+" /Producer (backend) >>")
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %s /Info %s >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, ref(catalogID), ref(infoID), xref)
+
+	n, err := out.Write(w.buf.Bytes())
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to write document: %w", err)
+	}
+	return int64(n), nil
+}
+
+// writeOutline записывает дерево закладок и возвращает номер его корня.
+func (d *Document) writeOutline(w *writer, destination func(Destination) string) int {
+	rootID := w.alloc()
+	ids := make([]int, len(d.outline))
+	parents := make([]int, len(d.outline))
+	for i := range d.outline {
+		ids[i] = w.alloc()
+		parents[i] = -1
+		for j := i - 1; j >= 0; j-- {
+			if d.outline[j].level < d.outline[i].level {
+				parents[i] = j
+				break
+			}
+		}
+	}
+	childrenOf := func(parent int) []int {
+		var children []int
+		for i := range d.outline {
+			if parents[i] == parent {
+				children = append(children, i)
+			}
+		}
+		return children
+	}
+
+	for i, item := range d.outline {
+		parentID := rootID
+		if parents[i] >= 0 {
+			parentID = ids[parents[i]]
+		}
+		body := "<< /Title " + textString(item.title) + " /Parent " + ref(parentID) + " /Dest " + destination(item.dest)
+		siblings := childrenOf(parents[i])
+		for k, sibling := range siblings {
+			if sibling != i {
+				continue
+			}
+			if k > 0 {
+				body += " /Prev " + ref(ids[siblings[k-1]])
+			}
+			if k < len(siblings)-1 {
+				body += " /Next " + ref(ids[siblings[k+1]])
+			}
+		}
+		if children := childrenOf(i); len(children) > 0 {
+			body += fmt.Sprintf(" /First %s /Last %s /Count -%d", ref(ids[children[0]]), ref(ids[children[len(children)-1]]), len(children))
+		}
+		w.object(ids[i], body+" >>")
+	}
+
+	top := childrenOf(-1)
+	w.object(rootID, fmt.Sprintf("<< /Type /Outlines /First %s /Last %s /Count %d >>",
+		ref(ids[top[0]]), ref(ids[top[len(top)-1]]), len(top)))
+	return rootID
+}
@@ -0,0 +1,177 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkXref проверяет, что таблица xref указывает на начала объектов.
+func checkXref(t *testing.T, data []byte) {
+	t.Helper()
+	start := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	require.NotNil(t, start)
+	offset, err := strconv.Atoi(string(start[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data[offset:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[offset:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		position, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(data[position:], []byte(strconv.Itoa(i+1)+" 0 obj\n")), "object %d", i+1)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	doc := New("Заметка")
+	second := doc.AddPage()
+	first := doc.InsertPage(0)
+	require.Equal(t, 1, doc.PageNumber(first))
+	require.Equal(t, 2, doc.PageNumber(second))
+
+	first.Text(56, 700, Standard.Bold, 14, Black, "Title (draft)")
+	first.Rect(56, 600, 100, 20, Color{R: 0.9, G: 0.9, B: 0.9})
+	first.Line(56, 590, 156, 590, 1, Black)
+	first.LinkTo(56, 695, 100, 20, Destination{Page: second, Y: 800})
+	second.LinkURI(56, 700, 100, 20, "https://example.com")
+	doc.AddBookmark("Title", 0, Destination{Page: first, Y: 800})
+	doc.AddBookmark("Section", 1, Destination{Page: second, Y: 800})
+
+	var out bytes.Buffer
+	_, err := doc.WriteTo(&out)
+	require.NoError(t, err)
+	data := out.Bytes()
+	require.True(t, bytes.HasPrefix(data, []byte("%PDF-1.7\n")))
+	checkXref(t, data)
+
+	text := string(data)
+	require.Contains(t, text, "/Type /Pages /Kids [5 0 R 6 0 R] /Count 2")
+	require.Contains(t, text, "/BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding")
+	require.Contains(t, text, "/A << /S /URI /URI (https://example.com) >>")
+	require.Contains(t, text, "/Dest [6 0 R /XYZ null 800 null]")
+	require.Contains(t, text, "/Type /Outlines")
+	require.Contains(t, text, "/Title <FEFF04170430043C04350442043A0430>")
+}
+
+func TestStandardFonts(t *testing.T) {
+	require.Len(t, helveticaWidths, 95)
+	require.Len(t, helveticaBoldWidths, 95)
+	require.InDelta(t, 22.78, Standard.Regular.Width("Hello", 10), 1e-9)
+	require.InDelta(t, 30, Standard.Mono.Width("Hello", 10), 1e-9)
+	require.True(t, Standard.Regular.Has('é'))
+	require.True(t, Standard.Regular.Has('—'))
+	require.False(t, Standard.Regular.Has('Ж'))
+	require.Equal(t, `(caf\351 \(1\) \227 ?)`, helvetica.encode("café (1) — Ж", nil))
+}
+
+// testFont собирает шрифт TrueType из таблиц, нужных для вёрстки: «A» —
+// глиф 1 шириной 600, «Ж» — глиф 2 шириной 700 при 1000 единицах на кегль.
+func testFont() []byte {
+	u16 := func(values ...int) []byte {
+		var b []byte
+		for _, v := range values {
+			b = binary.BigEndian.AppendUint16(b, uint16(v))
+		}
+		return b
+	}
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint16(head[18:], 1000)
+	hhea := make([]byte, 36)
+	binary.BigEndian.PutUint16(hhea[4:], 800)
+	binary.BigEndian.PutUint16(hhea[6:], uint16(0x10000-200))
+	binary.BigEndian.PutUint16(hhea[34:], 3)
+	maxp := u16(0, 0x5000, 3)
+	hmtx := u16(500, 0, 600, 0, 700, 0)
+	// Формат 4: сегменты «A», «Ж» и завершающий 0xFFFF.
+	subtable := u16(4, 0, 0, 6, 4, 1, 2)
+	subtable = append(subtable, u16(0x41, 0x416, 0xFFFF, 0, 0x41, 0x416, 0xFFFF)...)
+	subtable = append(subtable, u16(1-0x41, 2-0x416, 1, 0, 0, 0)...)
+	binary.BigEndian.PutUint16(subtable[2:], uint16(len(subtable)))
+	cmap := append(u16(0, 1, 3, 1), binary.BigEndian.AppendUint32(nil, 12)...)
+	cmap = append(cmap, subtable...)
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"head", head}, {"hhea", hhea}, {"hmtx", hmtx}, {"maxp", maxp}}
+	font := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	font = append(font, u16(len(tables), 0, 0, 0)...)
+	offset := 12 + 16*len(tables)
+	var body []byte
+	for _, table := range tables {
+		font = append(font, table.tag...)
+		font = binary.BigEndian.AppendUint32(font, 0)
+		font = binary.BigEndian.AppendUint32(font, uint32(offset+len(body)))
+		font = binary.BigEndian.AppendUint32(font, uint32(len(table.data)))
+		body = append(body, table.data...)
+	}
+	return append(font, body...)
+}
+
+func TestTrueType(t *testing.T) {
+	font, err := LoadTrueType(testFont())
+	require.NoError(t, err)
+	require.True(t, font.Has('Ж'))
+	require.False(t, font.Has('B'))
+	require.InDelta(t, 13, font.Width("AЖ", 10), 1e-9)
+
+	family := Synthetic(font)
+	doc := New("ttf")
+	page := doc.AddPage()
+	page.Text(10, 10, family.Regular, 10, Black, "AЖ")
+	page.Text(10, 30, family.BoldItalic, 10, Black, "Ж")
+	require.Len(t, doc.fonts, 1)
+	require.Equal(t, map[uint16]rune{1: 'A', 2: 'Ж'}, doc.fonts[0].glyphs)
+	require.Contains(t, page.content.String(), "<00010002> Tj")
+	require.Contains(t, page.content.String(), "2 Tr\n1 0 0.2 1 10 30 Tm")
+
+	var out bytes.Buffer
+	_, err = doc.WriteTo(&out)
+	require.NoError(t, err)
+	checkXref(t, out.Bytes())
+	require.Contains(t, out.String(), "/Encoding /Identity-H")
+	require.Contains(t, out.String(), "/DW 500 /W [1 [600] 2 [700]]")
+	require.Contains(t, string(toUnicode([]int{1, 2}, doc.fonts[0].glyphs)), "<0001> <0041>\n<0002> <0416>\n")
+
+	_, err = LoadTrueType([]byte("OTTO0000000000"))
+	require.ErrorIs(t, err, ErrInvalidFont)
+	_, err = LoadTrueType(testFont()[:40])
+	require.ErrorIs(t, err, ErrInvalidFont)
+}
+
+func TestNewImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 128})
+
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, img))
+	converted, err := NewImage(encoded.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, 4, converted.Width)
+	require.Len(t, converted.data, 4*2*3)
+	require.Equal(t, []byte{255, 0, 0}, converted.data[:3])
+	require.NotNil(t, converted.mask)
+	require.Equal(t, byte(128), converted.mask.data[0])
+
+	encoded.Reset()
+	require.NoError(t, jpeg.Encode(&encoded, img, nil))
+	photo, err := NewImage(encoded.Bytes())
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(photo.dict, "/DCTDecode"))
+	require.Equal(t, encoded.Bytes(), photo.data)
+	require.Nil(t, photo.mask)
+
+	_, err = NewImage([]byte("not an image"))
+	require.Error(t, err)
+}
@@ -0,0 +1,266 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrInvalidFont = errors.New("invalid TrueType font")
+
+// trueType — разобранный шрифт TrueType. Файл встраивается в документ
+// целиком, текст выводится номерами глифов в кодировке Identity-H.
+type trueType struct {
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	advances   []int
+	glyphs     map[rune]uint16
+}
+
+// LoadTrueType разбирает шрифт TrueType (.ttf). Шрифты OpenType с
+// контурами CFF не поддерживаются.
+func LoadTrueType(data []byte) (*Font, error) {
+	ttf, err := parseTrueType(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Font{face: &face{name: "EmbeddedFont", ttf: ttf}}, nil
+}
+
+func parseTrueType(data []byte) (*trueType, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+	if version := binary.BigEndian.Uint32(data); version != 0x00010000 && version != 0x74727565 {
+		return nil, fmt.Errorf("%w: unsupported font format", ErrInvalidFont)
+	}
+	tables := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, ErrInvalidFont
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) || offset+length < offset {
+			return nil, ErrInvalidFont
+		}
+		tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("%w: missing %s table", ErrInvalidFont, tag)
+		}
+	}
+
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, ErrInvalidFont
+	}
+	ttf := &trueType{
+		data:       data,
+		unitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+	}
+	if ttf.unitsPerEm == 0 {
+		return nil, ErrInvalidFont
+	}
+	for i := range ttf.bbox {
+		ttf.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if metrics == 0 || metrics > numGlyphs || len(hmtx) < 4*metrics {
+		return nil, ErrInvalidFont
+	}
+	ttf.advances = make([]int, numGlyphs)
+	for i := range ttf.advances {
+		if i < metrics {
+			ttf.advances[i] = int(binary.BigEndian.Uint16(hmtx[4*i:]))
+		} else {
+			ttf.advances[i] = ttf.advances[metrics-1]
+		}
+	}
+
+	glyphs, err := parseCmap(tables["cmap"], numGlyphs)
+	if err != nil {
+		return nil, err
+	}
+	ttf.glyphs = glyphs
+	return ttf, nil
+}
+
+// parseCmap читает таблицу символов Юникода: формат 12 для всего Юникода
+// или формат 4 для базовой плоскости.
+func parseCmap(cmap []byte, numGlyphs int) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, ErrInvalidFont
+	}
+	best, bestRank := -1, 0
+	count := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < count; i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			return nil, ErrInvalidFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+2 > len(cmap) {
+			continue
+		}
+		format := binary.BigEndian.Uint16(cmap[offset:])
+		rank := 0
+		switch {
+		case format == 12 && (platform == 0 || platform == 3 && encoding == 10):
+			rank = 2
+		case format == 4 && (platform == 0 || platform == 3 && encoding == 1):
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = offset, rank
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("%w: no unicode character map", ErrInvalidFont)
+	}
+
+	glyphs := make(map[rune]uint16)
+	add := func(r rune, glyph int) {
+		if glyph > 0 && glyph < numGlyphs {
+			glyphs[r] = uint16(glyph)
+		}
+	}
+	table := cmap[best:]
+	if bestRank == 2 {
+		if len(table) < 16 {
+			return nil, ErrInvalidFont
+		}
+		groups := int(binary.BigEndian.Uint32(table[12:]))
+		if groups > (len(table)-16)/12 {
+			return nil, ErrInvalidFont
+		}
+		for i := 0; i < groups; i++ {
+			group := table[16+12*i:]
+			start := binary.BigEndian.Uint32(group)
+			end := binary.BigEndian.Uint32(group[4:])
+			glyph := binary.BigEndian.Uint32(group[8:])
+			if end < start || end > 0x10FFFF || end-start > 0xFFFF {
+				return nil, ErrInvalidFont
+			}
+			for r := start; r <= end; r++ {
+				add(rune(r), int(glyph+r-start))
+			}
+		}
+		return glyphs, nil
+	}
+
+	if len(table) < 14 {
+		return nil, ErrInvalidFont
+	}
+	segments := int(binary.BigEndian.Uint16(table[6:])) / 2
+	ends, starts := 14, 16+2*segments
+	deltas, ranges := starts+2*segments, starts+4*segments
+	if len(table) < ranges+2*segments {
+		return nil, ErrInvalidFont
+	}
+	u16 := func(offset int) int {
+		if offset+2 > len(table) {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(table[offset:]))
+	}
+	for i := 0; i < segments; i++ {
+		start, end := u16(starts+2*i), u16(ends+2*i)
+		delta, rangeOffset := u16(deltas+2*i), u16(ranges+2*i)
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			if rangeOffset == 0 {
+				add(rune(c), (c+delta)&0xFFFF)
+				continue
+			}
+			glyph := u16(ranges + 2*i + rangeOffset + 2*(c-start))
+			if glyph != 0 {
+				glyph = (glyph + delta) & 0xFFFF
+			}
+			add(rune(c), glyph)
+		}
+	}
+	return glyphs, nil
+}
+
+// scale переводит единицы шрифта в тысячные доли кегля.
+func (t *trueType) scale(v int) int {
+	return v * 1000 / t.unitsPerEm
+}
+
+func (t *trueType) width(glyph uint16) int {
+	if int(glyph) >= len(t.advances) {
+		return 0
+	}
+	return t.scale(t.advances[glyph])
+}
+
+// write встраивает шрифт: словарь Type0, шрифт CIDFontType2 с ширинами
+// использованных глифов, описание шрифта, файл шрифта и ToUnicode для
+// копирования текста.
+func (t *trueType) write(w *writer, glyphs map[uint16]rune) (int, error) {
+	fontID, cidID, descriptorID, fileID, unicodeID := w.alloc(), w.alloc(), w.alloc(), w.alloc(), w.alloc()
+
+	used := make([]int, 0, len(glyphs))
+	for glyph := range glyphs {
+		used = append(used, int(glyph))
+	}
+	sort.Ints(used)
+	var widths strings.Builder
+	for _, glyph := range used {
+		fmt.Fprintf(&widths, "%d [%d] ", glyph, t.width(uint16(glyph)))
+	}
+
+	if err := w.stream(fileID, fmt.Sprintf("/Length1 %d", len(t.data)), t.data, true); err != nil {
+		return 0, err
+	}
+	w.object(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName /EmbeddedFont /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %s >>",
+		t.scale(t.bbox[0]), t.scale(t.bbox[1]), t.scale(t.bbox[2]), t.scale(t.bbox[3]),
+		t.scale(t.ascent), t.scale(t.descent), t.scale(t.ascent), ref(fileID)))
+	w.object(cidID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /EmbeddedFont /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %s /DW %d /W [%s] /CIDToGIDMap /Identity >>",
+		ref(descriptorID), t.width(0), strings.TrimSpace(widths.String())))
+	if err := w.stream(unicodeID, "", toUnicode(used, glyphs), true); err != nil {
+		return 0, err
+	}
+	w.object(fontID, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /EmbeddedFont /Encoding /Identity-H /DescendantFonts [%s] /ToUnicode %s >>",
+		ref(cidID), ref(unicodeID)))
+	return fontID, nil
+}
+
+// toUnicode строит CMap, по которой программы просмотра восстанавливают
+// текст из номеров глифов.
+func toUnicode(used []int, glyphs map[uint16]rune) []byte {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(used); start += 100 {
+		end := min(start+100, len(used))
+		fmt.Fprintf(&b, "%d beginbfchar\n", end-start)
+		for _, glyph := range used[start:end] {
+			fmt.Fprintf(&b, "<%04X> <", glyph)
+			for _, unit := range utf16.Encode([]rune{glyphs[uint16(glyph)]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,145 @@
+package presenceDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	presenceUsecase "backend/presence/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxMessageSize = 4 << 10
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+)
+
+type PresenceUsecase interface {
+	Join(userID, viewerID, noteID uint64) (*presenceUsecase.Member, error)
+	Leave(member *presenceUsecase.Member)
+	Handle(member *presenceUsecase.Member, event models.PresenceEvent)
+}
+
+type PresenceDelivery struct {
+	Usecase  PresenceUsecase
+	upgrader websocket.Upgrader
+}
+
+func NewPresenceDelivery(usecase PresenceUsecase) *PresenceDelivery {
+	return &PresenceDelivery{
+		Usecase: usecase,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || mw.IsAllowedOrigin(origin)
+			},
+		},
+	}
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// Connect переводит запрос в WebSocket-соединение присутствия на заметке.
+func (d *PresenceDelivery) Connect(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	noteID, err := parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	viewerID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	member, err := d.Usecase.Join(userID, viewerID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to open note")
+		return
+	}
+
+	conn, err := d.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		d.Usecase.Leave(member)
+		log.Info().Err(err).Msg("websocket upgrade failed")
+		return
+	}
+
+	go writeEvents(conn, member)
+	d.readEvents(conn, member)
+}
+
+// readEvents передаёт heartbeat и курсоры подключения, пока соединение живо.
+func (d *PresenceDelivery) readEvents(conn *websocket.Conn, member *presenceUsecase.Member) {
+	defer d.Usecase.Leave(member)
+
+	conn.SetReadLimit(maxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var event models.PresenceEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Info().Err(err).Msg("presence connection closed")
+			}
+			return
+		}
+		d.Usecase.Handle(member, event)
+	}
+}
+
+// writeEvents — единственный писатель в соединение: отправляет события
+// присутствия и пинги, а после отключения закрывает соединение.
+func writeEvents(conn *websocket.Conn, member *presenceUsecase.Member) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case event, ok := <-member.Events():
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package presenceUsecase
+
+import (
+	"backend/models"
+	"backend/pubsub"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultTimeout — время без heartbeat, после которого подключение считается ушедшим.
+	DefaultTimeout = 30 * time.Second
+	// MemberBuffer — размер очереди исходящих событий подключения.
+	MemberBuffer = 64
+)
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// Member — локальное подключение пользователя к присутствию на заметке.
+type Member struct {
+	ConnectionID string
+	UserID       uint64
+
+	noteID uint64
+	events chan models.PresenceEvent
+	closed bool
+}
+
+// Events возвращает очередь событий для отправки подключению; она
+// закрывается, когда подключение отключено.
+func (m *Member) Events() <-chan models.PresenceEvent {
+	return m.events
+}
+
+type memberState struct {
+	member   models.PresenceMember
+	lastSeen time.Time
+}
+
+// notePresence — известные этому экземпляру участники заметки. Состояние
+// меняется только событиями из шины, поэтому экземпляры, подписанные на
+// одну тему, видят одинаковый состав.
+type notePresence struct {
+	members     map[string]*memberState
+	local       map[string]*Member
+	unsubscribe func()
+	timer       *time.Timer
+}
+
+// PresenceUsecase отслеживает, кто открыл заметку и где его курсор.
+// Подключения шлют heartbeat; пропавшие дольше Timeout удаляются каждым
+// экземпляром самостоятельно.
+type PresenceUsecase struct {
+	Authorizer Authorizer
+	Bus        pubsub.PubSub
+	Timeout    time.Duration
+
+	mu    sync.Mutex
+	notes map[uint64]*notePresence
+	now   func() time.Time
+}
+
+func NewPresenceUsecase(authorizer Authorizer, bus pubsub.PubSub, timeout time.Duration) *PresenceUsecase {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &PresenceUsecase{
+		Authorizer: authorizer,
+		Bus:        bus,
+		Timeout:    timeout,
+		notes:      make(map[uint64]*notePresence),
+		now:        time.Now,
+	}
+}
+
+func topic(noteID uint64) string {
+	return fmt.Sprintf("presence:%d", noteID)
+}
+
+// Join подключает пользователя userID к присутствию на заметке владельца
+// ownerID и отправляет ему текущий состав участников.
+func (u *PresenceUsecase) Join(ownerID, userID, noteID uint64) (*Member, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, userID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to join presence: %w", err)
+	}
+
+	member := &Member{
+		ConnectionID: uuid.NewString(),
+		UserID:       userID,
+		noteID:       noteID,
+		events:       make(chan models.PresenceEvent, MemberBuffer),
+	}
+
+	u.mu.Lock()
+	np, ok := u.notes[noteID]
+	if !ok {
+		np = &notePresence{
+			members: make(map[string]*memberState),
+			local:   make(map[string]*Member),
+		}
+		np.unsubscribe = u.Bus.Subscribe(topic(noteID), func(payload []byte) {
+			u.receive(noteID, payload)
+		})
+		u.notes[noteID] = np
+		u.scheduleSweep(noteID, np)
+	}
+	np.local[member.ConnectionID] = member
+
+	snapshot := make([]models.PresenceMember, 0, len(np.members))
+	for _, state := range np.members {
+		snapshot = append(snapshot, state.member)
+	}
+	u.send(np, member, models.PresenceEvent{
+		Type:         models.PresenceSnapshot,
+		NoteID:       noteID,
+		ConnectionID: member.ConnectionID,
+		UserID:       userID,
+		Members:      snapshot,
+	})
+	u.mu.Unlock()
+
+	u.publish(member, models.PresenceEvent{Type: models.PresenceJoin})
+	return member, nil
+}
+
+// Leave отключает подключение и сообщает остальным о его уходе.
+func (u *PresenceUsecase) Leave(member *Member) {
+	u.mu.Lock()
+	if np, ok := u.notes[member.noteID]; ok {
+		u.disconnect(np, member)
+		if len(np.local) == 0 {
+			np.unsubscribe()
+			np.timer.Stop()
+			delete(u.notes, member.noteID)
+		}
+	}
+	u.mu.Unlock()
+
+	u.publish(member, models.PresenceEvent{Type: models.PresenceLeave})
+}
+
+// Handle обрабатывает событие подключения: heartbeat продлевает
+// присутствие, cursor ещё и передаёт положение курсора.
+func (u *PresenceUsecase) Handle(member *Member, event models.PresenceEvent) {
+	switch event.Type {
+	case models.PresenceHeartbeat:
+		u.publish(member, models.PresenceEvent{Type: models.PresenceHeartbeat})
+	case models.PresenceCursor:
+		u.publish(member, models.PresenceEvent{Type: models.PresenceCursor, Cursor: event.Cursor})
+	}
+}
+
+// publish дополняет событие данными подключения и отправляет его в шину.
+func (u *PresenceUsecase) publish(member *Member, event models.PresenceEvent) {
+	event.NoteID = member.noteID
+	event.ConnectionID = member.ConnectionID
+	event.UserID = member.UserID
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode presence event")
+		return
+	}
+	if err = u.Bus.Publish(topic(member.noteID), payload); err != nil {
+		log.Error().Err(err).Uint64("note_id", member.noteID).Msg("failed to publish presence event")
+	}
+}
+
+// receive применяет событие из шины к составу участников и рассылает его
+// локальным подключениям.
+func (u *PresenceUsecase) receive(noteID uint64, payload []byte) {
+	var event models.PresenceEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		log.Error().Err(err).Msg("failed to decode presence event")
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	np, ok := u.notes[noteID]
+	if !ok {
+		return
+	}
+
+	if event.Type == models.PresenceLeave {
+		if _, known := np.members[event.ConnectionID]; known {
+			delete(np.members, event.ConnectionID)
+			u.broadcast(np, event)
+		}
+		return
+	}
+
+	state, known := np.members[event.ConnectionID]
+	if !known {
+		state = &memberState{member: models.PresenceMember{ConnectionID: event.ConnectionID, UserID: event.UserID}}
+		np.members[event.ConnectionID] = state
+	}
+	state.lastSeen = u.now()
+	if event.Type == models.PresenceCursor {
+		state.member.Cursor = event.Cursor
+	}
+
+	switch {
+	case !known:
+		// Участник другого экземпляра мог появиться до нашей подписки —
+		// первый его heartbeat для локальных подключений означает вход.
+		u.broadcast(np, models.PresenceEvent{
+			Type:         models.PresenceJoin,
+			NoteID:       noteID,
+			ConnectionID: event.ConnectionID,
+			UserID:       event.UserID,
+			Cursor:       state.member.Cursor,
+		})
+	case event.Type == models.PresenceCursor:
+		u.broadcast(np, event)
+	}
+}
+
+// scheduleSweep периодически удаляет участников без heartbeat, пока у
+// заметки есть локальные подключения.
+// Вызывается под блокировкой u.mu
+func (u *PresenceUsecase) scheduleSweep(noteID uint64, np *notePresence) {
+	np.timer = time.AfterFunc(u.Timeout/2, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		if u.notes[noteID] != np {
+			return
+		}
+		u.sweep(noteID, np)
+		u.scheduleSweep(noteID, np)
+	})
+}
+
+// sweep удаляет участников, от которых дольше Timeout не было событий.
+// Вызывается под блокировкой u.mu
+func (u *PresenceUsecase) sweep(noteID uint64, np *notePresence) {
+	deadline := u.now().Add(-u.Timeout)
+	for id, state := range np.members {
+		if state.lastSeen.After(deadline) {
+			continue
+		}
+		delete(np.members, id)
+		if member, ok := np.local[id]; ok {
+			u.disconnect(np, member)
+		}
+		u.broadcast(np, models.PresenceEvent{
+			Type:         models.PresenceLeave,
+			NoteID:       noteID,
+			ConnectionID: id,
+			UserID:       state.member.UserID,
+		})
+	}
+}
+
+// Вызывается под блокировкой u.mu
+func (u *PresenceUsecase) broadcast(np *notePresence, event models.PresenceEvent) {
+	for id, member := range np.local {
+		if id != event.ConnectionID {
+			u.send(np, member, event)
+		}
+	}
+}
+
+// Вызывается под блокировкой u.mu
+func (u *PresenceUsecase) send(np *notePresence, member *Member, event models.PresenceEvent) {
+	select {
+	case member.events <- event:
+	default:
+		u.disconnect(np, member)
+	}
+}
+
+// Вызывается под блокировкой u.mu
+func (u *PresenceUsecase) disconnect(np *notePresence, member *Member) {
+	if member.closed {
+		return
+	}
+	member.closed = true
+	close(member.events)
+	delete(np.local, member.ConnectionID)
+}
@@ -0,0 +1,189 @@
+package presenceUsecase
+
+import (
+	"backend/crdt"
+	"backend/models"
+	"backend/pubsub"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	return &models.Note{ID: noteID, OwnerID: ownerID}, models.RoleViewer, nil
+}
+
+// testClock — часы, которые идут только по команде теста.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestUsecase создаёт экземпляр на общей шине. Таймаут велик, чтобы
+// настоящий таймер очистки не сработал во время теста; очистку тест
+// вызывает сам.
+func newTestUsecase(bus pubsub.PubSub, clock *testClock) *PresenceUsecase {
+	u := NewPresenceUsecase(fakeAuthorizer{}, bus, time.Hour)
+	u.now = clock.Now
+	return u
+}
+
+func next(t *testing.T, member *Member) models.PresenceEvent {
+	t.Helper()
+	select {
+	case event, ok := <-member.Events():
+		require.True(t, ok, "member is disconnected")
+		return event
+	default:
+		require.FailNow(t, "no presence event")
+		return models.PresenceEvent{}
+	}
+}
+
+func requireNoEvent(t *testing.T, member *Member) {
+	t.Helper()
+	select {
+	case event, ok := <-member.Events():
+		require.False(t, ok, "unexpected event %+v", event)
+	default:
+	}
+}
+
+func requireClosed(t *testing.T, member *Member) {
+	t.Helper()
+	for range member.Events() {
+	}
+}
+
+func (u *PresenceUsecase) sweepNow(noteID uint64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if np, ok := u.notes[noteID]; ok {
+		u.sweep(noteID, np)
+	}
+}
+
+func TestJoinLeave(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	u := newTestUsecase(pubsub.NewMemory(), clock)
+
+	a, err := u.Join(1, 1, 7)
+	require.NoError(t, err)
+	snapshot := next(t, a)
+	require.Equal(t, models.PresenceSnapshot, snapshot.Type)
+	require.Equal(t, a.ConnectionID, snapshot.ConnectionID)
+	require.Empty(t, snapshot.Members)
+
+	b, err := u.Join(1, 2, 7)
+	require.NoError(t, err)
+	snapshot = next(t, b)
+	require.Equal(t, []models.PresenceMember{{ConnectionID: a.ConnectionID, UserID: 1}}, snapshot.Members)
+	join := next(t, a)
+	require.Equal(t, models.PresenceJoin, join.Type)
+	require.Equal(t, b.ConnectionID, join.ConnectionID)
+	require.Equal(t, uint64(2), join.UserID)
+
+	cursor := &models.Cursor{Head: crdt.ID{Site: "server", Clock: 3}}
+	u.Handle(b, models.PresenceEvent{Type: models.PresenceCursor, Cursor: cursor})
+	event := next(t, a)
+	require.Equal(t, models.PresenceCursor, event.Type)
+	require.Equal(t, cursor, event.Cursor)
+	requireNoEvent(t, b)
+
+	u.Handle(b, models.PresenceEvent{Type: models.PresenceHeartbeat})
+	requireNoEvent(t, a)
+
+	u.Leave(b)
+	requireClosed(t, b)
+	leave := next(t, a)
+	require.Equal(t, models.PresenceLeave, leave.Type)
+	require.Equal(t, b.ConnectionID, leave.ConnectionID)
+
+	u.Leave(a)
+	requireClosed(t, a)
+	require.Empty(t, u.notes)
+}
+
+func TestSweep(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	u := newTestUsecase(pubsub.NewMemory(), clock)
+
+	a, err := u.Join(1, 1, 7)
+	require.NoError(t, err)
+	b, err := u.Join(1, 2, 7)
+	require.NoError(t, err)
+	next(t, a)
+	next(t, a)
+	next(t, b)
+
+	clock.Advance(u.Timeout / 2)
+	u.Handle(a, models.PresenceEvent{Type: models.PresenceHeartbeat})
+	u.sweepNow(7)
+	requireNoEvent(t, a)
+	requireNoEvent(t, b)
+
+	clock.Advance(u.Timeout/2 + time.Second)
+	u.sweepNow(7)
+	requireClosed(t, b)
+	leave := next(t, a)
+	require.Equal(t, models.PresenceLeave, leave.Type)
+	require.Equal(t, b.ConnectionID, leave.ConnectionID)
+	require.Equal(t, uint64(2), leave.UserID)
+
+	u.Leave(a)
+}
+
+func TestRemoteMembers(t *testing.T) {
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	bus := pubsub.NewMemory()
+	first := newTestUsecase(bus, clock)
+	second := newTestUsecase(bus, clock)
+
+	a, err := first.Join(1, 1, 7)
+	require.NoError(t, err)
+	next(t, a)
+
+	// Участник, подключившийся к другому экземпляру до нашей подписки,
+	// появляется с первым heartbeat.
+	payload, err := json.Marshal(models.PresenceEvent{Type: models.PresenceHeartbeat, NoteID: 7, ConnectionID: "remote", UserID: 3})
+	require.NoError(t, err)
+	require.NoError(t, bus.Publish(topic(7), payload))
+	join := next(t, a)
+	require.Equal(t, models.PresenceJoin, join.Type)
+	require.Equal(t, "remote", join.ConnectionID)
+	require.NoError(t, bus.Publish(topic(7), payload))
+	requireNoEvent(t, a)
+
+	c, err := second.Join(1, 2, 7)
+	require.NoError(t, err)
+	snapshot := next(t, c)
+	require.Empty(t, snapshot.Members, "second instance subscribed after the others joined")
+	join = next(t, a)
+	require.Equal(t, c.ConnectionID, join.ConnectionID)
+
+	clock.Advance(first.Timeout + time.Second)
+	first.Handle(a, models.PresenceEvent{Type: models.PresenceHeartbeat})
+	first.sweepNow(7)
+	leave := next(t, a)
+	require.Equal(t, models.PresenceLeave, leave.Type)
+	other := next(t, a)
+	require.Equal(t, models.PresenceLeave, other.Type)
+	require.ElementsMatch(t, []string{"remote", c.ConnectionID}, []string{leave.ConnectionID, other.ConnectionID})
+
+	second.Leave(c)
+	requireClosed(t, c)
+	requireNoEvent(t, a)
+	first.Leave(a)
+}
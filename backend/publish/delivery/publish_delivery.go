@@ -0,0 +1,328 @@
+package publishDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/render"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PageMaxAge — время, на которое браузеры и прокси кешируют опубликованные страницы.
+	PageMaxAge = 5 * time.Minute
+
+	descriptionLength = 160
+)
+
+var slugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type PublishUsecase interface {
+	GetPublication(userID, actorID, noteID uint64) (*models.Publication, error)
+	Publish(userID, actorID, noteID uint64, slug string, includeSubpages bool) (*models.Publication, error)
+	Unpublish(userID, actorID, noteID uint64) error
+	Page(slug string, pageID uint64) (*models.PublishedPage, error)
+	Sitemap() ([]models.PublishedPage, error)
+}
+
+type PublishDelivery struct {
+	Usecase PublishUsecase
+	BaseURL string
+}
+
+// NewPublishDelivery создаёт обработчики публикации. baseURL — внешний адрес
+// сервера для канонических ссылок и sitemap; пустой берётся из запроса.
+func NewPublishDelivery(usecase PublishUsecase, baseURL string) *PublishDelivery {
+	return &PublishDelivery{
+		Usecase: usecase,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+type publishRequest struct {
+	Slug            string `json:"slug"`
+	IncludeSubpages bool   `json:"include_subpages"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseNoteVars читает владельца и note_id из пути и пользователя сессии,
+// при ошибке сам пишет ответ.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (userID, actorID, noteID uint64, ok bool) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, 0, false
+	}
+	noteID, err = parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, 0, false
+	}
+	return userID, actorID, noteID, true
+}
+
+func (d *PublishDelivery) GetPublication(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	publication, err := d.Usecase.GetPublication(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "publication not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get publication")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, publication)
+}
+
+func (d *PublishDelivery) Publish(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req publishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if req.Slug != "" && (len(req.Slug) > 100 || !slugRe.MatchString(req.Slug)) {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid slug, expected lowercase letters, digits and dashes")
+		return
+	}
+
+	publication, err := d.Usecase.Publish(userID, actorID, noteID, req.Slug, req.IncludeSubpages)
+	if errors.Is(err, namederrors.ErrSlugTaken) {
+		apiutils.WriteError(w, http.StatusConflict, "slug already taken")
+		return
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to publish note")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, publication)
+}
+
+func (d *PublishDelivery) Unpublish(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	err := d.Usecase.Unpublish(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "publication not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to unpublish note")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "unpublished"})
+}
+
+func (d *PublishDelivery) baseURL(r *http.Request) string {
+	if d.BaseURL != "" {
+		return d.BaseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func pagePath(slug string, pageID uint64) string {
+	if pageID == 0 {
+		return "/p/" + slug
+	}
+	return "/p/" + slug + "/" + strconv.FormatUint(pageID, 10)
+}
+
+type pageLink struct {
+	Title   string
+	Path    string
+	Current bool
+}
+
+type pageView struct {
+	Title       string
+	SiteTitle   string
+	Description string
+	Canonical   string
+	Modified    string
+	Tags        []string
+	Content     template.HTML
+	Pages       []pageLink
+}
+
+// Page отдаёт корневую страницу опубликованной заметки.
+func (d *PublishDelivery) Page(w http.ResponseWriter, r *http.Request) {
+	d.servePage(w, r, 0)
+}
+
+// SubPage отдаёт подстраницу опубликованного сайта.
+func (d *PublishDelivery) SubPage(w http.ResponseWriter, r *http.Request) {
+	pageID, err := parseUintVar(r, "page_id")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	d.servePage(w, r, pageID)
+}
+
+func (d *PublishDelivery) servePage(w http.ResponseWriter, r *http.Request, pageID uint64) {
+	slug := mux.Vars(r)["slug"]
+	page, err := d.Usecase.Page(slug, pageID)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("failed to get published page")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	view := pageView{
+		Title:       page.Note.Title,
+		SiteTitle:   page.Root.Title,
+		Description: render.Summary(page.Note.Text, descriptionLength),
+		Canonical:   d.baseURL(r) + pagePath(slug, pageID),
+		Modified:    page.Note.UpdatedAt.Format(time.RFC3339),
+		Tags:        page.Note.Tags,
+		Content:     template.HTML(render.HTML(page.Note.Text)),
+	}
+	if len(page.Pages) > 0 {
+		view.Pages = append(view.Pages, pageLink{Title: page.Root.Title, Path: pagePath(slug, 0), Current: page.Note.ID == page.Root.ID})
+		for _, note := range page.Pages {
+			view.Pages = append(view.Pages, pageLink{Title: note.Title, Path: pagePath(slug, note.ID), Current: page.Note.ID == note.ID})
+		}
+	}
+
+	var body bytes.Buffer
+	if err = pageTemplate.Execute(&body, view); err != nil {
+		log.Error().Err(err).Str("slug", slug).Msg("failed to render published page")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	modified := page.Note.UpdatedAt
+	if page.Publication.UpdatedAt.After(modified) {
+		modified = page.Publication.UpdatedAt
+	}
+	writeCached(w, r, "text/html; charset=utf-8", body.Bytes(), modified)
+}
+
+// writeCached отдаёт ответ с заголовками кеширования и отвечает 304, если
+// у клиента актуальная версия.
+func writeCached(w http.ResponseWriter, r *http.Request, contentType string, body []byte, modified time.Time) {
+	hash := fnv.New64a()
+	hash.Write(body)
+	etag := fmt.Sprintf(`"%x"`, hash.Sum64())
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(PageMaxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write published page")
+	}
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// Sitemap отдаёт sitemap.xml со всеми опубликованными страницами.
+func (d *PublishDelivery) Sitemap(w http.ResponseWriter, r *http.Request) {
+	sites, err := d.Usecase.Sitemap()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build sitemap")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	base := d.baseURL(r)
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	var modified time.Time
+	for _, site := range sites {
+		slug := site.Publication.Slug
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + pagePath(slug, 0), LastMod: site.Root.UpdatedAt.Format(time.RFC3339)})
+		for _, note := range site.Pages {
+			set.URLs = append(set.URLs, sitemapURL{Loc: base + pagePath(slug, note.ID), LastMod: note.UpdatedAt.Format(time.RFC3339)})
+		}
+		if site.Root.UpdatedAt.After(modified) {
+			modified = site.Root.UpdatedAt
+		}
+	}
+
+	body, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode sitemap")
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	writeCached(w, r, "application/xml; charset=utf-8", append([]byte(xml.Header), body...), modified)
+}
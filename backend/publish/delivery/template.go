@@ -0,0 +1,63 @@
+package publishDelivery
+
+import "html/template"
+
+// pageTemplate — простая тема опубликованной страницы. Содержимое заметки
+// уже очищено render.HTML, остальные поля экранирует html/template.
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}{{if ne .Title .SiteTitle}} · {{.SiteTitle}}{{end}}</title>
+<meta name="description" content="{{.Description}}">
+{{- if .Tags}}
+<meta name="keywords" content="{{range $i, $tag := .Tags}}{{if $i}}, {{end}}{{$tag}}{{end}}">
+{{- end}}
+<link rel="canonical" href="{{.Canonical}}">
+<meta property="og:type" content="article">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.Canonical}}">
+<meta property="og:site_name" content="{{.SiteTitle}}">
+<meta property="article:modified_time" content="{{.Modified}}">
+<meta name="twitter:card" content="summary">
+<style>
+body{margin:0;font:17px/1.6 -apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;color:#1f2328;background:#fff}
+.layout{display:flex;max-width:1040px;margin:0 auto;padding:32px 20px;gap:40px}
+nav{flex:0 0 220px;font-size:15px}
+nav a{display:block;padding:4px 8px;border-radius:6px;color:#57606a;text-decoration:none}
+nav a.current,nav a:hover{background:#f3f4f6;color:#1f2328}
+main{flex:1;min-width:0}
+h1{font-size:2.2em;line-height:1.2;margin:0 0 .6em}
+pre{background:#f6f8fa;padding:12px 16px;border-radius:6px;overflow:auto}
+code{font-family:SFMono-Regular,Consolas,monospace;font-size:.9em}
+blockquote{margin:0;padding:0 1em;border-left:4px solid #d0d7de;color:#57606a}
+a{color:#0969da}
+.tags{margin-top:2em}
+.tags span{display:inline-block;margin-right:6px;padding:2px 10px;border-radius:12px;background:#f3f4f6;font-size:14px}
+@media (max-width:720px){.layout{flex-direction:column;gap:16px}nav{flex:none}}
+</style>
+</head>
+<body>
+<div class="layout">
+{{- if .Pages}}
+<nav>
+{{- range .Pages}}
+<a href="{{.Path}}"{{if .Current}} class="current" aria-current="page"{{end}}>{{.Title}}</a>
+{{- end}}
+</nav>
+{{- end}}
+<main>
+<article>
+<h1>{{.Title}}</h1>
+{{.Content}}
+{{- if .Tags}}
+<div class="tags">{{range .Tags}}<span>{{.}}</span>{{end}}</div>
+{{- end}}
+</article>
+</main>
+</div>
+</body>
+</html>
+`))
@@ -0,0 +1,66 @@
+package publishRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type PublishRepository struct {
+	Store *store.Store
+}
+
+func NewPublishRepository(store *store.Store) *PublishRepository {
+	return &PublishRepository{
+		Store: store,
+	}
+}
+
+func (r *PublishRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, err := r.Store.GetNote(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *PublishRepository) GetNotes(ownerID uint64) ([]models.Note, error) {
+	notes := r.Store.ListNotes(ownerID)
+	return notes, nil
+}
+
+func (r *PublishRepository) Publish(publication models.Publication) (*models.Publication, error) {
+	published, err := r.Store.Publish(publication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish note: %w", err)
+	}
+	return &published, nil
+}
+
+func (r *PublishRepository) GetPublication(noteID uint64) (*models.Publication, error) {
+	publication, err := r.Store.GetPublication(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+	return &publication, nil
+}
+
+func (r *PublishRepository) GetPublicationBySlug(slug string) (*models.Publication, error) {
+	publication, err := r.Store.GetPublicationBySlug(slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+	return &publication, nil
+}
+
+func (r *PublishRepository) ListPublications() ([]models.Publication, error) {
+	publications := r.Store.ListPublications()
+	return publications, nil
+}
+
+func (r *PublishRepository) Unpublish(noteID uint64) error {
+	if err := r.Store.Unpublish(noteID); err != nil {
+		return fmt.Errorf("failed to unpublish note: %w", err)
+	}
+	return nil
+}
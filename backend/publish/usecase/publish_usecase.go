@@ -0,0 +1,214 @@
+package publishUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/subpages"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxSlugBase — длина части адреса, взятой из заголовка заметки.
+const maxSlugBase = 60
+
+type PublishRepository interface {
+	GetNote(noteID uint64) (*models.Note, error)
+	GetNotes(ownerID uint64) ([]models.Note, error)
+	Publish(publication models.Publication) (*models.Publication, error)
+	GetPublication(noteID uint64) (*models.Publication, error)
+	GetPublicationBySlug(slug string) (*models.Publication, error)
+	ListPublications() ([]models.Publication, error)
+	Unpublish(noteID uint64) error
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+type PublishUsecase struct {
+	Repository PublishRepository
+	Authorizer Authorizer
+}
+
+func NewPublishUsecase(repository PublishRepository, authorizer Authorizer) *PublishUsecase {
+	return &PublishUsecase{
+		Repository: repository,
+		Authorizer: authorizer,
+	}
+}
+
+func (u *PublishUsecase) GetPublication(ownerID, actorID, noteID uint64) (*models.Publication, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+
+	publication, err := u.Repository.GetPublication(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get publication: %w", err)
+	}
+	return publication, nil
+}
+
+// Publish публикует заметку как веб-страницу. Без указанного адреса
+// сохраняется прежний, а для новой публикации он строится из заголовка.
+// С includeSubpages сайт включает текущие подстраницы заметки; список
+// обновляется повторной публикацией. Публиковать заметку может только владелец.
+func (u *PublishUsecase) Publish(ownerID, actorID, noteID uint64, slug string, includeSubpages bool) (*models.Publication, error) {
+	note, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish note: %w", err)
+	}
+
+	if slug == "" {
+		existing, err := u.Repository.GetPublication(noteID)
+		switch {
+		case err == nil:
+			slug = existing.Slug
+		case errors.Is(err, namederrors.ErrNotFound):
+			if slug, err = newSlug(note.Title); err != nil {
+				return nil, fmt.Errorf("failed to generate slug: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("failed to publish note: %w", err)
+		}
+	}
+
+	publication := models.Publication{
+		NoteID:          noteID,
+		OwnerID:         ownerID,
+		Slug:            slug,
+		IncludeSubpages: includeSubpages,
+	}
+	if includeSubpages {
+		notes, err := u.Repository.GetNotes(note.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to publish note: %w", err)
+		}
+		for _, page := range subpages.Of(*note, notes) {
+			publication.PageIDs = append(publication.PageIDs, page.ID)
+		}
+	}
+
+	published, err := u.Repository.Publish(publication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish note: %w", err)
+	}
+	return published, nil
+}
+
+// newSlug строит адрес из заголовка и случайного суффикса, чтобы
+// одинаковые заголовки не конфликтовали.
+func newSlug(title string) (string, error) {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= maxSlugBase {
+			break
+		}
+	}
+	base := strings.Trim(b.String(), "-")
+	if base == "" {
+		base = "note"
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return base + "-" + hex.EncodeToString(suffix), nil
+}
+
+func (u *PublishUsecase) Unpublish(ownerID, actorID, noteID uint64) error {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+		return fmt.Errorf("failed to unpublish note: %w", err)
+	}
+
+	if err := u.Repository.Unpublish(noteID); err != nil {
+		return fmt.Errorf("failed to unpublish note: %w", err)
+	}
+	return nil
+}
+
+// Page возвращает страницу опубликованного сайта: корневую заметку при
+// нулевом pageID или одну из опубликованных подстраниц.
+func (u *PublishUsecase) Page(slug string, pageID uint64) (*models.PublishedPage, error) {
+	publication, err := u.Repository.GetPublicationBySlug(slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+	page, err := u.site(*publication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+	if pageID == 0 || pageID == page.Root.ID {
+		return page, nil
+	}
+
+	for _, note := range page.Pages {
+		if note.ID == pageID {
+			page.Note = note
+			return page, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to get page: %w", namederrors.ErrNotFound)
+}
+
+// Sitemap возвращает все опубликованные сайты с их подстраницами.
+func (u *PublishUsecase) Sitemap() ([]models.PublishedPage, error) {
+	publications, err := u.Repository.ListPublications()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sitemap: %w", err)
+	}
+
+	sites := make([]models.PublishedPage, 0, len(publications))
+	for _, publication := range publications {
+		site, err := u.site(publication)
+		if errors.Is(err, namederrors.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to build sitemap: %w", err)
+		}
+		sites = append(sites, *site)
+	}
+	return sites, nil
+}
+
+// site собирает корневую страницу публикации и список подстраниц.
+// Удалённые с момента публикации подстраницы пропускаются.
+func (u *PublishUsecase) site(publication models.Publication) (*models.PublishedPage, error) {
+	root, err := u.Repository.GetNote(publication.NoteID)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.PublishedPage{Publication: publication, Note: *root, Root: *root}
+	if !publication.IncludeSubpages {
+		return page, nil
+	}
+
+	for _, pageID := range publication.PageIDs {
+		note, err := u.Repository.GetNote(pageID)
+		if errors.Is(err, namederrors.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if note.OwnerID == root.OwnerID {
+			page.Pages = append(page.Pages, *note)
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,131 @@
+package publishUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepository struct {
+	notes        map[uint64]models.Note
+	publications map[uint64]models.Publication
+}
+
+func (r *fakeRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, ok := r.notes[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &note, nil
+}
+
+func (r *fakeRepository) GetNotes(ownerID uint64) ([]models.Note, error) {
+	var notes []models.Note
+	for _, note := range r.notes {
+		if note.OwnerID == ownerID {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+func (r *fakeRepository) Publish(publication models.Publication) (*models.Publication, error) {
+	r.publications[publication.NoteID] = publication
+	return &publication, nil
+}
+
+func (r *fakeRepository) GetPublication(noteID uint64) (*models.Publication, error) {
+	publication, ok := r.publications[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &publication, nil
+}
+
+func (r *fakeRepository) GetPublicationBySlug(slug string) (*models.Publication, error) {
+	for _, publication := range r.publications {
+		if publication.Slug == slug {
+			return &publication, nil
+		}
+	}
+	return nil, namederrors.ErrNotFound
+}
+
+func (r *fakeRepository) ListPublications() ([]models.Publication, error) {
+	var publications []models.Publication
+	for _, publication := range r.publications {
+		publications = append(publications, publication)
+	}
+	return publications, nil
+}
+
+func (r *fakeRepository) Unpublish(noteID uint64) error {
+	delete(r.publications, noteID)
+	return nil
+}
+
+type fakeAuthorizer struct {
+	repository *fakeRepository
+}
+
+func (a *fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	note, err := a.repository.GetNote(noteID)
+	if err != nil {
+		return nil, "", err
+	}
+	if note.OwnerID != actorID {
+		return nil, "", namederrors.ErrForbidden
+	}
+	return note, models.RoleOwner, nil
+}
+
+func pageIDs(page *models.PublishedPage) []uint64 {
+	var ids []uint64
+	for _, note := range page.Pages {
+		ids = append(ids, note.ID)
+	}
+	return ids
+}
+
+func TestPublishSubpages(t *testing.T) {
+	repository := &fakeRepository{
+		notes: map[uint64]models.Note{
+			1: {ID: 1, OwnerID: 1, Title: "Guide"},
+			2: {ID: 2, OwnerID: 1, Folder: "Guide", Title: "Setup"},
+			3: {ID: 3, OwnerID: 1, Folder: "Guide/Setup", Title: "Linux"},
+			4: {ID: 4, OwnerID: 1, Title: "Unfiled"},
+			5: {ID: 5, OwnerID: 2, Folder: "Guide", Title: "Foreign"},
+		},
+		publications: make(map[uint64]models.Publication),
+	}
+	u := NewPublishUsecase(repository, &fakeAuthorizer{repository: repository})
+
+	publication, err := u.Publish(1, 1, 1, "guide", true)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 3}, publication.PageIDs, "unfiled notes next to the root are not sub-pages")
+
+	repository.notes[6] = models.Note{ID: 6, OwnerID: 1, Folder: "Guide", Title: "Later"}
+	delete(repository.notes, 3)
+	page, err := u.Page("guide", 0)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, pageIDs(page), "later notes are not published, deleted ones are skipped")
+	_, err = u.Page("guide", 6)
+	require.ErrorIs(t, err, namederrors.ErrNotFound)
+	_, err = u.Page("guide", 4)
+	require.ErrorIs(t, err, namederrors.ErrNotFound)
+
+	_, err = u.Publish(1, 1, 1, "", true)
+	require.NoError(t, err)
+	page, err = u.Page("guide", 6)
+	require.NoError(t, err)
+	require.Equal(t, "Later", page.Note.Title, "publishing again refreshes the page list")
+	require.Equal(t, []uint64{6, 2}, pageIDs(page))
+
+	_, err = u.Publish(1, 1, 1, "", false)
+	require.NoError(t, err)
+	page, err = u.Page("guide", 0)
+	require.NoError(t, err)
+	require.Empty(t, page.Pages)
+}
@@ -0,0 +1,65 @@
+package pubsub
+
+import "sync"
+
+// PubSub — шина сообщений между экземплярами сервера. Реализация по
+// умолчанию работает в памяти процесса; для нескольких экземпляров её
+// заменяют брокером (Redis, NATS) с тем же интерфейсом.
+type PubSub interface {
+	Publish(topic string, payload []byte) error
+	// Subscribe регистрирует обработчик сообщений темы и возвращает функцию
+	// отписки. Обработчик не должен вызываться внутри Subscribe.
+	Subscribe(topic string, handler func(payload []byte)) (unsubscribe func())
+}
+
+type subscription struct {
+	handler func(payload []byte)
+}
+
+// Memory — шина внутри одного процесса; сообщения доставляются синхронно.
+type Memory struct {
+	mu     sync.RWMutex
+	topics map[string]map[*subscription]struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		topics: make(map[string]map[*subscription]struct{}),
+	}
+}
+
+func (m *Memory) Publish(topic string, payload []byte) error {
+	m.mu.RLock()
+	handlers := make([]func([]byte), 0, len(m.topics[topic]))
+	for sub := range m.topics[topic] {
+		handlers = append(handlers, sub.handler)
+	}
+	m.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(payload)
+	}
+	return nil
+}
+
+func (m *Memory) Subscribe(topic string, handler func(payload []byte)) func() {
+	sub := &subscription{handler: handler}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.topics[topic] == nil {
+		m.topics[topic] = make(map[*subscription]struct{})
+	}
+	m.topics[topic][sub] = struct{}{}
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		delete(m.topics[topic], sub)
+		if len(m.topics[topic]) == 0 {
+			delete(m.topics, topic)
+		}
+	}
+}
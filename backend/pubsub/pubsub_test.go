@@ -0,0 +1,29 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	bus := NewMemory()
+
+	var first, second []string
+	unsubscribe := bus.Subscribe("notes", func(payload []byte) {
+		first = append(first, string(payload))
+	})
+	bus.Subscribe("notes", func(payload []byte) {
+		second = append(second, string(payload))
+	})
+	bus.Subscribe("other", func(payload []byte) {
+		t.Fatal("message delivered to another topic")
+	})
+
+	require.NoError(t, bus.Publish("notes", []byte("a")))
+	unsubscribe()
+	require.NoError(t, bus.Publish("notes", []byte("b")))
+
+	require.Equal(t, []string{"a"}, first)
+	require.Equal(t, []string{"a", "b"}, second)
+}
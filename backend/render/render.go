@@ -0,0 +1,342 @@
+package render
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRe  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedRe = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	linkRe    = regexp.MustCompile(`^\[([^\]]*)\]\(([^()\s]+)\)`)
+)
+
+// BlockKind — вид блока текста.
+type BlockKind int
+
+const (
+	ParagraphBlock BlockKind = iota
+	HeadingBlock
+	BulletListBlock
+	OrderedListBlock
+	QuoteBlock
+	CodeBlock
+)
+
+// Block — блок разобранного текста заметки.
+type Block struct {
+	Kind BlockKind
+	// Level — уровень заголовка от 1 до 6.
+	Level int
+	// Lines — строки абзаца, пункты списка или текст заголовка и цитаты.
+	Lines [][]Span
+	// Code — текст блока кода без разметки.
+	Code string
+}
+
+// SpanKind — вид фрагмента строки.
+type SpanKind int
+
+const (
+	TextSpan SpanKind = iota
+	CodeSpan
+	StrongSpan
+	EmphasisSpan
+	LinkSpan
+	ImageSpan
+)
+
+// Span — фрагмент строки: текст, код, выделение, ссылка или изображение.
+type Span struct {
+	Kind SpanKind
+	// Text — текст, код или подпись изображения.
+	Text string
+	// URL — адрес ссылки или изображения.
+	URL string
+	// Children — содержимое выделения и текст ссылки.
+	Children []Span
+}
+
+// Heading — заголовок текста для оглавления.
+type Heading struct {
+	Level int
+	Text  string
+	ID    string
+}
+
+// Options настраивает RenderHTML.
+type Options struct {
+	// IDPrefix включает id заголовков: префикс и номер заголовка в тексте.
+	IDPrefix string
+	// Image заменяет адрес изображения, например на data URL вложения.
+	Image func(src string) string
+}
+
+// Parse разбирает текст заметки с базовой Markdown-разметкой: заголовки,
+// списки, цитаты, блоки кода, выделение, ссылки и изображения. Ссылки и
+// изображения допускаются только на http, https, mailto и относительные
+// адреса, остальные остаются текстом.
+func Parse(text string) []Block {
+	var blocks []Block
+	var paragraph [][]Span
+	list := -1
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, Block{Kind: ParagraphBlock, Lines: paragraph})
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		list = -1
+	}
+	addItem := func(kind BlockKind, item string) {
+		flushParagraph()
+		if list < 0 || blocks[list].Kind != kind {
+			blocks = append(blocks, Block{Kind: kind})
+			list = len(blocks) - 1
+		}
+		blocks[list].Lines = append(blocks[list].Lines, ParseInline(item))
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, Block{Kind: CodeBlock, Code: strings.Join(code, "\n")})
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case headingRe.MatchString(trimmed):
+			flushParagraph()
+			closeList()
+			m := headingRe.FindStringSubmatch(trimmed)
+			blocks = append(blocks, Block{Kind: HeadingBlock, Level: len(m[1]), Lines: [][]Span{ParseInline(m[2])}})
+		case bulletRe.MatchString(line):
+			addItem(BulletListBlock, bulletRe.FindStringSubmatch(line)[1])
+		case orderedRe.MatchString(line):
+			addItem(OrderedListBlock, orderedRe.FindStringSubmatch(line)[1])
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			closeList()
+			quote := ParseInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+			blocks = append(blocks, Block{Kind: QuoteBlock, Lines: [][]Span{quote}})
+		default:
+			closeList()
+			paragraph = append(paragraph, ParseInline(trimmed))
+		}
+	}
+	flushParagraph()
+	return blocks
+}
+
+// ParseInline разбирает выделение, код, ссылки и изображения внутри строки.
+func ParseInline(s string) []Span {
+	var spans []Span
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, Span{Kind: TextSpan, Text: text.String()})
+			text.Reset()
+		}
+	}
+	add := func(span Span) {
+		flush()
+		spans = append(spans, span)
+	}
+
+	for len(s) > 0 {
+		next := strings.IndexAny(s, "`*[!")
+		if next < 0 {
+			text.WriteString(s)
+			break
+		}
+		text.WriteString(s[:next])
+		s = s[next:]
+
+		switch {
+		case s[0] == '`':
+			if end := strings.IndexByte(s[1:], '`'); end >= 0 {
+				add(Span{Kind: CodeSpan, Text: s[1 : 1+end]})
+				s = s[end+2:]
+				continue
+			}
+		case strings.HasPrefix(s, "**"):
+			if end := strings.Index(s[2:], "**"); end > 0 {
+				add(Span{Kind: StrongSpan, Children: ParseInline(s[2 : 2+end])})
+				s = s[end+4:]
+				continue
+			}
+		case s[0] == '*':
+			if end := strings.IndexByte(s[1:], '*'); end > 0 {
+				add(Span{Kind: EmphasisSpan, Children: ParseInline(s[1 : 1+end])})
+				s = s[end+2:]
+				continue
+			}
+		case s[0] == '[':
+			if m := linkRe.FindStringSubmatch(s); m != nil && safeURL(m[2]) {
+				add(Span{Kind: LinkSpan, URL: m[2], Children: ParseInline(m[1])})
+				s = s[len(m[0]):]
+				continue
+			}
+		case s[0] == '!':
+			if m := linkRe.FindStringSubmatch(s[1:]); m != nil && safeURL(m[2]) {
+				add(Span{Kind: ImageSpan, Text: m[1], URL: m[2]})
+				s = s[1+len(m[0]):]
+				continue
+			}
+		}
+		text.WriteByte(s[0])
+		s = s[1:]
+	}
+	flush()
+	return spans
+}
+
+// HTML преобразует текст заметки с базовой Markdown-разметкой в HTML.
+// Исходный HTML не пропускается: весь текст экранируется, поэтому результат
+// безопасно вставлять в страницу.
+func HTML(text string) string {
+	return RenderHTML(Parse(text), Options{})
+}
+
+// RenderHTML выводит разобранный текст в HTML.
+func RenderHTML(blocks []Block, options Options) string {
+	var b strings.Builder
+	heading := 0
+	for _, block := range blocks {
+		switch block.Kind {
+		case ParagraphBlock:
+			rendered := make([]string, 0, len(block.Lines))
+			for _, line := range block.Lines {
+				rendered = append(rendered, renderSpans(line, options))
+			}
+			b.WriteString("<p>" + strings.Join(rendered, "<br>\n") + "</p>\n")
+		case HeadingBlock:
+			heading++
+			tag := "h" + strconv.Itoa(block.Level)
+			id := ""
+			if options.IDPrefix != "" {
+				id = ` id="` + html.EscapeString(headingID(options.IDPrefix, heading)) + `"`
+			}
+			b.WriteString("<" + tag + id + ">" + renderSpans(block.Lines[0], options) + "</" + tag + ">\n")
+		case BulletListBlock, OrderedListBlock:
+			tag := "ul"
+			if block.Kind == OrderedListBlock {
+				tag = "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for _, item := range block.Lines {
+				b.WriteString("<li>" + renderSpans(item, options) + "</li>\n")
+			}
+			b.WriteString("</" + tag + ">\n")
+		case QuoteBlock:
+			b.WriteString("<blockquote>" + renderSpans(block.Lines[0], options) + "</blockquote>\n")
+		case CodeBlock:
+			b.WriteString("<pre><code>" + html.EscapeString(block.Code) + "</code></pre>\n")
+		}
+	}
+	return b.String()
+}
+
+func renderSpans(spans []Span, options Options) string {
+	var b strings.Builder
+	for _, span := range spans {
+		switch span.Kind {
+		case TextSpan:
+			b.WriteString(html.EscapeString(span.Text))
+		case CodeSpan:
+			b.WriteString("<code>" + html.EscapeString(span.Text) + "</code>")
+		case StrongSpan:
+			b.WriteString("<strong>" + renderSpans(span.Children, options) + "</strong>")
+		case EmphasisSpan:
+			b.WriteString("<em>" + renderSpans(span.Children, options) + "</em>")
+		case LinkSpan:
+			b.WriteString(`<a href="` + html.EscapeString(span.URL) + `" rel="nofollow noopener">` + renderSpans(span.Children, options) + "</a>")
+		case ImageSpan:
+			src := span.URL
+			if options.Image != nil {
+				src = options.Image(src)
+			}
+			b.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(span.Text) + `">`)
+		}
+	}
+	return b.String()
+}
+
+// Headings возвращает заголовки текста с теми же id, что выводит RenderHTML
+// с префиксом prefix.
+func Headings(blocks []Block, prefix string) []Heading {
+	var headings []Heading
+	for _, block := range blocks {
+		if block.Kind != HeadingBlock {
+			continue
+		}
+		headings = append(headings, Heading{
+			Level: block.Level,
+			Text:  PlainText(block.Lines[0]),
+			ID:    headingID(prefix, len(headings)+1),
+		})
+	}
+	return headings
+}
+
+func headingID(prefix string, n int) string {
+	return prefix + strconv.Itoa(n)
+}
+
+// PlainText возвращает текст строки без разметки.
+func PlainText(spans []Span) string {
+	var b strings.Builder
+	for _, span := range spans {
+		if len(span.Children) > 0 {
+			b.WriteString(PlainText(span.Children))
+		} else {
+			b.WriteString(span.Text)
+		}
+	}
+	return b.String()
+}
+
+func safeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	case "":
+		return !strings.HasPrefix(raw, "//")
+	}
+	return false
+}
+
+// Summary возвращает начало текста без разметки длиной не больше limit
+// символов — для описания страницы.
+func Summary(text string, limit int) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = strings.Trim(word, "#*`>_[]")
+	}
+	summary := strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+	if utf8.RuneCountInString(summary) <= limit {
+		return summary
+	}
+	runes := []rune(summary)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "paragraphs and line breaks",
+			text: "first\nsecond\n\nthird",
+			want: "<p>first<br>\nsecond</p>\n<p>third</p>\n",
+		},
+		{
+			name: "heading and list",
+			text: "## Plan\n- one\n- **two**",
+			want: "<h2>Plan</h2>\n<ul>\n<li>one</li>\n<li><strong>two</strong></li>\n</ul>\n",
+		},
+		{
+			name: "ordered list",
+			text: "1. a\n2. b",
+			want: "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n",
+		},
+		{
+			name: "code block is escaped verbatim",
+			text: "```\n<b>*x*</b>\n```",
+			want: "<pre><code>&lt;b&gt;*x*&lt;/b&gt;</code></pre>\n",
+		},
+		{
+			name: "raw html is escaped",
+			text: `<script>alert("x")</script>`,
+			want: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>\n",
+		},
+		{
+			name: "safe link",
+			text: "see [docs](https://example.com/a?b=1&c=2)",
+			want: `<p>see <a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener">docs</a></p>` + "\n",
+		},
+		{
+			name: "script link is not linked",
+			text: "[x](javascript:alert(1))",
+			want: "<p>[x](javascript:alert(1))</p>\n",
+		},
+		{
+			name: "image",
+			text: "![chart](/api/user/1/notes/5/attachments/2) and ![x](javascript:alert(1))",
+			want: `<p><img src="/api/user/1/notes/5/attachments/2" alt="chart"> and ![x](javascript:alert(1))</p>` + "\n",
+		},
+		{
+			name: "inline code and emphasis",
+			text: "use `a<b` and *this*",
+			want: "<p>use <code>a&lt;b</code> and <em>this</em></p>\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, HTML(test.text))
+		})
+	}
+}
+
+func TestRenderHTMLOptions(t *testing.T) {
+	blocks := Parse("# Plan\n\n## **Next** steps\n![a](a.png)")
+	html := RenderHTML(blocks, Options{
+		IDPrefix: "note-5-",
+		Image:    func(src string) string { return "data:image/png;base64,AA==" },
+	})
+	require.Equal(t, `<h1 id="note-5-1">Plan</h1>`+"\n"+`<h2 id="note-5-2"><strong>Next</strong> steps</h2>`+"\n"+`<p><img src="data:image/png;base64,AA==" alt="a"></p>`+"\n", html)
+
+	require.Equal(t, []Heading{
+		{Level: 1, Text: "Plan", ID: "note-5-1"},
+		{Level: 2, Text: "Next steps", ID: "note-5-2"},
+	}, Headings(blocks, "note-5-"))
+}
+
+func TestParse(t *testing.T) {
+	blocks := Parse("- a\n- b\n1. c\n> *q*\n```\ncode\n```")
+	require.Len(t, blocks, 4)
+	require.Equal(t, BulletListBlock, blocks[0].Kind)
+	require.Len(t, blocks[0].Lines, 2)
+	require.Equal(t, OrderedListBlock, blocks[1].Kind)
+	require.Equal(t, []Span{{Kind: EmphasisSpan, Children: []Span{{Kind: TextSpan, Text: "q"}}}}, blocks[2].Lines[0])
+	require.Equal(t, Block{Kind: CodeBlock, Code: "code"}, blocks[3])
+}
+
+func TestSummary(t *testing.T) {
+	require.Equal(t, "Title some text", Summary("# Title\n\nsome   **text**", 100))
+	require.Equal(t, "abcd…", Summary("abcdefgh", 5))
+}
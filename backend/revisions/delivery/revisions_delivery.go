@@ -0,0 +1,167 @@
+package revisionsDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type RevisionsUsecase interface {
+	ListRevisions(userID, actorID, noteID uint64) ([]models.NoteRevision, error)
+	GetRevision(userID, actorID, noteID, number uint64) (*models.NoteRevision, error)
+	DiffRevisions(userID, actorID, noteID, from, to uint64) (*models.RevisionDiff, error)
+	RestoreRevision(userID, editorID, noteID, number uint64) (*models.Note, error)
+}
+
+type RevisionsDelivery struct {
+	Usecase RevisionsUsecase
+}
+
+func NewRevisionsDelivery(usecase RevisionsUsecase) *RevisionsDelivery {
+	return &RevisionsDelivery{
+		Usecase: usecase,
+	}
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseNoteVars читает владельца и note_id из пути и пользователя сессии,
+// при ошибке сам пишет ответ.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (userID, actorID, noteID uint64, ok bool) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, 0, false
+	}
+	noteID, err = parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, 0, false
+	}
+	return userID, actorID, noteID, true
+}
+
+func (d *RevisionsDelivery) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := d.Usecase.ListRevisions(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list revisions")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, revisions)
+}
+
+func (d *RevisionsDelivery) GetRevision(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	number, err := parseUintVar(r, "revision")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	revision, err := d.Usecase.GetRevision(userID, actorID, noteID, number)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "revision not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get revision")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, revision)
+}
+
+func (d *RevisionsDelivery) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid from revision")
+		return
+	}
+	to, err := strconv.ParseUint(r.URL.Query().Get("to"), 10, 64)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid to revision")
+		return
+	}
+
+	diff, err := d.Usecase.DiffRevisions(userID, actorID, noteID, from, to)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "revision not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to diff revisions")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, diff)
+}
+
+func (d *RevisionsDelivery) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	number, err := parseUintVar(r, "revision")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid revision")
+		return
+	}
+
+	note, err := d.Usecase.RestoreRevision(userID, actorID, noteID, number)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "revision not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, note)
+}
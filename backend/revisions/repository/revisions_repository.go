@@ -0,0 +1,38 @@
+package revisionsRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type RevisionsRepository struct {
+	Store *store.Store
+}
+
+func NewRevisionsRepository(store *store.Store) *RevisionsRepository {
+	return &RevisionsRepository{
+		Store: store,
+	}
+}
+
+func (r *RevisionsRepository) ListRevisions(noteID uint64) ([]models.NoteRevision, error) {
+	revisions := r.Store.ListRevisions(noteID)
+	return revisions, nil
+}
+
+func (r *RevisionsRepository) GetRevision(noteID, number uint64) (*models.NoteRevision, error) {
+	revision, err := r.Store.GetRevision(noteID, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+	return &revision, nil
+}
+
+func (r *RevisionsRepository) RestoreRevision(noteID, number, editorID uint64) (*models.Note, error) {
+	note, err := r.Store.RestoreRevision(noteID, number, editorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore revision: %w", err)
+	}
+	return &note, nil
+}
@@ -0,0 +1,106 @@
+package revisionsUsecase
+
+import (
+	"backend/events"
+	"backend/models"
+	"backend/textdiff"
+	"fmt"
+)
+
+type RevisionsRepository interface {
+	ListRevisions(noteID uint64) ([]models.NoteRevision, error)
+	GetRevision(noteID, number uint64) (*models.NoteRevision, error)
+	RestoreRevision(noteID, number, editorID uint64) (*models.Note, error)
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+type RevisionsUsecase struct {
+	Repository RevisionsRepository
+	Events     events.Publisher
+	Authorizer Authorizer
+}
+
+func NewRevisionsUsecase(repository RevisionsRepository, events events.Publisher, authorizer Authorizer) *RevisionsUsecase {
+	return &RevisionsUsecase{
+		Repository: repository,
+		Events:     events,
+		Authorizer: authorizer,
+	}
+}
+
+// ListRevisions возвращает историю заметки; она доступна всем, кто может читать заметку.
+func (u *RevisionsUsecase) ListRevisions(ownerID, actorID, noteID uint64) ([]models.NoteRevision, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	revisions, err := u.Repository.ListRevisions(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+func (u *RevisionsUsecase) GetRevision(ownerID, actorID, noteID, number uint64) (*models.NoteRevision, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleViewer); err != nil {
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+
+	revision, err := u.Repository.GetRevision(noteID, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+	return revision, nil
+}
+
+// DiffRevisions построчно сравнивает текст двух ревизий заметки.
+func (u *RevisionsUsecase) DiffRevisions(ownerID, actorID, noteID, from, to uint64) (*models.RevisionDiff, error) {
+	fromRevision, err := u.GetRevision(ownerID, actorID, noteID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff revisions: %w", err)
+	}
+	toRevision, err := u.GetRevision(ownerID, actorID, noteID, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff revisions: %w", err)
+	}
+
+	diff := textdiff.Lines(fromRevision.Text, toRevision.Text)
+	lines := make([]models.RevisionDiffLine, 0, len(diff))
+	for _, line := range diff {
+		lines = append(lines, models.RevisionDiffLine{Op: string(line.Op), Text: line.Text})
+	}
+
+	return &models.RevisionDiff{
+		From:      from,
+		To:        to,
+		OldTitle:  fromRevision.Title,
+		NewTitle:  toRevision.Title,
+		OldFolder: fromRevision.Folder,
+		NewFolder: toRevision.Folder,
+		Lines:     lines,
+	}, nil
+}
+
+// RestoreRevision восстанавливает старую ревизию как новое состояние заметки.
+func (u *RevisionsUsecase) RestoreRevision(ownerID, editorID, noteID, number uint64) (*models.Note, error) {
+	current, _, err := u.Authorizer.AuthorizeNote(ownerID, editorID, noteID, models.RoleEditor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore revision: %w", err)
+	}
+
+	note, err := u.Repository.RestoreRevision(noteID, number, editorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore revision: %w", err)
+	}
+
+	event := models.NoteEvent{Type: models.NoteUpdated, NoteID: note.ID, Note: note}
+	if note.Folder != current.Folder {
+		event.Type = models.NoteMoved
+		event.FromFolder = current.Folder
+	}
+	u.Events.Publish(ownerID, event)
+	return note, nil
+}
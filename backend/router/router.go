@@ -21,12 +21,132 @@ func NewRouter(s *store.Store, deliveries *initialize.Deliveries) http.Handler {
 	api.HandleFunc("/register", deliveries.UserDelivery.Register).Methods("POST")
 	api.HandleFunc("/logout", deliveries.AuthDelivery.Logout).Methods("POST")
 	api.HandleFunc("/session", deliveries.UserDelivery.GetProfile).Methods("GET")
+	api.HandleFunc("/public/links/{token}", deliveries.LinksDelivery.OpenLink).Methods("GET")
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
+	r.HandleFunc("/p/{slug}", deliveries.PublishDelivery.Page).Methods("GET")
+	r.HandleFunc("/p/{slug}/{page_id}", deliveries.PublishDelivery.SubPage).Methods("GET")
+	r.HandleFunc("/sitemap.xml", deliveries.PublishDelivery.Sitemap).Methods("GET")
+
 	protected := api.PathPrefix("").Subrouter()
 	protected.Use(mw.AuthMiddleware(s))
-	protected.Use(mw.UserAccessMiddleware())
-	protected.HandleFunc("/user/{user_id}/notes", deliveries.NotesDelivery.GetAllNotes).Methods("GET")
+	protected.HandleFunc("/workspaces", deliveries.WorkspacesDelivery.ListWorkspaces).Methods("GET")
+	protected.HandleFunc("/workspaces", deliveries.WorkspacesDelivery.CreateWorkspace).Methods("POST")
+	protected.HandleFunc("/workspaces/{workspace_id}", deliveries.WorkspacesDelivery.GetWorkspace).Methods("GET")
+	protected.HandleFunc("/workspaces/{workspace_id}", deliveries.WorkspacesDelivery.RenameWorkspace).Methods("PUT")
+	protected.HandleFunc("/workspaces/{workspace_id}", deliveries.WorkspacesDelivery.DeleteWorkspace).Methods("DELETE")
+	protected.HandleFunc("/workspaces/{workspace_id}/members", deliveries.WorkspacesDelivery.ListMembers).Methods("GET")
+	protected.HandleFunc("/workspaces/{workspace_id}/members/{member_id}", deliveries.WorkspacesDelivery.UpdateMember).Methods("PUT")
+	protected.HandleFunc("/workspaces/{workspace_id}/members/{member_id}", deliveries.WorkspacesDelivery.RemoveMember).Methods("DELETE")
+	protected.HandleFunc("/workspaces/{workspace_id}/invitations", deliveries.WorkspacesDelivery.ListInvitations).Methods("GET")
+	protected.HandleFunc("/workspaces/{workspace_id}/invitations", deliveries.WorkspacesDelivery.Invite).Methods("POST")
+	protected.HandleFunc("/workspaces/{workspace_id}/invitations/{invitation_id}", deliveries.WorkspacesDelivery.RevokeInvitation).Methods("DELETE")
+	protected.HandleFunc("/invitations", deliveries.WorkspacesDelivery.MyInvitations).Methods("GET")
+	protected.HandleFunc("/invitations/{invitation_id}/accept", deliveries.WorkspacesDelivery.AcceptInvitation).Methods("POST")
+	protected.HandleFunc("/invitations/{invitation_id}/decline", deliveries.WorkspacesDelivery.DeclineInvitation).Methods("POST")
+
+	// Личные данные пользователя есть только в его пространстве.
+	userRoutes := protected.PathPrefix("/user/{user_id}").Subrouter()
+	userRoutes.Use(mw.UserOwner(s))
+	userRoutes.HandleFunc("/shared-with-me", deliveries.SharingDelivery.SharedWithMe).Methods("GET")
+
+	userRoutes.HandleFunc("/notifications", deliveries.NotificationsDelivery.ListNotifications).Methods("GET")
+	userRoutes.HandleFunc("/notifications/unread-count", deliveries.NotificationsDelivery.UnreadCount).Methods("GET")
+	userRoutes.HandleFunc("/notifications/stream", deliveries.NotificationsDelivery.Stream).Methods("GET")
+	userRoutes.HandleFunc("/notifications/read-all", deliveries.NotificationsDelivery.MarkAllRead).Methods("POST")
+	userRoutes.HandleFunc("/notifications/{notification_id}/read", deliveries.NotificationsDelivery.MarkRead).Methods("POST")
+	userRoutes.HandleFunc("/reminders", deliveries.NotificationsDelivery.ListReminders).Methods("GET")
+	userRoutes.HandleFunc("/reminders/{reminder_id}", deliveries.NotificationsDelivery.DeleteReminder).Methods("DELETE")
+
+	// Заметки и всё, что к ним относится, доступны и в личном пространстве
+	// пользователя, и в рабочих пространствах.
+	registerOwnerRoutes(userRoutes, deliveries)
+	workspaceRoutes := protected.PathPrefix("/workspaces/{workspace_id}").Subrouter()
+	workspaceRoutes.Use(mw.WorkspaceOwner(s))
+	registerOwnerRoutes(workspaceRoutes, deliveries)
 
 	return mw.CORS(r)
 }
+
+// registerOwnerRoutes регистрирует маршруты ресурсов владельца — пользователя
+// или рабочего пространства — на подмаршрутизаторе с его префиксом.
+func registerOwnerRoutes(owner *mux.Router, deliveries *initialize.Deliveries) {
+	owner.HandleFunc("/notes", deliveries.NotesDelivery.GetAllNotes).Methods("GET")
+	owner.HandleFunc("/notes", deliveries.NotesDelivery.CreateNote).Methods("POST")
+	owner.HandleFunc("/notes/search", deliveries.NotesDelivery.SearchNotes).Methods("GET")
+	owner.HandleFunc("/notes/changes", deliveries.NotesDelivery.StreamChanges).Methods("GET")
+	owner.HandleFunc("/notes/graph", deliveries.NoteLinksDelivery.Graph).Methods("GET")
+	owner.HandleFunc("/notes/broken-links", deliveries.NoteLinksDelivery.ListBrokenLinks).Methods("GET")
+	owner.HandleFunc("/quick-switch", deliveries.NotesDelivery.QuickSwitch).Methods("GET")
+	owner.HandleFunc("/sync", deliveries.SyncDelivery.Sync).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}", deliveries.NotesDelivery.GetNote).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}", deliveries.NotesDelivery.UpdateNote).Methods("PUT")
+	owner.HandleFunc("/notes/{note_id}", deliveries.NotesDelivery.DeleteNote).Methods("DELETE")
+	owner.HandleFunc("/notes/{note_id}/collab", deliveries.CollabDelivery.Connect).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/presence", deliveries.PresenceDelivery.Connect).Methods("GET")
+
+	owner.HandleFunc("/notes/{note_id}/shares", deliveries.SharingDelivery.ListShares).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/shares", deliveries.SharingDelivery.ShareNote).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/shares/{share_id}", deliveries.SharingDelivery.UpdateShare).Methods("PUT")
+	owner.HandleFunc("/notes/{note_id}/shares/{share_id}", deliveries.SharingDelivery.RevokeShare).Methods("DELETE")
+
+	owner.HandleFunc("/notes/{note_id}/threads", deliveries.CommentsDelivery.ListThreads).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/threads", deliveries.CommentsDelivery.CreateThread).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/threads/{thread_id}", deliveries.CommentsDelivery.GetThread).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/threads/{thread_id}/resolve", deliveries.CommentsDelivery.ResolveThread).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/threads/{thread_id}/reopen", deliveries.CommentsDelivery.ReopenThread).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/threads/{thread_id}/comments", deliveries.CommentsDelivery.Reply).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/threads/{thread_id}/comments/{comment_id}", deliveries.CommentsDelivery.EditComment).Methods("PUT")
+	owner.HandleFunc("/notes/{note_id}/threads/{thread_id}/comments/{comment_id}", deliveries.CommentsDelivery.DeleteComment).Methods("DELETE")
+
+	owner.HandleFunc("/notes/{note_id}/outlinks", deliveries.NoteLinksDelivery.ListLinks).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/backlinks", deliveries.NoteLinksDelivery.ListBacklinks).Methods("GET")
+
+	owner.HandleFunc("/notes/{note_id}/reminders", deliveries.NotificationsDelivery.CreateReminder).Methods("POST")
+
+	owner.HandleFunc("/notes/{note_id}/attachments", deliveries.AttachmentsDelivery.ListAttachments).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/attachments", deliveries.AttachmentsDelivery.Upload).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/attachments/{attachment_id}", deliveries.AttachmentsDelivery.Download).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/attachments/{attachment_id}", deliveries.AttachmentsDelivery.DeleteAttachment).Methods("DELETE")
+	owner.HandleFunc("/storage", deliveries.AttachmentsDelivery.StorageUsage).Methods("GET")
+
+	owner.HandleFunc("/notes/{note_id}/export", deliveries.TransferDelivery.ExportNote).Methods("GET")
+	owner.HandleFunc("/export", deliveries.TransferDelivery.ExportNotes).Methods("GET")
+	owner.HandleFunc("/import", deliveries.TransferDelivery.Import).Methods("POST")
+	owner.HandleFunc("/imports", deliveries.ImportsDelivery.ListImports).Methods("GET")
+	owner.HandleFunc("/imports", deliveries.ImportsDelivery.StartImport).Methods("POST")
+	owner.HandleFunc("/imports/{job_id}", deliveries.ImportsDelivery.GetImport).Methods("GET")
+
+	owner.HandleFunc("/notes/{note_id}/links", deliveries.LinksDelivery.ListLinks).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/links", deliveries.LinksDelivery.CreateLink).Methods("POST")
+	owner.HandleFunc("/notes/{note_id}/links/{link_id}", deliveries.LinksDelivery.RevokeLink).Methods("DELETE")
+
+	owner.HandleFunc("/notes/{note_id}/publication", deliveries.PublishDelivery.GetPublication).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/publication", deliveries.PublishDelivery.Publish).Methods("PUT")
+	owner.HandleFunc("/notes/{note_id}/publication", deliveries.PublishDelivery.Unpublish).Methods("DELETE")
+
+	owner.HandleFunc("/trash", deliveries.NotesDelivery.ListTrash).Methods("GET")
+	owner.HandleFunc("/trash", deliveries.NotesDelivery.EmptyTrash).Methods("DELETE")
+	owner.HandleFunc("/trash/{note_id}/restore", deliveries.NotesDelivery.RestoreNote).Methods("POST")
+	owner.HandleFunc("/trash/{note_id}", deliveries.NotesDelivery.PurgeNote).Methods("DELETE")
+
+	owner.HandleFunc("/notes/{note_id}/revisions", deliveries.RevisionsDelivery.ListRevisions).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/revisions/diff", deliveries.RevisionsDelivery.DiffRevisions).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/revisions/{revision}", deliveries.RevisionsDelivery.GetRevision).Methods("GET")
+	owner.HandleFunc("/notes/{note_id}/revisions/{revision}/restore", deliveries.RevisionsDelivery.RestoreRevision).Methods("POST")
+
+	owner.HandleFunc("/templates", deliveries.NoteTemplatesDelivery.ListTemplates).Methods("GET")
+	owner.HandleFunc("/templates", deliveries.NoteTemplatesDelivery.CreateTemplate).Methods("POST")
+	owner.HandleFunc("/templates/{template_id}", deliveries.NoteTemplatesDelivery.GetTemplate).Methods("GET")
+	owner.HandleFunc("/templates/{template_id}", deliveries.NoteTemplatesDelivery.UpdateTemplate).Methods("PUT")
+	owner.HandleFunc("/templates/{template_id}", deliveries.NoteTemplatesDelivery.DeleteTemplate).Methods("DELETE")
+	owner.HandleFunc("/templates/{template_id}/notes", deliveries.NoteTemplatesDelivery.CreateNote).Methods("POST")
+
+	owner.HandleFunc("/folders", deliveries.SavedSearchDelivery.GetFolderList).Methods("GET")
+	owner.HandleFunc("/smart-folders", deliveries.SavedSearchDelivery.ListSavedSearches).Methods("GET")
+	owner.HandleFunc("/smart-folders", deliveries.SavedSearchDelivery.CreateSavedSearch).Methods("POST")
+	owner.HandleFunc("/smart-folders/{smart_folder_id}", deliveries.SavedSearchDelivery.GetSavedSearch).Methods("GET")
+	owner.HandleFunc("/smart-folders/{smart_folder_id}", deliveries.SavedSearchDelivery.UpdateSavedSearch).Methods("PUT")
+	owner.HandleFunc("/smart-folders/{smart_folder_id}", deliveries.SavedSearchDelivery.DeleteSavedSearch).Methods("DELETE")
+	owner.HandleFunc("/smart-folders/{smart_folder_id}/notes", deliveries.SavedSearchDelivery.GetSavedSearchNotes).Methods("GET")
+}
@@ -1,6 +1,13 @@
 package router
 
 import (
+	"backend/blobstore"
+	"backend/config"
+	"backend/events"
+	"backend/initialize"
+	"backend/jobs"
+	"backend/pdf"
+	"backend/pubsub"
 	"backend/store"
 	"net/http"
 	"net/http/httptest"
@@ -11,7 +18,9 @@ import (
 
 func TestNewRouter(t *testing.T) {
 	s := store.NewStore()
-	router := NewRouter(s)
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	router := NewRouter(s, initialize.InitDeliveries(s, &config.Config{}, pubsub.NewMemory(), events.NewFeed(0), blobs, jobs.NewPool(1, 0), jobs.NewPool(1, 0), pdf.Standard))
 	require.NotNil(t, router, "router should not be nil")
 
 	tests := []struct {
@@ -41,6 +50,60 @@ func TestNewRouter(t *testing.T) {
 			path:     "/api/user/1/notes",
 			wantCode: http.StatusBadRequest,
 		},
+		{
+			name:     "smart folders endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/smart-folders",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "note graph endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/notes/graph",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "attachments endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/notes/1/attachments",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "export endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/export",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "imports endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/imports",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "templates endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/templates",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "notifications endpoint requires auth",
+			method:   "GET",
+			path:     "/api/user/1/notifications",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "public link does not require auth",
+			method:   "GET",
+			path:     "/api/public/links/unknown",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "sitemap is public",
+			method:   "GET",
+			path:     "/sitemap.xml",
+			wantCode: http.StatusOK,
+		},
 		{
 			name:     "non-existent endpoint returns 404",
 			method:   "GET",
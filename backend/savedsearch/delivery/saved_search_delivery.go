@@ -0,0 +1,275 @@
+package savedSearchDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/validation"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type SavedSearchUsecase interface {
+	CreateSavedSearch(userID, actorID uint64, name string, filter models.NotesFilter) (*models.SavedSearch, error)
+	GetSavedSearch(userID, actorID, searchID uint64) (*models.SavedSearch, error)
+	ListSavedSearches(userID, actorID uint64) ([]models.SavedSearch, error)
+	UpdateSavedSearch(userID, actorID, searchID uint64, name string, filter models.NotesFilter) (*models.SavedSearch, error)
+	DeleteSavedSearch(userID, actorID, searchID uint64) error
+	GetSavedSearchNotes(userID, actorID, searchID uint64) ([]models.Note, error)
+	GetFolderList(userID, actorID uint64) (*models.FolderList, error)
+}
+
+type SavedSearchDelivery struct {
+	Usecase SavedSearchUsecase
+}
+
+func NewSavedSearchDelivery(usecase SavedSearchUsecase) *SavedSearchDelivery {
+	return &SavedSearchDelivery{
+		Usecase: usecase,
+	}
+}
+
+type savedSearchRequest struct {
+	Name   string             `json:"name" valid:"required"`
+	Filter models.NotesFilter `json:"filter"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+func (d *SavedSearchDelivery) GetFolderList(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	folders, err := d.Usecase.GetFolderList(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get folders")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, folders)
+}
+
+func (d *SavedSearchDelivery) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	searches, err := d.Usecase.ListSavedSearches(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get smart folders")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, searches)
+}
+
+func (d *SavedSearchDelivery) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req savedSearchRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	search, err := d.Usecase.CreateSavedSearch(userID, actorID, req.Name, req.Filter)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create smart folder")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, search)
+}
+
+func (d *SavedSearchDelivery) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	searchID, err := parseUintVar(r, "smart_folder_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid smart folder ID")
+		return
+	}
+
+	search, err := d.Usecase.GetSavedSearch(userID, actorID, searchID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "smart folder not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get smart folder")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, search)
+}
+
+func (d *SavedSearchDelivery) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	searchID, err := parseUintVar(r, "smart_folder_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid smart folder ID")
+		return
+	}
+
+	var req savedSearchRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	search, err := d.Usecase.UpdateSavedSearch(userID, actorID, searchID, req.Name, req.Filter)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "smart folder not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to update smart folder")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, search)
+}
+
+func (d *SavedSearchDelivery) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	searchID, err := parseUintVar(r, "smart_folder_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid smart folder ID")
+		return
+	}
+
+	err = d.Usecase.DeleteSavedSearch(userID, actorID, searchID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "smart folder not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete smart folder")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (d *SavedSearchDelivery) GetSavedSearchNotes(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	searchID, err := parseUintVar(r, "smart_folder_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid smart folder ID")
+		return
+	}
+
+	notes, err := d.Usecase.GetSavedSearchNotes(userID, actorID, searchID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "smart folder not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get smart folder notes")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, notes)
+}
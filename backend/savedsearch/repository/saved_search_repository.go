@@ -0,0 +1,51 @@
+package savedSearchRepository
+
+import (
+	"backend/models"
+	"backend/store"
+	"fmt"
+)
+
+type SavedSearchRepository struct {
+	Store *store.Store
+}
+
+func NewSavedSearchRepository(store *store.Store) *SavedSearchRepository {
+	return &SavedSearchRepository{
+		Store: store,
+	}
+}
+
+func (r *SavedSearchRepository) CreateSavedSearch(search models.SavedSearch) (*models.SavedSearch, error) {
+	created := r.Store.CreateSavedSearch(search)
+	return &created, nil
+}
+
+func (r *SavedSearchRepository) GetSavedSearch(searchID uint64) (*models.SavedSearch, error) {
+	search, err := r.Store.GetSavedSearch(searchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	return &search, nil
+}
+
+func (r *SavedSearchRepository) ListSavedSearches(ownerID uint64) ([]models.SavedSearch, error) {
+	searches := r.Store.ListSavedSearches(ownerID)
+	return searches, nil
+}
+
+func (r *SavedSearchRepository) UpdateSavedSearch(search models.SavedSearch) (*models.SavedSearch, error) {
+	updated, err := r.Store.UpdateSavedSearch(search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return &updated, nil
+}
+
+func (r *SavedSearchRepository) DeleteSavedSearch(searchID uint64) error {
+	err := r.Store.DeleteSavedSearch(searchID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	return nil
+}
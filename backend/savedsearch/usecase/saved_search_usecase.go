@@ -0,0 +1,147 @@
+package savedSearchUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"fmt"
+)
+
+type SavedSearchRepository interface {
+	CreateSavedSearch(search models.SavedSearch) (*models.SavedSearch, error)
+	GetSavedSearch(searchID uint64) (*models.SavedSearch, error)
+	ListSavedSearches(userID uint64) ([]models.SavedSearch, error)
+	UpdateSavedSearch(search models.SavedSearch) (*models.SavedSearch, error)
+	DeleteSavedSearch(searchID uint64) error
+}
+
+// NotesUsecase — отбор заметок, которым вычисляется содержимое умных папок.
+type NotesUsecase interface {
+	GetAllNotes(userID, actorID uint64, filter models.NotesFilter) ([]models.Note, error)
+	ListFolders(userID, actorID uint64) ([]models.Folder, error)
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+}
+
+// SavedSearchUsecase управляет умными папками. Они не выдаются по
+// приглашению: работать с ними может только владелец.
+type SavedSearchUsecase struct {
+	Repository SavedSearchRepository
+	Notes      NotesUsecase
+	Authorizer Authorizer
+}
+
+func NewSavedSearchUsecase(repository SavedSearchRepository, notes NotesUsecase, authorizer Authorizer) *SavedSearchUsecase {
+	return &SavedSearchUsecase{
+		Repository: repository,
+		Notes:      notes,
+		Authorizer: authorizer,
+	}
+}
+
+func (u *SavedSearchUsecase) CreateSavedSearch(ownerID, actorID uint64, name string, filter models.NotesFilter) (*models.SavedSearch, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to create saved search: %w", err)
+	}
+	filter.Tags = notesUsecase.NormalizeTags(filter.Tags)
+
+	search, err := u.Repository.CreateSavedSearch(models.SavedSearch{
+		OwnerID: ownerID,
+		Name:    name,
+		Filter:  filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create saved search: %w", err)
+	}
+	return search, nil
+}
+
+func (u *SavedSearchUsecase) GetSavedSearch(ownerID, actorID, searchID uint64) (*models.SavedSearch, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+
+	search, err := u.Repository.GetSavedSearch(searchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+	if search.OwnerID != ownerID {
+		return nil, fmt.Errorf("failed to get saved search: %w", namederrors.ErrNotFound)
+	}
+	return search, nil
+}
+
+func (u *SavedSearchUsecase) ListSavedSearches(ownerID, actorID uint64) ([]models.SavedSearch, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+
+	searches, err := u.Repository.ListSavedSearches(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+func (u *SavedSearchUsecase) UpdateSavedSearch(ownerID, actorID, searchID uint64, name string, filter models.NotesFilter) (*models.SavedSearch, error) {
+	if _, err := u.GetSavedSearch(ownerID, actorID, searchID); err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+	filter.Tags = notesUsecase.NormalizeTags(filter.Tags)
+
+	search, err := u.Repository.UpdateSavedSearch(models.SavedSearch{
+		ID:     searchID,
+		Name:   name,
+		Filter: filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+	return search, nil
+}
+
+func (u *SavedSearchUsecase) DeleteSavedSearch(ownerID, actorID, searchID uint64) error {
+	if _, err := u.GetSavedSearch(ownerID, actorID, searchID); err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	err := u.Repository.DeleteSavedSearch(searchID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	return nil
+}
+
+// GetSavedSearchNotes вычисляет содержимое умной папки на текущий момент.
+func (u *SavedSearchUsecase) GetSavedSearchNotes(ownerID, actorID, searchID uint64) ([]models.Note, error) {
+	search, err := u.GetSavedSearch(ownerID, actorID, searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := u.Notes.GetAllNotes(ownerID, actorID, search.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate saved search: %w", err)
+	}
+	return notes, nil
+}
+
+// GetFolderList возвращает обычные и умные папки пользователя для боковой панели.
+func (u *SavedSearchUsecase) GetFolderList(ownerID, actorID uint64) (*models.FolderList, error) {
+	folders, err := u.Notes.ListFolders(ownerID, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder list: %w", err)
+	}
+
+	searches, err := u.ListSavedSearches(ownerID, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder list: %w", err)
+	}
+
+	return &models.FolderList{
+		Folders:      folders,
+		SmartFolders: searches,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token — нормализованное слово текста с позицией и байтовыми границами в исходной строке.
+// Позиции учитывают удалённые стоп-слова, поэтому фразы сравниваются с учётом пропусков.
+type Token struct {
+	Term     string
+	Position int
+	Start    int
+	End      int
+}
+
+// Analyze разбивает текст на слова и приводит их к виду, в котором они хранятся в индексе:
+// нижний регистр, ё→е, без стоп-слов, со стеммингом по языку слова.
+func Analyze(text string) []Token {
+	words := splitWords(text)
+	tokens := words[:0]
+	for _, word := range words {
+		lang := DetectLanguage(word.Term)
+		if isStopWord(word.Term, lang) {
+			continue
+		}
+		word.Term = stem(word.Term, lang)
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// splitWords разбивает текст на нормализованные слова без стемминга и удаления стоп-слов.
+func splitWords(text string) []Token {
+	tokens := make([]Token, 0)
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		tokens = append(tokens, Token{
+			Term:     normalize(text[start:end]),
+			Position: len(tokens),
+			Start:    start,
+			End:      end,
+		})
+		start = -1
+	}
+
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+
+	return tokens
+}
+
+var yoReplacer = strings.NewReplacer("ё", "е")
+
+func normalize(word string) string {
+	return yoReplacer.Replace(strings.ToLower(word))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
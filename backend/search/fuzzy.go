@@ -0,0 +1,319 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Пороговые значения нечёткого поиска.
+const (
+	minFuzzyScore      = 0.62
+	minSharedTrigrams  = 0.3
+	prefixMatchScore   = 1.0
+	wordPrefixScore    = 0.95
+	substringScore     = 0.9
+	editDistanceWeight = 0.9
+	// На каждые typoRunes символов запроса допускается одна опечатка.
+	typoRunes = 3
+	// maxQueryRunes ограничивает длину запроса, чтобы счётчики общих триграмм
+	// в sharedTrigrams не переполнялись.
+	maxQueryRunes = 256
+)
+
+// FuzzyMatch — запись индекса заголовков, похожая на запрос.
+type FuzzyMatch struct {
+	Key   string
+	Score float64
+}
+
+type titleEntry struct {
+	text     string
+	words    [][]rune
+	trigrams map[string]struct{}
+	// postings хранит положение записи в списке слотов каждой её триграммы.
+	postings map[string]int
+}
+
+func newTitleEntry(text string) *titleEntry {
+	normalized := normalize(text)
+	fields := strings.Fields(normalized)
+	words := make([][]rune, 0, len(fields))
+	for _, field := range fields {
+		words = append(words, []rune(field))
+	}
+	return &titleEntry{
+		text:     normalized,
+		words:    words,
+		trigrams: trigrams(normalized),
+		postings: make(map[string]int),
+	}
+}
+
+// titleOwner хранит записи одного владельца в слотах, чтобы при отборе
+// кандидатов считать общие триграммы в срезе, а не в map.
+type titleOwner struct {
+	keys     []string
+	entries  []*titleEntry
+	slots    map[string]int
+	free     []int
+	trigrams map[string][]int
+}
+
+// TitleIndex — триграммный индекс коротких строк (заголовков заметок, названий папок)
+// для нечёткого поиска с опечатками. Как и Index, не потокобезопасен.
+type TitleIndex struct {
+	owners map[uint64]*titleOwner
+}
+
+func NewTitleIndex() *TitleIndex {
+	return &TitleIndex{owners: make(map[uint64]*titleOwner)}
+}
+
+// Add добавляет или заменяет запись key владельца ownerID.
+func (ix *TitleIndex) Add(ownerID uint64, key, text string) {
+	ix.Remove(ownerID, key)
+
+	owner, ok := ix.owners[ownerID]
+	if !ok {
+		owner = &titleOwner{
+			slots:    make(map[string]int),
+			trigrams: make(map[string][]int),
+		}
+		ix.owners[ownerID] = owner
+	}
+
+	entry := newTitleEntry(text)
+	var slot int
+	if n := len(owner.free); n > 0 {
+		slot = owner.free[n-1]
+		owner.free = owner.free[:n-1]
+		owner.keys[slot], owner.entries[slot] = key, entry
+	} else {
+		slot = len(owner.entries)
+		owner.keys = append(owner.keys, key)
+		owner.entries = append(owner.entries, entry)
+	}
+	owner.slots[key] = slot
+
+	for trigram := range entry.trigrams {
+		entry.postings[trigram] = len(owner.trigrams[trigram])
+		owner.trigrams[trigram] = append(owner.trigrams[trigram], slot)
+	}
+}
+
+func (ix *TitleIndex) Remove(ownerID uint64, key string) {
+	owner, ok := ix.owners[ownerID]
+	if !ok {
+		return
+	}
+	slot, ok := owner.slots[key]
+	if !ok {
+		return
+	}
+
+	for trigram, i := range owner.entries[slot].postings {
+		slots := owner.trigrams[trigram]
+		last := slots[len(slots)-1]
+		slots[i] = last
+		owner.entries[last].postings[trigram] = i
+		if len(slots) == 1 {
+			delete(owner.trigrams, trigram)
+			continue
+		}
+		owner.trigrams[trigram] = slots[:len(slots)-1]
+	}
+	delete(owner.slots, key)
+	owner.keys[slot], owner.entries[slot] = "", nil
+	owner.free = append(owner.free, slot)
+
+	if len(owner.slots) == 0 {
+		delete(ix.owners, ownerID)
+	}
+}
+
+// Match возвращает записи владельца, похожие на запрос, в произвольном порядке.
+// Кандидаты отбираются по общим триграммам, короткие запросы сравниваются со всеми записями.
+func (ix *TitleIndex) Match(ownerID uint64, query string) []FuzzyMatch {
+	owner, ok := ix.owners[ownerID]
+	q := newFuzzyQuery(query)
+	if !ok || q.text == "" {
+		return []FuzzyMatch{}
+	}
+
+	shared := owner.sharedTrigrams(q)
+	threshold := 0
+	if len(q.runes) >= 3 {
+		threshold = max(1, int(minSharedTrigrams*float64(len(q.trigrams))))
+	}
+
+	matches := make([]FuzzyMatch, 0)
+	for slot, entry := range owner.entries {
+		if entry == nil || int(shared[slot]) < threshold {
+			continue
+		}
+		score := q.score(entry, int(shared[slot]))
+		if score >= minFuzzyScore {
+			matches = append(matches, FuzzyMatch{Key: owner.keys[slot], Score: score})
+		}
+	}
+
+	return matches
+}
+
+// sharedTrigrams считает для каждого слота число триграмм, общих с запросом.
+func (o *titleOwner) sharedTrigrams(q *fuzzyQuery) []uint16 {
+	shared := make([]uint16, len(o.entries))
+	for trigram := range q.trigrams {
+		for _, slot := range o.trigrams[trigram] {
+			shared[slot]++
+		}
+	}
+	return shared
+}
+
+type fuzzyQuery struct {
+	text     string
+	runes    []rune
+	trigrams map[string]struct{}
+	maxTypos int
+	distance *editDistanceBuffer
+}
+
+func newFuzzyQuery(query string) *fuzzyQuery {
+	runes := []rune(normalize(strings.TrimSpace(query)))
+	if len(runes) > maxQueryRunes {
+		runes = runes[:maxQueryRunes]
+	}
+	text := string(runes)
+	return &fuzzyQuery{
+		text:     text,
+		runes:    runes,
+		trigrams: trigrams(text),
+		maxTypos: max(1, len(runes)/typoRunes),
+		distance: newEditDistanceBuffer(len(runes) + 2),
+	}
+}
+
+// FuzzyScore оценивает похожесть строки на запрос от 0 до 1.
+func FuzzyScore(query, text string) float64 {
+	q := newFuzzyQuery(query)
+	entry := newTitleEntry(text)
+
+	shared := 0
+	for trigram := range q.trigrams {
+		if _, ok := entry.trigrams[trigram]; ok {
+			shared++
+		}
+	}
+	return q.score(entry, shared)
+}
+
+func (q *fuzzyQuery) score(entry *titleEntry, sharedTrigrams int) float64 {
+	switch {
+	case strings.HasPrefix(entry.text, q.text):
+		return prefixMatchScore
+	case strings.Contains(entry.text, q.text):
+		for _, word := range entry.words {
+			if utf8.RuneCountInString(q.text) <= len(word) && strings.HasPrefix(string(word), q.text) {
+				return wordPrefixScore
+			}
+		}
+		return substringScore
+	}
+
+	// Коэффициент Дайса по множествам триграмм.
+	score := 0.0
+	if total := len(q.trigrams) + len(entry.trigrams); total > 0 {
+		score = 2 * float64(sharedTrigrams) / float64(total)
+	}
+
+	// Запрос сравнивается с началами слов заголовка: пользователь мог
+	// допечатать слово лишь частично.
+	for _, word := range entry.words {
+		distance, ok := q.distance.prefix(q.runes, word, q.maxTypos)
+		if !ok {
+			continue
+		}
+		similarity := editDistanceWeight * (1 - float64(distance)/float64(len(q.runes)))
+		if similarity > score {
+			score = similarity
+		}
+	}
+
+	return score
+}
+
+// trigrams возвращает триграммы слов строки, дополненных пробелами как в pg_trgm.
+func trigrams(text string) map[string]struct{} {
+	result := make(map[string]struct{})
+	for _, word := range strings.Fields(text) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			result[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return result
+}
+
+type editDistanceBuffer struct {
+	rows [3][]int
+}
+
+func newEditDistanceBuffer(size int) *editDistanceBuffer {
+	b := &editDistanceBuffer{}
+	for i := range b.rows {
+		b.rows[i] = make([]int, size)
+	}
+	return b
+}
+
+// prefix считает наименьшее расстояние Дамерау–Левенштейна (с транспозицией соседних
+// символов) между a и началами b. Счёт прекращается, как только расстояние
+// гарантированно превысит limit.
+func (buf *editDistanceBuffer) prefix(a, b []rune, limit int) (int, bool) {
+	if len(b) > len(a)+limit {
+		b = b[:len(a)+limit]
+	}
+	if len(a)-len(b) > limit {
+		return 0, false
+	}
+	for i := range buf.rows {
+		if len(buf.rows[i]) < len(b)+1 {
+			buf.rows[i] = make([]int, len(b)+1)
+		}
+	}
+
+	prev2, prev, cur := buf.rows[0][:len(b)+1], buf.rows[1][:len(b)+1], buf.rows[2][:len(b)+1]
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return 0, false
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	distance := prev[0]
+	for _, d := range prev[1:] {
+		distance = min(distance, d)
+	}
+	if distance > limit {
+		return 0, false
+	}
+	return distance, true
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query   string
+		text    string
+		matches bool
+	}{
+		{query: "shop", text: "Shopping list", matches: true},
+		{query: "list", text: "Shopping list", matches: true},
+		{query: "shoping", text: "Shopping list", matches: true},
+		{query: "shpoping", text: "Shopping list", matches: true},
+		{query: "домашка", text: "Домашнее задание", matches: true},
+		{query: "ёлка", text: "Елка на Новый год", matches: true},
+		{query: "film", text: "Films to watch", matches: true},
+		{query: "physics", text: "Shopping list", matches: false},
+		{query: "zzz", text: "Books to read", matches: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query+"/"+test.text, func(t *testing.T) {
+			score := FuzzyScore(test.query, test.text)
+			require.Equal(t, test.matches, score >= minFuzzyScore, "score %.2f", score)
+		})
+	}
+
+	require.Greater(t, FuzzyScore("shop", "Shopping list"), FuzzyScore("shpo", "Shopping list"))
+}
+
+func TestTitleIndexMatch(t *testing.T) {
+	ix := NewTitleIndex()
+	ix.Add(1, "a", "Meeting notes")
+	ix.Add(1, "b", "Reading list")
+	ix.Add(1, "c", "University")
+	ix.Add(2, "d", "Meeting notes")
+
+	keys := func(matches []FuzzyMatch) []string {
+		result := make([]string, 0, len(matches))
+		for _, match := range matches {
+			result = append(result, match.Key)
+		}
+		return result
+	}
+
+	require.ElementsMatch(t, []string{"a"}, keys(ix.Match(1, "meetnig")))
+	require.ElementsMatch(t, []string{"c"}, keys(ix.Match(1, "un")))
+	require.Empty(t, ix.Match(1, "   "))
+
+	ix.Add(1, "a", "Standup")
+	require.Empty(t, ix.Match(1, "meeting"))
+
+	ix.Remove(1, "b")
+	require.Empty(t, ix.Match(1, "reading"))
+
+	require.Len(t, newFuzzyQuery(strings.Repeat("я", 100000)).runes, maxQueryRunes)
+}
+
+func BenchmarkTitleIndexMatch(b *testing.B) {
+	ix := NewTitleIndex()
+	words := []string{
+		"meeting", "project", "заметка", "лекция", "shopping", "ideas", "отчёт", "plan",
+		"books", "homework", "рецепты", "путешествие", "budget", "design", "задачи", "review",
+	}
+	for i := 0; i < 50000; i++ {
+		title := fmt.Sprintf("%s %s %d", words[i%len(words)], words[(i/len(words))%len(words)], i)
+		ix.Add(1, fmt.Sprint(i), title)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.Match(1, "projcet")
+	}
+}
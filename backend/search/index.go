@@ -0,0 +1,307 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Параметры ранжирования BM25 и вес совпадений в заголовке.
+const (
+	bm25K1       = 1.2
+	bm25B        = 0.75
+	titleBoost   = 2.5
+	prefixWeight = 0.8
+	phraseBoost  = 1.5
+)
+
+// Document — индексируемые поля заметки.
+type Document struct {
+	ID      uint64
+	OwnerID uint64
+	Title   string
+	Text    string
+}
+
+// Hit — найденный документ с релевантностью и словами, по которым он совпал.
+type Hit struct {
+	ID           uint64
+	Score        float64
+	MatchedTerms map[string]bool
+}
+
+type posting struct {
+	title []int
+	text  []int
+}
+
+type docStats struct {
+	length int
+	terms  []string
+}
+
+type ownerIndex struct {
+	postings    map[string]map[uint64]*posting
+	docs        map[uint64]*docStats
+	totalLength int
+}
+
+// Index — инвертированный индекс заметок, разбитый по владельцам.
+// Index не потокобезопасен: синхронизацию обеспечивает владелец индекса.
+type Index struct {
+	owners   map[uint64]*ownerIndex
+	docOwner map[uint64]uint64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		owners:   make(map[uint64]*ownerIndex),
+		docOwner: make(map[uint64]uint64),
+	}
+}
+
+// Add индексирует документ, заменяя предыдущую версию с тем же ID.
+func (ix *Index) Add(doc Document) {
+	ix.Remove(doc.ID)
+
+	owner, ok := ix.owners[doc.OwnerID]
+	if !ok {
+		owner = &ownerIndex{
+			postings: make(map[string]map[uint64]*posting),
+			docs:     make(map[uint64]*docStats),
+		}
+		ix.owners[doc.OwnerID] = owner
+	}
+
+	titleTokens := Analyze(doc.Title)
+	textTokens := Analyze(doc.Text)
+	stats := &docStats{length: len(titleTokens) + len(textTokens)}
+
+	entry := func(term string) *posting {
+		docs, ok := owner.postings[term]
+		if !ok {
+			docs = make(map[uint64]*posting)
+			owner.postings[term] = docs
+		}
+		p, ok := docs[doc.ID]
+		if !ok {
+			p = &posting{}
+			docs[doc.ID] = p
+			stats.terms = append(stats.terms, term)
+		}
+		return p
+	}
+	for _, token := range titleTokens {
+		p := entry(token.Term)
+		p.title = append(p.title, token.Position)
+	}
+	for _, token := range textTokens {
+		p := entry(token.Term)
+		p.text = append(p.text, token.Position)
+	}
+
+	owner.docs[doc.ID] = stats
+	owner.totalLength += stats.length
+	ix.docOwner[doc.ID] = doc.OwnerID
+}
+
+// Remove удаляет документ из индекса. Отсутствующий ID игнорируется.
+func (ix *Index) Remove(id uint64) {
+	ownerID, ok := ix.docOwner[id]
+	if !ok {
+		return
+	}
+	delete(ix.docOwner, id)
+
+	owner := ix.owners[ownerID]
+	stats := owner.docs[id]
+	for _, term := range stats.terms {
+		delete(owner.postings[term], id)
+		if len(owner.postings[term]) == 0 {
+			delete(owner.postings, term)
+		}
+	}
+	owner.totalLength -= stats.length
+	delete(owner.docs, id)
+
+	if len(owner.docs) == 0 {
+		delete(ix.owners, ownerID)
+	}
+}
+
+// Search возвращает документы владельца, удовлетворяющие всем условиям запроса,
+// по убыванию релевантности. limit <= 0 означает отсутствие ограничения.
+func (ix *Index) Search(ownerID uint64, query Query, limit int) []Hit {
+	owner, ok := ix.owners[ownerID]
+	if !ok || query.IsEmpty() {
+		return []Hit{}
+	}
+
+	var hits map[uint64]*Hit
+	for _, clause := range query.Clauses {
+		matches := owner.matchClause(clause)
+		if hits == nil {
+			hits = matches
+		} else {
+			for id, hit := range hits {
+				match, ok := matches[id]
+				if !ok {
+					delete(hits, id)
+					continue
+				}
+				hit.Score += match.Score
+				for term := range match.MatchedTerms {
+					hit.MatchedTerms[term] = true
+				}
+			}
+		}
+		if len(hits) == 0 {
+			return []Hit{}
+		}
+	}
+
+	result := make([]Hit, 0, len(hits))
+	for _, hit := range hits {
+		result = append(result, *hit)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].ID < result[j].ID
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+func (o *ownerIndex) matchClause(clause Clause) map[uint64]*Hit {
+	switch {
+	case clause.Phrase:
+		return o.matchPhrase(clause.Terms, clause.Offsets)
+	case clause.Prefix:
+		return o.matchPrefix(clause.Terms)
+	default:
+		return o.matchTerm(clause.Terms[0], 1)
+	}
+}
+
+func (o *ownerIndex) matchTerm(term string, weight float64) map[uint64]*Hit {
+	docs := o.postings[term]
+	hits := make(map[uint64]*Hit, len(docs))
+	idf := o.idf(len(docs))
+	for id, p := range docs {
+		hits[id] = &Hit{
+			ID:           id,
+			Score:        weight * idf * o.termWeight(id, p),
+			MatchedTerms: map[string]bool{term: true},
+		}
+	}
+	return hits
+}
+
+func (o *ownerIndex) matchPrefix(prefixes []string) map[uint64]*Hit {
+	hits := make(map[uint64]*Hit)
+	for term := range o.postings {
+		matched, exact := false, false
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(term, prefix) {
+				matched = true
+				exact = exact || term == prefix
+			}
+		}
+		if !matched {
+			continue
+		}
+		weight := prefixWeight
+		if exact {
+			weight = 1
+		}
+		for id, hit := range o.matchTerm(term, weight) {
+			if existing, ok := hits[id]; ok {
+				existing.Score += hit.Score
+				existing.MatchedTerms[term] = true
+				continue
+			}
+			hits[id] = hit
+		}
+	}
+	return hits
+}
+
+func (o *ownerIndex) matchPhrase(terms []string, offsets []int) map[uint64]*Hit {
+	var hits map[uint64]*Hit
+	for _, term := range terms {
+		matches := o.matchTerm(term, phraseBoost)
+		if hits == nil {
+			hits = matches
+			continue
+		}
+		for id, hit := range hits {
+			match, ok := matches[id]
+			if !ok {
+				delete(hits, id)
+				continue
+			}
+			hit.Score += match.Score
+			hit.MatchedTerms[term] = true
+		}
+	}
+
+	for id := range hits {
+		if !o.hasPhrase(id, terms, offsets) {
+			delete(hits, id)
+		}
+	}
+	return hits
+}
+
+func (o *ownerIndex) hasPhrase(id uint64, terms []string, offsets []int) bool {
+	positions := func(term string, title bool) []int {
+		p := o.postings[term][id]
+		if title {
+			return p.title
+		}
+		return p.text
+	}
+
+	for _, title := range []bool{true, false} {
+		for _, start := range positions(terms[0], title) {
+			found := true
+			for i, term := range terms[1:] {
+				if !containsInt(positions(term, title), start+offsets[i+1]) {
+					found = false
+					break
+				}
+			}
+			if found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (o *ownerIndex) idf(docFreq int) float64 {
+	n := float64(len(o.docs))
+	df := float64(docFreq)
+	return math.Log(1 + (n-df+0.5)/(df+0.5))
+}
+
+func (o *ownerIndex) termWeight(id uint64, p *posting) float64 {
+	tf := titleBoost*float64(len(p.title)) + float64(len(p.text))
+	avgLength := float64(o.totalLength) / float64(len(o.docs))
+	length := float64(o.docs[id].length)
+	norm := 1.0
+	if avgLength > 0 {
+		norm = 1 - bm25B + bm25B*length/avgLength
+	}
+	return tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+}
+
+func containsInt(values []int, target int) bool {
+	i := sort.SearchInts(values, target)
+	return i < len(values) && values[i] == target
+}
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"unicode"
+
+	"github.com/kljensen/snowball/english"
+	"github.com/kljensen/snowball/russian"
+)
+
+type Language int
+
+const (
+	LanguageUnknown Language = iota
+	LanguageRussian
+	LanguageEnglish
+)
+
+// DetectLanguage определяет язык по алфавиту: кириллица — русский, латиница — английский.
+// Смешанный текст относится к языку, букв которого больше.
+func DetectLanguage(text string) Language {
+	cyrillic, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case cyrillic == 0 && latin == 0:
+		return LanguageUnknown
+	case cyrillic >= latin:
+		return LanguageRussian
+	default:
+		return LanguageEnglish
+	}
+}
+
+func isStopWord(word string, lang Language) bool {
+	switch lang {
+	case LanguageRussian:
+		return russian.IsStopWord(word)
+	case LanguageEnglish:
+		return english.IsStopWord(word)
+	default:
+		return false
+	}
+}
+
+// stem приводит нормализованное слово к основе. Основы русских слов тоже
+// нормализуются, так как стеммер может вернуть ё из исходного слова.
+func stem(word string, lang Language) string {
+	switch lang {
+	case LanguageRussian:
+		return normalize(russian.Stem(word, true))
+	case LanguageEnglish:
+		return english.Stem(word, true)
+	default:
+		return word
+	}
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// morphologyCorpus — заметки, в которых слова запросов встречаются в других словоформах.
+var morphologyCorpus = []Document{
+	{ID: 1, Title: "Список книг", Text: "Купить новые книги в книжном магазине на Арбате"},
+	{ID: 2, Title: "Домашнее задание", Text: "Написать сочинение о русской литературе и прочитать главу учебника"},
+	{ID: 3, Title: "Ёлка", Text: "Нарядить ёлку к Новому году, купить гирлянды"},
+	{ID: 4, Title: "Встречи", Text: "Встретился с преподавателем, обсудили проекты студентов"},
+	{ID: 5, Title: "Running plan", Text: "I ran five miles and will be running again tomorrow"},
+	{ID: 6, Title: "Films to watch", Text: "The Lord of the Rings, Harry Potter and other movies"},
+	{ID: 7, Title: "Recipes", Text: "Baked apples with cinnamon; the apple pie recipe"},
+	{ID: 8, Title: "Заметки по проекту", Text: "Project deadline и список задач для команды"},
+}
+
+func TestMorphologyRecall(t *testing.T) {
+	ix := NewIndex()
+	for _, doc := range morphologyCorpus {
+		ix.Add(doc)
+	}
+
+	tests := []struct {
+		query string
+		want  []uint64
+	}{
+		{query: "книга", want: []uint64{1}},
+		{query: "книгами", want: []uint64{1}},
+		{query: "магазин", want: []uint64{1}},
+		{query: "сочинения", want: []uint64{2}},
+		{query: "литература", want: []uint64{2}},
+		{query: "учебник", want: []uint64{2}},
+		{query: "елка", want: []uint64{3}},
+		{query: "ёлки", want: []uint64{3}},
+		{query: "гирлянда", want: []uint64{3}},
+		{query: "преподаватель", want: []uint64{4}},
+		{query: "проект", want: []uint64{4, 8}},
+		{query: "студент", want: []uint64{4}},
+		{query: "run", want: []uint64{5}},
+		{query: "movie", want: []uint64{6}},
+		{query: "apple", want: []uint64{7}},
+		{query: `"lord of the rings"`, want: []uint64{6}},
+		{query: "projects", want: []uint64{8}},
+		{query: "задача команда", want: []uint64{8}},
+		{query: "книги*", want: []uint64{1}},
+	}
+
+	relevant, found := 0, 0
+	for _, test := range tests {
+		hits := hitIDs(ix.Search(0, ParseQuery(test.query), 0))
+		t.Run(test.query, func(t *testing.T) {
+			require.ElementsMatch(t, test.want, hits)
+		})
+
+		relevant += len(test.want)
+		for _, id := range hits {
+			for _, want := range test.want {
+				if id == want {
+					found++
+				}
+			}
+		}
+	}
+	require.Equal(t, relevant, found, "every relevant note must be found")
+}
+
+func TestStopWordsIgnored(t *testing.T) {
+	ix := NewIndex()
+	for _, doc := range morphologyCorpus {
+		ix.Add(doc)
+	}
+
+	require.Empty(t, ix.Search(0, ParseQuery("the"), 0))
+	require.Empty(t, ix.Search(0, ParseQuery("и"), 0))
+	require.Equal(t, []uint64{1}, hitIDs(ix.Search(0, ParseQuery("книги в магазине"), 0)))
+}
+
+func TestDetectLanguage(t *testing.T) {
+	require.Equal(t, LanguageRussian, DetectLanguage("заметка"))
+	require.Equal(t, LanguageEnglish, DetectLanguage("note"))
+	require.Equal(t, LanguageUnknown, DetectLanguage("2025"))
+	require.Equal(t, LanguageRussian, DetectLanguage("Project дедлайн по проекту"))
+}
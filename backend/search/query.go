@@ -0,0 +1,110 @@
+package search
+
+import (
+	"strings"
+)
+
+// Clause — одно условие запроса. Документ должен удовлетворять всем условиям.
+type Clause struct {
+	// Terms содержит одно слово для обычного условия, слова фразы по порядку
+	// или варианты префикса (как введён и его основу) для префиксного условия.
+	Terms []string
+	// Offsets — позиции слов фразы относительно первого слова с учётом стоп-слов.
+	Offsets []int
+	Phrase  bool
+	Prefix  bool
+}
+
+// Query — разобранный поисковый запрос. Структура не зависит от хранилища,
+// так что её может транслировать и in-memory индекс, и SQL-репозиторий.
+type Query struct {
+	Clauses []Clause
+}
+
+func (q Query) IsEmpty() bool {
+	return len(q.Clauses) == 0
+}
+
+// ParseQuery разбирает строку запроса:
+//   - слова в двойных кавычках ищутся как фраза;
+//   - слово, оканчивающееся на '*', ищется по префиксу;
+//   - остальные слова должны встречаться в заметке в любом порядке.
+func ParseQuery(raw string) Query {
+	var query Query
+
+	for raw != "" {
+		raw = strings.TrimLeft(raw, " \t\r\n")
+		if raw == "" {
+			break
+		}
+
+		if raw[0] == '"' {
+			phrase := raw[1:]
+			end := strings.IndexByte(phrase, '"')
+			if end < 0 {
+				end = len(phrase)
+				raw = ""
+			} else {
+				raw = phrase[end+1:]
+			}
+			query.addPhrase(phrase[:end])
+			continue
+		}
+
+		end := strings.IndexAny(raw, " \t\r\n\"")
+		if end < 0 {
+			end = len(raw)
+		}
+		word := raw[:end]
+		raw = raw[end:]
+
+		if strings.HasSuffix(word, "*") {
+			query.addPrefix(strings.TrimRight(word, "*"))
+			continue
+		}
+		query.addWords(word)
+	}
+
+	return query
+}
+
+func (q *Query) addPhrase(text string) {
+	tokens := Analyze(text)
+	switch len(tokens) {
+	case 0:
+		return
+	case 1:
+		q.Clauses = append(q.Clauses, Clause{Terms: []string{tokens[0].Term}})
+	default:
+		terms := make([]string, 0, len(tokens))
+		offsets := make([]int, 0, len(tokens))
+		for _, token := range tokens {
+			terms = append(terms, token.Term)
+			offsets = append(offsets, token.Position-tokens[0].Position)
+		}
+		q.Clauses = append(q.Clauses, Clause{Terms: terms, Offsets: offsets, Phrase: true})
+	}
+}
+
+// addPrefix добавляет префиксное условие для последнего слова. Индекс хранит основы,
+// поэтому кроме введённого префикса ищется и его основа: «книги*» найдёт «книга».
+func (q *Query) addPrefix(text string) {
+	words := splitWords(text)
+	if len(words) == 0 {
+		return
+	}
+	last := words[len(words)-1]
+	q.addWords(text[:last.Start])
+
+	terms := []string{last.Term}
+	if stemmed := stem(last.Term, DetectLanguage(last.Term)); stemmed != last.Term {
+		terms = append(terms, stemmed)
+	}
+	q.Clauses = append(q.Clauses, Clause{Terms: terms, Prefix: true})
+}
+
+func (q *Query) addWords(text string) {
+	for _, token := range Analyze(text) {
+		q.Clauses = append(q.Clauses, Clause{Terms: []string{token.Term}})
+	}
+}
@@ -0,0 +1,132 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIndex() *Index {
+	ix := NewIndex()
+	docs := []Document{
+		{ID: 1, OwnerID: 1, Title: "Math lecture", Text: "Linear algebra and calculus notes"},
+		{ID: 2, OwnerID: 1, Title: "Shopping list", Text: "Milk, bread and a math textbook"},
+		{ID: 3, OwnerID: 1, Title: "Project", Text: "Calculus homework due on Monday"},
+		{ID: 4, OwnerID: 2, Title: "Math", Text: "Another user's math note"},
+	}
+	for _, doc := range docs {
+		ix.Add(doc)
+	}
+	return ix
+}
+
+func hitIDs(hits []Hit) []uint64 {
+	ids := make([]uint64, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		want      []uint64
+		unordered bool
+	}{
+		{
+			name:  "title match ranks first",
+			query: "math",
+			want:  []uint64{1, 2},
+		},
+		{
+			name:  "all words required",
+			query: "calculus homework",
+			want:  []uint64{3},
+		},
+		{
+			name:  "phrase",
+			query: `"linear algebra"`,
+			want:  []uint64{1},
+		},
+		{
+			name:  "phrase words out of order",
+			query: `"algebra linear"`,
+			want:  []uint64{},
+		},
+		{
+			name:      "prefix",
+			query:     "calc*",
+			want:      []uint64{1, 3},
+			unordered: true,
+		},
+		{
+			name:  "case insensitive",
+			query: "MILK",
+			want:  []uint64{2},
+		},
+		{
+			name:  "no match",
+			query: "physics",
+			want:  []uint64{},
+		},
+		{
+			name:  "empty query",
+			query: "  ",
+			want:  []uint64{},
+		},
+	}
+
+	ix := newTestIndex()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hits := ix.Search(1, ParseQuery(test.query), 0)
+			if test.unordered {
+				require.ElementsMatch(t, test.want, hitIDs(hits))
+				return
+			}
+			require.Equal(t, test.want, hitIDs(hits))
+		})
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	ix := newTestIndex()
+
+	ix.Add(Document{ID: 2, OwnerID: 1, Title: "Groceries", Text: "Eggs"})
+	require.Equal(t, []uint64{1}, hitIDs(ix.Search(1, ParseQuery("math"), 0)))
+	require.Equal(t, []uint64{2}, hitIDs(ix.Search(1, ParseQuery("eggs"), 0)))
+
+	ix.Remove(1)
+	require.Empty(t, ix.Search(1, ParseQuery("math"), 0))
+	require.Equal(t, []uint64{4}, hitIDs(ix.Search(2, ParseQuery("math"), 0)))
+
+	ix.Remove(100)
+	require.Len(t, ix.Search(1, ParseQuery("calculus"), 10), 1)
+}
+
+func TestParseQuery(t *testing.T) {
+	query := ParseQuery(`"Lord of the Rings" calc* Notes книги* "unterminated phrase`)
+	require.Equal(t, []Clause{
+		{Terms: []string{"lord", "ring"}, Offsets: []int{0, 3}, Phrase: true},
+		{Terms: []string{"calc"}, Prefix: true},
+		{Terms: []string{"note"}},
+		{Terms: []string{"книги", "книг"}, Prefix: true},
+		{Terms: []string{"untermin", "phrase"}, Offsets: []int{0, 1}, Phrase: true},
+	}, query.Clauses)
+}
+
+func TestSnippet(t *testing.T) {
+	terms := map[string]bool{"bread": true}
+
+	require.Equal(t, "Milk, <mark>bread</mark> &amp; eggs", Snippet("Milk, bread & eggs", terms))
+
+	long := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen " +
+		"fifteen sixteen seventeen eighteen nineteen twenty a b c d e f g h i j bread twentyone twentytwo twentythree " +
+		"twentyfour twentyfive twentysix twentyseven twentyeight twentynine thirty"
+	snippet := Snippet(long, terms)
+	require.Contains(t, snippet, "<mark>bread</mark>")
+	require.Equal(t, ellipsis, snippet[:len(ellipsis)])
+	require.NotContains(t, snippet, "one two")
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	snippetTokens = 24
+	markOpen      = "<mark>"
+	markClose     = "</mark>"
+	ellipsis      = "…"
+)
+
+// Highlight экранирует текст как HTML и оборачивает совпавшие слова в <mark>.
+func Highlight(text string, terms map[string]bool) string {
+	return highlightRange(text, Analyze(text), 0, len(text), terms)
+}
+
+// Snippet возвращает фрагмент текста длиной до snippetTokens слов, содержащий
+// наибольшее число совпадений, с подсветкой совпавших слов.
+func Snippet(text string, terms map[string]bool) string {
+	tokens := Analyze(text)
+	if len(tokens) <= snippetTokens {
+		return Highlight(text, terms)
+	}
+
+	bestStart, bestCount, count := 0, -1, 0
+	for i, token := range tokens {
+		if terms[token.Term] {
+			count++
+		}
+		if i >= snippetTokens && terms[tokens[i-snippetTokens].Term] {
+			count--
+		}
+		if i >= snippetTokens-1 && count > bestCount {
+			bestStart, bestCount = i-snippetTokens+1, count
+		}
+	}
+
+	window := tokens[bestStart : bestStart+snippetTokens]
+	start, end := window[0].Start, window[len(window)-1].End
+
+	var b strings.Builder
+	if bestStart > 0 {
+		b.WriteString(ellipsis)
+	}
+	b.WriteString(highlightRange(text, window, start, end, terms))
+	if bestStart+snippetTokens < len(tokens) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+func highlightRange(text string, tokens []Token, start, end int, terms map[string]bool) string {
+	var b strings.Builder
+	cursor := start
+	for _, token := range tokens {
+		if !terms[token.Term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[cursor:token.Start]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[token.Start:token.End]))
+		b.WriteString(markClose)
+		cursor = token.End
+	}
+	b.WriteString(html.EscapeString(text[cursor:end]))
+	return b.String()
+}
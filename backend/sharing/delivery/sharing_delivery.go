@@ -0,0 +1,218 @@
+package sharingDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/validation"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type SharingUsecase interface {
+	ShareNote(userID, actorID, noteID uint64, email, role string) (*models.NoteShare, error)
+	ListShares(userID, actorID, noteID uint64) ([]models.NoteShare, error)
+	UpdateShare(userID, actorID, noteID, shareID uint64, role string) (*models.NoteShare, error)
+	RevokeShare(userID, actorID, noteID, shareID uint64) error
+	SharedWithMe(userID, actorID uint64) ([]models.SharedNote, error)
+}
+
+type SharingDelivery struct {
+	Usecase SharingUsecase
+}
+
+func NewSharingDelivery(usecase SharingUsecase) *SharingDelivery {
+	return &SharingDelivery{
+		Usecase: usecase,
+	}
+}
+
+type shareRequest struct {
+	Email string `json:"email" valid:"required,email"`
+	Role  string `json:"role" valid:"required,in(viewer|commenter|editor)"`
+}
+
+type shareRoleRequest struct {
+	Role string `json:"role" valid:"required,in(viewer|commenter|editor)"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseNoteVars читает владельца и note_id из пути и пользователя сессии,
+// при ошибке сам пишет ответ.
+func parseNoteVars(w http.ResponseWriter, r *http.Request) (userID, actorID, noteID uint64, ok bool) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, 0, false
+	}
+	noteID, err = parseUintVar(r, "note_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return 0, 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, 0, false
+	}
+	return userID, actorID, noteID, true
+}
+
+func (d *SharingDelivery) ShareNote(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req shareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	share, err := d.Usecase.ShareNote(userID, actorID, noteID, req.Email, req.Role)
+	if errors.Is(err, namederrors.ErrSelfShare) {
+		apiutils.WriteError(w, http.StatusBadRequest, "cannot share note with its owner")
+		return
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to share note")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, share)
+}
+
+func (d *SharingDelivery) ListShares(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+
+	shares, err := d.Usecase.ListShares(userID, actorID, noteID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list shares")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, shares)
+}
+
+func (d *SharingDelivery) UpdateShare(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	shareID, err := parseUintVar(r, "share_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid share ID")
+		return
+	}
+
+	var req shareRoleRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	share, err := d.Usecase.UpdateShare(userID, actorID, noteID, shareID, req.Role)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "share not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to update share")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, share)
+}
+
+func (d *SharingDelivery) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	userID, actorID, noteID, ok := parseNoteVars(w, r)
+	if !ok {
+		return
+	}
+	shareID, err := parseUintVar(r, "share_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid share ID")
+		return
+	}
+
+	err = d.Usecase.RevokeShare(userID, actorID, noteID, shareID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "share not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to revoke share")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func (d *SharingDelivery) SharedWithMe(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUintVar(r, "user_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	notes, err := d.Usecase.SharedWithMe(userID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list shared notes")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, notes)
+}
@@ -0,0 +1,83 @@
+package sharingRepository
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/store"
+	"fmt"
+)
+
+type SharingRepository struct {
+	Store *store.Store
+}
+
+func NewSharingRepository(store *store.Store) *SharingRepository {
+	return &SharingRepository{
+		Store: store,
+	}
+}
+
+func (r *SharingRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, err := r.Store.GetNote(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	return &note, nil
+}
+
+func (r *SharingRepository) GetUserByEmail(email string) (*models.User, error) {
+	user, ok := r.Store.GetUserByEmail(email)
+	if !ok {
+		return nil, fmt.Errorf("failed to get user by email: %w", namederrors.ErrNotFound)
+	}
+	return &user, nil
+}
+
+func (r *SharingRepository) ShareNote(share models.NoteShare) (*models.NoteShare, error) {
+	shared, err := r.Store.ShareNote(share)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share note: %w", err)
+	}
+	return &shared, nil
+}
+
+func (r *SharingRepository) GetShare(shareID uint64) (*models.NoteShare, error) {
+	share, err := r.Store.GetShare(shareID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share: %w", err)
+	}
+	return &share, nil
+}
+
+func (r *SharingRepository) GetNoteShare(noteID, userID uint64) (*models.NoteShare, error) {
+	share, err := r.Store.GetNoteShare(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note share: %w", err)
+	}
+	return &share, nil
+}
+
+func (r *SharingRepository) ListNoteShares(noteID uint64) ([]models.NoteShare, error) {
+	shares := r.Store.ListNoteShares(noteID)
+	return shares, nil
+}
+
+func (r *SharingRepository) ListSharedWith(userID uint64) ([]models.NoteShare, error) {
+	shares := r.Store.ListSharedWith(userID)
+	return shares, nil
+}
+
+func (r *SharingRepository) UpdateShareRole(shareID uint64, role string) (*models.NoteShare, error) {
+	share, err := r.Store.UpdateShareRole(shareID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update share: %w", err)
+	}
+	return &share, nil
+}
+
+func (r *SharingRepository) DeleteShare(shareID uint64) error {
+	if err := r.Store.DeleteShare(shareID); err != nil {
+		return fmt.Errorf("failed to delete share: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package sharingUsecase
+
+import (
+	"backend/authz"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+type SharingRepository interface {
+	GetNote(noteID uint64) (*models.Note, error)
+	GetUserByEmail(email string) (*models.User, error)
+	ShareNote(share models.NoteShare) (*models.NoteShare, error)
+	GetShare(shareID uint64) (*models.NoteShare, error)
+	ListNoteShares(noteID uint64) ([]models.NoteShare, error)
+	ListSharedWith(userID uint64) ([]models.NoteShare, error)
+	UpdateShareRole(shareID uint64, role string) (*models.NoteShare, error)
+	DeleteShare(shareID uint64) error
+}
+
+type Authorizer interface {
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// Notifier уведомляет пользователя о выданном ему доступе к заметке.
+type Notifier interface {
+	NoteShared(actorID uint64, note *models.Note, share *models.NoteShare)
+}
+
+type SharingUsecase struct {
+	Repository SharingRepository
+	Authorizer Authorizer
+	Notifier   Notifier
+}
+
+func NewSharingUsecase(repository SharingRepository, authorizer Authorizer, notifier Notifier) *SharingUsecase {
+	return &SharingUsecase{
+		Repository: repository,
+		Authorizer: authorizer,
+		Notifier:   notifier,
+	}
+}
+
+// ShareNote выдаёт доступ к заметке пользователю с указанным email. Если
+// такого пользователя ещё нет, приглашение ждёт его регистрации.
+func (u *SharingUsecase) ShareNote(ownerID, actorID, noteID uint64, email, role string) (*models.NoteShare, error) {
+	note, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share note: %w", err)
+	}
+
+	share := models.NoteShare{
+		NoteID:    noteID,
+		OwnerID:   ownerID,
+		Email:     strings.TrimSpace(email),
+		Role:      role,
+		CreatedBy: actorID,
+	}
+	user, err := u.Repository.GetUserByEmail(share.Email)
+	switch {
+	case err == nil:
+		if user.ID == ownerID {
+			return nil, fmt.Errorf("failed to share note: %w", namederrors.ErrSelfShare)
+		}
+		share.UserID = user.ID
+		share.Email = user.Email
+	case errors.Is(err, namederrors.ErrNotFound):
+		log.Info().Uint64("note_id", noteID).Str("email", share.Email).Msg("note shared with unregistered email, invitation is pending")
+	default:
+		return nil, fmt.Errorf("failed to share note: %w", err)
+	}
+
+	shared, err := u.Repository.ShareNote(share)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share note: %w", err)
+	}
+	if u.Notifier != nil {
+		u.Notifier.NoteShared(actorID, note, shared)
+	}
+	return shared, nil
+}
+
+func (u *SharingUsecase) ListShares(ownerID, actorID, noteID uint64) ([]models.NoteShare, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+
+	shares, err := u.Repository.ListNoteShares(noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+	return shares, nil
+}
+
+func (u *SharingUsecase) getNoteShare(noteID, shareID uint64) (*models.NoteShare, error) {
+	share, err := u.Repository.GetShare(shareID)
+	if err != nil {
+		return nil, err
+	}
+	if share.NoteID != noteID {
+		return nil, namederrors.ErrNotFound
+	}
+	return share, nil
+}
+
+func (u *SharingUsecase) UpdateShare(ownerID, actorID, noteID, shareID uint64, role string) (*models.NoteShare, error) {
+	if _, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to update share: %w", err)
+	}
+	if _, err := u.getNoteShare(noteID, shareID); err != nil {
+		return nil, fmt.Errorf("failed to update share: %w", err)
+	}
+
+	share, err := u.Repository.UpdateShareRole(shareID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update share: %w", err)
+	}
+	return share, nil
+}
+
+// RevokeShare отзывает доступ. Кроме владельца, отказаться от доступа может
+// сам пользователь, которому он выдан.
+func (u *SharingUsecase) RevokeShare(ownerID, actorID, noteID, shareID uint64) error {
+	share, err := u.getNoteShare(noteID, shareID)
+	if err != nil || share.OwnerID != ownerID {
+		return fmt.Errorf("failed to revoke share: %w", namederrors.ErrNotFound)
+	}
+	if share.UserID == 0 || share.UserID != actorID {
+		if _, _, err = u.Authorizer.AuthorizeNote(ownerID, actorID, noteID, models.RoleOwner); err != nil {
+			return fmt.Errorf("failed to revoke share: %w", err)
+		}
+	}
+
+	if err = u.Repository.DeleteShare(shareID); err != nil {
+		return fmt.Errorf("failed to revoke share: %w", err)
+	}
+	return nil
+}
+
+// SharedWithMe возвращает чужие заметки, доступные пользователю. Заметки в
+// корзине владельца не показываются.
+func (u *SharingUsecase) SharedWithMe(userID, actorID uint64) ([]models.SharedNote, error) {
+	if err := authz.CheckSelf(userID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to list shared notes: %w", err)
+	}
+
+	shares, err := u.Repository.ListSharedWith(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shared notes: %w", err)
+	}
+
+	notes := make([]models.SharedNote, 0, len(shares))
+	for _, share := range shares {
+		note, err := u.Repository.GetNote(share.NoteID)
+		if errors.Is(err, namederrors.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list shared notes: %w", err)
+		}
+		notes = append(notes, models.SharedNote{Note: *note, Role: share.Role, ShareID: share.ID})
+	}
+	return notes, nil
+}
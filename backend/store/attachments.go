@@ -0,0 +1,145 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"slices"
+	"time"
+)
+
+// CreateAttachment сохраняет сведения о вложении, если оно помещается в квоту
+// владельца заметки. Нулевая quota место не ограничивает.
+func (s *Store) CreateAttachment(attachment models.Attachment, quota int64) (models.Attachment, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	note, ok := s.activeNote(attachment.NoteID)
+	if !ok {
+		return models.Attachment{}, namederrors.ErrNotFound
+	}
+	if quota > 0 && s.storageUsed[note.OwnerID]+attachment.Size > quota {
+		return models.Attachment{}, namederrors.ErrQuotaExceeded
+	}
+
+	attachment.ID = s.nextAttachmentID
+	s.nextAttachmentID++
+	attachment.OwnerID = note.OwnerID
+	attachment.CreatedAt = time.Now().UTC()
+
+	stored := attachment
+	s.attachments[stored.ID] = &stored
+	s.noteAttachments[stored.NoteID] = append(s.noteAttachments[stored.NoteID], stored.ID)
+	s.storageUsed[stored.OwnerID] += stored.Size
+
+	return attachment, nil
+}
+
+func (s *Store) GetAttachment(attachmentID uint64) (models.Attachment, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	attachment, ok := s.attachments[attachmentID]
+	if !ok {
+		return models.Attachment{}, namederrors.ErrNotFound
+	}
+	return *attachment, nil
+}
+
+// ListAttachments возвращает вложения заметки в порядке загрузки.
+func (s *Store) ListAttachments(noteID uint64) []models.Attachment {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	attachments := make([]models.Attachment, 0, len(s.noteAttachments[noteID]))
+	for _, attachmentID := range s.noteAttachments[noteID] {
+		attachments = append(attachments, *s.attachments[attachmentID])
+	}
+	return attachments
+}
+
+// DeleteAttachment удаляет сведения о вложении и возвращает их, чтобы
+// вызывающий удалил содержимое из хранилища файлов.
+func (s *Store) DeleteAttachment(attachmentID uint64) (models.Attachment, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	attachment, ok := s.attachments[attachmentID]
+	if !ok {
+		return models.Attachment{}, namederrors.ErrNotFound
+	}
+	s.removeAttachment(attachment)
+	s.noteAttachments[attachment.NoteID] = slices.DeleteFunc(s.noteAttachments[attachment.NoteID], func(id uint64) bool {
+		return id == attachmentID
+	})
+	if len(s.noteAttachments[attachment.NoteID]) == 0 {
+		delete(s.noteAttachments, attachment.NoteID)
+	}
+
+	return *attachment, nil
+}
+
+// SetAttachmentImage сохраняет результат обработки изображения. size —
+// размер содержимого после обработки: повёрнутое изображение перекодируется.
+func (s *Store) SetAttachmentImage(attachmentID uint64, image models.AttachmentImage, size int64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	attachment, ok := s.attachments[attachmentID]
+	if !ok {
+		return namederrors.ErrNotFound
+	}
+	// Image заменяется целиком и не меняется на месте, поэтому копии вложения
+	// могут разделять его.
+	image.Variants = slices.Clone(image.Variants)
+	attachment.Image = &image
+	s.storageUsed[attachment.OwnerID] += size - attachment.Size
+	attachment.Size = size
+
+	return nil
+}
+
+// StorageUsed возвращает место, занятое вложениями заметок владельца, в байтах.
+// Вложения заметок в корзине учитываются до их окончательного удаления.
+func (s *Store) StorageUsed(ownerID uint64) int64 {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	return s.storageUsed[ownerID]
+}
+
+// TakeOrphanBlobs возвращает и забывает ключи содержимого вложений удалённых
+// заметок, которое осталось удалить из хранилища файлов.
+func (s *Store) TakeOrphanBlobs() []string {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	keys := s.orphanBlobs
+	s.orphanBlobs = nil
+	return keys
+}
+
+// removeAttachment забывает вложение и освобождает его место в квоте.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) removeAttachment(attachment *models.Attachment) {
+	delete(s.attachments, attachment.ID)
+	s.storageUsed[attachment.OwnerID] -= attachment.Size
+	if s.storageUsed[attachment.OwnerID] <= 0 {
+		delete(s.storageUsed, attachment.OwnerID)
+	}
+}
+
+// removeNoteAttachments удаляет вложения заметки и откладывает удаление их
+// содержимого. Вызывается под блокировкой s.Mu.
+func (s *Store) removeNoteAttachments(noteID uint64) {
+	for _, attachmentID := range s.noteAttachments[noteID] {
+		attachment := s.attachments[attachmentID]
+		s.removeAttachment(attachment)
+		s.orphanBlobs = append(s.orphanBlobs, attachment.Key)
+		if attachment.Image != nil {
+			for _, variant := range attachment.Image.Variants {
+				s.orphanBlobs = append(s.orphanBlobs, variant.Key)
+			}
+		}
+	}
+	delete(s.noteAttachments, noteID)
+}
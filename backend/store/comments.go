@@ -0,0 +1,202 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/textdiff"
+	"slices"
+	"time"
+)
+
+// CreateThread открывает обсуждение заметки первым комментарием. Диапазон
+// привязки проверяется по текущему тексту заметки, цитата берётся из него же.
+func (s *Store) CreateThread(thread models.CommentThread, comment models.Comment) (models.CommentThread, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	note, ok := s.activeNote(thread.NoteID)
+	if !ok {
+		return models.CommentThread{}, namederrors.ErrNotFound
+	}
+	if thread.Anchor != nil {
+		text := []rune(note.Text)
+		anchor := *thread.Anchor
+		if anchor.Start < 0 || anchor.Start >= anchor.End || anchor.End > len(text) {
+			return models.CommentThread{}, namederrors.ErrInvalidAnchor
+		}
+		anchor.Quote = string(text[anchor.Start:anchor.End])
+		thread.Anchor = &anchor
+	}
+
+	now := time.Now().UTC()
+	thread.ID = s.nextThreadID
+	s.nextThreadID++
+	thread.OwnerID = note.OwnerID
+	thread.CreatedAt = now
+	thread.UpdatedAt = now
+
+	comment.ID = s.nextCommentID
+	s.nextCommentID++
+	comment.ThreadID = thread.ID
+	comment.CreatedAt = now
+	thread.Comments = []models.Comment{comment}
+
+	stored := thread
+	s.threads[stored.ID] = &stored
+	s.noteThreads[stored.NoteID] = append(s.noteThreads[stored.NoteID], stored.ID)
+
+	return copyThread(&stored), nil
+}
+
+func (s *Store) GetThread(threadID uint64) (models.CommentThread, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return models.CommentThread{}, namederrors.ErrNotFound
+	}
+	return copyThread(thread), nil
+}
+
+// ListThreads возвращает обсуждения заметки в порядке создания.
+func (s *Store) ListThreads(noteID uint64) []models.CommentThread {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	threads := make([]models.CommentThread, 0, len(s.noteThreads[noteID]))
+	for _, threadID := range s.noteThreads[noteID] {
+		threads = append(threads, copyThread(s.threads[threadID]))
+	}
+	return threads
+}
+
+func (s *Store) AddComment(threadID uint64, comment models.Comment) (models.Comment, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return models.Comment{}, namederrors.ErrNotFound
+	}
+
+	comment.ID = s.nextCommentID
+	s.nextCommentID++
+	comment.ThreadID = threadID
+	comment.CreatedAt = time.Now().UTC()
+	thread.Comments = append(thread.Comments, comment)
+	thread.UpdatedAt = comment.CreatedAt
+
+	return comment, nil
+}
+
+func (s *Store) UpdateComment(threadID, commentID uint64, text string) (models.Comment, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return models.Comment{}, namederrors.ErrNotFound
+	}
+	i := slices.IndexFunc(thread.Comments, func(comment models.Comment) bool {
+		return comment.ID == commentID
+	})
+	if i < 0 {
+		return models.Comment{}, namederrors.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	thread.Comments[i].Text = text
+	thread.Comments[i].EditedAt = &now
+	thread.UpdatedAt = now
+
+	return thread.Comments[i], nil
+}
+
+// DeleteComment удаляет комментарий. Обсуждение без комментариев удаляется
+// целиком, об этом сообщает threadDeleted.
+func (s *Store) DeleteComment(threadID, commentID uint64) (threadDeleted bool, err error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return false, namederrors.ErrNotFound
+	}
+	i := slices.IndexFunc(thread.Comments, func(comment models.Comment) bool {
+		return comment.ID == commentID
+	})
+	if i < 0 {
+		return false, namederrors.ErrNotFound
+	}
+
+	thread.Comments = slices.Delete(thread.Comments, i, i+1)
+	if len(thread.Comments) > 0 {
+		thread.UpdatedAt = time.Now().UTC()
+		return false, nil
+	}
+
+	delete(s.threads, threadID)
+	s.noteThreads[thread.NoteID] = slices.DeleteFunc(s.noteThreads[thread.NoteID], func(id uint64) bool {
+		return id == threadID
+	})
+	return true, nil
+}
+
+// SetThreadResolved закрывает обсуждение от имени userID или открывает его снова.
+func (s *Store) SetThreadResolved(threadID uint64, resolved bool, userID uint64) (models.CommentThread, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	thread, ok := s.threads[threadID]
+	if !ok {
+		return models.CommentThread{}, namederrors.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	thread.Resolved = resolved
+	thread.ResolvedBy = 0
+	thread.ResolvedAt = nil
+	if resolved {
+		thread.ResolvedBy = userID
+		thread.ResolvedAt = &now
+	}
+	thread.UpdatedAt = now
+
+	return copyThread(thread), nil
+}
+
+// reanchorThreads переносит привязки обсуждений заметки из старого текста в
+// новый. Обсуждение, чей фрагмент не найден, помечается устаревшим и сохраняет
+// последнюю привязку. Вызывается под блокировкой s.Mu.
+func (s *Store) reanchorThreads(noteID uint64, oldText, newText string) {
+	if oldText == newText {
+		return
+	}
+	for _, threadID := range s.noteThreads[noteID] {
+		thread := s.threads[threadID]
+		if thread.Anchor == nil || thread.Outdated {
+			continue
+		}
+		start, end, ok := textdiff.MapRange(oldText, newText, thread.Anchor.Start, thread.Anchor.End, thread.Anchor.Quote)
+		if !ok {
+			thread.Outdated = true
+			continue
+		}
+		thread.Anchor = &models.CommentAnchor{Start: start, End: end, Quote: thread.Anchor.Quote}
+	}
+}
+
+// removeNoteThreads удаляет обсуждения заметки. Вызывается под блокировкой s.Mu.
+func (s *Store) removeNoteThreads(noteID uint64) {
+	for _, threadID := range s.noteThreads[noteID] {
+		delete(s.threads, threadID)
+	}
+	delete(s.noteThreads, noteID)
+}
+
+func copyThread(thread *models.CommentThread) models.CommentThread {
+	copied := *thread
+	copied.Comments = slices.Clone(thread.Comments)
+	return copied
+}
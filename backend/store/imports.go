@@ -0,0 +1,79 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+// cloneImportJob копирует задачу вместе со списками, чтобы ход работы можно
+// было читать, пока задача выполняется.
+func cloneImportJob(job models.ImportJob) models.ImportJob {
+	job.NoteIDs = slices.Clone(job.NoteIDs)
+	job.Errors = slices.Clone(job.Errors)
+	return job
+}
+
+// CreateImportJob сохраняет задачу переноса заметок в очереди.
+func (s *Store) CreateImportJob(job models.ImportJob) models.ImportJob {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	job.ID = s.nextImportJobID
+	s.nextImportJobID++
+	job.Status = models.ImportQueued
+	job.CreatedAt = time.Now().UTC()
+
+	stored := cloneImportJob(job)
+	s.importJobs[stored.ID] = &stored
+	return job
+}
+
+func (s *Store) GetImportJob(jobID uint64) (models.ImportJob, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	job, ok := s.importJobs[jobID]
+	if !ok {
+		return models.ImportJob{}, namederrors.ErrNotFound
+	}
+	return cloneImportJob(*job), nil
+}
+
+// ListImportJobs возвращает задачи владельца, новые первыми.
+func (s *Store) ListImportJobs(ownerID uint64) []models.ImportJob {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	jobs := []models.ImportJob{}
+	for _, job := range s.importJobs {
+		if job.OwnerID == ownerID {
+			jobs = append(jobs, cloneImportJob(*job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID > jobs[j].ID
+	})
+	return jobs
+}
+
+// UpdateImportJob сохраняет состояние и ход работы задачи.
+func (s *Store) UpdateImportJob(job models.ImportJob) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	stored, ok := s.importJobs[job.ID]
+	if !ok {
+		return namederrors.ErrNotFound
+	}
+	stored.Status = job.Status
+	stored.Total = job.Total
+	stored.Processed = job.Processed
+	stored.NoteIDs = slices.Clone(job.NoteIDs)
+	stored.Errors = slices.Clone(job.Errors)
+	stored.Error = job.Error
+	stored.FinishedAt = job.FinishedAt
+	return nil
+}
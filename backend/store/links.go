@@ -0,0 +1,169 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"sort"
+	"time"
+)
+
+// linkAttempts — неудачные попытки ввести пароль ссылки подряд.
+type linkAttempts struct {
+	failures    int
+	lockedUntil time.Time
+}
+
+// CreateShareLink сохраняет публичную ссылку на заметку.
+func (s *Store) CreateShareLink(link models.ShareLink) (models.ShareLink, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.activeNote(link.NoteID); !ok {
+		return models.ShareLink{}, namederrors.ErrNotFound
+	}
+
+	link.ID = s.nextShareLinkID
+	s.nextShareLinkID++
+	link.Views = 0
+	link.RevokedAt = nil
+	link.CreatedAt = time.Now().UTC()
+
+	stored := link
+	s.shareLinks[stored.ID] = &stored
+	s.linkTokens[stored.Token] = stored.ID
+
+	return link, nil
+}
+
+func (s *Store) GetShareLink(linkID uint64) (models.ShareLink, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	link, ok := s.shareLinks[linkID]
+	if !ok {
+		return models.ShareLink{}, namederrors.ErrNotFound
+	}
+	return *link, nil
+}
+
+func (s *Store) GetShareLinkByToken(token string) (models.ShareLink, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	linkID, ok := s.linkTokens[token]
+	if !ok {
+		return models.ShareLink{}, namederrors.ErrNotFound
+	}
+	return *s.shareLinks[linkID], nil
+}
+
+// ListShareLinks возвращает ссылки заметки, новые первыми, включая отозванные.
+func (s *Store) ListShareLinks(noteID uint64) []models.ShareLink {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	var links []models.ShareLink
+	for _, link := range s.shareLinks {
+		if link.NoteID == noteID {
+			links = append(links, *link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID > links[j].ID
+	})
+	return links
+}
+
+// RevokeShareLink отзывает ссылку; повторный отзыв ничего не меняет.
+func (s *Store) RevokeShareLink(linkID uint64) (models.ShareLink, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	link, ok := s.shareLinks[linkID]
+	if !ok {
+		return models.ShareLink{}, namederrors.ErrNotFound
+	}
+	if link.RevokedAt == nil {
+		now := time.Now().UTC()
+		link.RevokedAt = &now
+	}
+	return *link, nil
+}
+
+// ViewShareLink засчитывает просмотр по ссылке и возвращает заметку. Проверка
+// срока и лимита просмотров атомарна с увеличением счётчика, поэтому лимит не
+// превышается при параллельных запросах.
+func (s *Store) ViewShareLink(token string) (models.ShareLink, models.Note, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	linkID, ok := s.linkTokens[token]
+	if !ok {
+		return models.ShareLink{}, models.Note{}, namederrors.ErrNotFound
+	}
+	link := s.shareLinks[linkID]
+	if link.RevokedAt != nil {
+		return models.ShareLink{}, models.Note{}, namederrors.ErrNotFound
+	}
+	if link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt) {
+		return models.ShareLink{}, models.Note{}, namederrors.ErrLinkExpired
+	}
+	if link.MaxViews > 0 && link.Views >= link.MaxViews {
+		return models.ShareLink{}, models.Note{}, namederrors.ErrLinkExpired
+	}
+	note, ok := s.activeNote(link.NoteID)
+	if !ok {
+		return models.ShareLink{}, models.Note{}, namederrors.ErrNotFound
+	}
+
+	link.Views++
+	return *link, *note, nil
+}
+
+// StartLinkAttempt засчитывает попытку ввести пароль ссылки как неудачную
+// до проверки пароля, чтобы параллельные запросы не обходили лимит. После
+// limit попыток подряд без верного пароля ссылка блокируется на lockout.
+func (s *Store) StartLinkAttempt(linkID uint64, now time.Time, limit int, lockout time.Duration) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.shareLinks[linkID]; !ok {
+		return namederrors.ErrNotFound
+	}
+	attempts, ok := s.linkAttempts[linkID]
+	if !ok {
+		attempts = &linkAttempts{}
+		s.linkAttempts[linkID] = attempts
+	}
+	if now.Before(attempts.lockedUntil) {
+		return namederrors.ErrTooManyAttempts
+	}
+	if attempts.failures >= limit {
+		attempts.failures = 0
+	}
+
+	attempts.failures++
+	if attempts.failures >= limit {
+		attempts.lockedUntil = now.Add(lockout)
+	}
+	return nil
+}
+
+// ResetLinkAttempts сбрасывает счётчик попыток после верного пароля.
+func (s *Store) ResetLinkAttempts(linkID uint64) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	delete(s.linkAttempts, linkID)
+}
+
+// removeNoteLinks удаляет ссылки на заметку. Вызывается под блокировкой s.Mu.
+func (s *Store) removeNoteLinks(noteID uint64) {
+	for id, link := range s.shareLinks {
+		if link.NoteID == noteID {
+			delete(s.linkTokens, link.Token)
+			delete(s.linkAttempts, id)
+			delete(s.shareLinks, id)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"sort"
+	"time"
+)
+
+// AddNotification сохраняет уведомление пользователя.
+func (s *Store) AddNotification(notification models.Notification) models.Notification {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	notification.ID = s.nextNotifyID
+	s.nextNotifyID++
+	notification.Read = false
+	notification.ReadAt = nil
+	notification.CreatedAt = time.Now().UTC()
+
+	stored := notification
+	s.notifications[stored.UserID] = append(s.notifications[stored.UserID], &stored)
+
+	return notification
+}
+
+// ListNotifications возвращает уведомления пользователя от новых к старым.
+// Нулевой limit выборку не ограничивает.
+func (s *Store) ListNotifications(userID uint64, unreadOnly bool, limit int) []models.Notification {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	notifications := s.notifications[userID]
+	result := make([]models.Notification, 0)
+	for i := len(notifications) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		if unreadOnly && notifications[i].Read {
+			continue
+		}
+		result = append(result, *notifications[i])
+	}
+	return result
+}
+
+func (s *Store) CountUnreadNotifications(userID uint64) int {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	unread := 0
+	for _, notification := range s.notifications[userID] {
+		if !notification.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+func (s *Store) MarkNotificationRead(userID, notificationID uint64) (models.Notification, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	for _, notification := range s.notifications[userID] {
+		if notification.ID == notificationID {
+			markRead(notification, time.Now().UTC())
+			return *notification, nil
+		}
+	}
+	return models.Notification{}, namederrors.ErrNotFound
+}
+
+// MarkAllNotificationsRead отмечает прочитанными все уведомления пользователя
+// и возвращает, сколько из них было непрочитано.
+func (s *Store) MarkAllNotificationsRead(userID uint64) int {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	now := time.Now().UTC()
+	marked := 0
+	for _, notification := range s.notifications[userID] {
+		if !notification.Read {
+			markRead(notification, now)
+			marked++
+		}
+	}
+	return marked
+}
+
+func markRead(notification *models.Notification, at time.Time) {
+	if notification.Read {
+		return
+	}
+	notification.Read = true
+	notification.ReadAt = &at
+}
+
+func (s *Store) CreateReminder(reminder models.Reminder) (models.Reminder, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.activeNote(reminder.NoteID); !ok {
+		return models.Reminder{}, namederrors.ErrNotFound
+	}
+
+	reminder.ID = s.nextReminderID
+	s.nextReminderID++
+	reminder.CreatedAt = time.Now().UTC()
+
+	stored := reminder
+	s.reminders[stored.ID] = &stored
+
+	return reminder, nil
+}
+
+func (s *Store) GetReminder(reminderID uint64) (models.Reminder, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	reminder, ok := s.reminders[reminderID]
+	if !ok {
+		return models.Reminder{}, namederrors.ErrNotFound
+	}
+	return *reminder, nil
+}
+
+// ListReminders возвращает ожидающие напоминания пользователя по времени срабатывания.
+func (s *Store) ListReminders(userID uint64) []models.Reminder {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.Reminder, 0)
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID {
+			result = append(result, *reminder)
+		}
+	}
+	sortReminders(result)
+	return result
+}
+
+func (s *Store) DeleteReminder(reminderID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.reminders[reminderID]; !ok {
+		return namederrors.ErrNotFound
+	}
+	delete(s.reminders, reminderID)
+
+	return nil
+}
+
+// TakeDueReminders удаляет и возвращает напоминания, время которых наступило к now.
+func (s *Store) TakeDueReminders(now time.Time) []models.Reminder {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	due := make([]models.Reminder, 0)
+	for id, reminder := range s.reminders {
+		if !reminder.RemindAt.After(now) {
+			due = append(due, *reminder)
+			delete(s.reminders, id)
+		}
+	}
+	sortReminders(due)
+	return due
+}
+
+// removeNoteReminders удаляет напоминания о заметке. Вызывается под блокировкой s.Mu.
+func (s *Store) removeNoteReminders(noteID uint64) {
+	for id, reminder := range s.reminders {
+		if reminder.NoteID == noteID {
+			delete(s.reminders, id)
+		}
+	}
+}
+
+func sortReminders(reminders []models.Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].RemindAt.Equal(reminders[j].RemindAt) {
+			return reminders[i].RemindAt.Before(reminders[j].RemindAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+}
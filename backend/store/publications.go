@@ -0,0 +1,97 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Publish публикует заметку или меняет параметры её публикации. Адрес
+// страницы (slug) уникален среди всех публикаций.
+func (s *Store) Publish(publication models.Publication) (models.Publication, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.activeNote(publication.NoteID); !ok {
+		return models.Publication{}, namederrors.ErrNotFound
+	}
+	if noteID, ok := s.slugs[publication.Slug]; ok && noteID != publication.NoteID {
+		return models.Publication{}, namederrors.ErrSlugTaken
+	}
+
+	now := time.Now().UTC()
+	publication.CreatedAt = now
+	if existing, ok := s.publications[publication.NoteID]; ok {
+		publication.CreatedAt = existing.CreatedAt
+		delete(s.slugs, existing.Slug)
+	}
+	publication.UpdatedAt = now
+	publication.PageIDs = slices.Clone(publication.PageIDs)
+
+	stored := publication
+	stored.PageIDs = slices.Clone(publication.PageIDs)
+	s.publications[stored.NoteID] = &stored
+	s.slugs[stored.Slug] = stored.NoteID
+
+	return publication, nil
+}
+
+func copyPublication(publication *models.Publication) models.Publication {
+	result := *publication
+	result.PageIDs = slices.Clone(publication.PageIDs)
+	return result
+}
+
+func (s *Store) GetPublication(noteID uint64) (models.Publication, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	publication, ok := s.publications[noteID]
+	if !ok {
+		return models.Publication{}, namederrors.ErrNotFound
+	}
+	return copyPublication(publication), nil
+}
+
+func (s *Store) GetPublicationBySlug(slug string) (models.Publication, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	noteID, ok := s.slugs[slug]
+	if !ok {
+		return models.Publication{}, namederrors.ErrNotFound
+	}
+	return copyPublication(s.publications[noteID]), nil
+}
+
+// ListPublications возвращает публикации заметок не из корзины по адресу.
+func (s *Store) ListPublications() []models.Publication {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	publications := make([]models.Publication, 0, len(s.publications))
+	for noteID, publication := range s.publications {
+		if _, ok := s.activeNote(noteID); ok {
+			publications = append(publications, copyPublication(publication))
+		}
+	}
+	sort.Slice(publications, func(i, j int) bool {
+		return publications[i].Slug < publications[j].Slug
+	})
+	return publications
+}
+
+func (s *Store) Unpublish(noteID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	publication, ok := s.publications[noteID]
+	if !ok {
+		return namederrors.ErrNotFound
+	}
+	delete(s.slugs, publication.Slug)
+	delete(s.publications, noteID)
+	return nil
+}
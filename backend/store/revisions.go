@@ -0,0 +1,144 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"slices"
+	"time"
+)
+
+// RevisionRetention ограничивает историю изменений заметки. Нулевые значения
+// снимают соответствующее ограничение; последняя ревизия хранится всегда.
+type RevisionRetention struct {
+	MaxRevisions int
+	MaxAge       time.Duration
+}
+
+func (s *Store) SetRevisionRetention(retention RevisionRetention) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.revisionRetention = retention
+}
+
+// recordRevision сохраняет текущее состояние заметки как новую ревизию.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) recordRevision(note *models.Note, restoredFrom uint64) {
+	revisions := s.revisions[note.ID]
+
+	number := uint64(1)
+	if len(revisions) > 0 {
+		number = revisions[len(revisions)-1].Number + 1
+	}
+
+	revision := &models.NoteRevision{
+		ID:           s.nextRevisionID,
+		NoteID:       note.ID,
+		Number:       number,
+		AuthorID:     note.UpdatedBy,
+		CreatedAt:    note.UpdatedAt,
+		Title:        note.Title,
+		Text:         note.Text,
+		Favourite:    note.Favourite,
+		Folder:       note.Folder,
+		Tags:         slices.Clone(note.Tags),
+		RestoredFrom: restoredFrom,
+	}
+	s.nextRevisionID++
+
+	s.revisions[note.ID] = s.pruneRevisions(append(revisions, revision))
+}
+
+// pruneRevisions отбрасывает старые ревизии по правилам хранения.
+func (s *Store) pruneRevisions(revisions []*models.NoteRevision) []*models.NoteRevision {
+	retention := s.revisionRetention
+
+	start := 0
+	if retention.MaxRevisions > 0 && len(revisions) > retention.MaxRevisions {
+		start = len(revisions) - retention.MaxRevisions
+	}
+	if retention.MaxAge > 0 {
+		cutoff := time.Now().UTC().Add(-retention.MaxAge)
+		for start < len(revisions)-1 && revisions[start].CreatedAt.Before(cutoff) {
+			start++
+		}
+	}
+	if start == 0 {
+		return revisions
+	}
+
+	return slices.Clone(revisions[start:])
+}
+
+// ListRevisions возвращает ревизии заметки от новых к старым.
+func (s *Store) ListRevisions(noteID uint64) []models.NoteRevision {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	revisions := s.revisions[noteID]
+	result := make([]models.NoteRevision, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		result = append(result, *revisions[i])
+	}
+
+	return result
+}
+
+func (s *Store) GetRevision(noteID, number uint64) (models.NoteRevision, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	revision, ok := s.findRevision(noteID, number)
+	if !ok {
+		return models.NoteRevision{}, namederrors.ErrNotFound
+	}
+
+	return *revision, nil
+}
+
+// RestoreRevision делает содержимое старой ревизии текущим состоянием заметки.
+// Восстановление записывается как новая ревизия со ссылкой на исходную.
+func (s *Store) RestoreRevision(noteID, number, editorID uint64) (models.Note, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	note, ok := s.activeNote(noteID)
+	if !ok {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+	revision, ok := s.findRevision(noteID, number)
+	if !ok {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+
+	s.unindexNote(note)
+	s.reanchorThreads(note.ID, note.Text, revision.Text)
+	note.Title = revision.Title
+	note.Text = revision.Text
+	note.Favourite = revision.Favourite
+	note.Folder = revision.Folder
+	note.Tags = slices.Clone(revision.Tags)
+	s.touchNote(note, editorID)
+	s.indexNote(note)
+	s.recordRevision(note, revision.Number)
+
+	return *note, nil
+}
+
+func (s *Store) findRevision(noteID, number uint64) (*models.NoteRevision, bool) {
+	revisions := s.revisions[noteID]
+	i, found := slices.BinarySearchFunc(revisions, number, func(revision *models.NoteRevision, number uint64) int {
+		switch {
+		case revision.Number < number:
+			return -1
+		case revision.Number > number:
+			return 1
+		default:
+			return 0
+		}
+	})
+	if !found {
+		return nil, false
+	}
+	return revisions[i], true
+}
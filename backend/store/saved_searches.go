@@ -0,0 +1,112 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+func (s *Store) CreateSavedSearch(search models.SavedSearch) models.SavedSearch {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	now := time.Now().UTC()
+	search.ID = s.nextSavedSearchID
+	s.nextSavedSearchID++
+	search.CreatedAt = now
+	search.UpdatedAt = now
+
+	stored := copySavedSearch(&search)
+	s.SavedSearches[stored.ID] = &stored
+
+	return copySavedSearch(&stored)
+}
+
+func (s *Store) GetSavedSearch(searchID uint64) (models.SavedSearch, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	search, ok := s.SavedSearches[searchID]
+	if !ok {
+		return models.SavedSearch{}, namederrors.ErrNotFound
+	}
+
+	return copySavedSearch(search), nil
+}
+
+// ListSavedSearches возвращает сохранённые поиски владельца по алфавиту.
+func (s *Store) ListSavedSearches(ownerID uint64) []models.SavedSearch {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.SavedSearch, 0)
+	for _, search := range s.SavedSearches {
+		if search.OwnerID == ownerID {
+			result = append(result, copySavedSearch(search))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+func (s *Store) UpdateSavedSearch(search models.SavedSearch) (models.SavedSearch, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	stored, ok := s.SavedSearches[search.ID]
+	if !ok {
+		return models.SavedSearch{}, namederrors.ErrNotFound
+	}
+
+	stored.Name = search.Name
+	stored.Filter = copyFilter(search.Filter)
+	stored.UpdatedAt = time.Now().UTC()
+
+	return copySavedSearch(stored), nil
+}
+
+func (s *Store) DeleteSavedSearch(searchID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.SavedSearches[searchID]; !ok {
+		return namederrors.ErrNotFound
+	}
+	delete(s.SavedSearches, searchID)
+
+	return nil
+}
+
+// copySavedSearch копирует сохранённый поиск вместе с фильтром, чтобы
+// хранилище не делило с вызывающим срез тегов и указатели фильтра.
+func copySavedSearch(search *models.SavedSearch) models.SavedSearch {
+	copied := *search
+	copied.Filter = copyFilter(search.Filter)
+	return copied
+}
+
+func copyFilter(filter models.NotesFilter) models.NotesFilter {
+	filter.Tags = slices.Clone(filter.Tags)
+	if filter.Favourite != nil {
+		favourite := *filter.Favourite
+		filter.Favourite = &favourite
+	}
+	if filter.UpdatedAfter != nil {
+		after := *filter.UpdatedAfter
+		filter.UpdatedAfter = &after
+	}
+	if filter.UpdatedBefore != nil {
+		before := *filter.UpdatedBefore
+		filter.UpdatedBefore = &before
+	}
+	return filter
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"backend/models"
+	"backend/search"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Веса при ранжировании быстрого перехода.
+const (
+	favouriteBoost    = 0.15
+	recentOpenBoost   = 0.3
+	recentOpenHalfAge = 72 * time.Hour
+)
+
+const (
+	titleKeyNotePrefix   = "note:"
+	titleKeyFolderPrefix = "folder:"
+)
+
+// indexNote добавляет заметку в поисковые индексы. Вызывается под блокировкой s.Mu.
+func (s *Store) indexNote(note *models.Note) {
+	s.searchIndex.Add(search.Document{
+		ID:      note.ID,
+		OwnerID: note.OwnerID,
+		Title:   note.Title,
+		Text:    note.Text,
+	})
+	s.titleIndex.Add(note.OwnerID, titleKeyNotePrefix+strconv.FormatUint(note.ID, 10), note.Title)
+	s.indexWikiLinks(note)
+
+	if note.Folder == "" {
+		return
+	}
+	folders, ok := s.folders[note.OwnerID]
+	if !ok {
+		folders = make(map[string]int)
+		s.folders[note.OwnerID] = folders
+	}
+	if folders[note.Folder] == 0 {
+		s.titleIndex.Add(note.OwnerID, titleKeyFolderPrefix+note.Folder, note.Folder)
+	}
+	folders[note.Folder]++
+}
+
+// unindexNote удаляет заметку из поисковых индексов. Вызывается под блокировкой s.Mu.
+func (s *Store) unindexNote(note *models.Note) {
+	s.searchIndex.Remove(note.ID)
+	s.titleIndex.Remove(note.OwnerID, titleKeyNotePrefix+strconv.FormatUint(note.ID, 10))
+	delete(s.wikiLinks, note.ID)
+
+	folders := s.folders[note.OwnerID]
+	if note.Folder == "" || folders[note.Folder] == 0 {
+		return
+	}
+	folders[note.Folder]--
+	if folders[note.Folder] == 0 {
+		delete(folders, note.Folder)
+		s.titleIndex.Remove(note.OwnerID, titleKeyFolderPrefix+note.Folder)
+	}
+}
+
+// SearchNotes ищет по заголовкам и тексту заметок владельца и возвращает
+// не более limit результатов по убыванию релевантности.
+func (s *Store) SearchNotes(ownerID uint64, rawQuery string, limit int) []models.NoteSearchResult {
+	query := search.ParseQuery(rawQuery)
+
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	hits := s.searchIndex.Search(ownerID, query, limit)
+	results := make([]models.NoteSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		note, ok := s.Notes[hit.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, models.NoteSearchResult{
+			Note:           *note,
+			Score:          hit.Score,
+			TitleHighlight: search.Highlight(note.Title, hit.MatchedTerms),
+			Snippet:        search.Snippet(note.Text, hit.MatchedTerms),
+		})
+	}
+
+	return results
+}
+
+// MarkNoteOpened запоминает, когда пользователь последний раз открывал заметку.
+// Блокировка s.Mu на чтение не даёт удалить заметку, пока отметка не записана.
+func (s *Store) MarkNoteOpened(userID, noteID uint64) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	if _, ok := s.Notes[noteID]; !ok {
+		return
+	}
+
+	s.opensMu.Lock()
+	defer s.opensMu.Unlock()
+	opens, ok := s.noteOpens[noteID]
+	if !ok {
+		opens = make(map[uint64]time.Time)
+		s.noteOpens[noteID] = opens
+	}
+	opens[userID] = time.Now().UTC()
+}
+
+// openedAt возвращает, когда пользователь последний раз открывал заметку.
+func (s *Store) openedAt(userID, noteID uint64) (time.Time, bool) {
+	s.opensMu.Lock()
+	defer s.opensMu.Unlock()
+
+	openedAt, ok := s.noteOpens[noteID][userID]
+	return openedAt, ok
+}
+
+// QuickSwitch нечётко ищет по заголовкам заметок и названиям папок владельца.
+// Избранные и недавно открытые пользователем actorID заметки поднимаются выше.
+func (s *Store) QuickSwitch(ownerID, actorID uint64, query string, limit int) []models.QuickSwitchResult {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	now := time.Now().UTC()
+	matches := s.titleIndex.Match(ownerID, query)
+	results := make([]models.QuickSwitchResult, 0, len(matches))
+	for _, match := range matches {
+		if folder, ok := strings.CutPrefix(match.Key, titleKeyFolderPrefix); ok {
+			results = append(results, models.QuickSwitchResult{
+				Type:   models.QuickSwitchFolder,
+				Title:  folder,
+				Folder: folder,
+				Score:  match.Score,
+			})
+			continue
+		}
+
+		idStr, _ := strings.CutPrefix(match.Key, titleKeyNotePrefix)
+		noteID, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		note, ok := s.Notes[noteID]
+		if !ok {
+			continue
+		}
+
+		score := match.Score
+		if note.Favourite {
+			score += favouriteBoost
+		}
+		if openedAt, ok := s.openedAt(actorID, noteID); ok {
+			age := now.Sub(openedAt)
+			score += recentOpenBoost * math.Exp2(-float64(age)/float64(recentOpenHalfAge))
+		}
+
+		results = append(results, models.QuickSwitchResult{
+			Type:      models.QuickSwitchNote,
+			NoteID:    note.ID,
+			Title:     note.Title,
+			Folder:    note.Folder,
+			Favourite: note.Favourite,
+			Score:     score,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Title < results[j].Title
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// ListFolders возвращает непустые папки владельца по алфавиту.
+func (s *Store) ListFolders(ownerID uint64) []models.Folder {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	folders := make([]models.Folder, 0, len(s.folders[ownerID]))
+	for name, count := range s.folders[ownerID] {
+		folders = append(folders, models.Folder{Name: name, NoteCount: count})
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].Name < folders[j].Name
+	})
+
+	return folders
+}
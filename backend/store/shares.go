@@ -0,0 +1,151 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ShareNote выдаёт доступ к заметке или меняет роль уже выданного. Доступ
+// ищется по пользователю, а для приглашения без аккаунта — по email.
+func (s *Store) ShareNote(share models.NoteShare) (models.NoteShare, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.Notes[share.NoteID]; !ok {
+		return models.NoteShare{}, namederrors.ErrNotFound
+	}
+
+	for _, shareID := range s.noteShares[share.NoteID] {
+		existing := s.shares[shareID]
+		if sameRecipient(existing, share) {
+			existing.Role = share.Role
+			return *existing, nil
+		}
+	}
+
+	share.ID = s.nextShareID
+	s.nextShareID++
+	share.Pending = share.UserID == 0
+	share.CreatedAt = time.Now().UTC()
+
+	stored := share
+	s.shares[stored.ID] = &stored
+	s.noteShares[stored.NoteID] = append(s.noteShares[stored.NoteID], stored.ID)
+
+	return share, nil
+}
+
+func sameRecipient(existing *models.NoteShare, share models.NoteShare) bool {
+	if share.UserID != 0 {
+		return existing.UserID == share.UserID
+	}
+	return existing.UserID == 0 && strings.EqualFold(existing.Email, share.Email)
+}
+
+func (s *Store) GetShare(shareID uint64) (models.NoteShare, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	share, ok := s.shares[shareID]
+	if !ok {
+		return models.NoteShare{}, namederrors.ErrNotFound
+	}
+	return *share, nil
+}
+
+// GetNoteShare возвращает доступ пользователя к заметке.
+func (s *Store) GetNoteShare(noteID, userID uint64) (models.NoteShare, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	for _, shareID := range s.noteShares[noteID] {
+		if share := s.shares[shareID]; share.UserID == userID {
+			return *share, nil
+		}
+	}
+	return models.NoteShare{}, namederrors.ErrNotFound
+}
+
+// ListNoteShares возвращает доступы к заметке в порядке выдачи.
+func (s *Store) ListNoteShares(noteID uint64) []models.NoteShare {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	shares := make([]models.NoteShare, 0, len(s.noteShares[noteID]))
+	for _, shareID := range s.noteShares[noteID] {
+		shares = append(shares, *s.shares[shareID])
+	}
+	return shares
+}
+
+// ListSharedWith возвращает доступы пользователя к чужим заметкам, новые первыми.
+func (s *Store) ListSharedWith(userID uint64) []models.NoteShare {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	var shares []models.NoteShare
+	for _, share := range s.shares {
+		if share.UserID == userID {
+			shares = append(shares, *share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].ID > shares[j].ID
+	})
+	return shares
+}
+
+func (s *Store) UpdateShareRole(shareID uint64, role string) (models.NoteShare, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	share, ok := s.shares[shareID]
+	if !ok {
+		return models.NoteShare{}, namederrors.ErrNotFound
+	}
+	share.Role = role
+	return *share, nil
+}
+
+func (s *Store) DeleteShare(shareID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	share, ok := s.shares[shareID]
+	if !ok {
+		return namederrors.ErrNotFound
+	}
+	s.removeShare(share)
+	return nil
+}
+
+// Вызывается под блокировкой s.Mu
+func (s *Store) removeShare(share *models.NoteShare) {
+	delete(s.shares, share.ID)
+	ids := s.noteShares[share.NoteID]
+	for i, id := range ids {
+		if id == share.ID {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(s.noteShares, share.NoteID)
+	} else {
+		s.noteShares[share.NoteID] = ids
+	}
+}
+
+// bindPendingShares привязывает к новому пользователю приглашения на его email.
+// Вызывается под блокировкой s.Mu
+func (s *Store) bindPendingShares(user *models.User) {
+	for _, share := range s.shares {
+		if share.UserID == 0 && strings.EqualFold(share.Email, user.Email) {
+			share.UserID = user.ID
+			share.Pending = false
+		}
+	}
+}
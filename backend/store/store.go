@@ -3,7 +3,12 @@ package store
 import (
 	"backend/models"
 	namederrors "backend/named_errors"
+	"backend/search"
+	"backend/templates"
+	"backend/wikilinks"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,109 +18,209 @@ import (
 )
 
 type Store struct {
-	Mu           sync.RWMutex
-	Users        map[uint64]*models.User
-	UsersByEmail map[string]uint64
-	Notes        map[uint64]*models.Note
-	sessions     map[string]uint64
+	Mu            sync.RWMutex
+	Users         map[uint64]*models.User
+	UsersByEmail  map[string]uint64
+	Notes         map[uint64]*models.Note
+	SavedSearches map[uint64]*models.SavedSearch
+	sessions      map[string]uint64
+	searchIndex   *search.Index
+	titleIndex    *search.TitleIndex
+	folders       map[uint64]map[string]int
+	noteOpens     map[uint64]map[uint64]time.Time
+	// opensMu охраняет noteOpens, чтобы открытие заметки не брало s.Mu на запись.
+	opensMu       sync.Mutex
+	revisions     map[uint64][]*models.NoteRevision
+	tombstones    []models.Tombstone
+	mutations     map[uint64]*mutationLog
+	shares        map[uint64]*models.NoteShare
+	noteShares    map[uint64][]uint64
+	shareLinks    map[uint64]*models.ShareLink
+	linkTokens    map[string]uint64
+	linkAttempts  map[uint64]*linkAttempts
+	publications  map[uint64]*models.Publication
+	slugs         map[string]uint64
+	workspaces    map[uint64]*models.Workspace
+	members       map[uint64]map[uint64]*models.WorkspaceMember
+	invitations   map[uint64]*models.WorkspaceInvitation
+	threads       map[uint64]*models.CommentThread
+	noteThreads   map[uint64][]uint64
+	notifications map[uint64][]*models.Notification
+	reminders     map[uint64]*models.Reminder
+	wikiLinks     map[uint64][]wikilinks.Link
+	attachments   map[uint64]*models.Attachment
+	storageUsed   map[uint64]int64
+	orphanBlobs   []string
+	importJobs    map[uint64]*models.ImportJob
+	noteTemplates map[uint64]*models.NoteTemplate
+	onboarding    []models.NoteTemplate
 
-	nextUserID uint64
+	noteAttachments map[uint64][]uint64
+
+	revisionRetention RevisionRetention
+
+	nextUserID        uint64
+	nextNoteID        uint64
+	nextSavedSearchID uint64
+	nextRevisionID    uint64
+	nextShareID       uint64
+	nextShareLinkID   uint64
+	nextInvitationID  uint64
+	nextThreadID      uint64
+	nextCommentID     uint64
+	nextNotifyID      uint64
+	nextReminderID    uint64
+	nextAttachmentID  uint64
+	nextImportJobID   uint64
+	nextTemplateID    uint64
+	nextChangeSeq     uint64
+	tombstoneFloor    uint64
 }
 
 func (s *Store) InitFillStore() error {
-	_, err := s.CreateUser("user@example.com", "password")
+	user, err := s.CreateUser("user@example.com", "password")
 	if err != nil {
 		return fmt.Errorf("init fill store: %w", err)
 	}
 
 	notes := []*models.Note{
 		{
-			ID:        1,
-			OwnerID:   1,
+			OwnerID:   user.ID,
 			Title:     "University note",
 			Text:      "Lecture notes for math and history",
 			Favourite: true,
 			Folder:    "University",
 		},
 		{
-			ID:        2,
-			OwnerID:   1,
+			OwnerID:   user.ID,
 			Title:     "Project idea",
 			Text:      "Brainstorming app features and sketches",
 			Favourite: false,
 			Folder:    "University",
 		},
 		{
-			ID:        3,
-			OwnerID:   1,
+			OwnerID:   user.ID,
 			Title:     "Shopping list",
 			Text:      "Milk, bread, eggs, and vegetables",
 			Favourite: false,
 			Folder:    "Personal",
 		},
 		{
-			ID:        4,
-			OwnerID:   1,
+			OwnerID:   user.ID,
 			Title:     "Note №4",
 			Text:      "Random text of the note",
 			Favourite: false,
 			Folder:    "Personal",
 		},
 	}
+
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
 	for _, note := range notes {
-		s.Notes[note.ID] = note
+		s.insertNote(note)
 	}
 	return nil
 }
 
 func NewStore() *Store {
-	return &Store{
-		Users:        make(map[uint64]*models.User),
-		UsersByEmail: make(map[string]uint64),
-		Notes:        make(map[uint64]*models.Note),
-		sessions:     make(map[string]uint64),
-		nextUserID:   1,
+	s := &Store{
+		Users:             make(map[uint64]*models.User),
+		UsersByEmail:      make(map[string]uint64),
+		Notes:             make(map[uint64]*models.Note),
+		SavedSearches:     make(map[uint64]*models.SavedSearch),
+		sessions:          make(map[string]uint64),
+		searchIndex:       search.NewIndex(),
+		titleIndex:        search.NewTitleIndex(),
+		folders:           make(map[uint64]map[string]int),
+		noteOpens:         make(map[uint64]map[uint64]time.Time),
+		revisions:         make(map[uint64][]*models.NoteRevision),
+		mutations:         make(map[uint64]*mutationLog),
+		shares:            make(map[uint64]*models.NoteShare),
+		noteShares:        make(map[uint64][]uint64),
+		shareLinks:        make(map[uint64]*models.ShareLink),
+		linkTokens:        make(map[string]uint64),
+		linkAttempts:      make(map[uint64]*linkAttempts),
+		publications:      make(map[uint64]*models.Publication),
+		slugs:             make(map[string]uint64),
+		workspaces:        make(map[uint64]*models.Workspace),
+		members:           make(map[uint64]map[uint64]*models.WorkspaceMember),
+		invitations:       make(map[uint64]*models.WorkspaceInvitation),
+		threads:           make(map[uint64]*models.CommentThread),
+		noteThreads:       make(map[uint64][]uint64),
+		notifications:     make(map[uint64][]*models.Notification),
+		reminders:         make(map[uint64]*models.Reminder),
+		wikiLinks:         make(map[uint64][]wikilinks.Link),
+		attachments:       make(map[uint64]*models.Attachment),
+		storageUsed:       make(map[uint64]int64),
+		noteAttachments:   make(map[uint64][]uint64),
+		importJobs:        make(map[uint64]*models.ImportJob),
+		noteTemplates:     make(map[uint64]*models.NoteTemplate),
+		onboarding:        templates.Onboarding(),
+		nextUserID:        1,
+		nextNoteID:        1,
+		nextSavedSearchID: 1,
+		nextRevisionID:    1,
+		nextShareID:       1,
+		nextShareLinkID:   1,
+		nextInvitationID:  1,
+		nextThreadID:      1,
+		nextCommentID:     1,
+		nextNotifyID:      1,
+		nextReminderID:    1,
+		nextAttachmentID:  1,
+		nextImportJobID:   1,
+		nextTemplateID:    1,
+		nextChangeSeq:     1,
 	}
+	s.addBuiltinTemplates()
+	return s
 }
 
-func (s *Store) CreateDefaultNotes(userID uint64) {
-	notes := []*models.Note{
-		{
-			ID:        userID*1000 + 1,
-			OwnerID:   userID,
-			Title:     "Books to read",
-			Text:      "The Three Musketeers, Animal Farm, Angels and Demons",
-			Favourite: false,
-			Folder:    "Personal",
-		},
-		{
-			ID:        userID*1000 + 2,
-			OwnerID:   userID,
-			Title:     "Homework",
-			Text:      "Write an essay",
-			Favourite: false,
-			Folder:    "University",
-		},
-		{
-			ID:        userID*1000 + 3,
-			OwnerID:   userID,
-			Title:     "My wishes",
-			Text:      "I want to be a millionaire",
-			Favourite: true,
-			Folder:    "Personal",
-		},
-		{
-			ID:        userID*1000 + 4,
-			OwnerID:   userID,
-			Title:     "Films to watch",
-			Text:      "Harry Potter, The Lord of the Rings, Avatar",
-			Favourite: false,
-			Folder:    "Personal",
-		},
+// CreateDefaultNotes создаёт стартовые заметки нового пользователя по
+// шаблонам онбординга.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) CreateDefaultNotes(user *models.User) {
+	vars := templates.Vars{Now: user.CreatedAt, User: user.Email}
+	for _, template := range s.onboarding {
+		note := templates.Apply(template, vars)
+		note.OwnerID = user.ID
+		s.insertNote(&note)
 	}
-	for _, note := range notes {
-		s.Notes[note.ID] = note
+}
+
+// touchNote отмечает изменение заметки и увеличивает её версию. Время изменения
+// строго возрастает, чтобы клиенты, опрашивающие по updated_since, не пропускали правки.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) touchNote(note *models.Note, editorID uint64) {
+	s.markChanged(note)
+	note.Version++
+	now := time.Now().UTC()
+	if !now.After(note.UpdatedAt) {
+		now = note.UpdatedAt.Add(time.Nanosecond)
 	}
+	note.UpdatedAt = now
+	note.UpdatedBy = editorID
+}
+
+// insertNote присваивает заметке ID и метаданные создания и сохраняет её.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) insertNote(note *models.Note) {
+	now := time.Now().UTC()
+
+	note.ID = s.nextNoteID
+	s.nextNoteID++
+	s.markChanged(note)
+	note.Version = 1
+	note.CreatedAt = now
+	note.UpdatedAt = now
+	if note.CreatedBy == 0 {
+		note.CreatedBy = note.OwnerID
+	}
+	note.UpdatedBy = note.CreatedBy
+
+	s.Notes[note.ID] = note
+	s.indexNote(note)
+	s.recordRevision(note, 0)
 }
 
 func (s *Store) CreateUser(email, password string) (*models.User, error) {
@@ -139,7 +244,8 @@ func (s *Store) CreateUser(email, password string) (*models.User, error) {
 	}
 	s.Users[user.ID] = user
 	s.UsersByEmail[email] = user.ID
-	s.CreateDefaultNotes(user.ID)
+	s.CreateDefaultNotes(user)
+	s.bindPendingShares(user)
 	s.nextUserID++
 
 	return user, nil
@@ -193,16 +299,159 @@ func (s *Store) GetUserBySession(sessionID string) (*models.User, bool) {
 	return user, ok
 }
 
+func (s *Store) GetUser(userID uint64) (models.User, bool) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	user, ok := s.Users[userID]
+	if !ok {
+		return models.User{}, false
+	}
+	return *user, true
+}
+
+// GetUserByEmail ищет пользователя по email.
+func (s *Store) GetUserByEmail(email string) (models.User, bool) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	userID, ok := s.UsersByEmail[email]
+	if !ok {
+		return models.User{}, false
+	}
+	return *s.Users[userID], true
+}
+
 func (s *Store) ListNotes(ownerID uint64) []models.Note {
 	s.Mu.RLock()
 	defer s.Mu.RUnlock()
 
 	result := make([]models.Note, 0)
 	for _, note := range s.Notes {
-		if note.OwnerID == ownerID {
+		if note.OwnerID == ownerID && note.DeletedAt == nil {
 			result = append(result, *note)
 		}
 	}
 
 	return result
 }
+
+// ListNotesUpdatedSince возвращает заметки владельца, изменённые строго позже since,
+// в порядке возрастания времени изменения.
+func (s *Store) ListNotesUpdatedSince(ownerID uint64, since time.Time) []models.Note {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.Note, 0)
+	for _, note := range s.Notes {
+		if note.OwnerID == ownerID && note.DeletedAt == nil && note.UpdatedAt.After(since) {
+			result = append(result, *note)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UpdatedAt.Before(result[j].UpdatedAt)
+	})
+
+	return result
+}
+
+// GetNote возвращает заметку, если она существует и не лежит в корзине.
+func (s *Store) GetNote(noteID uint64) (models.Note, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	note, ok := s.activeNote(noteID)
+	if !ok {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+
+	return *note, nil
+}
+
+func (s *Store) CreateNote(note models.Note) models.Note {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	created := note
+	created.Tags = slices.Clone(note.Tags)
+	s.insertNote(&created)
+
+	return created
+}
+
+// UpdateNote заменяет редактируемые поля заметки и отмечает editorID как последнего редактора.
+// Если note.Version не нулевая, изменение применяется только к этой версии заметки.
+func (s *Store) UpdateNote(note models.Note, editorID uint64) (models.Note, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	stored, ok := s.activeNote(note.ID)
+	if !ok {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+	if note.Version != 0 && note.Version != stored.Version {
+		return models.Note{}, &namederrors.VersionConflictError{CurrentVersion: stored.Version}
+	}
+
+	s.unindexNote(stored)
+	s.reanchorThreads(stored.ID, stored.Text, note.Text)
+	stored.Title = note.Title
+	stored.Text = note.Text
+	stored.Favourite = note.Favourite
+	stored.Folder = note.Folder
+	stored.Tags = slices.Clone(note.Tags)
+	s.touchNote(stored, editorID)
+	s.indexNote(stored)
+	s.recordRevision(stored, 0)
+
+	return *stored, nil
+}
+
+// DeleteNote удаляет заметку безвозвратно вместе с историей, в том числе из корзины.
+func (s *Store) DeleteNote(noteID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	note, ok := s.Notes[noteID]
+	if !ok {
+		return namederrors.ErrNotFound
+	}
+	s.removeNote(note)
+
+	return nil
+}
+
+// removeNote удаляет заметку из хранилища. Вызывается под блокировкой s.Mu.
+func (s *Store) removeNote(note *models.Note) {
+	if note.DeletedAt == nil {
+		s.unindexNote(note)
+	}
+	delete(s.Notes, note.ID)
+	delete(s.revisions, note.ID)
+	s.opensMu.Lock()
+	delete(s.noteOpens, note.ID)
+	s.opensMu.Unlock()
+	for _, shareID := range s.noteShares[note.ID] {
+		delete(s.shares, shareID)
+	}
+	delete(s.noteShares, note.ID)
+	s.removeNoteLinks(note.ID)
+	s.removeNoteThreads(note.ID)
+	s.removeNoteReminders(note.ID)
+	s.removeNoteAttachments(note.ID)
+	if publication, ok := s.publications[note.ID]; ok {
+		delete(s.slugs, publication.Slug)
+		delete(s.publications, note.ID)
+	}
+	s.recordTombstone(note)
+}
+
+// activeNote возвращает заметку не из корзины. Вызывается под блокировкой s.Mu.
+func (s *Store) activeNote(noteID uint64) (*models.Note, bool) {
+	note, ok := s.Notes[noteID]
+	if !ok || note.DeletedAt != nil {
+		return nil, false
+	}
+	return note, true
+}
@@ -2,7 +2,11 @@ package store
 
 import (
 	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -120,3 +124,789 @@ func TestListNotes(t *testing.T) {
 	noNotes := s.ListNotes(999)
 	require.Len(t, noNotes, 0)
 }
+
+func TestNoteMetadata(t *testing.T) {
+	s := NewStore()
+
+	owner, err := s.CreateUser("owner@example.com", "password")
+	require.NoError(t, err)
+
+	created := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Draft", Folder: "Work"})
+	require.NotZero(t, created.ID)
+	require.False(t, created.CreatedAt.IsZero())
+	require.Equal(t, created.CreatedAt, created.UpdatedAt)
+	require.Equal(t, owner.ID, created.CreatedBy)
+	require.Equal(t, owner.ID, created.UpdatedBy)
+
+	checkpoint := created.UpdatedAt
+	require.Empty(t, s.ListNotesUpdatedSince(owner.ID, checkpoint))
+
+	created.Title = "Final"
+	updated, err := s.UpdateNote(created, 42)
+	require.NoError(t, err)
+	require.Equal(t, "Final", updated.Title)
+	require.Equal(t, created.CreatedAt, updated.CreatedAt)
+	require.True(t, updated.UpdatedAt.After(checkpoint))
+	require.Equal(t, uint64(42), updated.UpdatedBy)
+
+	changed := s.ListNotesUpdatedSince(owner.ID, checkpoint)
+	require.Len(t, changed, 1)
+	require.Equal(t, created.ID, changed[0].ID)
+
+	_, err = s.UpdateNote(models.Note{ID: 999999}, owner.ID)
+	require.Error(t, err)
+
+	require.NoError(t, s.DeleteNote(created.ID))
+	_, err = s.GetNote(created.ID)
+	require.Error(t, err)
+}
+
+func TestNoteVersions(t *testing.T) {
+	s := NewStore()
+
+	created := s.CreateNote(models.Note{OwnerID: 1, Title: "Draft"})
+	require.Equal(t, uint64(1), created.Version)
+
+	first := created
+	first.Title = "First"
+	updated, err := s.UpdateNote(first, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), updated.Version)
+
+	stale := created
+	stale.Title = "Stale"
+	_, err = s.UpdateNote(stale, 2)
+	var conflict *namederrors.VersionConflictError
+	require.True(t, errors.As(err, &conflict))
+	require.Equal(t, uint64(2), conflict.CurrentVersion)
+	require.ErrorIs(t, err, namederrors.ErrVersionConflict)
+
+	note, err := s.GetNote(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "First", note.Title)
+
+	_, err = s.TrashNote(created.ID, 1)
+	require.NoError(t, err)
+	restored, err := s.RestoreNote(created.ID, 1)
+	require.NoError(t, err)
+	require.Greater(t, restored.Version, updated.Version)
+}
+
+func TestSearchNotes(t *testing.T) {
+	s := NewStore()
+
+	owner, err := s.CreateUser("search@example.com", "password")
+	require.NoError(t, err)
+
+	note := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Trip plan", Text: "Pack the tent"})
+
+	results := s.SearchNotes(owner.ID, "tent", 10)
+	require.Len(t, results, 1)
+	require.Equal(t, note.ID, results[0].Note.ID)
+	require.Equal(t, "Pack the <mark>tent</mark>", results[0].Snippet)
+
+	note.Text = "Pack the sleeping bag"
+	_, err = s.UpdateNote(note, owner.ID)
+	require.NoError(t, err)
+	require.Empty(t, s.SearchNotes(owner.ID, "tent", 10))
+	require.Len(t, s.SearchNotes(owner.ID, "sleeping", 10), 1)
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	require.Empty(t, s.SearchNotes(owner.ID, "sleeping", 10))
+
+	require.Len(t, s.SearchNotes(owner.ID, "films", 10), 1, "default notes are indexed")
+}
+
+func TestQuickSwitch(t *testing.T) {
+	s := NewStore()
+
+	owner, err := s.CreateUser("switch@example.com", "password")
+	require.NoError(t, err)
+
+	plain := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Reading plan", Folder: "Reading"})
+	favourite := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Reading list", Favourite: true})
+
+	results := s.QuickSwitch(owner.ID, owner.ID, "readng", 10)
+	require.Len(t, results, 3)
+	require.Equal(t, favourite.ID, results[0].NoteID, "favourite note ranks first")
+
+	s.MarkNoteOpened(owner.ID, plain.ID)
+	results = s.QuickSwitch(owner.ID, owner.ID, "readng", 10)
+	require.Equal(t, plain.ID, results[0].NoteID, "recently opened note ranks first")
+	member, err := s.CreateUser("member@example.com", "password")
+	require.NoError(t, err)
+	results = s.QuickSwitch(owner.ID, member.ID, "readng", 10)
+	require.Equal(t, favourite.ID, results[0].NoteID, "opens are counted per user")
+	s.MarkNoteOpened(member.ID, plain.ID)
+	results = s.QuickSwitch(owner.ID, member.ID, "readng", 10)
+	require.Equal(t, plain.ID, results[0].NoteID, "notes opened by the actor rank first in another owner's notes")
+
+	folderFound := false
+	for _, result := range results {
+		if result.Type == models.QuickSwitchFolder {
+			folderFound = true
+			require.Equal(t, "Reading", result.Title)
+		}
+	}
+	require.True(t, folderFound, "folders are matched too")
+
+	plain.Folder = "Archive"
+	_, err = s.UpdateNote(plain, owner.ID)
+	require.NoError(t, err)
+	for _, result := range s.QuickSwitch(owner.ID, owner.ID, "reading", 10) {
+		require.NotEqual(t, models.QuickSwitchFolder, result.Type, "empty folder is removed")
+	}
+
+	require.Empty(t, s.QuickSwitch(owner.ID+1, owner.ID, "reading", 10))
+
+	require.NoError(t, s.DeleteNote(plain.ID))
+	_, opened := s.openedAt(owner.ID, plain.ID)
+	require.False(t, opened, "opens of deleted notes are forgotten")
+	s.MarkNoteOpened(owner.ID, plain.ID)
+	require.Empty(t, s.noteOpens)
+}
+
+func TestSavedSearches(t *testing.T) {
+	s := NewStore()
+
+	favourite := true
+	created := s.CreateSavedSearch(models.SavedSearch{
+		OwnerID: 1,
+		Name:    "Work favourites",
+		Filter:  models.NotesFilter{Tags: []string{"work"}, Favourite: &favourite},
+	})
+	require.NotZero(t, created.ID)
+	favourite = false
+	created.Filter.Tags[0] = "changed"
+	got, err := s.GetSavedSearch(created.ID)
+	require.NoError(t, err)
+	require.True(t, *got.Filter.Favourite, "stored searches do not share the filter with callers")
+	require.Equal(t, []string{"work"}, got.Filter.Tags)
+	*got.Filter.Favourite = false
+	got, err = s.GetSavedSearch(created.ID)
+	require.NoError(t, err)
+	require.True(t, *got.Filter.Favourite)
+	created.Filter.Tags[0] = "work"
+
+	s.CreateSavedSearch(models.SavedSearch{OwnerID: 1, Name: "All drafts"})
+	s.CreateSavedSearch(models.SavedSearch{OwnerID: 2, Name: "Someone else's"})
+
+	list := s.ListSavedSearches(1)
+	require.Len(t, list, 2)
+	require.Equal(t, "All drafts", list[0].Name)
+
+	created.Name = "Work"
+	updated, err := s.UpdateSavedSearch(created)
+	require.NoError(t, err)
+	require.Equal(t, "Work", updated.Name)
+	require.Equal(t, []string{"work"}, updated.Filter.Tags)
+
+	require.NoError(t, s.DeleteSavedSearch(created.ID))
+	_, err = s.GetSavedSearch(created.ID)
+	require.Error(t, err)
+	require.Error(t, s.DeleteSavedSearch(created.ID))
+}
+
+func TestNoteTemplates(t *testing.T) {
+	s := NewStore()
+
+	builtin := s.ListTemplates(1)
+	require.NotEmpty(t, builtin)
+	for _, template := range builtin {
+		require.True(t, template.BuiltIn)
+		require.Zero(t, template.OwnerID)
+	}
+
+	created := s.CreateTemplate(models.NoteTemplate{OwnerID: 1, Name: "Weekly review", Tags: []string{"review"}})
+	require.False(t, created.BuiltIn)
+	s.CreateTemplate(models.NoteTemplate{OwnerID: 1, Name: "Bug report"})
+	s.CreateTemplate(models.NoteTemplate{OwnerID: 2, Name: "Someone else's"})
+
+	list := s.ListTemplates(1)
+	require.Len(t, list, len(builtin)+2)
+	require.Equal(t, "Bug report", list[len(builtin)].Name, "own templates follow built-in ones by name")
+
+	created.Name = "Review"
+	created.Tags = []string{"weekly"}
+	updated, err := s.UpdateTemplate(created)
+	require.NoError(t, err)
+	require.Equal(t, "Review", updated.Name)
+	created.Tags[0] = "changed"
+	got, err := s.GetTemplate(created.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"weekly"}, got.Tags, "stored templates do not share slices with callers")
+
+	_, err = s.UpdateTemplate(builtin[0])
+	require.True(t, errors.Is(err, namederrors.ErrForbidden))
+	require.True(t, errors.Is(s.DeleteTemplate(builtin[0].ID), namederrors.ErrForbidden))
+
+	require.NoError(t, s.DeleteTemplate(created.ID))
+	_, err = s.GetTemplate(created.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound))
+	require.True(t, errors.Is(s.DeleteTemplate(created.ID), namederrors.ErrNotFound))
+}
+
+func TestOnboardingTemplates(t *testing.T) {
+	s := NewStore()
+	s.SetOnboardingTemplates([]models.NoteTemplate{
+		{Name: "Welcome", Title: "Welcome, {{user}}", Text: "Joined {{date}}", Folder: "Start", Tags: []string{"intro"}},
+	})
+
+	user, err := s.CreateUser("new@example.com", "password")
+	require.NoError(t, err)
+	notes := s.ListNotes(user.ID)
+	require.Len(t, notes, 1)
+	require.Equal(t, "Welcome, new@example.com", notes[0].Title)
+	require.Equal(t, "Joined "+user.CreatedAt.Format("2006-01-02"), notes[0].Text)
+	require.Equal(t, "Start", notes[0].Folder)
+	require.Equal(t, []string{"intro"}, notes[0].Tags)
+
+	s.SetOnboardingTemplates(nil)
+	user, err = s.CreateUser("empty@example.com", "password")
+	require.NoError(t, err)
+	require.Empty(t, s.ListNotes(user.ID))
+}
+
+func TestListFolders(t *testing.T) {
+	s := NewStore()
+
+	user, err := s.CreateUser("folders@example.com", "password")
+	require.NoError(t, err)
+
+	require.Equal(t, []models.Folder{
+		{Name: "Personal", NoteCount: 3},
+		{Name: "University", NoteCount: 1},
+	}, s.ListFolders(user.ID))
+
+	note := s.CreateNote(models.Note{OwnerID: user.ID, Title: "Report", Folder: "Work"})
+	require.Len(t, s.ListFolders(user.ID), 3)
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	require.Len(t, s.ListFolders(user.ID), 2)
+}
+
+func TestRevisions(t *testing.T) {
+	s := NewStore()
+
+	owner, err := s.CreateUser("history@example.com", "password")
+	require.NoError(t, err)
+
+	note := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Plan", Text: "v1"})
+	for _, text := range []string{"v2", "v3"} {
+		note.Text = text
+		note, err = s.UpdateNote(note, 7)
+		require.NoError(t, err)
+	}
+
+	revisions := s.ListRevisions(note.ID)
+	require.Len(t, revisions, 3)
+	require.Equal(t, uint64(3), revisions[0].Number, "newest revision first")
+	require.Equal(t, "v3", revisions[0].Text)
+	require.Equal(t, uint64(7), revisions[0].AuthorID)
+	require.Equal(t, owner.ID, revisions[2].AuthorID)
+
+	restored, err := s.RestoreRevision(note.ID, 1, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, "v1", restored.Text)
+
+	latest := s.ListRevisions(note.ID)[0]
+	require.Equal(t, uint64(4), latest.Number)
+	require.Equal(t, uint64(1), latest.RestoredFrom)
+	require.Len(t, s.SearchNotes(owner.ID, "v1", 10), 1, "restored content is searchable")
+
+	_, err = s.RestoreRevision(note.ID, 42, owner.ID)
+	require.Error(t, err)
+}
+
+func TestRevisionRetention(t *testing.T) {
+	s := NewStore()
+	s.SetRevisionRetention(RevisionRetention{MaxRevisions: 2})
+
+	note := s.CreateNote(models.Note{OwnerID: 1, Title: "Draft"})
+	note.Version = 0
+	for i := 0; i < 3; i++ {
+		_, err := s.UpdateNote(note, 1)
+		require.NoError(t, err)
+	}
+
+	revisions := s.ListRevisions(note.ID)
+	require.Len(t, revisions, 2)
+	require.Equal(t, uint64(4), revisions[0].Number)
+	_, err := s.GetRevision(note.ID, 1)
+	require.Error(t, err, "pruned revision is gone")
+
+	s.SetRevisionRetention(RevisionRetention{MaxAge: time.Nanosecond})
+	_, err = s.UpdateNote(note, 1)
+	require.NoError(t, err)
+	require.Len(t, s.ListRevisions(note.ID), 1, "latest revision is always kept")
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	require.Empty(t, s.ListRevisions(note.ID))
+}
+
+func TestTrash(t *testing.T) {
+	s := NewStore()
+
+	owner, err := s.CreateUser("trash@example.com", "password")
+	require.NoError(t, err)
+
+	note := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Old idea", Text: "trashable", Folder: "Ideas"})
+
+	trashed, err := s.TrashNote(note.ID, owner.ID)
+	require.NoError(t, err)
+	require.NotNil(t, trashed.DeletedAt)
+
+	require.Len(t, s.ListNotes(owner.ID), 4, "trashed note is hidden from the list")
+	require.Empty(t, s.SearchNotes(owner.ID, "trashable", 10))
+	require.Empty(t, s.QuickSwitch(owner.ID, owner.ID, "old idea", 10))
+	for _, folder := range s.ListFolders(owner.ID) {
+		require.NotEqual(t, "Ideas", folder.Name)
+	}
+	_, err = s.GetNote(note.ID)
+	require.Error(t, err)
+	_, err = s.UpdateNote(note, owner.ID)
+	require.Error(t, err)
+	_, err = s.TrashNote(note.ID, owner.ID)
+	require.Error(t, err, "note is already in trash")
+
+	trash := s.ListTrash(owner.ID)
+	require.Len(t, trash, 1)
+	require.Equal(t, note.ID, trash[0].ID)
+
+	restored, err := s.RestoreNote(note.ID, owner.ID)
+	require.NoError(t, err)
+	require.Nil(t, restored.DeletedAt)
+	require.Equal(t, "Ideas", restored.Folder)
+	require.Len(t, s.SearchNotes(owner.ID, "trashable", 10), 1)
+	require.Empty(t, s.ListTrash(owner.ID))
+
+	_, err = s.TrashNote(note.ID, owner.ID)
+	require.NoError(t, err)
+	require.Empty(t, s.PurgeTrash(0, time.Now().UTC().Add(-time.Hour)), "recently trashed notes are kept")
+	purged := s.PurgeTrash(owner.ID, time.Now().UTC().Add(time.Second))
+	require.Len(t, purged, 1)
+	require.Equal(t, note.ID, purged[0].ID)
+	_, err = s.GetTrashedNote(note.ID)
+	require.Error(t, err)
+	require.Empty(t, s.ListRevisions(note.ID))
+}
+
+func TestChangesSince(t *testing.T) {
+	s := NewStore()
+
+	first := s.CreateNote(models.Note{OwnerID: 1, Title: "First"})
+	second := s.CreateNote(models.Note{OwnerID: 1, Title: "Second"})
+	s.CreateNote(models.Note{OwnerID: 2, Title: "Foreign"})
+
+	initial := s.ChangesSince(1, 0)
+	require.Len(t, initial.Notes, 2)
+	require.Empty(t, initial.Tombstones)
+	require.Empty(t, s.ChangesSince(1, initial.Cursor).Notes)
+
+	second.Title = "Second, edited"
+	_, err := s.UpdateNote(second, 1)
+	require.NoError(t, err)
+	_, err = s.TrashNote(first.ID, 1)
+	require.NoError(t, err)
+
+	changes := s.ChangesSince(1, initial.Cursor)
+	require.Len(t, changes.Notes, 2)
+	require.Equal(t, second.ID, changes.Notes[0].ID, "ordered by change")
+	require.NotNil(t, changes.Notes[1].DeletedAt, "trashed notes are reported")
+
+	require.NoError(t, s.DeleteNote(first.ID))
+	purged := s.ChangesSince(1, changes.Cursor)
+	require.Empty(t, purged.Notes)
+	require.Len(t, purged.Tombstones, 1)
+	require.Equal(t, first.ID, purged.Tombstones[0].NoteID)
+	require.Empty(t, s.ChangesSince(2, changes.Cursor).Tombstones)
+
+	require.True(t, s.ChangesSince(1, purged.Cursor+10).Reset, "cursor from a previous store")
+}
+
+func TestNoteShares(t *testing.T) {
+	s := NewStore()
+	owner, err := s.CreateUser("owner@example.com", "password")
+	require.NoError(t, err)
+	reader, err := s.CreateUser("reader@example.com", "password")
+	require.NoError(t, err)
+	note := s.CreateNote(models.Note{OwnerID: owner.ID, Title: "Shared"})
+
+	share, err := s.ShareNote(models.NoteShare{NoteID: note.ID, OwnerID: owner.ID, UserID: reader.ID, Email: reader.Email, Role: models.RoleViewer})
+	require.NoError(t, err)
+	require.False(t, share.Pending)
+
+	again, err := s.ShareNote(models.NoteShare{NoteID: note.ID, OwnerID: owner.ID, UserID: reader.ID, Email: reader.Email, Role: models.RoleEditor})
+	require.NoError(t, err)
+	require.Equal(t, share.ID, again.ID, "sharing again changes the role")
+	found, err := s.GetNoteShare(note.ID, reader.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.RoleEditor, found.Role)
+
+	pending, err := s.ShareNote(models.NoteShare{NoteID: note.ID, OwnerID: owner.ID, Email: "Invitee@example.com", Role: models.RoleCommenter})
+	require.NoError(t, err)
+	require.True(t, pending.Pending)
+	require.Len(t, s.ListNoteShares(note.ID), 2)
+
+	invitee, err := s.CreateUser("invitee@example.com", "password")
+	require.NoError(t, err)
+	shared := s.ListSharedWith(invitee.ID)
+	require.Len(t, shared, 1, "invitation is bound on registration")
+	require.Equal(t, pending.ID, shared[0].ID)
+	require.False(t, shared[0].Pending)
+
+	require.NoError(t, s.DeleteShare(share.ID))
+	_, err = s.GetNoteShare(note.ID, reader.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound))
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	require.Empty(t, s.ListSharedWith(invitee.ID), "shares are removed with the note")
+	_, err = s.ShareNote(models.NoteShare{NoteID: note.ID, UserID: reader.ID, Role: models.RoleViewer})
+	require.True(t, errors.Is(err, namederrors.ErrNotFound))
+}
+
+func TestShareLinks(t *testing.T) {
+	s := NewStore()
+	note := s.CreateNote(models.Note{OwnerID: 1, Title: "Public"})
+
+	limited, err := s.CreateShareLink(models.ShareLink{Token: "limited", NoteID: note.ID, OwnerID: 1, MaxViews: 2})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		link, viewed, err := s.ViewShareLink("limited")
+		require.NoError(t, err)
+		require.Equal(t, i+1, link.Views)
+		require.Equal(t, "Public", viewed.Title)
+	}
+	_, _, err = s.ViewShareLink("limited")
+	require.True(t, errors.Is(err, namederrors.ErrLinkExpired), "view limit reached")
+
+	past := time.Now().Add(-time.Minute)
+	_, err = s.CreateShareLink(models.ShareLink{Token: "expired", NoteID: note.ID, OwnerID: 1, ExpiresAt: &past})
+	require.NoError(t, err)
+	_, _, err = s.ViewShareLink("expired")
+	require.True(t, errors.Is(err, namederrors.ErrLinkExpired))
+
+	open, err := s.CreateShareLink(models.ShareLink{Token: "open", NoteID: note.ID, OwnerID: 1})
+	require.NoError(t, err)
+	_, err = s.RevokeShareLink(open.ID)
+	require.NoError(t, err)
+	_, _, err = s.ViewShareLink("open")
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "revoked links do not open")
+	require.Len(t, s.ListShareLinks(note.ID), 3)
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	_, err = s.GetShareLink(limited.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "links are removed with the note")
+}
+
+func TestShareLinkAttempts(t *testing.T) {
+	s := NewStore()
+	note := s.CreateNote(models.Note{OwnerID: 1, Title: "Secret"})
+	link, err := s.CreateShareLink(models.ShareLink{Token: "secret", NoteID: note.ID, OwnerID: 1})
+	require.NoError(t, err)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.StartLinkAttempt(link.ID, now, 3, time.Minute))
+	}
+	err = s.StartLinkAttempt(link.ID, now.Add(59*time.Second), 3, time.Minute)
+	require.True(t, errors.Is(err, namederrors.ErrTooManyAttempts), "locked after the limit")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, s.StartLinkAttempt(link.ID, now, 3, time.Minute), "lock expires")
+	require.NoError(t, s.StartLinkAttempt(link.ID, now, 3, time.Minute))
+	s.ResetLinkAttempts(link.ID)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.StartLinkAttempt(link.ID, now, 3, time.Minute), "correct password resets the counter")
+	}
+
+	err = s.StartLinkAttempt(link.ID+1, now, 3, time.Minute)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound))
+	require.NoError(t, s.DeleteNote(note.ID))
+	require.Empty(t, s.linkAttempts, "attempts are removed with the link")
+}
+
+func TestPublications(t *testing.T) {
+	s := NewStore()
+	first := s.CreateNote(models.Note{OwnerID: 1, Title: "First"})
+	second := s.CreateNote(models.Note{OwnerID: 1, Title: "Second"})
+
+	published, err := s.Publish(models.Publication{NoteID: first.ID, OwnerID: 1, Slug: "first"})
+	require.NoError(t, err)
+	_, err = s.Publish(models.Publication{NoteID: second.ID, OwnerID: 1, Slug: "first"})
+	require.True(t, errors.Is(err, namederrors.ErrSlugTaken))
+
+	pages := []uint64{second.ID}
+	renamed, err := s.Publish(models.Publication{NoteID: first.ID, OwnerID: 1, Slug: "renamed", IncludeSubpages: true, PageIDs: pages})
+	require.NoError(t, err)
+	require.Equal(t, published.CreatedAt, renamed.CreatedAt)
+	pages[0] = 0
+	stored, err := s.GetPublication(first.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{second.ID}, stored.PageIDs, "page list is copied")
+	_, err = s.GetPublicationBySlug("first")
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "old slug is released")
+
+	_, err = s.TrashNote(first.ID, 1)
+	require.NoError(t, err)
+	require.Empty(t, s.ListPublications(), "trashed notes are not listed")
+
+	require.NoError(t, s.DeleteNote(first.ID))
+	_, err = s.GetPublicationBySlug("renamed")
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "publication is removed with the note")
+}
+
+func TestWorkspaces(t *testing.T) {
+	s := NewStore()
+	owner, err := s.CreateUser("owner@example.com", "password")
+	require.NoError(t, err)
+
+	workspace, err := s.CreateWorkspace("Team", owner.ID)
+	require.NoError(t, err)
+	_, taken := s.Users[workspace.ID]
+	require.False(t, taken, "workspace IDs do not collide with user IDs")
+	member, err := s.GetWorkspaceMember(workspace.ID, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorkspaceRoleOwner, member.Role)
+
+	invitation, err := s.InviteToWorkspace(models.WorkspaceInvitation{WorkspaceID: workspace.ID, Email: "Invitee@example.com", Role: models.WorkspaceRoleGuest})
+	require.NoError(t, err)
+	again, err := s.InviteToWorkspace(models.WorkspaceInvitation{WorkspaceID: workspace.ID, Email: "invitee@example.com", Role: models.WorkspaceRoleMember})
+	require.NoError(t, err)
+	require.Equal(t, invitation.ID, again.ID, "inviting again changes the role")
+	require.Equal(t, "Team", again.WorkspaceName)
+
+	invitee, err := s.CreateUser("invitee@example.com", "password")
+	require.NoError(t, err)
+	invitations := s.ListInvitationsByEmail(invitee.Email)
+	require.Len(t, invitations, 1)
+	joined, err := s.AcceptWorkspaceInvitation(invitations[0].ID, invitee.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorkspaceRoleMember, joined.Role)
+	require.Empty(t, s.ListWorkspaceInvitations(workspace.ID), "accepted invitation is removed")
+	require.Len(t, s.ListWorkspaceMembers(workspace.ID), 2)
+	require.Len(t, s.ListUserWorkspaces(invitee.ID), 1)
+
+	note := s.CreateNote(models.Note{OwnerID: workspace.ID, Title: "Roadmap", CreatedBy: invitee.ID})
+	require.Len(t, s.ListNotes(workspace.ID), 1)
+	_, err = s.CreateAttachment(models.Attachment{NoteID: note.ID, Filename: "plan.pdf", Size: 10, Key: "ws/plan"}, 0)
+	require.NoError(t, err)
+	job := s.CreateImportJob(models.ImportJob{OwnerID: workspace.ID, Source: "notion"})
+	s.SaveMutationResult(workspace.ID, models.MutationResult{MutationID: "m1"})
+	cursor := s.ChangesSince(workspace.ID, 0).Cursor
+
+	removed, err := s.DeleteWorkspace(workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	require.Equal(t, note.ID, removed[0].ID)
+	_, err = s.GetNote(note.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "notes are removed with the workspace")
+	require.Empty(t, s.ListUserWorkspaces(invitee.ID))
+	require.Zero(t, s.StorageUsed(workspace.ID))
+	require.Equal(t, []string{"ws/plan"}, s.TakeOrphanBlobs(), "attachment content is deleted later")
+	_, err = s.GetImportJob(job.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "import jobs are removed with the workspace")
+	_, ok := s.GetMutationResult(workspace.ID, "m1")
+	require.False(t, ok)
+	tombstones := s.ChangesSince(workspace.ID, cursor).Tombstones
+	require.Len(t, tombstones, 1, "sync clients learn that the note is gone")
+	require.Equal(t, note.ID, tombstones[0].NoteID)
+}
+
+func TestCommentThreads(t *testing.T) {
+	s := NewStore()
+	note := s.CreateNote(models.Note{OwnerID: 1, Title: "Spec", Text: "Intro. Details here."})
+
+	_, err := s.CreateThread(models.CommentThread{NoteID: note.ID, Anchor: &models.CommentAnchor{Start: 5, End: 50}}, models.Comment{AuthorID: 1, Text: "?"})
+	require.True(t, errors.Is(err, namederrors.ErrInvalidAnchor))
+
+	thread, err := s.CreateThread(models.CommentThread{NoteID: note.ID, Anchor: &models.CommentAnchor{Start: 7, End: 14}}, models.Comment{AuthorID: 1, Text: "Expand"})
+	require.NoError(t, err)
+	require.Equal(t, "Details", thread.Anchor.Quote)
+	whole, err := s.CreateThread(models.CommentThread{NoteID: note.ID}, models.Comment{AuthorID: 2, Text: "Nice"})
+	require.NoError(t, err)
+
+	reply, err := s.AddComment(thread.ID, models.Comment{AuthorID: 2, Text: "Agreed"})
+	require.NoError(t, err)
+	_, err = s.UpdateComment(thread.ID, reply.ID, "Agreed!")
+	require.NoError(t, err)
+	resolved, err := s.SetThreadResolved(thread.ID, true, 2)
+	require.NoError(t, err)
+	require.True(t, resolved.Resolved)
+	require.Len(t, resolved.Comments, 2)
+
+	note.Text = "Summary first. Intro. Details here."
+	_, err = s.UpdateNote(note, 1)
+	require.NoError(t, err)
+	moved, err := s.GetThread(thread.ID)
+	require.NoError(t, err)
+	require.Equal(t, 22, moved.Anchor.Start, "anchor follows the edit")
+	require.False(t, moved.Outdated)
+
+	note.Text = "Summary first. Intro."
+	note.Version = 0
+	_, err = s.UpdateNote(note, 1)
+	require.NoError(t, err)
+	lost, err := s.GetThread(thread.ID)
+	require.NoError(t, err)
+	require.True(t, lost.Outdated, "anchor text is gone")
+
+	deleted, err := s.DeleteComment(whole.ID, whole.Comments[0].ID)
+	require.NoError(t, err)
+	require.True(t, deleted, "thread without comments is removed")
+	require.Len(t, s.ListThreads(note.ID), 1)
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	_, err = s.GetThread(thread.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "threads are removed with the note")
+}
+
+func TestNotifications(t *testing.T) {
+	s := NewStore()
+
+	first := s.AddNotification(models.Notification{UserID: 1, Type: models.NotificationMention, NoteID: 1})
+	s.AddNotification(models.Notification{UserID: 1, Type: models.NotificationShare, NoteID: 2})
+	s.AddNotification(models.Notification{UserID: 2, Type: models.NotificationShare, NoteID: 2})
+	require.Equal(t, 2, s.CountUnreadNotifications(1))
+
+	listed := s.ListNotifications(1, false, 0)
+	require.Len(t, listed, 2)
+	require.Equal(t, models.NotificationShare, listed[0].Type, "newest first")
+
+	read, err := s.MarkNotificationRead(1, first.ID)
+	require.NoError(t, err)
+	require.True(t, read.Read)
+	require.NotNil(t, read.ReadAt)
+	require.Len(t, s.ListNotifications(1, true, 0), 1)
+	_, err = s.MarkNotificationRead(2, first.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "other users cannot mark notifications")
+
+	require.Equal(t, 1, s.MarkAllNotificationsRead(1))
+	require.Zero(t, s.CountUnreadNotifications(1))
+	require.Equal(t, 1, s.CountUnreadNotifications(2))
+}
+
+func TestReminders(t *testing.T) {
+	s := NewStore()
+	note := s.CreateNote(models.Note{OwnerID: 1, Title: "Later"})
+	now := time.Now()
+
+	due, err := s.CreateReminder(models.Reminder{UserID: 1, OwnerID: 1, NoteID: note.ID, RemindAt: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	_, err = s.CreateReminder(models.Reminder{UserID: 1, OwnerID: 1, NoteID: note.ID, RemindAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, s.ListReminders(1), 2)
+
+	taken := s.TakeDueReminders(now)
+	require.Len(t, taken, 1)
+	require.Equal(t, due.ID, taken[0].ID)
+	require.Empty(t, s.TakeDueReminders(now), "due reminders fire once")
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	require.Empty(t, s.ListReminders(1), "reminders are removed with the note")
+}
+
+func TestWikiLinks(t *testing.T) {
+	s := NewStore()
+	books := s.CreateNote(models.Note{OwnerID: 1, Title: "Books"})
+	plan := s.CreateNote(models.Note{OwnerID: 1, Title: "Plan", Text: "Read [[books|the list]], see [[#" + strconv.FormatUint(books.ID, 10) + "]] and [[Films]]"})
+	s.CreateNote(models.Note{OwnerID: 2, Title: "Films", Text: "[[Books]]"})
+
+	links, err := s.ListWikiLinks(plan.ID)
+	require.NoError(t, err)
+	require.Len(t, links, 3)
+	require.Equal(t, books.ID, links[0].NoteID)
+	require.Equal(t, books.ID, links[1].NoteID, "ID links resolve too")
+	require.True(t, links[2].Broken, "links do not cross owners")
+
+	backlinks, err := s.ListBacklinks(books.ID)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 1)
+	require.Equal(t, plan.ID, backlinks[0].NoteID)
+	require.Len(t, s.ListBrokenWikiLinks(1), 1)
+
+	films := s.CreateNote(models.Note{OwnerID: 1, Title: "films"})
+	require.Empty(t, s.ListBrokenWikiLinks(1), "a new note fixes the link")
+	graph := s.NoteGraph(1)
+	require.Len(t, graph.Nodes, 3)
+	require.Len(t, graph.Edges, 2)
+
+	books.Title = "Reading list"
+	_, err = s.UpdateNote(books, 1)
+	require.NoError(t, err)
+	renamed := s.RenameWikiLinks(1, "Books", "Reading list", 1)
+	require.Len(t, renamed, 1)
+	require.Equal(t, "Read [[Reading list|the list]], see [[#"+strconv.FormatUint(books.ID, 10)+"]] and [[Films]]", renamed[0].Text)
+	require.Equal(t, plan.Version+1, renamed[0].Version)
+	other := s.ListNotes(2)
+	require.Equal(t, "[[Books]]", other[0].Text, "other owners keep their links")
+
+	_, err = s.TrashNote(films.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, s.ListBrokenWikiLinks(1), 1, "links to trashed notes are broken")
+}
+
+func TestAttachments(t *testing.T) {
+	s := NewStore()
+	note := s.CreateNote(models.Note{OwnerID: 1, Title: "Trip"})
+
+	photo, err := s.CreateAttachment(models.Attachment{NoteID: note.ID, Filename: "photo.png", Size: 60, Key: "1/photo"}, 100)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), photo.OwnerID)
+	_, err = s.CreateAttachment(models.Attachment{NoteID: note.ID, Filename: "video.mp4", Size: 50, Key: "1/video"}, 100)
+	require.True(t, errors.Is(err, namederrors.ErrQuotaExceeded))
+	ticket, err := s.CreateAttachment(models.Attachment{NoteID: note.ID, Filename: "ticket.pdf", Size: 40, Key: "1/ticket"}, 100)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), s.StorageUsed(1))
+	require.Len(t, s.ListAttachments(note.ID), 2)
+
+	deleted, err := s.DeleteAttachment(photo.ID)
+	require.NoError(t, err)
+	require.Equal(t, "1/photo", deleted.Key)
+	require.Equal(t, int64(40), s.StorageUsed(1))
+	require.Empty(t, s.TakeOrphanBlobs(), "deleted attachments are cleaned up by the caller")
+
+	require.NoError(t, s.SetAttachmentImage(ticket.ID, models.AttachmentImage{
+		Status:   models.ImageReady,
+		Variants: []models.ImageVariant{{Name: "small", Size: 5, Key: "1/ticket.small"}},
+	}, 30))
+	require.Equal(t, int64(30), s.StorageUsed(1), "variants do not count towards the quota")
+	require.True(t, errors.Is(s.SetAttachmentImage(photo.ID, models.AttachmentImage{}, 1), namederrors.ErrNotFound))
+
+	require.NoError(t, s.DeleteNote(note.ID))
+	_, err = s.GetAttachment(ticket.ID)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound), "attachments are removed with the note")
+	require.Zero(t, s.StorageUsed(1))
+	require.Equal(t, []string{"1/ticket", "1/ticket.small"}, s.TakeOrphanBlobs())
+	require.Empty(t, s.TakeOrphanBlobs())
+}
+
+func TestImportJobs(t *testing.T) {
+	s := NewStore()
+	first := s.CreateImportJob(models.ImportJob{OwnerID: 1, Source: "notion"})
+	require.Equal(t, models.ImportQueued, first.Status)
+	second := s.CreateImportJob(models.ImportJob{OwnerID: 1, Source: "evernote"})
+	s.CreateImportJob(models.ImportJob{OwnerID: 2, Source: "obsidian"})
+
+	second.Status = models.ImportRunning
+	second.Total = 2
+	second.Processed = 1
+	second.NoteIDs = []uint64{7}
+	second.Errors = []models.ImportItemError{{Path: "Trip.enex/Beach", Error: "invalid note"}}
+	require.NoError(t, s.UpdateImportJob(second))
+
+	second.NoteIDs[0] = 8
+	got, err := s.GetImportJob(second.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{7}, got.NoteIDs, "stored jobs do not share slices with callers")
+	require.Equal(t, 1, got.Processed)
+	require.Len(t, got.Errors, 1)
+
+	jobs := s.ListImportJobs(1)
+	require.Len(t, jobs, 2)
+	require.Equal(t, second.ID, jobs[0].ID)
+
+	_, err = s.GetImportJob(100)
+	require.True(t, errors.Is(err, namederrors.ErrNotFound))
+	require.True(t, errors.Is(s.UpdateImportJob(models.ImportJob{ID: 100}), namederrors.ErrNotFound))
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"backend/models"
+	"sort"
+	"time"
+)
+
+const (
+	// maxTombstones ограничивает журнал безвозвратно удалённых заметок; клиенты
+	// с курсором старше вытесненной записи получают полный список.
+	maxTombstones = 10000
+	// maxMutationResults — сколько последних результатов мутаций пользователя
+	// хранится для идемпотентных повторов.
+	maxMutationResults = 1000
+)
+
+type mutationLog struct {
+	results map[string]models.MutationResult
+	order   []string
+}
+
+// markChanged присваивает заметке очередной номер изменения.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) markChanged(note *models.Note) {
+	note.ChangeSeq = s.nextChangeSeq
+	s.nextChangeSeq++
+}
+
+// recordTombstone запоминает безвозвратное удаление заметки.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) recordTombstone(note *models.Note) {
+	s.tombstones = append(s.tombstones, models.Tombstone{
+		NoteID:    note.ID,
+		OwnerID:   note.OwnerID,
+		ChangeSeq: s.nextChangeSeq,
+		DeletedAt: time.Now().UTC(),
+	})
+	s.nextChangeSeq++
+
+	if len(s.tombstones) > maxTombstones {
+		dropped := len(s.tombstones) - maxTombstones
+		s.tombstoneFloor = s.tombstones[dropped-1].ChangeSeq
+		s.tombstones = append(s.tombstones[:0], s.tombstones[dropped:]...)
+	}
+}
+
+// ChangesSince возвращает заметки владельца (включая лежащие в корзине) и
+// надгробия, изменившиеся после курсора, упорядоченные по номеру изменения.
+func (s *Store) ChangesSince(ownerID, cursor uint64) models.NoteChanges {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	changes := models.NoteChanges{
+		Cursor:     s.nextChangeSeq - 1,
+		Notes:      make([]models.Note, 0),
+		Tombstones: make([]models.Tombstone, 0),
+	}
+	// Курсор из будущего означает, что хранилище начато заново.
+	if cursor < s.tombstoneFloor || cursor > changes.Cursor {
+		changes.Reset = true
+		cursor = 0
+	}
+
+	for _, note := range s.Notes {
+		if note.OwnerID == ownerID && note.ChangeSeq > cursor {
+			changes.Notes = append(changes.Notes, *note)
+		}
+	}
+	sort.Slice(changes.Notes, func(i, j int) bool {
+		return changes.Notes[i].ChangeSeq < changes.Notes[j].ChangeSeq
+	})
+
+	if cursor > 0 {
+		start := sort.Search(len(s.tombstones), func(i int) bool {
+			return s.tombstones[i].ChangeSeq > cursor
+		})
+		for _, tombstone := range s.tombstones[start:] {
+			if tombstone.OwnerID == ownerID {
+				changes.Tombstones = append(changes.Tombstones, tombstone)
+			}
+		}
+	}
+
+	return changes
+}
+
+// GetMutationResult возвращает сохранённый результат мутации владельца.
+func (s *Store) GetMutationResult(ownerID uint64, mutationID string) (models.MutationResult, bool) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	log, ok := s.mutations[ownerID]
+	if !ok {
+		return models.MutationResult{}, false
+	}
+	result, ok := log.results[mutationID]
+	return result, ok
+}
+
+// SaveMutationResult сохраняет результат мутации, вытесняя самые старые.
+func (s *Store) SaveMutationResult(ownerID uint64, result models.MutationResult) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	log, ok := s.mutations[ownerID]
+	if !ok {
+		log = &mutationLog{results: make(map[string]models.MutationResult)}
+		s.mutations[ownerID] = log
+	}
+	if _, exists := log.results[result.MutationID]; !exists {
+		log.order = append(log.order, result.MutationID)
+	}
+	log.results[result.MutationID] = result
+
+	if len(log.order) > maxMutationResults {
+		delete(log.results, log.order[0])
+		log.order = log.order[1:]
+	}
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/templates"
+	"slices"
+	"sort"
+	"time"
+)
+
+// SetOnboardingTemplates задаёт шаблоны стартовых заметок новых пользователей.
+func (s *Store) SetOnboardingTemplates(onboarding []models.NoteTemplate) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	s.onboarding = slices.Clone(onboarding)
+}
+
+// insertTemplate присваивает шаблону ID и время создания и сохраняет его.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) insertTemplate(template models.NoteTemplate) models.NoteTemplate {
+	now := time.Now().UTC()
+	template.ID = s.nextTemplateID
+	s.nextTemplateID++
+	template.Tags = slices.Clone(template.Tags)
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	stored := template
+	s.noteTemplates[stored.ID] = &stored
+
+	return template
+}
+
+// addBuiltinTemplates сохраняет встроенные шаблоны без владельца.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) addBuiltinTemplates() {
+	for _, template := range templates.Builtin() {
+		template.BuiltIn = true
+		s.insertTemplate(template)
+	}
+}
+
+func (s *Store) CreateTemplate(template models.NoteTemplate) models.NoteTemplate {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	template.BuiltIn = false
+	return s.insertTemplate(template)
+}
+
+func (s *Store) GetTemplate(templateID uint64) (models.NoteTemplate, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	template, ok := s.noteTemplates[templateID]
+	if !ok {
+		return models.NoteTemplate{}, namederrors.ErrNotFound
+	}
+
+	result := *template
+	result.Tags = slices.Clone(template.Tags)
+	return result, nil
+}
+
+// ListTemplates возвращает встроенные шаблоны, а за ними шаблоны владельца
+// по алфавиту.
+func (s *Store) ListTemplates(ownerID uint64) []models.NoteTemplate {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.NoteTemplate, 0)
+	for _, template := range s.noteTemplates {
+		if template.BuiltIn || template.OwnerID == ownerID {
+			stored := *template
+			stored.Tags = slices.Clone(template.Tags)
+			result = append(result, stored)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BuiltIn != result[j].BuiltIn {
+			return result[i].BuiltIn
+		}
+		if result[i].BuiltIn {
+			return result[i].ID < result[j].ID
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+func (s *Store) UpdateTemplate(template models.NoteTemplate) (models.NoteTemplate, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	stored, ok := s.noteTemplates[template.ID]
+	if !ok {
+		return models.NoteTemplate{}, namederrors.ErrNotFound
+	}
+	if stored.BuiltIn {
+		return models.NoteTemplate{}, namederrors.ErrForbidden
+	}
+
+	stored.Name = template.Name
+	stored.Title = template.Title
+	stored.Text = template.Text
+	stored.Favourite = template.Favourite
+	stored.Folder = template.Folder
+	stored.Tags = slices.Clone(template.Tags)
+	stored.UpdatedAt = time.Now().UTC()
+
+	result := *stored
+	result.Tags = slices.Clone(stored.Tags)
+	return result, nil
+}
+
+func (s *Store) DeleteTemplate(templateID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	template, ok := s.noteTemplates[templateID]
+	if !ok {
+		return namederrors.ErrNotFound
+	}
+	if template.BuiltIn {
+		return namederrors.ErrForbidden
+	}
+	delete(s.noteTemplates, templateID)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"sort"
+	"time"
+)
+
+// TrashNote перемещает заметку в корзину: она пропадает из списков и поиска,
+// но сохраняет папку и историю до восстановления или очистки.
+func (s *Store) TrashNote(noteID, userID uint64) (models.Note, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	note, ok := s.activeNote(noteID)
+	if !ok {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+
+	s.unindexNote(note)
+	now := time.Now().UTC()
+	note.DeletedAt = &now
+	note.DeletedBy = userID
+	s.markChanged(note)
+
+	return *note, nil
+}
+
+// RestoreNote возвращает заметку из корзины в её прежнюю папку.
+func (s *Store) RestoreNote(noteID, userID uint64) (models.Note, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	note, ok := s.Notes[noteID]
+	if !ok || note.DeletedAt == nil {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+
+	note.DeletedAt = nil
+	note.DeletedBy = 0
+	s.touchNote(note, userID)
+	s.indexNote(note)
+
+	return *note, nil
+}
+
+func (s *Store) GetTrashedNote(noteID uint64) (models.Note, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	note, ok := s.Notes[noteID]
+	if !ok || note.DeletedAt == nil {
+		return models.Note{}, namederrors.ErrNotFound
+	}
+
+	return *note, nil
+}
+
+// ListTrash возвращает заметки владельца из корзины, недавно удалённые первыми.
+func (s *Store) ListTrash(ownerID uint64) []models.Note {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.Note, 0)
+	for _, note := range s.Notes {
+		if note.OwnerID == ownerID && note.DeletedAt != nil {
+			result = append(result, *note)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DeletedAt.After(*result[j].DeletedAt)
+	})
+
+	return result
+}
+
+// PurgeTrash безвозвратно удаляет заметки, попавшие в корзину раньше before.
+// ownerID == 0 означает заметки всех пользователей. Возвращает удалённые заметки.
+func (s *Store) PurgeTrash(ownerID uint64, before time.Time) []models.Note {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	purged := make([]models.Note, 0)
+	for _, note := range s.Notes {
+		if note.DeletedAt == nil || !note.DeletedAt.Before(before) {
+			continue
+		}
+		if ownerID != 0 && note.OwnerID != ownerID {
+			continue
+		}
+		purged = append(purged, *note)
+		s.removeNote(note)
+	}
+
+	return purged
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/wikilinks"
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// Ссылки между заметками хранятся как разобранные из текста цели и
+// сопоставляются с заметками при запросе: так ссылка чинится сама, когда
+// появляется заметка с нужным заголовком, и ломается, когда цель удалена.
+// Ссылки действуют в пределах одного владельца.
+
+// indexWikiLinks запоминает ссылки из текста заметки. Вызывается под блокировкой s.Mu.
+func (s *Store) indexWikiLinks(note *models.Note) {
+	links := wikilinks.Parse(note.Text)
+	if len(links) == 0 {
+		delete(s.wikiLinks, note.ID)
+		return
+	}
+	s.wikiLinks[note.ID] = links
+}
+
+// titleTargets возвращает заметки владельца по ключу заголовка. Из заметок с
+// одинаковым заголовком ссылка ведёт на созданную раньше. Вызывается под
+// блокировкой s.Mu.
+func (s *Store) titleTargets(ownerID uint64) map[string]*models.Note {
+	targets := make(map[string]*models.Note)
+	for _, note := range s.Notes {
+		if note.OwnerID != ownerID || note.DeletedAt != nil {
+			continue
+		}
+		key := wikilinks.Key(note.Title)
+		if current, ok := targets[key]; !ok || note.ID < current.ID {
+			targets[key] = note
+		}
+	}
+	return targets
+}
+
+// resolveWikiLink находит заметку, на которую ведёт ссылка из заметки
+// владельца ownerID. Вызывается под блокировкой s.Mu.
+func (s *Store) resolveWikiLink(ownerID uint64, link wikilinks.Link, targets map[string]*models.Note) (*models.Note, bool) {
+	if link.NoteID != 0 {
+		note, ok := s.activeNote(link.NoteID)
+		if !ok || note.OwnerID != ownerID {
+			return nil, false
+		}
+		return note, true
+	}
+	note, ok := targets[wikilinks.Key(link.Title)]
+	return note, ok
+}
+
+// ownerNotes возвращает активные заметки владельца по возрастанию ID.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) ownerNotes(ownerID uint64) []*models.Note {
+	var notes []*models.Note
+	for _, note := range s.Notes {
+		if note.OwnerID == ownerID && note.DeletedAt == nil {
+			notes = append(notes, note)
+		}
+	}
+	slices.SortFunc(notes, func(a, b *models.Note) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return notes
+}
+
+// ListWikiLinks возвращает ссылки из текста заметки в порядке их появления.
+func (s *Store) ListWikiLinks(noteID uint64) ([]models.WikiLink, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	note, ok := s.activeNote(noteID)
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+
+	targets := s.titleTargets(note.OwnerID)
+	links := make([]models.WikiLink, 0, len(s.wikiLinks[noteID]))
+	for _, link := range s.wikiLinks[noteID] {
+		wikiLink := models.WikiLink{Target: link.Target()}
+		if target, ok := s.resolveWikiLink(note.OwnerID, link, targets); ok {
+			wikiLink.NoteID = target.ID
+			wikiLink.Title = target.Title
+		} else {
+			wikiLink.Broken = true
+		}
+		links = append(links, wikiLink)
+	}
+	return links, nil
+}
+
+// ListBacklinks возвращает заметки, ссылающиеся на заметку, от недавно
+// изменённых к давним.
+func (s *Store) ListBacklinks(noteID uint64) ([]models.Backlink, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	note, ok := s.activeNote(noteID)
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+
+	targets := s.titleTargets(note.OwnerID)
+	backlinks := make([]models.Backlink, 0)
+	for _, source := range s.ownerNotes(note.OwnerID) {
+		if source.ID == noteID {
+			continue
+		}
+		for _, link := range s.wikiLinks[source.ID] {
+			if target, ok := s.resolveWikiLink(note.OwnerID, link, targets); ok && target.ID == noteID {
+				backlinks = append(backlinks, models.Backlink{NoteID: source.ID, Title: source.Title, UpdatedAt: source.UpdatedAt})
+				break
+			}
+		}
+	}
+	sort.SliceStable(backlinks, func(i, j int) bool {
+		return backlinks[i].UpdatedAt.After(backlinks[j].UpdatedAt)
+	})
+	return backlinks, nil
+}
+
+// ListBrokenWikiLinks возвращает ссылки из заметок владельца, для которых не
+// нашлось заметки.
+func (s *Store) ListBrokenWikiLinks(ownerID uint64) []models.BrokenLink {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	targets := s.titleTargets(ownerID)
+	broken := make([]models.BrokenLink, 0)
+	for _, source := range s.ownerNotes(ownerID) {
+		for _, link := range s.wikiLinks[source.ID] {
+			if _, ok := s.resolveWikiLink(ownerID, link, targets); !ok {
+				broken = append(broken, models.BrokenLink{NoteID: source.ID, Title: source.Title, Target: link.Target()})
+			}
+		}
+	}
+	return broken
+}
+
+// NoteGraph возвращает заметки владельца и ссылки между ними. Ссылки заметки
+// на саму себя и битые ссылки в граф не входят.
+func (s *Store) NoteGraph(ownerID uint64) models.NoteGraph {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	notes := s.ownerNotes(ownerID)
+	targets := s.titleTargets(ownerID)
+	graph := models.NoteGraph{
+		Nodes: make([]models.GraphNode, 0, len(notes)),
+		Edges: make([]models.GraphEdge, 0),
+	}
+	nodes := make(map[uint64]int, len(notes))
+	for _, note := range notes {
+		nodes[note.ID] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, models.GraphNode{
+			ID:     note.ID,
+			Title:  note.Title,
+			Folder: note.Folder,
+			Tags:   slices.Clone(note.Tags),
+		})
+	}
+	for _, source := range notes {
+		linked := make(map[uint64]bool)
+		for _, link := range s.wikiLinks[source.ID] {
+			target, ok := s.resolveWikiLink(ownerID, link, targets)
+			if !ok || target.ID == source.ID || linked[target.ID] {
+				continue
+			}
+			linked[target.ID] = true
+			graph.Edges = append(graph.Edges, models.GraphEdge{Source: source.ID, Target: target.ID})
+			graph.Nodes[nodes[source.ID]].Links++
+			graph.Nodes[nodes[target.ID]].Backlinks++
+		}
+	}
+	return graph
+}
+
+// RenameWikiLinks переписывает ссылки на заголовок oldTitle в заметках
+// владельца на newTitle, если под старым заголовком не осталось другой
+// заметки. Возвращает изменённые заметки.
+func (s *Store) RenameWikiLinks(ownerID uint64, oldTitle, newTitle string, editorID uint64) []models.Note {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if wikilinks.Key(oldTitle) == wikilinks.Key(newTitle) {
+		return nil
+	}
+	if _, ok := s.titleTargets(ownerID)[wikilinks.Key(oldTitle)]; ok {
+		return nil
+	}
+
+	var renamed []models.Note
+	for _, note := range s.ownerNotes(ownerID) {
+		text, changed := wikilinks.Rename(note.Text, oldTitle, newTitle)
+		if !changed {
+			continue
+		}
+		s.unindexNote(note)
+		s.reanchorThreads(note.ID, note.Text, text)
+		note.Text = text
+		s.touchNote(note, editorID)
+		s.indexNote(note)
+		s.recordRevision(note, 0)
+		renamed = append(renamed, *note)
+	}
+	return renamed
+}
@@ -0,0 +1,319 @@
+package store
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CreateWorkspace создаёт рабочее пространство, создатель становится его
+// владельцем. ID пространства берётся из последовательности пользователей,
+// чтобы заметки пространства хранились под его ID как под OwnerID.
+func (s *Store) CreateWorkspace(name string, ownerID uint64) (models.Workspace, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	owner, ok := s.Users[ownerID]
+	if !ok {
+		return models.Workspace{}, namederrors.ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	workspace := &models.Workspace{
+		ID:        s.nextUserID,
+		Name:      name,
+		CreatedBy: ownerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.nextUserID++
+
+	s.workspaces[workspace.ID] = workspace
+	s.members[workspace.ID] = map[uint64]*models.WorkspaceMember{
+		ownerID: {
+			WorkspaceID: workspace.ID,
+			UserID:      ownerID,
+			Email:       owner.Email,
+			Role:        models.WorkspaceRoleOwner,
+			JoinedAt:    now,
+		},
+	}
+
+	return *workspace, nil
+}
+
+func (s *Store) GetWorkspace(workspaceID uint64) (models.Workspace, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	workspace, ok := s.workspaces[workspaceID]
+	if !ok {
+		return models.Workspace{}, namederrors.ErrNotFound
+	}
+	return *workspace, nil
+}
+
+func (s *Store) RenameWorkspace(workspaceID uint64, name string) (models.Workspace, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	workspace, ok := s.workspaces[workspaceID]
+	if !ok {
+		return models.Workspace{}, namederrors.ErrNotFound
+	}
+	workspace.Name = name
+	workspace.UpdatedAt = time.Now().UTC()
+
+	return *workspace, nil
+}
+
+// DeleteWorkspace удаляет рабочее пространство вместе с его заметками и их
+// вложениями, умными папками, шаблонами, задачами импорта, участниками и
+// приглашениями. Возвращает удалённые заметки.
+func (s *Store) DeleteWorkspace(workspaceID uint64) ([]models.Note, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.workspaces[workspaceID]; !ok {
+		return nil, namederrors.ErrNotFound
+	}
+
+	removed := make([]models.Note, 0)
+	for _, note := range s.Notes {
+		if note.OwnerID == workspaceID {
+			removed = append(removed, *note)
+			s.removeNote(note)
+		}
+	}
+	for id, search := range s.SavedSearches {
+		if search.OwnerID == workspaceID {
+			delete(s.SavedSearches, id)
+		}
+	}
+	for id, template := range s.noteTemplates {
+		if template.OwnerID == workspaceID {
+			delete(s.noteTemplates, id)
+		}
+	}
+	for id, job := range s.importJobs {
+		if job.OwnerID == workspaceID {
+			delete(s.importJobs, id)
+		}
+	}
+	for id, invitation := range s.invitations {
+		if invitation.WorkspaceID == workspaceID {
+			delete(s.invitations, id)
+		}
+	}
+	delete(s.storageUsed, workspaceID)
+	delete(s.mutations, workspaceID)
+	delete(s.members, workspaceID)
+	delete(s.workspaces, workspaceID)
+
+	return removed, nil
+}
+
+// ListUserWorkspaces возвращает рабочие пространства пользователя в порядке создания.
+func (s *Store) ListUserWorkspaces(userID uint64) []models.UserWorkspace {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.UserWorkspace, 0)
+	for workspaceID, members := range s.members {
+		if member, ok := members[userID]; ok {
+			result = append(result, models.UserWorkspace{
+				Workspace: *s.workspaces[workspaceID],
+				Role:      member.Role,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Workspace.ID < result[j].Workspace.ID
+	})
+
+	return result
+}
+
+func (s *Store) GetWorkspaceMember(workspaceID, userID uint64) (models.WorkspaceMember, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	member, ok := s.members[workspaceID][userID]
+	if !ok {
+		return models.WorkspaceMember{}, namederrors.ErrNotFound
+	}
+	return *member, nil
+}
+
+// ListWorkspaceMembers возвращает участников пространства в порядке вступления.
+func (s *Store) ListWorkspaceMembers(workspaceID uint64) []models.WorkspaceMember {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.WorkspaceMember, 0, len(s.members[workspaceID]))
+	for _, member := range s.members[workspaceID] {
+		result = append(result, *member)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].JoinedAt.Equal(result[j].JoinedAt) {
+			return result[i].JoinedAt.Before(result[j].JoinedAt)
+		}
+		return result[i].UserID < result[j].UserID
+	})
+
+	return result
+}
+
+func (s *Store) SetWorkspaceMemberRole(workspaceID, userID uint64, role string) (models.WorkspaceMember, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	member, ok := s.members[workspaceID][userID]
+	if !ok {
+		return models.WorkspaceMember{}, namederrors.ErrNotFound
+	}
+	member.Role = role
+
+	return *member, nil
+}
+
+func (s *Store) RemoveWorkspaceMember(workspaceID, userID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.members[workspaceID][userID]; !ok {
+		return namederrors.ErrNotFound
+	}
+	delete(s.members[workspaceID], userID)
+
+	return nil
+}
+
+// InviteToWorkspace создаёт приглашение или меняет роль в уже отправленном
+// на тот же email.
+func (s *Store) InviteToWorkspace(invitation models.WorkspaceInvitation) (models.WorkspaceInvitation, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.workspaces[invitation.WorkspaceID]; !ok {
+		return models.WorkspaceInvitation{}, namederrors.ErrNotFound
+	}
+
+	for _, existing := range s.invitations {
+		if existing.WorkspaceID == invitation.WorkspaceID && strings.EqualFold(existing.Email, invitation.Email) {
+			existing.Role = invitation.Role
+			return s.invitationView(existing), nil
+		}
+	}
+
+	invitation.ID = s.nextInvitationID
+	s.nextInvitationID++
+	invitation.CreatedAt = time.Now().UTC()
+
+	stored := invitation
+	s.invitations[stored.ID] = &stored
+
+	return s.invitationView(&stored), nil
+}
+
+func (s *Store) GetWorkspaceInvitation(invitationID uint64) (models.WorkspaceInvitation, error) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	invitation, ok := s.invitations[invitationID]
+	if !ok {
+		return models.WorkspaceInvitation{}, namederrors.ErrNotFound
+	}
+	return s.invitationView(invitation), nil
+}
+
+// ListWorkspaceInvitations возвращает приглашения в пространство в порядке отправки.
+func (s *Store) ListWorkspaceInvitations(workspaceID uint64) []models.WorkspaceInvitation {
+	return s.listInvitations(func(invitation *models.WorkspaceInvitation) bool {
+		return invitation.WorkspaceID == workspaceID
+	})
+}
+
+// ListInvitationsByEmail возвращает приглашения, отправленные на email.
+func (s *Store) ListInvitationsByEmail(email string) []models.WorkspaceInvitation {
+	return s.listInvitations(func(invitation *models.WorkspaceInvitation) bool {
+		return strings.EqualFold(invitation.Email, email)
+	})
+}
+
+func (s *Store) listInvitations(match func(*models.WorkspaceInvitation) bool) []models.WorkspaceInvitation {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+
+	result := make([]models.WorkspaceInvitation, 0)
+	for _, invitation := range s.invitations {
+		if match(invitation) {
+			result = append(result, s.invitationView(invitation))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+// invitationView копирует приглашение с актуальным названием пространства.
+// Вызывается под блокировкой s.Mu.
+func (s *Store) invitationView(invitation *models.WorkspaceInvitation) models.WorkspaceInvitation {
+	view := *invitation
+	if workspace, ok := s.workspaces[invitation.WorkspaceID]; ok {
+		view.WorkspaceName = workspace.Name
+	}
+	return view
+}
+
+func (s *Store) DeleteWorkspaceInvitation(invitationID uint64) error {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	if _, ok := s.invitations[invitationID]; !ok {
+		return namederrors.ErrNotFound
+	}
+	delete(s.invitations, invitationID)
+
+	return nil
+}
+
+// AcceptWorkspaceInvitation добавляет пользователя в пространство с ролью из
+// приглашения и удаляет приглашение. Роль уже состоящего в пространстве
+// участника не меняется.
+func (s *Store) AcceptWorkspaceInvitation(invitationID, userID uint64) (models.WorkspaceMember, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+
+	invitation, ok := s.invitations[invitationID]
+	if !ok {
+		return models.WorkspaceMember{}, namederrors.ErrNotFound
+	}
+	user, ok := s.Users[userID]
+	if !ok {
+		return models.WorkspaceMember{}, namederrors.ErrNotFound
+	}
+	delete(s.invitations, invitationID)
+
+	members := s.members[invitation.WorkspaceID]
+	if member, ok := members[userID]; ok {
+		return *member, nil
+	}
+	member := &models.WorkspaceMember{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      userID,
+		Email:       user.Email,
+		Role:        invitation.Role,
+		JoinedAt:    time.Now().UTC(),
+	}
+	members[userID] = member
+
+	return *member, nil
+}
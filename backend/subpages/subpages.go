@@ -0,0 +1,62 @@
+package subpages
+
+import (
+	"backend/models"
+	"slices"
+	"strings"
+)
+
+// Folder возвращает папку подстраниц заметки. Вложенных заметок в дереве
+// нет, поэтому подстраницами служат заметки из папки с заголовком заметки и
+// её подпапок — так их раскладывает перенос из Notion.
+func Folder(note models.Note) string {
+	return joinFolders(note.Folder, note.Title)
+}
+
+// Of возвращает подстраницы note среди notes в порядке дерева: подстраница
+// идёт сразу за заметкой своей папки.
+func Of(note models.Note, notes []models.Note) []models.Note {
+	folder := Folder(note)
+	if folder == "" {
+		return nil
+	}
+
+	var pages []models.Note
+	for _, other := range notes {
+		noteFolder := joinFolders(other.Folder)
+		if other.ID != note.ID && (noteFolder == folder || strings.HasPrefix(noteFolder, folder+"/")) {
+			pages = append(pages, other)
+		}
+	}
+	slices.SortStableFunc(pages, func(a, b models.Note) int {
+		return slices.Compare(append(folderParts(a.Folder), a.Title), append(folderParts(b.Folder), b.Title))
+	})
+	return pages
+}
+
+// Depth возвращает вложенность подстраницы page заметки note, начиная с 1.
+func Depth(note, page models.Note) int {
+	return len(folderParts(page.Folder)) - len(folderParts(Folder(note))) + 1
+}
+
+// joinFolders склеивает папки через «/», пропуская пустые части.
+func joinFolders(folders ...string) string {
+	var parts []string
+	for _, folder := range folders {
+		for _, part := range strings.Split(folder, "/") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// folderParts возвращает части пути папки.
+func folderParts(folder string) []string {
+	folder = joinFolders(folder)
+	if folder == "" {
+		return nil
+	}
+	return strings.Split(folder, "/")
+}
@@ -0,0 +1,32 @@
+package subpages
+
+import (
+	"backend/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	root := models.Note{ID: 1, Folder: "Wiki", Title: "Guide"}
+	notes := []models.Note{
+		root,
+		{ID: 2, Folder: "Wiki", Title: "Sibling"},
+		{ID: 3, Folder: "Wiki/Guide/Setup", Title: "Linux"},
+		{ID: 4, Folder: "Wiki/Guide", Title: "Setup"},
+		{ID: 5, Folder: " Wiki / Guide ", Title: "Basics"},
+		{ID: 6, Folder: "Wiki/Guidebook", Title: "Other"},
+		{ID: 7, Folder: "", Title: "Unfiled"},
+	}
+
+	pages := Of(root, notes)
+	var ids []uint64
+	for _, page := range pages {
+		ids = append(ids, page.ID)
+	}
+	require.Equal(t, []uint64{5, 4, 3}, ids)
+	require.Equal(t, []int{1, 1, 2}, []int{Depth(root, pages[0]), Depth(root, pages[1]), Depth(root, pages[2])})
+
+	require.Empty(t, Of(models.Note{ID: 7}, notes), "a note without a title has no sub-pages")
+	require.Equal(t, "Guide", Folder(models.Note{Title: "Guide"}))
+}
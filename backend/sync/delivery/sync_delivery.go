@@ -0,0 +1,72 @@
+package syncDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// MaxMutations ограничивает размер пачки мутаций в одном запросе.
+const MaxMutations = 500
+
+type SyncUsecase interface {
+	Sync(userID, editorID uint64, req models.SyncRequest) (*models.SyncResponse, error)
+}
+
+type SyncDelivery struct {
+	Usecase SyncUsecase
+}
+
+func NewSyncDelivery(usecase SyncUsecase) *SyncDelivery {
+	return &SyncDelivery{
+		Usecase: usecase,
+	}
+}
+
+// Sync принимает курсор и пачку офлайн-мутаций клиента и возвращает их
+// итоги и изменения заметок на сервере после курсора.
+func (d *SyncDelivery) Sync(w http.ResponseWriter, r *http.Request) {
+	userID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+	editorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req models.SyncRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if len(req.Mutations) > MaxMutations {
+		apiutils.WriteError(w, http.StatusBadRequest, "too many mutations")
+		return
+	}
+	for _, mutation := range req.Mutations {
+		if mutation.ID == "" {
+			apiutils.WriteError(w, http.StatusBadRequest, "mutation id is required")
+			return
+		}
+	}
+
+	resp, err := d.Usecase.Sync(userID, editorID, req)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to sync")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,34 @@
+package syncRepository
+
+import (
+	"backend/models"
+	"backend/store"
+)
+
+type SyncRepository struct {
+	Store *store.Store
+}
+
+func NewSyncRepository(store *store.Store) *SyncRepository {
+	return &SyncRepository{
+		Store: store,
+	}
+}
+
+func (r *SyncRepository) ChangesSince(userID, cursor uint64) (*models.NoteChanges, error) {
+	changes := r.Store.ChangesSince(userID, cursor)
+	return &changes, nil
+}
+
+func (r *SyncRepository) GetMutationResult(userID uint64, mutationID string) (*models.MutationResult, bool, error) {
+	result, ok := r.Store.GetMutationResult(userID, mutationID)
+	if !ok {
+		return nil, false, nil
+	}
+	return &result, true, nil
+}
+
+func (r *SyncRepository) SaveMutationResult(userID uint64, result models.MutationResult) error {
+	r.Store.SaveMutationResult(userID, result)
+	return nil
+}
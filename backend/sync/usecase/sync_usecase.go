@@ -0,0 +1,387 @@
+package syncUsecase
+
+import (
+	"backend/events"
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	conflictCopySuffix = " (conflict copy)"
+	applyAttempts      = 3
+)
+
+type SyncRepository interface {
+	ChangesSince(userID, cursor uint64) (*models.NoteChanges, error)
+	GetMutationResult(userID uint64, mutationID string) (*models.MutationResult, bool, error)
+	SaveMutationResult(userID uint64, result models.MutationResult) error
+}
+
+type NotesRepository interface {
+	GetNote(noteID uint64) (*models.Note, error)
+	GetTrashedNote(noteID uint64) (*models.Note, error)
+	CreateNote(note models.Note) (*models.Note, error)
+	UpdateNote(note models.Note, editorID uint64) (*models.Note, error)
+	TrashNote(noteID, userID uint64) (*models.Note, error)
+	RestoreNote(noteID, userID uint64) (*models.Note, error)
+	RenameWikiLinks(ownerID uint64, oldTitle, newTitle string, editorID uint64) ([]models.Note, error)
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// Notifier уведомляет пользователей, упомянутых в тексте сохранённой заметки.
+type Notifier interface {
+	NoteSaved(actorID uint64, note *models.Note, previousText string)
+}
+
+// SyncUsecase применяет офлайн-мутации клиентов поверх NotesRepository и
+// отдаёт изменения после курсора. Конфликты разрешаются детерминированно:
+// поле, изменённое и клиентом, и сервером, остаётся серверным, а текст
+// клиента сохраняется в копию заметки; правка побеждает удаление.
+type SyncUsecase struct {
+	Repository SyncRepository
+	Notes      NotesRepository
+	Events     events.Publisher
+	Authorizer Authorizer
+	Notifier   Notifier
+
+	locks sync.Map
+}
+
+func NewSyncUsecase(repository SyncRepository, notes NotesRepository, events events.Publisher, authorizer Authorizer, notifier Notifier) *SyncUsecase {
+	return &SyncUsecase{
+		Repository: repository,
+		Notes:      notes,
+		Events:     events,
+		Authorizer: authorizer,
+		Notifier:   notifier,
+	}
+}
+
+// Sync применяет мутации по порядку и возвращает их итоги вместе с
+// изменениями заметок после курсора, включая только что применённые.
+// Синхронизировать заметки могут владелец и участники рабочего пространства;
+// права на каждую заметку проверяются так же, как при правке через API.
+func (u *SyncUsecase) Sync(ownerID, editorID uint64, req models.SyncRequest) (*models.SyncResponse, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, editorID); err != nil {
+		return nil, fmt.Errorf("failed to sync: %w", err)
+	}
+
+	// Пачки одного пользователя применяются по очереди, чтобы повтор мутации
+	// из параллельного запроса не применился дважды.
+	lock, _ := u.locks.LoadOrStore(ownerID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	results := make([]models.MutationResult, 0, len(req.Mutations))
+	for _, mutation := range req.Mutations {
+		saved, ok, err := u.Repository.GetMutationResult(ownerID, mutation.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync: %w", err)
+		}
+		if ok {
+			results = append(results, *saved)
+			continue
+		}
+
+		result, err := u.apply(ownerID, editorID, mutation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync: %w", err)
+		}
+		if err = u.Repository.SaveMutationResult(ownerID, result); err != nil {
+			return nil, fmt.Errorf("failed to sync: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	changes, err := u.Repository.ChangesSince(ownerID, req.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync: %w", err)
+	}
+	return &models.SyncResponse{NoteChanges: *changes, Results: results}, nil
+}
+
+func (u *SyncUsecase) apply(ownerID, editorID uint64, mutation models.Mutation) (models.MutationResult, error) {
+	result := models.MutationResult{MutationID: mutation.ID}
+
+	if mutation.Type == models.MutationCreate {
+		return u.create(ownerID, editorID, mutation)
+	}
+	if mutation.Type != models.MutationUpdate && mutation.Type != models.MutationDelete {
+		return reject(result, "unknown mutation type"), nil
+	}
+
+	noteID, err := u.resolveNoteID(ownerID, mutation)
+	if err != nil {
+		return result, err
+	}
+	if noteID == 0 {
+		return reject(result, "note not found"), nil
+	}
+	result.NoteID = noteID
+
+	if mutation.Type == models.MutationDelete {
+		return u.delete(ownerID, editorID, mutation, result)
+	}
+	return u.update(ownerID, editorID, mutation, result)
+}
+
+func reject(result models.MutationResult, reason string) models.MutationResult {
+	result.Status = models.MutationRejected
+	result.Error = reason
+	return result
+}
+
+// resolveNoteID находит заметку мутации: по ID или, для заметки, созданной
+// офлайн, по ID мутации её создания.
+func (u *SyncUsecase) resolveNoteID(ownerID uint64, mutation models.Mutation) (uint64, error) {
+	if mutation.NoteID != 0 || mutation.CreateID == "" {
+		return mutation.NoteID, nil
+	}
+	created, ok, err := u.Repository.GetMutationResult(ownerID, mutation.CreateID)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return created.NoteID, nil
+}
+
+// loadNote возвращает заметку владельца и признак того, что она в корзине.
+func (u *SyncUsecase) loadNote(ownerID, noteID uint64) (*models.Note, bool, error) {
+	note, err := u.Notes.GetNote(noteID)
+	trashed := false
+	if errors.Is(err, namederrors.ErrNotFound) {
+		note, err = u.Notes.GetTrashedNote(noteID)
+		trashed = true
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if note.OwnerID != ownerID {
+		return nil, false, namederrors.ErrNotFound
+	}
+	return note, trashed, nil
+}
+
+func (u *SyncUsecase) create(ownerID, editorID uint64, mutation models.Mutation) (models.MutationResult, error) {
+	result := models.MutationResult{MutationID: mutation.ID}
+	patch := mutation.Patch
+	if patch.Title == nil || *patch.Title == "" {
+		return reject(result, "title is required"), nil
+	}
+
+	note := models.Note{OwnerID: ownerID, CreatedBy: editorID, Title: *patch.Title}
+	if patch.Text != nil {
+		note.Text = *patch.Text
+	}
+	if patch.Favourite != nil {
+		note.Favourite = *patch.Favourite
+	}
+	if patch.Folder != nil {
+		note.Folder = *patch.Folder
+	}
+	if patch.Tags != nil {
+		note.Tags = *patch.Tags
+	}
+	note.Tags = notesUsecase.NormalizeTags(note.Tags)
+
+	created, err := u.Notes.CreateNote(note)
+	if err != nil {
+		return result, err
+	}
+	u.emit(models.NoteCreated, ownerID, created.ID, created, "")
+	u.notify(editorID, created, "")
+
+	result.Status = models.MutationApplied
+	result.NoteID = created.ID
+	result.Note = created
+	return result, nil
+}
+
+// update применяет изменённые поля. Заметка, удалённая на сервере в корзину,
+// восстанавливается: правка побеждает удаление.
+func (u *SyncUsecase) update(ownerID, editorID uint64, mutation models.Mutation, result models.MutationResult) (models.MutationResult, error) {
+	for attempt := 0; attempt < applyAttempts; attempt++ {
+		current, trashed, err := u.loadNote(ownerID, result.NoteID)
+		if errors.Is(err, namederrors.ErrNotFound) {
+			return reject(result, "note not found"), nil
+		}
+		if err != nil {
+			return result, err
+		}
+		if trashed {
+			if current, err = u.Notes.RestoreNote(current.ID, editorID); err != nil {
+				return result, err
+			}
+			u.emit(models.NoteCreated, ownerID, current.ID, current, "")
+		}
+		_, _, err = u.Authorizer.AuthorizeNote(ownerID, editorID, current.ID, models.RoleEditor)
+		if errors.Is(err, namederrors.ErrForbidden) {
+			return reject(result, "access denied"), nil
+		}
+		if err != nil {
+			return result, err
+		}
+
+		merged, conflicts := mergePatch(*current, mutation)
+		updated, err := u.Notes.UpdateNote(merged, editorID)
+		if errors.Is(err, namederrors.ErrVersionConflict) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if updated.Folder != current.Folder {
+			u.emit(models.NoteMoved, ownerID, updated.ID, updated, current.Folder)
+		} else {
+			u.emit(models.NoteUpdated, ownerID, updated.ID, updated, "")
+		}
+		u.notify(editorID, updated, current.Text)
+		if updated.Title != current.Title {
+			renamed, err := u.Notes.RenameWikiLinks(ownerID, current.Title, updated.Title, editorID)
+			if err != nil {
+				return result, err
+			}
+			for i := range renamed {
+				u.emit(models.NoteUpdated, ownerID, renamed[i].ID, &renamed[i], "")
+			}
+		}
+
+		result.Note = updated
+		switch {
+		case len(conflicts) > 0:
+			result.Status = models.MutationConflict
+			result.Conflicts = conflicts
+		case mutation.BaseVersion == current.Version:
+			result.Status = models.MutationApplied
+		default:
+			result.Status = models.MutationMerged
+		}
+
+		if slices.Contains(conflicts, "text") {
+			conflictCopy, err := u.Notes.CreateNote(models.Note{
+				OwnerID:   ownerID,
+				CreatedBy: editorID,
+				Title:     updated.Title + conflictCopySuffix,
+				Text:      *mutation.Patch.Text,
+				Folder:    updated.Folder,
+				Tags:      slices.Clone(updated.Tags),
+			})
+			if err != nil {
+				return result, err
+			}
+			u.emit(models.NoteCreated, ownerID, conflictCopy.ID, conflictCopy, "")
+			result.ConflictNoteID = conflictCopy.ID
+		}
+		return result, nil
+	}
+	return result, fmt.Errorf("failed to update note: %w", namederrors.ErrVersionConflict)
+}
+
+// delete перемещает заметку в корзину. Если сервер менял заметку после
+// базовой версии клиента, заметка остаётся: правка побеждает удаление.
+func (u *SyncUsecase) delete(ownerID, editorID uint64, mutation models.Mutation, result models.MutationResult) (models.MutationResult, error) {
+	current, trashed, err := u.loadNote(ownerID, result.NoteID)
+	if errors.Is(err, namederrors.ErrNotFound) || trashed {
+		result.Status = models.MutationApplied
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	// Удалить заметку, как и через API, может только владелец.
+	_, _, err = u.Authorizer.AuthorizeNote(ownerID, editorID, current.ID, models.RoleOwner)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		return reject(result, "access denied"), nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if mutation.BaseVersion != 0 && mutation.BaseVersion < current.Version {
+		result.Status = models.MutationConflict
+		result.Note = current
+		return result, nil
+	}
+
+	if _, err = u.Notes.TrashNote(current.ID, editorID); err != nil {
+		return result, err
+	}
+	u.emit(models.NoteDeleted, ownerID, current.ID, nil, "")
+	result.Status = models.MutationApplied
+	return result, nil
+}
+
+// mergePatch накладывает мутацию на текущую заметку и возвращает поля, в
+// которых победило серверное значение. Если клиент правил текущую версию,
+// применяются все поля.
+func mergePatch(current models.Note, mutation models.Mutation) (models.Note, []string) {
+	fast := mutation.BaseVersion == current.Version
+	patch, base := mutation.Patch, mutation.Base
+	merged := current
+	var conflicts []string
+
+	mergeValue("title", fast, patch.Title, base.Title, &merged.Title, &conflicts)
+	mergeValue("text", fast, patch.Text, base.Text, &merged.Text, &conflicts)
+	mergeValue("favorite", fast, patch.Favourite, base.Favourite, &merged.Favourite, &conflicts)
+	mergeValue("folder", fast, patch.Folder, base.Folder, &merged.Folder, &conflicts)
+
+	if patch.Tags != nil {
+		tags := notesUsecase.NormalizeTags(*patch.Tags)
+		switch {
+		case fast || (base.Tags != nil && slices.Equal(notesUsecase.NormalizeTags(*base.Tags), current.Tags)):
+			merged.Tags = tags
+		case base.Tags != nil:
+			// Метки сливаются как множества: добавленные клиентом появляются,
+			// удалённые клиентом пропадают, серверные изменения сохраняются.
+			baseTags := notesUsecase.NormalizeTags(*base.Tags)
+			result := slices.DeleteFunc(slices.Clone(current.Tags), func(tag string) bool {
+				return slices.Contains(baseTags, tag) && !slices.Contains(tags, tag)
+			})
+			for _, tag := range tags {
+				if !slices.Contains(baseTags, tag) {
+					result = append(result, tag)
+				}
+			}
+			merged.Tags = notesUsecase.NormalizeTags(result)
+		case !slices.Equal(tags, current.Tags):
+			conflicts = append(conflicts, "tags")
+		}
+	}
+
+	return merged, conflicts
+}
+
+// mergeValue применяет поле клиента, если сервер не менял его после базовой
+// версии; иначе при расхождении оставляет серверное значение.
+func mergeValue[T comparable](name string, fast bool, patch, base *T, current *T, conflicts *[]string) {
+	if patch == nil {
+		return
+	}
+	if fast || (base != nil && *base == *current) {
+		*current = *patch
+		return
+	}
+	if *patch != *current {
+		*conflicts = append(*conflicts, name)
+	}
+}
+
+func (u *SyncUsecase) emit(eventType string, ownerID, noteID uint64, note *models.Note, fromFolder string) {
+	u.Events.Publish(ownerID, models.NoteEvent{Type: eventType, NoteID: noteID, Note: note, FromFolder: fromFolder})
+}
+
+// notify сообщает об упоминаниях, появившихся в тексте заметки.
+func (u *SyncUsecase) notify(actorID uint64, note *models.Note, previousText string) {
+	if u.Notifier == nil {
+		return
+	}
+	u.Notifier.NoteSaved(actorID, note, previousText)
+}
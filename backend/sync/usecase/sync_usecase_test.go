@@ -0,0 +1,495 @@
+package syncUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSyncRepository struct {
+	results map[string]models.MutationResult
+}
+
+func (r *fakeSyncRepository) ChangesSince(userID, cursor uint64) (*models.NoteChanges, error) {
+	return &models.NoteChanges{Cursor: cursor}, nil
+}
+
+func (r *fakeSyncRepository) GetMutationResult(userID uint64, mutationID string) (*models.MutationResult, bool, error) {
+	result, ok := r.results[mutationID]
+	if !ok {
+		return nil, false, nil
+	}
+	return &result, true, nil
+}
+
+func (r *fakeSyncRepository) SaveMutationResult(userID uint64, result models.MutationResult) error {
+	r.results[result.MutationID] = result
+	return nil
+}
+
+type fakeNotesRepository struct {
+	notes   map[uint64]models.Note
+	trash   map[uint64]models.Note
+	nextID  uint64
+	renames [][2]string
+	// conflicts — сколько следующих записей заметки проиграют гонку
+	// с параллельной правкой.
+	conflicts int
+}
+
+func newFakeNotesRepository(notes ...models.Note) *fakeNotesRepository {
+	r := &fakeNotesRepository{
+		notes:  make(map[uint64]models.Note),
+		trash:  make(map[uint64]models.Note),
+		nextID: 100,
+	}
+	for _, note := range notes {
+		r.notes[note.ID] = note
+	}
+	return r
+}
+
+func (r *fakeNotesRepository) GetNote(noteID uint64) (*models.Note, error) {
+	note, ok := r.notes[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &note, nil
+}
+
+func (r *fakeNotesRepository) GetTrashedNote(noteID uint64) (*models.Note, error) {
+	note, ok := r.trash[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	return &note, nil
+}
+
+func (r *fakeNotesRepository) CreateNote(note models.Note) (*models.Note, error) {
+	note.ID = r.nextID
+	note.Version = 1
+	r.nextID++
+	r.notes[note.ID] = note
+	return &note, nil
+}
+
+func (r *fakeNotesRepository) UpdateNote(note models.Note, editorID uint64) (*models.Note, error) {
+	stored, ok := r.notes[note.ID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	if r.conflicts > 0 {
+		r.conflicts--
+		stored.Version++
+		r.notes[note.ID] = stored
+	}
+	if note.Version != stored.Version {
+		return nil, &namederrors.VersionConflictError{CurrentVersion: stored.Version}
+	}
+	note.Version++
+	r.notes[note.ID] = note
+	return &note, nil
+}
+
+func (r *fakeNotesRepository) TrashNote(noteID, userID uint64) (*models.Note, error) {
+	note, ok := r.notes[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	delete(r.notes, noteID)
+	r.trash[noteID] = note
+	return &note, nil
+}
+
+func (r *fakeNotesRepository) RestoreNote(noteID, userID uint64) (*models.Note, error) {
+	note, ok := r.trash[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	delete(r.trash, noteID)
+	r.notes[noteID] = note
+	return &note, nil
+}
+
+func (r *fakeNotesRepository) RenameWikiLinks(ownerID uint64, oldTitle, newTitle string, editorID uint64) ([]models.Note, error) {
+	r.renames = append(r.renames, [2]string{oldTitle, newTitle})
+	return nil, nil
+}
+
+type fakeEvents struct {
+	types []string
+}
+
+func (e *fakeEvents) Publish(userID uint64, event models.NoteEvent) {
+	e.types = append(e.types, event.Type)
+}
+
+// fakeAuthorizer — пользователь 3 участвует в рабочем пространстве 1 с ролью
+// member и редактирует его заметки, но не удаляет их.
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) CheckOwner(ownerID, actorID uint64) error {
+	if ownerID != actorID && actorID != 3 {
+		return namederrors.ErrForbidden
+	}
+	return nil
+}
+
+func (a fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	if err := a.CheckOwner(ownerID, actorID); err != nil {
+		return nil, "", err
+	}
+	if ownerID != actorID && required == models.RoleOwner {
+		return nil, "", namederrors.ErrForbidden
+	}
+	return &models.Note{ID: noteID, OwnerID: ownerID}, models.RoleEditor, nil
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestMergePatch(t *testing.T) {
+	current := models.Note{
+		ID:        1,
+		Version:   3,
+		Title:     "server title",
+		Text:      "server text",
+		Favourite: true,
+		Folder:    "work",
+		Tags:      []string{"a", "b", "c"},
+	}
+
+	tests := []struct {
+		name      string
+		mutation  models.Mutation
+		want      func(note *models.Note)
+		conflicts []string
+	}{
+		{
+			name: "current version applies every field",
+			mutation: models.Mutation{
+				BaseVersion: 3,
+				Patch:       models.NotePatch{Title: ptr("client"), Favourite: ptr(false), Tags: ptr([]string{"x", "x"})},
+			},
+			want: func(note *models.Note) {
+				note.Title = "client"
+				note.Favourite = false
+				note.Tags = []string{"x"}
+			},
+		},
+		{
+			name: "field unchanged on server",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Base:        models.NotePatch{Folder: ptr("work")},
+				Patch:       models.NotePatch{Folder: ptr("home")},
+			},
+			want: func(note *models.Note) { note.Folder = "home" },
+		},
+		{
+			name: "different fields changed",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Base:        models.NotePatch{Text: ptr("server text"), Title: ptr("server title")},
+				Patch:       models.NotePatch{Text: ptr("client text")},
+			},
+			want: func(note *models.Note) { note.Text = "client text" },
+		},
+		{
+			name: "same field changed on both sides",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Base:        models.NotePatch{Title: ptr("old"), Text: ptr("old")},
+				Patch:       models.NotePatch{Title: ptr("client"), Text: ptr("client")},
+			},
+			want:      func(note *models.Note) {},
+			conflicts: []string{"title", "text"},
+		},
+		{
+			name: "same value on both sides",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Base:        models.NotePatch{Title: ptr("old")},
+				Patch:       models.NotePatch{Title: ptr("server title")},
+			},
+			want: func(note *models.Note) {},
+		},
+		{
+			name: "patch without base",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Patch:       models.NotePatch{Favourite: ptr(false)},
+			},
+			want:      func(note *models.Note) {},
+			conflicts: []string{"favorite"},
+		},
+		{
+			name: "tags unchanged on server",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Base:        models.NotePatch{Tags: ptr([]string{"a", "b", "c", ""})},
+				Patch:       models.NotePatch{Tags: ptr([]string{"c"})},
+			},
+			want: func(note *models.Note) { note.Tags = []string{"c"} },
+		},
+		{
+			name: "tags merged as sets",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Base:        models.NotePatch{Tags: ptr([]string{"a", "b"})},
+				Patch:       models.NotePatch{Tags: ptr([]string{"a", "d"})},
+			},
+			want: func(note *models.Note) { note.Tags = []string{"a", "c", "d"} },
+		},
+		{
+			name: "tags without base",
+			mutation: models.Mutation{
+				BaseVersion: 1,
+				Patch:       models.NotePatch{Tags: ptr([]string{"a"})},
+			},
+			want:      func(note *models.Note) {},
+			conflicts: []string{"tags"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := current
+			want.Tags = []string{"a", "b", "c"}
+			test.want(&want)
+
+			merged, conflicts := mergePatch(current, test.mutation)
+			require.Equal(t, want, merged)
+			require.Equal(t, test.conflicts, conflicts)
+			require.Equal(t, []string{"a", "b", "c"}, current.Tags, "current note is not modified")
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	note := func() models.Note {
+		return models.Note{ID: 1, OwnerID: 1, Version: 3, Title: "title", Text: "server text", Tags: []string{"a"}}
+	}
+
+	tests := []struct {
+		name      string
+		setup     func(notes *fakeNotesRepository)
+		mutations []models.Mutation
+		want      []models.MutationResult
+		check     func(t *testing.T, notes *fakeNotesRepository, events []string)
+	}{
+		{
+			name: "create",
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationCreate, Patch: models.NotePatch{Title: ptr("new"), Tags: ptr([]string{"x", "X"})}},
+				{ID: "m2", Type: models.MutationCreate, Patch: models.NotePatch{Text: ptr("no title")}},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationApplied, NoteID: 100},
+				{MutationID: "m2", Status: models.MutationRejected, Error: "title is required"},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Equal(t, []string{"x"}, notes.notes[100].Tags)
+				require.Equal(t, uint64(1), notes.notes[100].CreatedBy)
+				require.Equal(t, []string{models.NoteCreated}, events)
+			},
+		},
+		{
+			name: "update of a note created offline",
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationCreate, Patch: models.NotePatch{Title: ptr("new")}},
+				{ID: "m2", Type: models.MutationUpdate, CreateID: "m1", BaseVersion: 1, Patch: models.NotePatch{Text: ptr("offline")}},
+				{ID: "m3", Type: models.MutationUpdate, CreateID: "unknown", Patch: models.NotePatch{Text: ptr("lost")}},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationApplied, NoteID: 100},
+				{MutationID: "m2", Status: models.MutationApplied, NoteID: 100},
+				{MutationID: "m3", Status: models.MutationRejected, Error: "note not found"},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Equal(t, "offline", notes.notes[100].Text)
+			},
+		},
+		{
+			name: "merged update",
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationUpdate, NoteID: 1, BaseVersion: 2, Base: models.NotePatch{Title: ptr("title")}, Patch: models.NotePatch{Title: ptr("renamed")}},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationMerged, NoteID: 1},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Equal(t, "renamed", notes.notes[1].Title)
+				require.Equal(t, [][2]string{{"title", "renamed"}}, notes.renames)
+				require.Equal(t, []string{models.NoteUpdated}, events)
+			},
+		},
+		{
+			name: "text conflict keeps a copy",
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationUpdate, NoteID: 1, BaseVersion: 2, Base: models.NotePatch{Text: ptr("base text")}, Patch: models.NotePatch{Text: ptr("client text")}},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationConflict, NoteID: 1, Conflicts: []string{"text"}, ConflictNoteID: 100},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Equal(t, "server text", notes.notes[1].Text)
+				conflictCopy := notes.notes[100]
+				require.Equal(t, "title"+conflictCopySuffix, conflictCopy.Title)
+				require.Equal(t, "client text", conflictCopy.Text)
+				require.Equal(t, []string{"a"}, conflictCopy.Tags)
+				require.Equal(t, []string{models.NoteUpdated, models.NoteCreated}, events)
+			},
+		},
+		{
+			name: "update retried after a concurrent write",
+			setup: func(notes *fakeNotesRepository) {
+				notes.conflicts = 1
+			},
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationUpdate, NoteID: 1, BaseVersion: 3, Base: models.NotePatch{Folder: ptr("")}, Patch: models.NotePatch{Folder: ptr("work")}},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationMerged, NoteID: 1},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Equal(t, "work", notes.notes[1].Folder)
+				require.Equal(t, []string{models.NoteMoved}, events)
+			},
+		},
+		{
+			name: "edit wins over server delete",
+			setup: func(notes *fakeNotesRepository) {
+				_, _ = notes.TrashNote(1, 1)
+			},
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationUpdate, NoteID: 1, BaseVersion: 3, Patch: models.NotePatch{Favourite: ptr(true)}},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationApplied, NoteID: 1},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Empty(t, notes.trash)
+				require.True(t, notes.notes[1].Favourite)
+				require.Equal(t, []string{models.NoteCreated, models.NoteUpdated}, events)
+			},
+		},
+		{
+			name: "edit wins over client delete",
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationDelete, NoteID: 1, BaseVersion: 2},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationConflict, NoteID: 1},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Contains(t, notes.notes, uint64(1))
+				require.Empty(t, events)
+			},
+		},
+		{
+			name: "delete",
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationDelete, NoteID: 1, BaseVersion: 3},
+				{ID: "m2", Type: models.MutationDelete, NoteID: 1},
+				{ID: "m3", Type: models.MutationDelete, NoteID: 42},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationApplied, NoteID: 1},
+				{MutationID: "m2", Status: models.MutationApplied, NoteID: 1},
+				{MutationID: "m3", Status: models.MutationApplied, NoteID: 42},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Contains(t, notes.trash, uint64(1))
+				require.Equal(t, []string{models.NoteDeleted}, events)
+			},
+		},
+		{
+			name: "foreign note",
+			setup: func(notes *fakeNotesRepository) {
+				notes.notes[2] = models.Note{ID: 2, OwnerID: 2, Version: 1, Title: "foreign"}
+			},
+			mutations: []models.Mutation{
+				{ID: "m1", Type: models.MutationUpdate, NoteID: 2, BaseVersion: 1, Patch: models.NotePatch{Text: ptr("mine")}},
+				{ID: "m2", Type: "move"},
+			},
+			want: []models.MutationResult{
+				{MutationID: "m1", Status: models.MutationRejected, NoteID: 2, Error: "note not found"},
+				{MutationID: "m2", Status: models.MutationRejected, Error: "unknown mutation type"},
+			},
+			check: func(t *testing.T, notes *fakeNotesRepository, events []string) {
+				require.Empty(t, notes.notes[2].Text)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notes := newFakeNotesRepository(note())
+			if test.setup != nil {
+				test.setup(notes)
+			}
+			events := &fakeEvents{}
+			u := NewSyncUsecase(&fakeSyncRepository{results: make(map[string]models.MutationResult)}, notes, events, fakeAuthorizer{}, nil)
+
+			resp, err := u.Sync(1, 1, models.SyncRequest{Cursor: 5, Mutations: test.mutations})
+			require.NoError(t, err)
+			require.Equal(t, uint64(5), resp.Cursor)
+			require.Len(t, resp.Results, len(test.want))
+			for i, want := range test.want {
+				got := resp.Results[i]
+				got.Note = nil
+				require.Equal(t, want, got)
+			}
+			test.check(t, notes, events.types)
+		})
+	}
+}
+
+func TestSyncReplay(t *testing.T) {
+	notes := newFakeNotesRepository()
+	events := &fakeEvents{}
+	u := NewSyncUsecase(&fakeSyncRepository{results: make(map[string]models.MutationResult)}, notes, events, fakeAuthorizer{}, nil)
+	req := models.SyncRequest{Mutations: []models.Mutation{
+		{ID: "m1", Type: models.MutationCreate, Patch: models.NotePatch{Title: ptr("new")}},
+		{ID: "m2", Type: models.MutationUpdate, CreateID: "m1", BaseVersion: 1, Patch: models.NotePatch{Text: ptr("text")}},
+	}}
+
+	first, err := u.Sync(1, 1, req)
+	require.NoError(t, err)
+	second, err := u.Sync(1, 1, req)
+	require.NoError(t, err)
+
+	require.Equal(t, first.Results, second.Results)
+	require.Len(t, notes.notes, 1)
+	require.Equal(t, uint64(2), notes.notes[100].Version)
+	require.Len(t, events.types, 2)
+}
+
+func TestSyncForbidden(t *testing.T) {
+	u := NewSyncUsecase(&fakeSyncRepository{results: make(map[string]models.MutationResult)}, newFakeNotesRepository(), &fakeEvents{}, fakeAuthorizer{}, nil)
+
+	_, err := u.Sync(1, 2, models.SyncRequest{})
+	require.ErrorIs(t, err, namederrors.ErrForbidden)
+}
+
+func TestSyncMember(t *testing.T) {
+	notes := newFakeNotesRepository(models.Note{ID: 1, OwnerID: 1, Version: 3, Title: "title"})
+	events := &fakeEvents{}
+	u := NewSyncUsecase(&fakeSyncRepository{results: make(map[string]models.MutationResult)}, notes, events, fakeAuthorizer{}, nil)
+
+	resp, err := u.Sync(1, 3, models.SyncRequest{Mutations: []models.Mutation{
+		{ID: "m1", Type: models.MutationDelete, NoteID: 1, BaseVersion: 3},
+		{ID: "m2", Type: models.MutationUpdate, NoteID: 1, BaseVersion: 3, Patch: models.NotePatch{Text: ptr("member text")}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 2)
+	require.Equal(t, models.MutationRejected, resp.Results[0].Status, "a member cannot delete notes")
+	require.Equal(t, "access denied", resp.Results[0].Error)
+	require.Equal(t, models.MutationApplied, resp.Results[1].Status, "a member edits notes")
+	require.Equal(t, "member text", notes.notes[1].Text)
+	require.Equal(t, []string{models.NoteUpdated}, events.types)
+}
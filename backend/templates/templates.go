@@ -0,0 +1,142 @@
+// Package templates подставляет переменные в шаблоны заметок и содержит
+// встроенные шаблоны и шаблоны стартовых заметок нового пользователя.
+package templates
+
+import (
+	"backend/models"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DateLayout     = "2006-01-02"
+	TimeLayout     = "15:04"
+	DateTimeLayout = DateLayout + " " + TimeLayout
+)
+
+// Variables — имена переменных, которые подставляются в шаблоны.
+var Variables = []string{"date", "time", "datetime", "weekday", "user", "title"}
+
+// variable — переменная вида {{name}} или {{name:layout}}, где layout —
+// формат даты Go для date и time.
+var variable = regexp.MustCompile(`\{\{\s*([a-z]+)(?::([^{}]*))?\s*\}\}`)
+
+// Vars — значения переменных шаблона.
+type Vars struct {
+	// Now — момент создания заметки во временной зоне пользователя.
+	Now time.Time
+	// User — имя пользователя, создающего заметку.
+	User string
+	// Title — заголовок создаваемой заметки; в заголовке не подставляется.
+	Title string
+}
+
+// Expand подставляет в s значения переменных. Неизвестные переменные
+// остаются как есть.
+func Expand(s string, vars Vars) string {
+	return variable.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variable.FindStringSubmatch(match)
+		name, layout := groups[1], strings.TrimSpace(groups[2])
+		switch name {
+		case "date", "time":
+			if layout == "" {
+				layout = DateLayout
+				if name == "time" {
+					layout = TimeLayout
+				}
+			}
+			return vars.Now.Format(layout)
+		case "datetime":
+			return vars.Now.Format(DateTimeLayout)
+		case "weekday":
+			return vars.Now.Weekday().String()
+		case "user":
+			return vars.User
+		case "title":
+			return vars.Title
+		}
+		return match
+	})
+}
+
+// Apply создаёт по шаблону заметку: сначала подставляет переменные в
+// заголовок, затем — в текст, где {{title}} — уже готовый заголовок.
+func Apply(template models.NoteTemplate, vars Vars) models.Note {
+	vars.Title = ""
+	title := strings.TrimSpace(Expand(template.Title, vars))
+	if title == "" {
+		title = template.Name
+	}
+	vars.Title = title
+
+	return models.Note{
+		Title:     title,
+		Text:      Expand(template.Text, vars),
+		Favourite: template.Favourite,
+		Folder:    Expand(template.Folder, vars),
+		Tags:      slices.Clone(template.Tags),
+	}
+}
+
+// Builtin возвращает встроенные шаблоны, доступные всем пользователям.
+func Builtin() []models.NoteTemplate {
+	return []models.NoteTemplate{
+		{
+			Name:  "Daily note",
+			Title: "{{date}}",
+			Text:  "# {{weekday}}, {{date}}\n\n## Plans\n- [ ] \n\n## Notes\n\n",
+			Tags:  []string{"daily"},
+		},
+		{
+			Name:  "Meeting notes",
+			Title: "Meeting {{date}}",
+			Text:  "# {{title}}\n\nDate: {{datetime}}\nNotes by: {{user}}\n\n## Attendees\n- \n\n## Agenda\n1. \n\n## Decisions\n- \n\n## Action items\n- [ ] \n",
+			Tags:  []string{"meeting"},
+		},
+		{
+			Name:  "To-do list",
+			Title: "To-do",
+			Text:  "- [ ] \n- [ ] \n- [ ] \n",
+		},
+		{
+			Name:  "Reading notes",
+			Title: "Reading notes",
+			Text:  "# {{title}}\n\nAuthor: \nStarted: {{date}}\n\n## Summary\n\n## Quotes\n> \n\n## Thoughts\n",
+			Tags:  []string{"reading"},
+		},
+	}
+}
+
+// Onboarding возвращает шаблоны стартовых заметок нового пользователя,
+// если в конфигурации не заданы другие.
+func Onboarding() []models.NoteTemplate {
+	return []models.NoteTemplate{
+		{
+			Name:   "Books to read",
+			Title:  "Books to read",
+			Text:   "The Three Musketeers, Animal Farm, Angels and Demons",
+			Folder: "Personal",
+		},
+		{
+			Name:   "Homework",
+			Title:  "Homework",
+			Text:   "Write an essay",
+			Folder: "University",
+		},
+		{
+			Name:      "My wishes",
+			Title:     "My wishes",
+			Text:      "I want to be a millionaire",
+			Favourite: true,
+			Folder:    "Personal",
+		},
+		{
+			Name:   "Films to watch",
+			Title:  "Films to watch",
+			Text:   "Harry Potter, The Lord of the Rings, Avatar",
+			Folder: "Personal",
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"backend/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpand(t *testing.T) {
+	vars := Vars{
+		Now:   time.Date(2026, time.March, 5, 9, 7, 0, 0, time.UTC),
+		User:  "ann@example.com",
+		Title: "Standup",
+	}
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "no variables", want: "no variables"},
+		{name: "date and time", text: "{{date}} {{time}} {{datetime}}", want: "2026-03-05 09:07 2026-03-05 09:07"},
+		{name: "spaces", text: "{{ date }}", want: "2026-03-05"},
+		{name: "layout", text: "{{date:Jan 2, 2006}} at {{time:3:04PM}}", want: "Mar 5, 2026 at 9:07AM"},
+		{name: "weekday", text: "{{weekday}}", want: "Thursday"},
+		{name: "user and title", text: "{{title}} by {{user}}", want: "Standup by ann@example.com"},
+		{name: "unknown", text: "{{unknown}} {{Date}} {date}", want: "{{unknown}} {{Date}} {date}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, Expand(test.text, vars))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	vars := Vars{Now: time.Date(2026, time.March, 5, 9, 7, 0, 0, time.UTC), User: "ann@example.com"}
+	template := models.NoteTemplate{
+		Name:      "Meeting",
+		Title:     "Meeting {{date}} {{title}}",
+		Text:      "# {{title}}\nBy {{user}}",
+		Favourite: true,
+		Folder:    "Meetings/{{date:2006}}",
+		Tags:      []string{"meeting"},
+	}
+
+	note := Apply(template, vars)
+	require.Equal(t, "Meeting 2026-03-05", note.Title)
+	require.Equal(t, "# Meeting 2026-03-05\nBy ann@example.com", note.Text)
+	require.Equal(t, "Meetings/2026", note.Folder)
+	require.True(t, note.Favourite)
+	require.Equal(t, []string{"meeting"}, note.Tags)
+
+	note.Tags[0] = "changed"
+	require.Equal(t, "meeting", template.Tags[0], "notes do not share tags with templates")
+
+	template.Title = " {{title}} "
+	require.Equal(t, "Meeting", Apply(template, vars).Title, "empty title falls back to the name")
+}
+
+func TestBuiltin(t *testing.T) {
+	for _, template := range append(Builtin(), Onboarding()...) {
+		require.NotEmpty(t, template.Name)
+		require.NotEmpty(t, Apply(template, Vars{}).Title)
+	}
+	require.Len(t, Onboarding(), 4)
+}
@@ -0,0 +1,68 @@
+package textdiff
+
+// MapRange переносит диапазон [start, end) в рунах из текста a в текст b.
+// Диапазон, не задетый правкой, сдвигается вместе с текстом; задетый правкой
+// ищется в b по цитате quote, и из нескольких вхождений выбирается ближайшее к
+// прежнему месту. ok ложно, если цитаты в b больше нет.
+func MapRange(a, b string, start, end int, quote string) (newStart, newEnd int, ok bool) {
+	ra, rb := []rune(a), []rune(b)
+	if start < 0 || start > end || end > len(ra) {
+		return findNearest(rb, []rune(quote), start)
+	}
+
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ra)-prefix && suffix < len(rb)-prefix && ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+
+	switch {
+	case end <= prefix:
+		return start, end, true
+	case start >= len(ra)-suffix:
+		shift := len(rb) - len(ra)
+		return start + shift, end + shift, true
+	default:
+		return findNearest(rb, []rune(quote), start)
+	}
+}
+
+// findNearest ищет вхождение quote в text, ближайшее к позиции near.
+func findNearest(text, quote []rune, near int) (start, end int, ok bool) {
+	if len(quote) == 0 {
+		return 0, 0, false
+	}
+
+	best := -1
+	for i := 0; i+len(quote) <= len(text); i++ {
+		if !runesEqual(text[i:i+len(quote)], quote) {
+			continue
+		}
+		if best < 0 || distance(i, near) < distance(best, near) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	return best, best + len(quote), true
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
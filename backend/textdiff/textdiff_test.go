@@ -0,0 +1,88 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func apply(lines []Line) (string, string) {
+	var a, b []string
+	for _, line := range lines {
+		if line.Op != OpInsert {
+			a = append(a, line.Text)
+		}
+		if line.Op != OpDelete {
+			b = append(b, line.Text)
+		}
+	}
+	return strings.Join(a, "\n"), strings.Join(b, "\n")
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		changes int
+	}{
+		{name: "equal", a: "one\ntwo", b: "one\ntwo", changes: 0},
+		{name: "insert", a: "one\nthree", b: "one\ntwo\nthree", changes: 1},
+		{name: "delete", a: "one\ntwo\nthree", b: "one\nthree", changes: 1},
+		{name: "replace", a: "milk\nbread", b: "milk\neggs", changes: 2},
+		{name: "from empty", a: "", b: "first\nsecond", changes: 2},
+		{name: "to empty", a: "first", b: "", changes: 1},
+		{name: "both empty", a: "", b: "", changes: 0},
+		{name: "reordered", a: "a\nb\nc\nd", b: "b\na\nd\nc", changes: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := Lines(test.a, test.b)
+
+			changes := 0
+			for _, line := range lines {
+				if line.Op != OpEqual {
+					changes++
+				}
+			}
+			require.Equal(t, test.changes, changes)
+			require.Equal(t, test.changes > 0, Changed(lines))
+
+			a, b := apply(lines)
+			require.Equal(t, test.a, a)
+			require.Equal(t, test.b, b)
+		})
+	}
+}
+
+func TestMapRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       string
+		start, end int
+		quote      string
+		wantStart  int
+		wantEnd    int
+		wantOK     bool
+	}{
+		{name: "edit after range", a: "hello world", b: "hello world!", start: 0, end: 5, quote: "hello", wantStart: 0, wantEnd: 5, wantOK: true},
+		{name: "edit before range", a: "hello world", b: "oh, hello world", start: 6, end: 11, quote: "world", wantStart: 10, wantEnd: 15, wantOK: true},
+		{name: "edit inside range keeps quote elsewhere", a: "one two three", b: "three one", start: 8, end: 13, quote: "three", wantStart: 0, wantEnd: 5, wantOK: true},
+		{name: "nearest occurrence", a: "ab ab cd ab", b: "ab xx cd ab", start: 3, end: 5, quote: "ab", wantStart: 0, wantEnd: 2, wantOK: true},
+		{name: "quote removed", a: "keep drop", b: "keep", start: 5, end: 9, quote: "drop", wantOK: false},
+		{name: "runes", a: "привет мир", b: "ну привет мир", start: 7, end: 10, quote: "мир", wantStart: 10, wantEnd: 13, wantOK: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end, ok := MapRange(test.a, test.b, test.start, test.end, test.quote)
+			require.Equal(t, test.wantOK, ok)
+			if ok {
+				require.Equal(t, test.wantStart, start)
+				require.Equal(t, test.wantEnd, end)
+				require.Equal(t, test.quote, string([]rune(test.b)[start:end]))
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package transferDelivery
+
+import (
+	"backend/apiutils"
+	"backend/markdown"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	transferUsecase "backend/transfer/usecase"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxImportBody ограничивает тело запроса загрузки заметок.
+	maxImportBody = 20 << 20
+	maxFieldSize  = 1 << 10
+)
+
+// contentTypes — типы файлов выгрузки по форматам.
+var contentTypes = map[string]string{
+	transferUsecase.FormatMarkdown: markdown.ContentType,
+	transferUsecase.FormatHTML:     "text/html; charset=utf-8",
+	transferUsecase.FormatPDF:      "application/pdf",
+}
+
+type TransferUsecase interface {
+	ExportNote(ownerID, actorID, noteID uint64) (*markdown.File, error)
+	ExportDocument(ctx context.Context, ownerID, actorID, noteID uint64, format string, subpages bool) (*markdown.File, error)
+	ExportNotes(ownerID, actorID uint64, folder string) ([]markdown.File, error)
+	Import(ownerID, actorID uint64, folder string, uploads []transferUsecase.Upload) (*models.ImportResult, error)
+}
+
+type TransferDelivery struct {
+	Usecase TransferUsecase
+}
+
+func NewTransferDelivery(usecase TransferUsecase) *TransferDelivery {
+	return &TransferDelivery{
+		Usecase: usecase,
+	}
+}
+
+// parseOwnerVars читает владельца из пути и пользователя сессии, при ошибке
+// сам пишет ответ.
+func parseOwnerVars(w http.ResponseWriter, r *http.Request) (ownerID, actorID uint64, ok bool) {
+	ownerID, err := mw.OwnerID(r)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid owner ID")
+		return 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+	return ownerID, actorID, true
+}
+
+// parseFormat проверяет формат выгрузки из параметра format по списку
+// допустимых, при ошибке сам пишет ответ. По умолчанию выгружается Markdown.
+func parseFormat(w http.ResponseWriter, r *http.Request, formats ...string) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "md":
+		format = transferUsecase.FormatMarkdown
+	}
+	if slices.Contains(formats, format) {
+		return format, true
+	}
+	apiutils.WriteError(w, http.StatusBadRequest, "unsupported format")
+	return "", false
+}
+
+func writeAttachment(w http.ResponseWriter, contentType, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+// ExportNote отдаёт заметку файлом Markdown, страницей HTML или документом
+// PDF; с параметром subpages=true документ включает подстраницы.
+func (d *TransferDelivery) ExportNote(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+	noteID, err := strconv.ParseUint(mux.Vars(r)["note_id"], 10, 64)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid note ID")
+		return
+	}
+	format, ok := parseFormat(w, r, transferUsecase.FormatMarkdown, transferUsecase.FormatHTML, transferUsecase.FormatPDF)
+	if !ok {
+		return
+	}
+	subpages := false
+	if value := r.URL.Query().Get("subpages"); value != "" {
+		if subpages, err = strconv.ParseBool(value); err != nil {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid subpages value")
+			return
+		}
+	}
+
+	var file *markdown.File
+	if format == transferUsecase.FormatMarkdown {
+		file, err = d.Usecase.ExportNote(ownerID, actorID, noteID)
+	} else {
+		file, err = d.Usecase.ExportDocument(r.Context(), ownerID, actorID, noteID, format, subpages)
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "note not found")
+		return
+	}
+	if errors.Is(err, namederrors.ErrFileTooLarge) {
+		apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "too many subpages")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to export note")
+		return
+	}
+
+	writeAttachment(w, contentTypes[format], file.Path)
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Data)
+}
+
+// ExportNotes отдаёт ZIP-архив заметок папки из параметра folder вместе с
+// подпапками или всех заметок владельца.
+func (d *TransferDelivery) ExportNotes(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+	if _, ok = parseFormat(w, r, transferUsecase.FormatMarkdown); !ok {
+		return
+	}
+	folder := r.URL.Query().Get("folder")
+
+	files, err := d.Usecase.ExportNotes(ownerID, actorID, folder)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "folder not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to export notes")
+		return
+	}
+
+	name := "notes"
+	if folder != "" {
+		// Архив называется по последней папке пути.
+		name = path.Base(path.Dir(markdown.FilePath(folder, name)))
+	}
+	writeAttachment(w, "application/zip", name+".zip")
+	w.WriteHeader(http.StatusOK)
+	if err = markdown.WriteZip(w, files); err != nil {
+		log.Error().Err(err).Uint64("owner_id", ownerID).Msg("failed to write notes archive")
+	}
+}
+
+// Import принимает multipart/form-data с файлами .md или ZIP-архивами в полях
+// file и необязательной папкой для заметок в поле folder.
+func (d *TransferDelivery) Import(w http.ResponseWriter, r *http.Request) {
+	ownerID, actorID, ok := parseOwnerVars(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBody)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "expected multipart/form-data")
+		return
+	}
+
+	var folder string
+	var uploads []transferUsecase.Upload
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "import is too large")
+			return
+		}
+		if err != nil {
+			apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+			return
+		}
+
+		switch part.FormName() {
+		case "folder":
+			value, err := io.ReadAll(io.LimitReader(part, maxFieldSize))
+			if err != nil {
+				apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+				return
+			}
+			folder = string(value)
+		case "file":
+			data, err := io.ReadAll(part)
+			if errors.As(err, &maxBytesErr) {
+				apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "import is too large")
+				return
+			}
+			if err != nil {
+				apiutils.WriteError(w, http.StatusBadRequest, "invalid multipart body")
+				return
+			}
+			uploads = append(uploads, transferUsecase.Upload{Filename: part.FileName(), Data: data})
+		}
+		part.Close()
+	}
+	if len(uploads) == 0 {
+		apiutils.WriteError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	result, err := d.Usecase.Import(ownerID, actorID, folder, uploads)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrFileTooLarge) {
+		apiutils.WriteError(w, http.StatusRequestEntityTooLarge, "import is too large")
+		return
+	}
+	if errors.Is(err, namederrors.ErrUnsupportedMediaType) {
+		apiutils.WriteError(w, http.StatusUnsupportedMediaType, "only .md files and .zip archives can be imported")
+		return
+	}
+	if errors.Is(err, namederrors.ErrInvalidImport) {
+		apiutils.WriteError(w, http.StatusBadRequest, "no notes to import")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to import notes")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, result)
+}
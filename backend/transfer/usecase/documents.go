@@ -0,0 +1,230 @@
+package transferUsecase
+
+import (
+	"backend/document"
+	"backend/markdown"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/render"
+	"backend/subpages"
+	"backend/wikilinks"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+
+	// MaxDocumentNotes ограничивает число подстраниц в одном документе.
+	MaxDocumentNotes = 500
+	// maxDocumentImages ограничивает общий размер изображений документа,
+	// остальные изображения заменяются подписью.
+	maxDocumentImages = 50 << 20
+	// documentImageSize — вариант изображений-вложений в документах.
+	documentImageSize = "large"
+)
+
+// attachmentPath — путь скачивания вложения заметки.
+var attachmentPath = regexp.MustCompile(`/notes/(\d+)/attachments/(\d+)$`)
+
+// sectionAnchor — id раздела заметки в документе.
+func sectionAnchor(noteID uint64) string {
+	return "note-" + strconv.FormatUint(noteID, 10)
+}
+
+// ExportDocument возвращает заметку самостоятельным документом HTML или PDF с
+// оглавлением. Выгружать документ могут все, кому заметка доступна. При
+// withSubpages в документ попадают и видимые пользователю подстраницы заметки.
+// Изображения-вложения встраиваются в документ.
+func (u *TransferUsecase) ExportDocument(ctx context.Context, ownerID, actorID, noteID uint64, format string, withSubpages bool) (*markdown.File, error) {
+	note, err := u.Notes.GetNote(ownerID, actorID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export document: %w", err)
+	}
+	notes := []models.Note{*note}
+	if withSubpages {
+		pages, err := u.visibleSubpages(ownerID, actorID, *note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export document: %w", err)
+		}
+		notes = append(notes, pages...)
+	}
+
+	included := make(map[uint64]bool, len(notes))
+	byKey := make(map[string]uint64, len(notes))
+	titles := make(map[uint64]string, len(notes))
+	for _, n := range notes {
+		included[n.ID] = true
+		titles[n.ID] = n.Title
+		if _, ok := byKey[wikilinks.Key(n.Title)]; !ok {
+			byKey[wikilinks.Key(n.Title)] = n.ID
+		}
+	}
+	u.linkedTitles(ownerID, actorID, notes, titles)
+
+	doc := document.Document{Title: note.Title, Images: make(map[string]document.Image)}
+	for i, n := range notes {
+		section := document.Section{
+			Anchor: sectionAnchor(n.ID),
+			Title:  n.Title,
+			Blocks: render.Parse(linkSections(n.Text, included, byKey, titles)),
+		}
+		if i > 0 {
+			section.Depth = subpages.Depth(*note, n)
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	u.loadImages(ctx, ownerID, actorID, &doc)
+
+	name := strings.TrimSuffix(markdown.FilePath("", note.Title), markdown.Extension)
+	switch format {
+	case FormatHTML:
+		data, err := document.HTML(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export document: %w", err)
+		}
+		return &markdown.File{Path: name + ".html", Data: data}, nil
+	case FormatPDF:
+		data, err := document.PDF(doc, u.Fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export document: %w", err)
+		}
+		return &markdown.File{Path: name + ".pdf", Data: data}, nil
+	}
+	return nil, fmt.Errorf("failed to export document: unsupported format %q", format)
+}
+
+// visibleSubpages возвращает подстраницы заметки, которые actorID может читать.
+// Пользователю, которому недоступен список заметок владельца, подстраницы
+// не видны.
+func (u *TransferUsecase) visibleSubpages(ownerID, actorID uint64, note models.Note) ([]models.Note, error) {
+	all, err := u.Notes.GetAllNotes(ownerID, actorID, models.NotesFilter{})
+	if errors.Is(err, namederrors.ErrForbidden) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pages := subpages.Of(note, all)
+	if len(pages)+1 > MaxDocumentNotes {
+		return nil, namederrors.ErrFileTooLarge
+	}
+	return slices.DeleteFunc(pages, func(page models.Note) bool {
+		_, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, page.ID, models.RoleViewer)
+		return err != nil
+	}), nil
+}
+
+// linkedTitles дополняет titles заголовками заметок, на которые ссылаются
+// notes по ID, если они доступны actorID.
+func (u *TransferUsecase) linkedTitles(ownerID, actorID uint64, notes []models.Note, titles map[uint64]string) {
+	checked := make(map[uint64]bool)
+	for _, n := range notes {
+		for _, link := range wikilinks.Parse(n.Text) {
+			if _, ok := titles[link.NoteID]; ok || link.NoteID == 0 || checked[link.NoteID] {
+				continue
+			}
+			checked[link.NoteID] = true
+			linked, _, err := u.Authorizer.AuthorizeNote(ownerID, actorID, link.NoteID, models.RoleViewer)
+			if err == nil {
+				titles[link.NoteID] = linked.Title
+			}
+		}
+	}
+}
+
+// linkSections заменяет вики-ссылки на заметки документа ссылками на их
+// разделы, а ссылки на остальные заметки — их подписью или заголовком.
+func linkSections(text string, included map[uint64]bool, byKey map[string]uint64, titles map[uint64]string) string {
+	return wikilinks.ReplaceFunc(text, func(link wikilinks.Link, label string, labeled bool) (string, bool) {
+		noteID := link.NoteID
+		if noteID == 0 {
+			noteID = byKey[wikilinks.Key(link.Title)]
+		}
+		if !labeled {
+			label = link.Title
+			if title, ok := titles[noteID]; ok {
+				label = title
+			}
+		}
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return "", false
+		}
+		if included[noteID] {
+			return markdown.Link(label, "#"+sectionAnchor(noteID)), true
+		}
+		return label, true
+	})
+}
+
+// imageSources возвращает адреса изображений строки.
+func imageSources(spans []render.Span, sources []string) []string {
+	for _, span := range spans {
+		if span.Kind == render.ImageSpan {
+			sources = append(sources, span.URL)
+		}
+		sources = imageSources(span.Children, sources)
+	}
+	return sources
+}
+
+// loadImages загружает изображения-вложения, на которые ссылаются заметки
+// документа. Недоступные и не уместившиеся в лимит изображения пропускаются.
+func (u *TransferUsecase) loadImages(ctx context.Context, ownerID, actorID uint64, doc *document.Document) {
+	var sources []string
+	for _, section := range doc.Sections {
+		for _, block := range section.Blocks {
+			for _, line := range block.Lines {
+				sources = imageSources(line, sources)
+			}
+		}
+	}
+
+	total := 0
+	for _, src := range sources {
+		if _, ok := doc.Images[src]; ok {
+			continue
+		}
+		parsed, err := url.Parse(src)
+		if err != nil || parsed.Host != "" {
+			continue
+		}
+		groups := attachmentPath.FindStringSubmatch(path.Clean("/" + parsed.Path))
+		if groups == nil {
+			continue
+		}
+		noteID, _ := strconv.ParseUint(groups[1], 10, 64)
+		attachmentID, _ := strconv.ParseUint(groups[2], 10, 64)
+
+		attachment, variant, content, err := u.Attachments.Download(ctx, ownerID, actorID, noteID, attachmentID, documentImageSize)
+		if err != nil {
+			continue
+		}
+		contentType := attachment.ContentType
+		if variant != nil {
+			contentType = variant.ContentType
+		}
+		data, err := io.ReadAll(io.LimitReader(content, int64(maxDocumentImages-total)+1))
+		content.Close()
+		if err != nil || !strings.HasPrefix(contentType, "image/") {
+			continue
+		}
+		if total+len(data) > maxDocumentImages {
+			return
+		}
+		total += len(data)
+		doc.Images[src] = document.Image{ContentType: contentType, Data: data}
+	}
+}
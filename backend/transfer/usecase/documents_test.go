@@ -0,0 +1,136 @@
+package transferUsecase
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"backend/pdf"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotes — заметки владельца 1. Пользователь 1 видит все заметки, 2 —
+// участник, которому закрыта заметка 4, 3 видит только заметку 1 по доступу.
+type fakeNotes struct {
+	notes map[uint64]models.Note
+}
+
+func (n *fakeNotes) visible(actorID, noteID uint64) bool {
+	switch actorID {
+	case 1:
+		return true
+	case 2:
+		return noteID != 4
+	case 3:
+		return noteID == 1
+	}
+	return false
+}
+
+func (n *fakeNotes) GetNote(ownerID, actorID, noteID uint64) (*models.Note, error) {
+	note, ok := n.notes[noteID]
+	if !ok {
+		return nil, namederrors.ErrNotFound
+	}
+	if !n.visible(actorID, noteID) {
+		return nil, namederrors.ErrForbidden
+	}
+	return &note, nil
+}
+
+func (n *fakeNotes) GetAllNotes(ownerID, actorID uint64, filter models.NotesFilter) ([]models.Note, error) {
+	if actorID == 3 {
+		return nil, namederrors.ErrForbidden
+	}
+	var notes []models.Note
+	for _, note := range n.notes {
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (n *fakeNotes) CreateNote(ownerID, editorID uint64, note models.Note) (*models.Note, error) {
+	return nil, namederrors.ErrForbidden
+}
+
+func (n *fakeNotes) UpdateNote(ownerID, editorID uint64, note models.Note, match notesUsecase.VersionMatch) (*models.Note, error) {
+	return nil, namederrors.ErrForbidden
+}
+
+type fakeAuthorizer struct {
+	notes *fakeNotes
+}
+
+func (a *fakeAuthorizer) CheckOwner(ownerID, actorID uint64) error {
+	return nil
+}
+
+func (a *fakeAuthorizer) AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error) {
+	note, err := a.notes.GetNote(ownerID, actorID, noteID)
+	if err != nil {
+		return nil, "", err
+	}
+	return note, models.RoleViewer, nil
+}
+
+func TestExportDocument(t *testing.T) {
+	notes := &fakeNotes{notes: map[uint64]models.Note{
+		1: {ID: 1, OwnerID: 1, Folder: "Wiki", Title: "Guide", Text: "See [[#2]], [[#4]] and [[#5]]."},
+		2: {ID: 2, OwnerID: 1, Folder: "Wiki/Guide", Title: "Setup"},
+		4: {ID: 4, OwnerID: 1, Folder: "Wiki/Guide", Title: "Private"},
+		5: {ID: 5, OwnerID: 1, Folder: "Wiki", Title: "Sibling"},
+	}}
+	u := NewTransferUsecase(notes, nil, &fakeAuthorizer{notes: notes}, pdf.Family{})
+
+	tests := []struct {
+		name     string
+		actorID  uint64
+		subpages bool
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "owner with sub-pages",
+			actorID:  1,
+			subpages: true,
+			contains: []string{`id="note-2"`, `id="note-4"`, `href="#note-2"`, "Sibling"},
+			excludes: []string{`id="note-5"`},
+		},
+		{
+			name:     "hidden sub-page",
+			actorID:  2,
+			subpages: true,
+			contains: []string{`id="note-2"`},
+			excludes: []string{`id="note-4"`, "Private"},
+		},
+		{
+			name:     "shared note without sub-pages",
+			actorID:  3,
+			contains: []string{`id="note-1"`, "[[#2]]"},
+			excludes: []string{`id="note-2"`, "Setup"},
+		},
+		{
+			name:     "shared note with sub-pages",
+			actorID:  3,
+			subpages: true,
+			contains: []string{`id="note-1"`},
+			excludes: []string{`id="note-2"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := u.ExportDocument(context.Background(), 1, test.actorID, 1, FormatHTML, test.subpages)
+			require.NoError(t, err)
+			require.Equal(t, "Guide.html", file.Path)
+			for _, s := range test.contains {
+				require.Contains(t, string(file.Data), s)
+			}
+			for _, s := range test.excludes {
+				require.NotContains(t, string(file.Data), s)
+			}
+		})
+	}
+}
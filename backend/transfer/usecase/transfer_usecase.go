@@ -0,0 +1,247 @@
+package transferUsecase
+
+import (
+	"backend/markdown"
+	"backend/models"
+	namederrors "backend/named_errors"
+	notesUsecase "backend/notes/usecase"
+	"backend/pdf"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxImportFiles и MaxImportSize ограничивают число файлов и их размер
+	// после распаковки архивов в одной загрузке.
+	MaxImportFiles = 1000
+	MaxImportSize  = 50 << 20
+)
+
+// NotesUsecase создаёт и читает заметки с проверкой прав, лентой изменений и
+// уведомлениями, как при правке через API заметок.
+type NotesUsecase interface {
+	GetNote(ownerID, actorID, noteID uint64) (*models.Note, error)
+	GetAllNotes(ownerID, actorID uint64, filter models.NotesFilter) ([]models.Note, error)
+	CreateNote(ownerID, editorID uint64, note models.Note) (*models.Note, error)
+	UpdateNote(ownerID, editorID uint64, note models.Note, match notesUsecase.VersionMatch) (*models.Note, error)
+}
+
+// AttachmentsUsecase отдаёт вложения с проверкой прав — изображения для
+// выгружаемых документов.
+type AttachmentsUsecase interface {
+	Download(ctx context.Context, ownerID, actorID, noteID, attachmentID uint64, size string) (*models.Attachment, *models.ImageVariant, io.ReadCloser, error)
+}
+
+type Authorizer interface {
+	CheckOwner(ownerID, actorID uint64) error
+	AuthorizeNote(ownerID, actorID, noteID uint64, required string) (*models.Note, string, error)
+}
+
+// TransferUsecase выгружает и загружает заметки. Документы PDF верстаются
+// шрифтами Fonts.
+type TransferUsecase struct {
+	Notes       NotesUsecase
+	Attachments AttachmentsUsecase
+	Authorizer  Authorizer
+	Fonts       pdf.Family
+}
+
+func NewTransferUsecase(notes NotesUsecase, attachments AttachmentsUsecase, authorizer Authorizer, fonts pdf.Family) *TransferUsecase {
+	return &TransferUsecase{
+		Notes:       notes,
+		Attachments: attachments,
+		Authorizer:  authorizer,
+		Fonts:       fonts,
+	}
+}
+
+// Upload — загруженный файл: заметка .md или ZIP-архив заметок.
+type Upload struct {
+	Filename string
+	Data     []byte
+}
+
+// joinFolders склеивает папки через «/», пропуская пустые части.
+func joinFolders(folders ...string) string {
+	var parts []string
+	for _, folder := range folders {
+		for _, part := range strings.Split(folder, "/") {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// inFolder сообщает, лежит ли заметка в папке folder или её подпапках.
+func inFolder(noteFolder, folder string) bool {
+	return folder == "" || noteFolder == folder || strings.HasPrefix(noteFolder, folder+"/")
+}
+
+// ExportNote возвращает заметку файлом Markdown. Выгружать заметку могут все,
+// кому она доступна.
+func (u *TransferUsecase) ExportNote(ownerID, actorID, noteID uint64) (*markdown.File, error) {
+	note, err := u.Notes.GetNote(ownerID, actorID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export note: %w", err)
+	}
+	return &markdown.File{
+		Path: markdown.FilePath("", note.Title),
+		Data: markdown.Marshal(*note),
+	}, nil
+}
+
+// ExportNotes возвращает файлы Markdown заметок из папки folder и её подпапок,
+// а при пустой folder — всех заметок владельца. Пути файлов повторяют папки,
+// ссылки между выгружаемыми заметками становятся ссылками между файлами.
+func (u *TransferUsecase) ExportNotes(ownerID, actorID uint64, folder string) ([]markdown.File, error) {
+	folder = joinFolders(folder)
+	notes, err := u.Notes.GetAllNotes(ownerID, actorID, models.NotesFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export notes: %w", err)
+	}
+	notes = slices.DeleteFunc(notes, func(note models.Note) bool {
+		return !inFolder(note.Folder, folder)
+	})
+	if len(notes) == 0 && folder != "" {
+		return nil, fmt.Errorf("failed to export notes: %w", namederrors.ErrNotFound)
+	}
+	slices.SortFunc(notes, func(a, b models.Note) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	folders := make([]string, len(notes))
+	titles := make([]string, len(notes))
+	indexes := make(map[uint64]int, len(notes))
+	for i, note := range notes {
+		folders[i], titles[i] = note.Folder, note.Title
+		indexes[note.ID] = i
+	}
+	paths := markdown.Paths(folders, titles)
+
+	files := make([]markdown.File, len(notes))
+	for i, note := range notes {
+		note.Text = markdown.LinkFiles(note.Text, paths[i], func(noteID uint64) (string, string, bool) {
+			j, ok := indexes[noteID]
+			if !ok {
+				return "", "", false
+			}
+			return paths[j], titles[j], true
+		})
+		files[i] = markdown.File{Path: paths[i], Data: markdown.Marshal(note)}
+	}
+	return files, nil
+}
+
+// collectFiles разворачивает архивы и возвращает файлы Markdown и пути
+// пропущенных файлов.
+func collectFiles(uploads []Upload) (files []markdown.File, skipped []string, err error) {
+	var total int64
+	for _, upload := range uploads {
+		switch strings.ToLower(path.Ext(upload.Filename)) {
+		case markdown.Extension, ".markdown":
+			files = append(files, markdown.File{Path: path.Base(upload.Filename), Data: upload.Data})
+			total += int64(len(upload.Data))
+		case ".zip":
+			archived, err := markdown.ReadZip(upload.Data, MaxImportFiles-len(files), MaxImportSize-total)
+			if errors.Is(err, markdown.ErrArchiveTooLarge) {
+				return nil, nil, namederrors.ErrFileTooLarge
+			}
+			if err != nil {
+				return nil, nil, namederrors.ErrInvalidImport
+			}
+			for _, file := range archived {
+				ext := strings.ToLower(path.Ext(file.Path))
+				if ext != markdown.Extension && ext != ".markdown" {
+					skipped = append(skipped, file.Path)
+					continue
+				}
+				files = append(files, file)
+				total += int64(len(file.Data))
+			}
+		default:
+			return nil, nil, namederrors.ErrUnsupportedMediaType
+		}
+		if len(files) > MaxImportFiles || total > MaxImportSize {
+			return nil, nil, namederrors.ErrFileTooLarge
+		}
+	}
+	return files, skipped, nil
+}
+
+// Import создаёт заметки из файлов Markdown и архивов с ними в папке folder.
+// Заголовок и папка берутся из front matter, а без них — из имени файла и его
+// каталога в архиве. Относительные ссылки между загруженными файлами
+// становятся ссылками между созданными заметками.
+func (u *TransferUsecase) Import(ownerID, actorID uint64, folder string, uploads []Upload) (*models.ImportResult, error) {
+	if err := u.Authorizer.CheckOwner(ownerID, actorID); err != nil {
+		return nil, fmt.Errorf("failed to import notes: %w", err)
+	}
+	files, skipped, err := collectFiles(uploads)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import notes: %w", err)
+	}
+
+	result := &models.ImportResult{
+		Notes:   make([]models.Note, 0, len(files)),
+		Skipped: append(make([]string, 0, len(skipped)), skipped...),
+	}
+	var imported []markdown.File
+	for _, file := range files {
+		if !utf8.Valid(file.Data) {
+			result.Skipped = append(result.Skipped, file.Path)
+			continue
+		}
+		note := markdown.Unmarshal(file.Data)
+		if note.Title == "" {
+			note.Title = strings.TrimSuffix(path.Base(file.Path), path.Ext(file.Path))
+		}
+		if dir := path.Dir(file.Path); note.Folder == "" && dir != "." {
+			note.Folder = dir
+		}
+		note.Folder = joinFolders(folder, note.Folder)
+
+		created, err := u.Notes.CreateNote(ownerID, actorID, note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", file.Path, err)
+		}
+		result.Notes = append(result.Notes, *created)
+		imported = append(imported, file)
+	}
+	if len(result.Notes) == 0 {
+		return nil, fmt.Errorf("failed to import notes: %w", namederrors.ErrInvalidImport)
+	}
+
+	// Ссылки переписываются, когда у всех заметок уже есть ID.
+	byPath := make(map[string]int, len(imported))
+	for i, file := range imported {
+		byPath[strings.ToLower(file.Path)] = i
+	}
+	for i, note := range result.Notes {
+		text := markdown.ResolveLinks(note.Text, imported[i].Path, func(filePath string) (uint64, string, bool) {
+			j, ok := byPath[strings.ToLower(filePath)]
+			if !ok {
+				return 0, "", false
+			}
+			return result.Notes[j].ID, result.Notes[j].Title, true
+		})
+		if text == note.Text {
+			continue
+		}
+		note.Text = text
+		updated, err := u.Notes.UpdateNote(ownerID, actorID, note, notesUsecase.VersionMatch{Versions: []uint64{note.Version}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to link %s: %w", imported[i].Path, err)
+		}
+		result.Notes[i] = *updated
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package wikilinks
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// linkPattern — ссылка на заметку в виде [[Заголовок]], [[#ID]] или с
+// подписью после вертикальной черты: [[Заголовок|подпись]].
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// Link — ссылка из текста заметки: по заголовку или, если задан NoteID, по ID.
+type Link struct {
+	Title  string
+	NoteID uint64
+}
+
+// Target возвращает цель ссылки так, как она записана в тексте.
+func (l Link) Target() string {
+	if l.NoteID != 0 {
+		return "#" + strconv.FormatUint(l.NoteID, 10)
+	}
+	return l.Title
+}
+
+// Key приводит заголовок к виду, в котором ссылки сравниваются с заголовками
+// заметок: без учёта регистра и лишних пробелов.
+func Key(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// parseTarget разбирает содержимое скобок и возвращает цель ссылки и подпись.
+func parseTarget(inner string) (target, label string) {
+	target, label, _ = strings.Cut(inner, "|")
+	return strings.TrimSpace(target), label
+}
+
+func parseLink(target string) (Link, bool) {
+	if target == "" {
+		return Link{}, false
+	}
+	if id, ok := strings.CutPrefix(target, "#"); ok {
+		if noteID, err := strconv.ParseUint(id, 10, 64); err == nil && noteID != 0 {
+			return Link{NoteID: noteID}, true
+		}
+	}
+	return Link{Title: target}, true
+}
+
+// Parse возвращает ссылки из текста в порядке первого появления без повторов.
+// Ссылки на один заголовок, записанный в разном регистре, считаются одной.
+func Parse(text string) []Link {
+	seen := make(map[string]bool)
+	var links []Link
+	for _, match := range linkPattern.FindAllStringSubmatch(text, -1) {
+		target, _ := parseTarget(match[1])
+		link, ok := parseLink(target)
+		if !ok {
+			continue
+		}
+		key := Key(link.Target())
+		if !seen[key] {
+			seen[key] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// ReplaceFunc заменяет ссылки в тексте результатом replace. labeled сообщает,
+// записана ли у ссылки подпись. Если replace возвращает false, ссылка остаётся
+// как есть.
+func ReplaceFunc(text string, replace func(link Link, label string, labeled bool) (string, bool)) string {
+	return linkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		inner := match[2 : len(match)-2]
+		target, label := parseTarget(inner)
+		link, ok := parseLink(target)
+		if !ok {
+			return match
+		}
+		if replaced, ok := replace(link, label, strings.Contains(inner, "|")); ok {
+			return replaced
+		}
+		return match
+	})
+}
+
+// Rename заменяет в тексте ссылки на заголовок oldTitle ссылками на newTitle,
+// сохраняя подписи. changed сообщает, была ли заменена хотя бы одна ссылка.
+func Rename(text, oldTitle, newTitle string) (renamed string, changed bool) {
+	oldKey := Key(oldTitle)
+	renamed = ReplaceFunc(text, func(link Link, label string, labeled bool) (string, bool) {
+		if link.NoteID != 0 || Key(link.Title) != oldKey {
+			return "", false
+		}
+		changed = true
+		if labeled {
+			return "[[" + newTitle + "|" + label + "]]", true
+		}
+		return "[[" + newTitle + "]]", true
+	})
+	return renamed, changed
+}
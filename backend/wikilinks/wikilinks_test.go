@@ -0,0 +1,58 @@
+package wikilinks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Link
+	}{
+		{name: "none", text: "plain [text]", want: nil},
+		{name: "title", text: "see [[Books to read]]", want: []Link{{Title: "Books to read"}}},
+		{name: "label", text: "see [[Books to read|my list]]", want: []Link{{Title: "Books to read"}}},
+		{name: "id", text: "see [[#42]] and [[#42|that]]", want: []Link{{NoteID: 42}}},
+		{name: "not an id", text: "[[#tag]]", want: []Link{{Title: "#tag"}}},
+		{name: "duplicates and case", text: "[[Homework]] [[ homework ]] [[HOMEWORK|hw]]", want: []Link{{Title: "Homework"}}},
+		{name: "empty", text: "[[ ]] [[|label]]", want: nil},
+		{name: "multiline is not a link", text: "[[Books\nto read]]", want: nil},
+		{name: "order", text: "[[B]] then [[A]]", want: []Link{{Title: "B"}, {Title: "A"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, Parse(test.text))
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	require.Equal(t, "books to read", Key("  Books   to\tRead "))
+}
+
+func TestRename(t *testing.T) {
+	renamed, changed := Rename("[[Books]], [[books|list]], [[Bookshelf]], [[#1]]", "Books", "Reading list")
+	require.True(t, changed)
+	require.Equal(t, "[[Reading list]], [[Reading list|list]], [[Bookshelf]], [[#1]]", renamed)
+
+	renamed, changed = Rename("no links to [[Other]]", "Books", "Reading list")
+	require.False(t, changed)
+	require.Equal(t, "no links to [[Other]]", renamed)
+}
+
+func TestReplaceFunc(t *testing.T) {
+	replaced := ReplaceFunc("[[#7]], [[#7|seven]], [[Seven]] and [[ ]]", func(link Link, label string, labeled bool) (string, bool) {
+		if link.NoteID == 0 {
+			return "", false
+		}
+		if labeled {
+			return "<" + label + ">", true
+		}
+		return "<note>", true
+	})
+	require.Equal(t, "<note>, <seven>, [[Seven]] and [[ ]]", replaced)
+}
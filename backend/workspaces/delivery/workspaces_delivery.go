@@ -0,0 +1,439 @@
+package workspacesDelivery
+
+import (
+	"backend/apiutils"
+	mw "backend/middleware"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/validation"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type WorkspacesUsecase interface {
+	CreateWorkspace(actorID uint64, name string) (*models.Workspace, error)
+	ListWorkspaces(actorID uint64) ([]models.UserWorkspace, error)
+	GetWorkspace(workspaceID, actorID uint64) (*models.UserWorkspace, error)
+	RenameWorkspace(workspaceID, actorID uint64, name string) (*models.Workspace, error)
+	DeleteWorkspace(workspaceID, actorID uint64) error
+	ListMembers(workspaceID, actorID uint64) ([]models.WorkspaceMember, error)
+	UpdateMemberRole(workspaceID, actorID, userID uint64, role string) (*models.WorkspaceMember, error)
+	RemoveMember(workspaceID, actorID, userID uint64) error
+	Invite(workspaceID, actorID uint64, email, role string) (*models.WorkspaceInvitation, error)
+	ListInvitations(workspaceID, actorID uint64) ([]models.WorkspaceInvitation, error)
+	RevokeInvitation(workspaceID, actorID, invitationID uint64) error
+	MyInvitations(actorID uint64) ([]models.WorkspaceInvitation, error)
+	AcceptInvitation(actorID, invitationID uint64) (*models.WorkspaceMember, error)
+	DeclineInvitation(actorID, invitationID uint64) error
+}
+
+type WorkspacesDelivery struct {
+	Usecase WorkspacesUsecase
+}
+
+func NewWorkspacesDelivery(usecase WorkspacesUsecase) *WorkspacesDelivery {
+	return &WorkspacesDelivery{
+		Usecase: usecase,
+	}
+}
+
+type workspaceRequest struct {
+	Name string `json:"name" valid:"required"`
+}
+
+type inviteRequest struct {
+	Email string `json:"email" valid:"required,email"`
+	Role  string `json:"role" valid:"required,in(admin|member|guest)"`
+}
+
+type memberRoleRequest struct {
+	Role string `json:"role" valid:"required,in(admin|member|guest)"`
+}
+
+func parseUintVar(r *http.Request, name string) (uint64, error) {
+	return strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+}
+
+// parseWorkspaceVars читает workspace_id из пути и пользователя сессии,
+// при ошибке сам пишет ответ.
+func parseWorkspaceVars(w http.ResponseWriter, r *http.Request) (workspaceID, actorID uint64, ok bool) {
+	workspaceID, err := parseUintVar(r, "workspace_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid workspace ID")
+		return 0, 0, false
+	}
+	actorID, ok = mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return 0, 0, false
+	}
+	return workspaceID, actorID, true
+}
+
+func (d *WorkspacesDelivery) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	var req workspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	workspace, err := d.Usecase.CreateWorkspace(actorID, req.Name)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to create workspace")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, workspace)
+}
+
+func (d *WorkspacesDelivery) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	workspaces, err := d.Usecase.ListWorkspaces(actorID)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list workspaces")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, workspaces)
+}
+
+func (d *WorkspacesDelivery) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+
+	workspace, err := d.Usecase.GetWorkspace(workspaceID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to get workspace")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, workspace)
+}
+
+func (d *WorkspacesDelivery) RenameWorkspace(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req workspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	workspace, err := d.Usecase.RenameWorkspace(workspaceID, actorID, req.Name)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to rename workspace")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, workspace)
+}
+
+func (d *WorkspacesDelivery) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+
+	err := d.Usecase.DeleteWorkspace(workspaceID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to delete workspace")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (d *WorkspacesDelivery) ListMembers(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := d.Usecase.ListMembers(workspaceID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list members")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, members)
+}
+
+func (d *WorkspacesDelivery) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+	userID, err := parseUintVar(r, "member_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid member ID")
+		return
+	}
+
+	var req memberRoleRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err = validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	member, err := d.Usecase.UpdateMemberRole(workspaceID, actorID, userID, req.Role)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to update member")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, member)
+}
+
+func (d *WorkspacesDelivery) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+	userID, err := parseUintVar(r, "member_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid member ID")
+		return
+	}
+
+	err = d.Usecase.RemoveMember(workspaceID, actorID, userID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "member not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to remove member")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+func (d *WorkspacesDelivery) Invite(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+
+	var req inviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	if err := validation.ValidateStruct(req); err != nil {
+		apiutils.WriteValidationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	invitation, err := d.Usecase.Invite(workspaceID, actorID, req.Email, req.Role)
+	if errors.Is(err, namederrors.ErrAlreadyMember) {
+		apiutils.WriteError(w, http.StatusConflict, "user is already a workspace member")
+		return
+	}
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to invite to workspace")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusCreated, invitation)
+}
+
+func (d *WorkspacesDelivery) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+
+	invitations, err := d.Usecase.ListInvitations(workspaceID, actorID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "workspace not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list invitations")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, invitations)
+}
+
+func (d *WorkspacesDelivery) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	workspaceID, actorID, ok := parseWorkspaceVars(w, r)
+	if !ok {
+		return
+	}
+	invitationID, err := parseUintVar(r, "invitation_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+
+	err = d.Usecase.RevokeInvitation(workspaceID, actorID, invitationID)
+	if errors.Is(err, namederrors.ErrForbidden) {
+		apiutils.WriteError(w, http.StatusForbidden, "access denied")
+		return
+	}
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "invitation not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to revoke invitation")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func (d *WorkspacesDelivery) MyInvitations(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+
+	invitations, err := d.Usecase.MyInvitations(actorID)
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to list invitations")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, invitations)
+}
+
+func (d *WorkspacesDelivery) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	invitationID, err := parseUintVar(r, "invitation_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+
+	member, err := d.Usecase.AcceptInvitation(actorID, invitationID)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "invitation not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to accept invitation")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, member)
+}
+
+func (d *WorkspacesDelivery) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := mw.GetUserID(r.Context())
+	if !ok {
+		apiutils.WriteError(w, http.StatusUnauthorized, "user not authenticated")
+		return
+	}
+	invitationID, err := parseUintVar(r, "invitation_id")
+	if err != nil {
+		apiutils.WriteError(w, http.StatusBadRequest, "invalid invitation ID")
+		return
+	}
+
+	err = d.Usecase.DeclineInvitation(actorID, invitationID)
+	if errors.Is(err, namederrors.ErrNotFound) {
+		apiutils.WriteError(w, http.StatusNotFound, "invitation not found")
+		return
+	}
+	if err != nil {
+		apiutils.WriteError(w, http.StatusInternalServerError, "failed to decline invitation")
+		return
+	}
+
+	apiutils.WriteJSON(w, http.StatusOK, map[string]string{"status": "declined"})
+}
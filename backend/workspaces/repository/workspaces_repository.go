@@ -0,0 +1,140 @@
+package workspacesRepository
+
+import (
+	"backend/models"
+	namederrors "backend/named_errors"
+	"backend/store"
+	"fmt"
+)
+
+type WorkspacesRepository struct {
+	Store *store.Store
+}
+
+func NewWorkspacesRepository(store *store.Store) *WorkspacesRepository {
+	return &WorkspacesRepository{
+		Store: store,
+	}
+}
+
+func (r *WorkspacesRepository) GetUser(userID uint64) (*models.User, error) {
+	user, ok := r.Store.GetUser(userID)
+	if !ok {
+		return nil, fmt.Errorf("failed to get user: %w", namederrors.ErrNotFound)
+	}
+	return &user, nil
+}
+
+func (r *WorkspacesRepository) GetUserByEmail(email string) (*models.User, error) {
+	user, ok := r.Store.GetUserByEmail(email)
+	if !ok {
+		return nil, fmt.Errorf("failed to get user by email: %w", namederrors.ErrNotFound)
+	}
+	return &user, nil
+}
+
+func (r *WorkspacesRepository) CreateWorkspace(name string, ownerID uint64) (*models.Workspace, error) {
+	workspace, err := r.Store.CreateWorkspace(name, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return &workspace, nil
+}
+
+func (r *WorkspacesRepository) GetWorkspace(workspaceID uint64) (*models.Workspace, error) {
+	workspace, err := r.Store.GetWorkspace(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return &workspace, nil
+}
+
+func (r *WorkspacesRepository) RenameWorkspace(workspaceID uint64, name string) (*models.Workspace, error) {
+	workspace, err := r.Store.RenameWorkspace(workspaceID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename workspace: %w", err)
+	}
+	return &workspace, nil
+}
+
+func (r *WorkspacesRepository) DeleteWorkspace(workspaceID uint64) ([]models.Note, error) {
+	removed, err := r.Store.DeleteWorkspace(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	return removed, nil
+}
+
+func (r *WorkspacesRepository) ListUserWorkspaces(userID uint64) ([]models.UserWorkspace, error) {
+	workspaces := r.Store.ListUserWorkspaces(userID)
+	return workspaces, nil
+}
+
+func (r *WorkspacesRepository) GetWorkspaceMember(workspaceID, userID uint64) (*models.WorkspaceMember, error) {
+	member, err := r.Store.GetWorkspaceMember(workspaceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return &member, nil
+}
+
+func (r *WorkspacesRepository) ListWorkspaceMembers(workspaceID uint64) ([]models.WorkspaceMember, error) {
+	members := r.Store.ListWorkspaceMembers(workspaceID)
+	return members, nil
+}
+
+func (r *WorkspacesRepository) SetWorkspaceMemberRole(workspaceID, userID uint64, role string) (*models.WorkspaceMember, error) {
+	member, err := r.Store.SetWorkspaceMemberRole(workspaceID, userID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set workspace member role: %w", err)
+	}
+	return &member, nil
+}
+
+func (r *WorkspacesRepository) RemoveWorkspaceMember(workspaceID, userID uint64) error {
+	if err := r.Store.RemoveWorkspaceMember(workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkspacesRepository) InviteToWorkspace(invitation models.WorkspaceInvitation) (*models.WorkspaceInvitation, error) {
+	invited, err := r.Store.InviteToWorkspace(invitation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invite to workspace: %w", err)
+	}
+	return &invited, nil
+}
+
+func (r *WorkspacesRepository) GetWorkspaceInvitation(invitationID uint64) (*models.WorkspaceInvitation, error) {
+	invitation, err := r.Store.GetWorkspaceInvitation(invitationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace invitation: %w", err)
+	}
+	return &invitation, nil
+}
+
+func (r *WorkspacesRepository) ListWorkspaceInvitations(workspaceID uint64) ([]models.WorkspaceInvitation, error) {
+	invitations := r.Store.ListWorkspaceInvitations(workspaceID)
+	return invitations, nil
+}
+
+func (r *WorkspacesRepository) ListInvitationsByEmail(email string) ([]models.WorkspaceInvitation, error) {
+	invitations := r.Store.ListInvitationsByEmail(email)
+	return invitations, nil
+}
+
+func (r *WorkspacesRepository) DeleteWorkspaceInvitation(invitationID uint64) error {
+	if err := r.Store.DeleteWorkspaceInvitation(invitationID); err != nil {
+		return fmt.Errorf("failed to delete workspace invitation: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkspacesRepository) AcceptWorkspaceInvitation(invitationID, userID uint64) (*models.WorkspaceMember, error) {
+	member, err := r.Store.AcceptWorkspaceInvitation(invitationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept workspace invitation: %w", err)
+	}
+	return &member, nil
+}
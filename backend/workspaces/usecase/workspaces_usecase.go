@@ -0,0 +1,293 @@
+package workspacesUsecase
+
+import (
+	"backend/events"
+	"backend/models"
+	namederrors "backend/named_errors"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type WorkspacesRepository interface {
+	GetUser(userID uint64) (*models.User, error)
+	GetUserByEmail(email string) (*models.User, error)
+	CreateWorkspace(name string, ownerID uint64) (*models.Workspace, error)
+	RenameWorkspace(workspaceID uint64, name string) (*models.Workspace, error)
+	DeleteWorkspace(workspaceID uint64) ([]models.Note, error)
+	ListUserWorkspaces(userID uint64) ([]models.UserWorkspace, error)
+	GetWorkspaceMember(workspaceID, userID uint64) (*models.WorkspaceMember, error)
+	ListWorkspaceMembers(workspaceID uint64) ([]models.WorkspaceMember, error)
+	SetWorkspaceMemberRole(workspaceID, userID uint64, role string) (*models.WorkspaceMember, error)
+	RemoveWorkspaceMember(workspaceID, userID uint64) error
+	InviteToWorkspace(invitation models.WorkspaceInvitation) (*models.WorkspaceInvitation, error)
+	GetWorkspaceInvitation(invitationID uint64) (*models.WorkspaceInvitation, error)
+	ListWorkspaceInvitations(workspaceID uint64) ([]models.WorkspaceInvitation, error)
+	ListInvitationsByEmail(email string) ([]models.WorkspaceInvitation, error)
+	DeleteWorkspaceInvitation(invitationID uint64) error
+	AcceptWorkspaceInvitation(invitationID, userID uint64) (*models.WorkspaceMember, error)
+}
+
+type Authorizer interface {
+	AuthorizeWorkspace(workspaceID, actorID uint64, required string) (*models.Workspace, string, error)
+}
+
+type WorkspacesUsecase struct {
+	Repository WorkspacesRepository
+	Events     events.Publisher
+	Authorizer Authorizer
+}
+
+func NewWorkspacesUsecase(repository WorkspacesRepository, events events.Publisher, authorizer Authorizer) *WorkspacesUsecase {
+	return &WorkspacesUsecase{
+		Repository: repository,
+		Events:     events,
+		Authorizer: authorizer,
+	}
+}
+
+// CreateWorkspace создаёт рабочее пространство, actorID становится его владельцем.
+func (u *WorkspacesUsecase) CreateWorkspace(actorID uint64, name string) (*models.Workspace, error) {
+	workspace, err := u.Repository.CreateWorkspace(strings.TrimSpace(name), actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+func (u *WorkspacesUsecase) ListWorkspaces(actorID uint64) ([]models.UserWorkspace, error) {
+	workspaces, err := u.Repository.ListUserWorkspaces(actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+func (u *WorkspacesUsecase) GetWorkspace(workspaceID, actorID uint64) (*models.UserWorkspace, error) {
+	workspace, role, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleGuest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return &models.UserWorkspace{Workspace: *workspace, Role: role}, nil
+}
+
+func (u *WorkspacesUsecase) RenameWorkspace(workspaceID, actorID uint64, name string) (*models.Workspace, error) {
+	if _, _, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleAdmin); err != nil {
+		return nil, fmt.Errorf("failed to rename workspace: %w", err)
+	}
+
+	workspace, err := u.Repository.RenameWorkspace(workspaceID, strings.TrimSpace(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// DeleteWorkspace удаляет пространство вместе с его заметками. Доступно
+// только владельцу. Подписчики ленты пространства получают удаление каждой
+// заметки.
+func (u *WorkspacesUsecase) DeleteWorkspace(workspaceID, actorID uint64) error {
+	if _, _, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleOwner); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+
+	removed, err := u.Repository.DeleteWorkspace(workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	if u.Events != nil {
+		for _, note := range removed {
+			u.Events.Publish(workspaceID, models.NoteEvent{Type: models.NoteDeleted, NoteID: note.ID})
+		}
+	}
+	return nil
+}
+
+func (u *WorkspacesUsecase) ListMembers(workspaceID, actorID uint64) ([]models.WorkspaceMember, error) {
+	if _, _, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleGuest); err != nil {
+		return nil, fmt.Errorf("failed to list workspace members: %w", err)
+	}
+
+	members, err := u.Repository.ListWorkspaceMembers(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace members: %w", err)
+	}
+	return members, nil
+}
+
+// canManage сообщает, может ли участник с ролью actorRole менять участника
+// с ролью targetRole или выдавать роль role: владельца не меняет никто, а
+// администраторов назначает и снимает только владелец.
+func canManage(actorRole, targetRole, role string) bool {
+	if targetRole == models.WorkspaceRoleOwner {
+		return false
+	}
+	if actorRole == models.WorkspaceRoleOwner {
+		return true
+	}
+	return targetRole != models.WorkspaceRoleAdmin && role != models.WorkspaceRoleAdmin
+}
+
+func (u *WorkspacesUsecase) UpdateMemberRole(workspaceID, actorID, userID uint64, role string) (*models.WorkspaceMember, error) {
+	_, actorRole, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace member: %w", err)
+	}
+	target, err := u.Repository.GetWorkspaceMember(workspaceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace member: %w", err)
+	}
+	if !canManage(actorRole, target.Role, role) {
+		return nil, fmt.Errorf("failed to update workspace member: %w", namederrors.ErrForbidden)
+	}
+
+	member, err := u.Repository.SetWorkspaceMemberRole(workspaceID, userID, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update workspace member: %w", err)
+	}
+	return member, nil
+}
+
+// RemoveMember исключает участника из пространства. Кроме администраторов,
+// покинуть пространство может сам участник, если он не владелец.
+func (u *WorkspacesUsecase) RemoveMember(workspaceID, actorID, userID uint64) error {
+	required := models.WorkspaceRoleAdmin
+	if userID == actorID {
+		required = models.WorkspaceRoleGuest
+	}
+	_, actorRole, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, required)
+	if err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	target, err := u.Repository.GetWorkspaceMember(workspaceID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	if target.Role == models.WorkspaceRoleOwner || (userID != actorID && !canManage(actorRole, target.Role, "")) {
+		return fmt.Errorf("failed to remove workspace member: %w", namederrors.ErrForbidden)
+	}
+
+	if err = u.Repository.RemoveWorkspaceMember(workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	return nil
+}
+
+// Invite приглашает в пространство пользователя с указанным email. Приглашение
+// на email без аккаунта станет видно пользователю после регистрации.
+func (u *WorkspacesUsecase) Invite(workspaceID, actorID uint64, email, role string) (*models.WorkspaceInvitation, error) {
+	_, actorRole, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invite to workspace: %w", err)
+	}
+	if !canManage(actorRole, "", role) {
+		return nil, fmt.Errorf("failed to invite to workspace: %w", namederrors.ErrForbidden)
+	}
+
+	invitation := models.WorkspaceInvitation{
+		WorkspaceID: workspaceID,
+		Email:       strings.TrimSpace(email),
+		Role:        role,
+		InvitedBy:   actorID,
+	}
+	user, err := u.Repository.GetUserByEmail(invitation.Email)
+	switch {
+	case err == nil:
+		if _, err = u.Repository.GetWorkspaceMember(workspaceID, user.ID); err == nil {
+			return nil, fmt.Errorf("failed to invite to workspace: %w", namederrors.ErrAlreadyMember)
+		}
+		invitation.Email = user.Email
+	case !errors.Is(err, namederrors.ErrNotFound):
+		return nil, fmt.Errorf("failed to invite to workspace: %w", err)
+	}
+
+	invited, err := u.Repository.InviteToWorkspace(invitation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invite to workspace: %w", err)
+	}
+	return invited, nil
+}
+
+func (u *WorkspacesUsecase) ListInvitations(workspaceID, actorID uint64) ([]models.WorkspaceInvitation, error) {
+	if _, _, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleAdmin); err != nil {
+		return nil, fmt.Errorf("failed to list workspace invitations: %w", err)
+	}
+
+	invitations, err := u.Repository.ListWorkspaceInvitations(workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspace invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+func (u *WorkspacesUsecase) RevokeInvitation(workspaceID, actorID, invitationID uint64) error {
+	if _, _, err := u.Authorizer.AuthorizeWorkspace(workspaceID, actorID, models.WorkspaceRoleAdmin); err != nil {
+		return fmt.Errorf("failed to revoke workspace invitation: %w", err)
+	}
+	invitation, err := u.Repository.GetWorkspaceInvitation(invitationID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke workspace invitation: %w", err)
+	}
+	if invitation.WorkspaceID != workspaceID {
+		return fmt.Errorf("failed to revoke workspace invitation: %w", namederrors.ErrNotFound)
+	}
+
+	if err = u.Repository.DeleteWorkspaceInvitation(invitationID); err != nil {
+		return fmt.Errorf("failed to revoke workspace invitation: %w", err)
+	}
+	return nil
+}
+
+// MyInvitations возвращает приглашения, отправленные на email пользователя.
+func (u *WorkspacesUsecase) MyInvitations(actorID uint64) ([]models.WorkspaceInvitation, error) {
+	user, err := u.Repository.GetUser(actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+
+	invitations, err := u.Repository.ListInvitationsByEmail(user.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// getOwnInvitation возвращает приглашение, только если оно отправлено на
+// email пользователя actorID.
+func (u *WorkspacesUsecase) getOwnInvitation(actorID, invitationID uint64) (*models.WorkspaceInvitation, error) {
+	user, err := u.Repository.GetUser(actorID)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := u.Repository.GetWorkspaceInvitation(invitationID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, namederrors.ErrNotFound
+	}
+	return invitation, nil
+}
+
+func (u *WorkspacesUsecase) AcceptInvitation(actorID, invitationID uint64) (*models.WorkspaceMember, error) {
+	if _, err := u.getOwnInvitation(actorID, invitationID); err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+
+	member, err := u.Repository.AcceptWorkspaceInvitation(invitationID, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return member, nil
+}
+
+func (u *WorkspacesUsecase) DeclineInvitation(actorID, invitationID uint64) error {
+	if _, err := u.getOwnInvitation(actorID, invitationID); err != nil {
+		return fmt.Errorf("failed to decline invitation: %w", err)
+	}
+
+	if err := u.Repository.DeleteWorkspaceInvitation(invitationID); err != nil {
+		return fmt.Errorf("failed to decline invitation: %w", err)
+	}
+	return nil
+}